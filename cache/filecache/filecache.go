@@ -25,6 +25,7 @@ import (
 
 	"github.com/gohugoio/hugo/common/hugio"
 
+	"github.com/gohugoio/hugo/common/hmetrics"
 	"github.com/gohugoio/hugo/helpers"
 
 	"github.com/BurntSushi/locker"
@@ -45,6 +46,10 @@ type Cache struct {
 	maxAge time.Duration
 
 	nlocker *lockTracker
+
+	// Cache hits and misses, set when metrics are enabled.
+	hits   *hmetrics.Counter
+	misses *hmetrics.Counter
 }
 
 type lockTracker struct {
@@ -85,6 +90,13 @@ func NewCache(fs afero.Fs, maxAge time.Duration) *Cache {
 	}
 }
 
+// withMetrics counts the hits and misses of this cache in r.
+func (c *Cache) withMetrics(r *hmetrics.Registry, name string) *Cache {
+	c.hits = r.Counter("filecache_hits_total", "cache", name)
+	c.misses = r.Counter("filecache_misses_total", "cache", name)
+	return c
+}
+
 // lockedFile is a file with a lock that is released on Close.
 type lockedFile struct {
 	afero.File
@@ -243,12 +255,22 @@ func (c *Cache) Get(id string) (ItemInfo, io.ReadCloser, error) {
 
 // getOrRemove gets the file with the given id. If it's expired, it will
 // be removed.
-func (c *Cache) getOrRemove(id string) hugio.ReadSeekCloser {
+func (c *Cache) getOrRemove(id string) (r hugio.ReadSeekCloser) {
 	if c.maxAge == 0 {
 		// No caching.
 		return nil
 	}
 
+	if c.hits != nil {
+		defer func() {
+			if r != nil {
+				c.hits.Inc()
+			} else {
+				c.misses.Inc()
+			}
+		}()
+	}
+
 	if c.maxAge > 0 {
 		fi, err := c.Fs.Stat(id)
 		if err != nil {
@@ -342,7 +364,7 @@ func NewCaches(p *helpers.PathSpec) (Caches, error) {
 
 		bfs := afero.NewBasePathFs(cfs, baseDir)
 
-		m[k] = NewCache(bfs, v.MaxAge)
+		m[k] = NewCache(bfs, v.MaxAge).withMetrics(hmetrics.DefaultRegistry, k)
 	}
 
 	return m, nil
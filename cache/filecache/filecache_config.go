@@ -19,11 +19,11 @@ import (
 	"strings"
 	"time"
 
+	"github.com/gohugoio/hugo/config"
 	"github.com/gohugoio/hugo/helpers"
+	"github.com/gohugoio/hugo/hugofs"
 
-	"github.com/mitchellh/mapstructure"
 	"github.com/pkg/errors"
-	"github.com/spf13/afero"
 )
 
 const (
@@ -106,23 +106,12 @@ func decodeConfig(p *helpers.PathSpec) (cachesConfig, error) {
 
 	m := cfg.GetStringMap(cachesConfigKey)
 
-	_, isOsFs := p.Fs.Source.(*afero.OsFs)
+	isOsFs := hugofs.IsOsFs(p.Fs.Source)
 
 	for k, v := range m {
 		cc := defaultCacheConfig
 
-		dc := &mapstructure.DecoderConfig{
-			Result:           &cc,
-			DecodeHook:       mapstructure.StringToTimeDurationHookFunc(),
-			WeaklyTypedInput: true,
-		}
-
-		decoder, err := mapstructure.NewDecoder(dc)
-		if err != nil {
-			return c, err
-		}
-
-		if err := decoder.Decode(v); err != nil {
+		if err := config.DecodeValue(v, &cc); err != nil {
 			return nil, err
 		}
 
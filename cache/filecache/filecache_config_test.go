@@ -192,6 +192,28 @@ dir = "/"
 
 }
 
+func TestDecodeConfigRelativeDirDecoratedOsFs(t *testing.T) {
+	t.Parallel()
+
+	assert := require.New(t)
+
+	configStr := `
+[caches]
+[caches.getJSON]
+dir = "relative/dir"
+`
+
+	cfg, err := config.FromConfigString(configStr, "toml")
+	assert.NoError(err)
+	fs := hugofs.NewFrom(hugofs.NewTimeoutFs(hugofs.Os, hugofs.Timeouts{}), cfg)
+	p, err := helpers.NewPathSpec(fs, cfg)
+	assert.NoError(err)
+
+	_, err = decodeConfig(p)
+	assert.Error(err)
+	assert.Contains(err.Error(), "must resolve to an absolute directory")
+}
+
 func newTestConfig() *viper.Viper {
 	cfg := viper.New()
 	cfg.Set("workingDir", filepath.FromSlash("/my/cool/hugoproject"))
@@ -29,6 +29,7 @@ import (
 	"github.com/gohugoio/hugo/config"
 	"github.com/gohugoio/hugo/helpers"
 
+	"github.com/gohugoio/hugo/common/hmetrics"
 	"github.com/gohugoio/hugo/hugofs"
 	"github.com/spf13/afero"
 
@@ -250,6 +251,22 @@ dir = "/cache/c"
 	wg.Wait()
 }
 
+func TestFileCacheMetrics(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+
+	r := hmetrics.NewRegistry()
+	c := NewCache(afero.NewMemMapFs(), -1).withMetrics(r, "getjson")
+
+	for i := 0; i < 3; i++ {
+		_, _, err := c.GetOrCreateBytes("a", func() ([]byte, error) { return []byte("abc"), nil })
+		assert.NoError(err)
+	}
+
+	assert.Equal(int64(2), r.Counter("filecache_hits_total", "cache", "getjson").Value())
+	assert.Equal(int64(1), r.Counter("filecache_misses_total", "cache", "getjson").Value())
+}
+
 func TestCleanID(t *testing.T) {
 	assert := require.New(t)
 	assert.Equal(filepath.FromSlash("a/b/c.txt"), cleanID(filepath.FromSlash("/a/b//c.txt")))
@@ -15,6 +15,7 @@ package commands
 
 import (
 	"bytes"
+	"context"
 	"errors"
 
 	"io/ioutil"
@@ -37,13 +38,16 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/gohugoio/hugo/hugolib"
+	"github.com/gohugoio/hugo/hugolib/filesystems"
 	"github.com/spf13/afero"
 
 	"github.com/bep/debounce"
+	"github.com/gohugoio/hugo/common/hmetrics"
 	"github.com/gohugoio/hugo/common/types"
 	"github.com/gohugoio/hugo/deps"
 	"github.com/gohugoio/hugo/helpers"
 	"github.com/gohugoio/hugo/hugofs"
+	"github.com/gohugoio/hugo/hugolib/paths"
 	"github.com/gohugoio/hugo/langs"
 )
 
@@ -62,9 +66,37 @@ type commandeer struct {
 	// be fast enough that we could maybe just add it for all server modes.
 	changeDetector *fileChangeDetector
 
-	// We need to reuse this on server rebuilds.
+	// The destination with all the decorators applied.
 	destinationFs afero.Fs
 
+	// The destination before the per-config decorators (change detection,
+	// manifest, fingerprinting etc.) are applied.
+	// We need to reuse this on server rebuilds.
+	publishFs afero.Fs
+
+	// Limits the concurrent writes when publishConcurrency is set.
+	boundedWriteFs *hugofs.BoundedWriteFs
+
+	// Records the files published when a build manifest is configured.
+	manifestFs *hugofs.ManifestFs
+
+	// Fingerprints the published files matching the configured patterns.
+	fingerprintFs       *hugofs.FingerprintFs
+	fingerprintManifest string
+
+	// Indexes the content dirs across runs when dirIndex is set, or in memory
+	// when cacheDirListings is set.
+	// We need to reuse this on server rebuilds.
+	dirIndex *hugofs.DirIndex
+
+	// Caches the source file hashes across runs when hashCache is set.
+	// We need to reuse this on server rebuilds.
+	hashCache *hugofs.HashCache
+
+	// Cancelled on shutdown to abort any build in progress.
+	buildCtx    context.Context
+	cancelBuild context.CancelFunc
+
 	h    *hugoBuilderCommon
 	ftch flagsToConfigHandler
 
@@ -75,6 +107,9 @@ type commandeer struct {
 	// We watch these for changes.
 	configFiles []string
 
+	// Notifies subscribers about config changes in server mode.
+	configWatch *config.WatchableProvider
+
 	// Used in cases where we get flooded with events in server mode.
 	debounce func(f func())
 
@@ -90,6 +125,48 @@ type commandeer struct {
 
 	// Any error from the last build.
 	buildErr error
+
+	// The source filesystems of the current HugoSites, read from the HTTP
+	// handlers. Guarded by its own lock as c.hugo gets replaced on config
+	// reloads while the server is running.
+	baseFsMu sync.RWMutex
+	baseFs   *filesystems.BaseFs
+}
+
+// currentBaseFs returns the source filesystems of the last loaded config.
+// It is safe for concurrent use.
+func (c *commandeer) currentBaseFs() *filesystems.BaseFs {
+	c.baseFsMu.RLock()
+	defer c.baseFsMu.RUnlock()
+	return c.baseFs
+}
+
+func (c *commandeer) setBaseFs(fs *filesystems.BaseFs) {
+	c.baseFsMu.Lock()
+	c.baseFs = fs
+	c.baseFsMu.Unlock()
+}
+
+// liveConfig returns the configuration to read outside of the build, e.g.
+// in the HTTP handlers. When watching for changes, this is a snapshot
+// replaced after every config reload, so a rebuild in progress cannot
+// change it under the reader.
+func (c *commandeer) liveConfig() config.Provider {
+	if c.configWatch != nil {
+		return c.configWatch
+	}
+	return c.Cfg
+}
+
+// newConfigSnapshot returns an immutable copy of cfg, or cfg itself if its
+// settings cannot be listed.
+func newConfigSnapshot(cfg config.Provider) config.Provider {
+	if s, ok := cfg.(interface {
+		AllSettings() map[string]interface{}
+	}); ok {
+		return config.NewSnapshot(s.AllSettings())
+	}
+	return cfg
 }
 
 func (c *commandeer) errCount() int {
@@ -146,7 +223,11 @@ func newCommandeer(mustHaveConfigFile, running bool, h *hugoBuilderCommon, f fla
 		rebuildDebouncer = debounce.New(4 * time.Second)
 	}
 
+	buildCtx, cancelBuild := context.WithCancel(context.Background())
+
 	c := &commandeer{
+		buildCtx:            buildCtx,
+		cancelBuild:         cancelBuild,
 		h:                   h,
 		ftch:                f,
 		commandeerHugoState: &commandeerHugoState{},
@@ -174,6 +255,17 @@ func (f *fileChangeDetector) OnFileClose(name, md5sum string) {
 	f.current[name] = md5sum
 }
 
+// OnFileOpen records the cached MD5 sum of a file published before the
+// detector was created, e.g. before a restart, so it is not reported as
+// changed if written with the same content.
+func (f *fileChangeDetector) OnFileOpen(name, prevMD5sum string) {
+	f.Lock()
+	defer f.Unlock()
+	if _, found := f.prev[name]; !found {
+		f.prev[name] = prevMD5sum
+	}
+}
+
 func (f *fileChangeDetector) changed() []string {
 	if f == nil {
 		return nil
@@ -282,7 +374,8 @@ func (c *commandeer) loadConfig(mustHaveConfigFile, running bool) error {
 			WorkingDir:   dir,
 			Filename:     c.h.cfgFile,
 			AbsConfigDir: c.h.getConfigDir(dir),
-			Environment:  environment},
+			Environment:  environment,
+			Provenance:   c.h.provenance},
 		doWithCommandeer,
 		doWithConfig)
 
@@ -325,6 +418,7 @@ func (c *commandeer) loadConfig(mustHaveConfigFile, running bool) error {
 	c.logger = logger
 
 	createMemFs := config.GetBool("renderToMemory")
+	diskPublishDir := paths.AbsPathify(config.GetString("workingDir"), config.GetString("publishDir"))
 
 	if createMemFs {
 		// Rendering to memoryFS, publish to Root regardless of publishDir.
@@ -333,15 +427,82 @@ func (c *commandeer) loadConfig(mustHaveConfigFile, running bool) error {
 
 	c.fsCreate.Do(func() {
 		fs := hugofs.NewFrom(sourceFs, config)
+		fs.Logger = hugofs.NewLogger(logger)
+
+		if config.GetBool("serverMetrics") {
+			fs.Metrics = hmetrics.DefaultRegistry
+		}
+
+		if filename := config.GetString("dirIndex"); filename != "" {
+			if c.dirIndex == nil {
+				c.dirIndex = c.loadDirIndex(sourceFs, paths.AbsPathify(config.GetString("workingDir"), filename))
+			}
+			fs.DirIndex = c.dirIndex
+		} else if config.GetBool("cacheDirListings") {
+			// Keep the listings in memory only.
+			if c.dirIndex == nil {
+				c.dirIndex = hugofs.NewDirIndex()
+			}
+			fs.DirIndex = c.dirIndex
+		}
 
-		if c.destinationFs != nil {
-			// Need to reuse the destination on server rebuilds.
-			fs.Destination = c.destinationFs
-		} else if createMemFs {
-			// Hugo writes the output to memory instead of the disk.
-			fs.Destination = new(afero.MemMapFs)
+		var timeouts hugofs.Timeouts
+		timeouts, err = decodeFsTimeouts(config)
+		if err != nil {
+			return
+		}
+
+		if timeouts.Stat > 0 || timeouts.Open > 0 || timeouts.Read > 0 {
+			// Report hangs on e.g. dead network mounts as errors.
+			fs.Source = hugofs.NewTimeoutFs(fs.Source, timeouts)
+		}
+
+		if n := config.GetInt("prefetchFiles"); n > 0 {
+			// Read ahead the files listed in e.g. the content dirs.
+			fs.Source = hugofs.NewPrefetchFs(fs.Source, n)
+		}
+
+		var quota hugofs.Quota
+		quota, err = decodeBuildQuota(config)
+		if err != nil {
+			return
+		}
+
+		if quota.MaxBytes > 0 || quota.MaxFiles > 0 {
+			// Fail the build early if it reads more than configured.
+			fs.Source = hugofs.NewQuotaFs(fs.Source, quota)
 		}
 
+		if filename := config.GetString("hashCache"); filename != "" {
+			if c.hashCache == nil {
+				c.hashCache = c.loadHashCache(sourceFs, paths.AbsPathify(config.GetString("workingDir"), filename))
+			}
+			fs.HashCache = c.hashCache
+		}
+
+		if c.publishFs == nil {
+			// With renderToMemory, Hugo writes the output to memory
+			// instead of the disk.
+			fs.Destination = hugofs.NewPublishTarget(fs.Destination, diskPublishDir, createMemFs)
+
+			if n := config.GetInt("publishConcurrency"); n > 0 {
+				// Limit the number of files written concurrently, e.g. on
+				// network filesystems.
+				c.boundedWriteFs = hugofs.NewBoundedWriteFs(fs.Destination, n)
+				fs.Destination = c.boundedWriteFs
+			}
+
+			if config.GetBool("serverMetrics") {
+				fs.Destination = hugofs.NewMetricsFs(fs.Destination, hmetrics.DefaultRegistry, "publish")
+			}
+
+			c.publishFs = fs.Destination
+		}
+
+		// Need to reuse the destination on server rebuilds, but the
+		// decorators below are created from scratch on every config load.
+		fs.Destination = c.publishFs
+
 		if c.fastRenderMode {
 			// For now, fast render mode only. It should, however, be fast enough
 			// for the full variant, too.
@@ -353,12 +514,39 @@ func (c *commandeer) loadConfig(mustHaveConfigFile, running bool) error {
 			}
 
 			changeDetector.PrepareNew()
-			fs.Destination = hugofs.NewHashingFs(fs.Destination, changeDetector)
+			fs.Destination = hugofs.NewHashingFsWithCache(fs.Destination, changeDetector, c.hashCache)
 			c.changeDetector = changeDetector
 		}
 
+		publishDir := paths.AbsPathify(config.GetString("workingDir"), config.GetString("publishDir"))
+
+		if config.GetString("buildManifest") != "" {
+			c.manifestFs = hugofs.NewManifestFs(fs.Destination, publishDir)
+			fs.Destination = c.manifestFs
+		}
+
+		var fpCfg fingerprintConfig
+		fpCfg, err = decodeFingerprintConfig(config)
+		if err != nil {
+			return
+		}
+
+		if len(fpCfg.Patterns) > 0 {
+			c.fingerprintFs, err = hugofs.NewFingerprintFsWithCache(fs.Destination, publishDir, c.hashCache, fpCfg.Patterns...)
+			if err != nil {
+				return
+			}
+			c.fingerprintManifest = fpCfg.Manifest
+			fs.Destination = c.fingerprintFs
+		}
+
 		if c.Cfg.GetBool("logPathWarnings") {
-			fs.Destination = hugofs.NewCreateCountingFs(fs.Destination)
+			var folding hugofs.PathFolding
+			folding, err = decodePathFolding(config)
+			if err != nil {
+				return
+			}
+			fs.Destination = hugofs.NewCreateCountingFsWithFolding(fs.Destination, folding)
 		}
 
 		// To debug hard-to-find path issues.
@@ -373,6 +561,9 @@ func (c *commandeer) loadConfig(mustHaveConfigFile, running bool) error {
 
 		h, err = hugolib.NewHugoSites(*c.DepsCfg)
 		c.hugo = h
+		if h != nil {
+			c.setBaseFs(h.BaseFs)
+		}
 
 	})
 
@@ -54,6 +54,7 @@ func (b *commandsBuilder) addAll() *commandsBuilder {
 		newImportCmd(),
 		newGenCmd(),
 		createReleaser(),
+		createMounter(),
 	)
 
 	return b
@@ -213,6 +214,9 @@ type hugoBuilderCommon struct {
 	cfgFile string
 	cfgDir  string
 	logFile string
+
+	// If set, the origin of every config value is recorded here.
+	provenance *config.Provenance
 }
 
 func (cc *hugoBuilderCommon) getConfigDir(baseDir string) string {
@@ -14,9 +14,11 @@
 package commands
 
 import (
+	"os"
 	"reflect"
 	"sort"
 
+	"github.com/gohugoio/hugo/config"
 	"github.com/spf13/cobra"
 	jww "github.com/spf13/jwalterweatherman"
 	"github.com/spf13/viper"
@@ -27,6 +29,8 @@ var _ cmder = (*configCmd)(nil)
 type configCmd struct {
 	hugoBuilderCommon
 	*baseCmd
+
+	showProvenance bool
 }
 
 func newConfigCmd() *configCmd {
@@ -39,18 +43,27 @@ func newConfigCmd() *configCmd {
 	})
 
 	cc.cmd.Flags().StringVarP(&cc.source, "source", "s", "", "filesystem path to read files relative from")
+	cc.cmd.Flags().BoolVar(&cc.showProvenance, "provenance", false, "print the origin of every setting, e.g. the config file or theme it is set in")
 
 	return cc
 }
 
 func (c *configCmd) printConfig(cmd *cobra.Command, args []string) error {
+	if c.showProvenance {
+		c.provenance = config.NewProvenance()
+	}
+
 	cfg, err := initializeConfig(true, false, &c.hugoBuilderCommon, c, nil)
 
 	if err != nil {
 		return err
 	}
 
-	allSettings := cfg.Cfg.(*viper.Viper).AllSettings()
+	allSettings := config.DefaultRedactor.Redact(cfg.Cfg.(*viper.Viper).AllSettings())
+
+	if c.provenance != nil {
+		return c.provenance.Dump(os.Stdout, allSettings)
+	}
 
 	var separator string
 	if allSettings["metadataformat"] == "toml" {
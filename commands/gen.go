@@ -35,7 +35,8 @@ func newGenCmd() *genCmd {
 		newGenDocCmd().getCommand(),
 		newGenManCmd().getCommand(),
 		createGenDocsHelper().getCommand(),
-		createGenChromaStyles().getCommand())
+		createGenChromaStyles().getCommand(),
+		createGenConfigSchema().getCommand())
 
 	return cc
 }
@@ -0,0 +1,49 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"os"
+
+	"github.com/gohugoio/hugo/hugolib"
+	"github.com/spf13/cobra"
+)
+
+var (
+	_ cmder = (*genConfigSchema)(nil)
+)
+
+type genConfigSchema struct {
+	*baseCmd
+}
+
+func createGenConfigSchema() *genConfigSchema {
+	g := &genConfigSchema{
+		baseCmd: newBaseCmd(&cobra.Command{
+			Use:   "configschema",
+			Short: "Generate a JSON Schema for the site configuration",
+			Long: `Generate a JSON Schema describing the top-level site configuration keys and
+the kind of value they expect, for editors to validate config files as you type.
+
+The keys are matched case insensitive, as in Hugo. Keys not known to Hugo are
+allowed, as they may be used in templates.`,
+		}),
+	}
+
+	g.cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		return hugolib.ConfigSchema().WriteJSONSchema(os.Stdout, "Hugo site configuration")
+	}
+
+	return g
+}
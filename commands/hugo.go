@@ -16,13 +16,13 @@
 package commands
 
 import (
+	"bytes"
 	"fmt"
 	"io/ioutil"
 	"os/signal"
 	"runtime/pprof"
 	"runtime/trace"
 	"sort"
-	"sync/atomic"
 
 	"github.com/gohugoio/hugo/hugofs"
 
@@ -59,7 +59,6 @@ import (
 	"github.com/gohugoio/hugo/watcher"
 	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
-	"github.com/spf13/fsync"
 	jww "github.com/spf13/jwalterweatherman"
 )
 
@@ -484,17 +483,44 @@ func (c *commandeer) build() error {
 		return err
 	}
 
+	if err := c.writeFingerprintManifest(); err != nil {
+		return err
+	}
+
+	if err := c.writeBuildManifest(); err != nil {
+		return err
+	}
+
+	if err := c.writeDirIndex(); err != nil {
+		return err
+	}
+
+	// Drop the hashes of the files not seen in the build, e.g. deleted ones.
+	c.hashCache.Prune()
+
+	if err := c.writeHashCache(); err != nil {
+		return err
+	}
+
 	// TODO(bep) Feedback?
 	if !c.h.quiet {
 		fmt.Println()
 		c.hugo.PrintProcessingStats(os.Stdout)
 		fmt.Println()
 
+		if c.boundedWriteFs != nil {
+			stats := c.boundedWriteFs.Stats()
+			c.logger.INFO.Printf("Published %d files (%d bytes) at %.1f files/s, %.1f bytes/s", stats.Files, stats.Bytes, stats.FilesPerSecond(), stats.BytesPerSecond())
+		}
+
 		if createCounter, ok := c.destinationFs.(hugofs.DuplicatesReporter); ok {
 			dupes := createCounter.ReportDuplicates()
 			if dupes != "" {
 				c.logger.WARN.Println("Duplicate target paths:", dupes)
 			}
+			if conflicts := createCounter.ReportConflicts(); conflicts != "" {
+				c.logger.WARN.Println("Conflicting target paths:", conflicts)
+			}
 		}
 	}
 
@@ -513,6 +539,7 @@ func (c *commandeer) build() error {
 		signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
 
 		<-sigs
+		c.cancelBuild()
 	}
 
 	return nil
@@ -536,6 +563,13 @@ func (c *commandeer) serverBuild() error {
 		return err
 	}
 
+	// Drop the hashes of the files not seen in the build, e.g. deleted ones.
+	c.hashCache.Prune()
+
+	if err := c.writeHashCache(); err != nil {
+		return err
+	}
+
 	// TODO(bep) Feedback?
 	if !c.h.quiet {
 		fmt.Println()
@@ -579,21 +613,6 @@ func (c *commandeer) doWithPublishDirs(f func(sourceFs *filesystems.SourceFilesy
 	return langCount, nil
 }
 
-type countingStatFs struct {
-	afero.Fs
-	statCounter uint64
-}
-
-func (fs *countingStatFs) Stat(name string) (os.FileInfo, error) {
-	f, err := fs.Fs.Stat(name)
-	if err == nil {
-		if !f.IsDir() {
-			atomic.AddUint64(&fs.statCounter, 1)
-		}
-	}
-	return f, err
-}
-
 func (c *commandeer) copyStaticTo(sourceFs *filesystems.SourceFilesystem) (uint64, error) {
 	publishDir := c.hugo.PathSpec.PublishDir
 	// If root, remove the second '/'
@@ -605,13 +624,9 @@ func (c *commandeer) copyStaticTo(sourceFs *filesystems.SourceFilesystem) (uint6
 		publishDir = filepath.Join(publishDir, sourceFs.PublishFolder)
 	}
 
-	fs := &countingStatFs{Fs: sourceFs.Fs}
-
-	syncer := fsync.NewSyncer()
+	syncer := hugofs.NewSyncer(sourceFs.Fs, c.Fs.Destination)
 	syncer.NoTimes = c.Cfg.GetBool("noTimes")
 	syncer.NoChmod = c.Cfg.GetBool("noChmod")
-	syncer.SrcFs = fs
-	syncer.DestFs = c.Fs.Destination
 	// Now that we are using a unionFs for the static directories
 	// We can effectively clean the publishDir on initial sync
 	syncer.Delete = c.Cfg.GetBool("cleanDestinationDir")
@@ -627,15 +642,14 @@ func (c *commandeer) copyStaticTo(sourceFs *filesystems.SourceFilesystem) (uint6
 
 	// because we are using a baseFs (to get the union right).
 	// set sync src to root
-	err := syncer.Sync(publishDir, helpers.FilePathSeparator)
+	report, err := syncer.Sync(publishDir, helpers.FilePathSeparator)
 	if err != nil {
 		return 0, err
 	}
 
-	// Sync runs Stat 3 times for every source file (which sounds much)
-	numFiles := fs.statCounter / 3
+	c.logger.INFO.Printf("static files: %d copied, %d unchanged, %d deleted", len(report.Copied), len(report.Unchanged), len(report.Deleted))
 
-	return numFiles, err
+	return uint64(len(report.Copied) + len(report.Unchanged)), nil
 }
 
 func (c *commandeer) firstPathSpec() *helpers.PathSpec {
@@ -746,6 +760,12 @@ func (c *commandeer) getDirList() ([]string, error) {
 		_ = helpers.SymbolicWalk(c.Fs.Source, assetDir, regularWalker)
 	}
 
+	// Themes in a workspace are edited alongside the project, so watch all of
+	// their directories, including component folders not yet in use.
+	for _, tc := range c.hugo.PathSpec.WorkspaceThemes() {
+		_ = helpers.SymbolicWalk(c.Fs.Source, tc.Dir, symLinkWalker)
+	}
+
 	if len(nested) > 0 {
 		for {
 
@@ -769,7 +789,7 @@ func (c *commandeer) getDirList() ([]string, error) {
 }
 
 func (c *commandeer) buildSites() (err error) {
-	return c.hugo.Build(hugolib.BuildCfg{})
+	return c.hugo.Build(hugolib.BuildCfg{Context: c.buildCtx})
 }
 
 func (c *commandeer) handleBuildErr(err error, msg string) {
@@ -800,7 +820,7 @@ func (c *commandeer) rebuildSites(events []fsnotify.Event) error {
 		}
 
 	}
-	return c.hugo.Build(hugolib.BuildCfg{RecentlyVisited: visited}, events...)
+	return c.hugo.Build(hugolib.BuildCfg{RecentlyVisited: visited, Context: c.buildCtx}, events...)
 }
 
 func (c *commandeer) partialReRender(urls ...string) error {
@@ -809,7 +829,177 @@ func (c *commandeer) partialReRender(urls ...string) error {
 	for _, url := range urls {
 		visited[url] = true
 	}
-	return c.hugo.Build(hugolib.BuildCfg{RecentlyVisited: visited, PartialReRender: true})
+	return c.hugo.Build(hugolib.BuildCfg{RecentlyVisited: visited, PartialReRender: true, Context: c.buildCtx})
+}
+
+// decodeBuildQuota decodes the limits on what a build may read from the
+// source filesystem:
+//
+//	[buildQuota]
+//	maxBytes = 1000000000
+//	maxFiles = 50000
+func decodeBuildQuota(cfg config.Provider) (hugofs.Quota, error) {
+	var q hugofs.Quota
+	err := config.Decode(cfg, "buildQuota", &q)
+	return q, err
+}
+
+// decodeFsTimeouts decodes the timeouts for operations on the source
+// filesystem:
+//
+//	[fsTimeouts]
+//	stat = "5s"
+//	open = "5s"
+//	read = "30s"
+func decodeFsTimeouts(cfg config.Provider) (hugofs.Timeouts, error) {
+	var t hugofs.Timeouts
+	err := config.Decode(cfg, "fsTimeouts", &t)
+	return t, err
+}
+
+// decodePathFolding decodes how target paths are folded when looking for
+// conflicting paths with --path-warnings:
+//
+//	[pathFolding]
+//	case = true
+//	unicode = true
+func decodePathFolding(cfg config.Provider) (hugofs.PathFolding, error) {
+	var p hugofs.PathFolding
+	err := config.Decode(cfg, "pathFolding", &p)
+	return p, err
+}
+
+// fingerprintConfig configures the fingerprinting of published files:
+//
+//	[fingerprint]
+//	patterns = ["**.css", "**.js"]
+//	manifest = "assets.json"
+type fingerprintConfig struct {
+	// Glob patterns matching the files to fingerprint, relative to the
+	// publish dir.
+	Patterns []string
+
+	// The filename, relative to the publish dir, to write the original to
+	// fingerprinted filename mappings to.
+	Manifest string
+}
+
+func decodeFingerprintConfig(cfg config.Provider) (fingerprintConfig, error) {
+	var fc fingerprintConfig
+	err := config.Decode(cfg, "fingerprint", &fc)
+	return fc, err
+}
+
+// writeFingerprintManifest writes the mappings of the files fingerprinted in
+// the build to the configured manifest file in the publish dir, if any.
+func (c *commandeer) writeFingerprintManifest() error {
+	if c.fingerprintFs == nil || c.fingerprintManifest == "" {
+		return nil
+	}
+
+	var b bytes.Buffer
+	if err := c.fingerprintFs.WriteManifest(&b); err != nil {
+		return err
+	}
+
+	filename := filepath.Join(c.hugo.PathSpec.AbsPublishDir, filepath.FromSlash(c.fingerprintManifest))
+
+	// Write it through the wrapped filesystem so it is never fingerprinted.
+	return errors.Wrap(helpers.WriteToDisk(filename, &b, c.fingerprintFs.Fs), "failed to write fingerprint manifest")
+}
+
+// writeBuildManifest writes the manifest of the files published in the
+// build to the file set in buildManifest, if any.
+func (c *commandeer) writeBuildManifest() error {
+	if c.manifestFs == nil {
+		return nil
+	}
+
+	var b bytes.Buffer
+	if err := c.manifestFs.WriteManifest(&b); err != nil {
+		return err
+	}
+
+	filename := c.hugo.PathSpec.AbsPathify(c.Cfg.GetString("buildManifest"))
+
+	return errors.Wrap(helpers.WriteToDisk(filename, &b, c.Fs.Source), "failed to write build manifest")
+}
+
+// loadDirIndex loads the dir index from the given file. Any error is logged
+// and an empty index returned, the index will be rebuilt.
+func (c *commandeer) loadDirIndex(fs afero.Fs, filename string) *hugofs.DirIndex {
+	f, err := fs.Open(filename)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			c.logger.WARN.Printf("Failed to open dir index: %s", err)
+		}
+		return hugofs.NewDirIndex()
+	}
+	defer f.Close()
+
+	idx, err := hugofs.ReadDirIndex(f)
+	if err != nil {
+		c.logger.WARN.Printf("Failed to load dir index %q: %s", filename, err)
+		return hugofs.NewDirIndex()
+	}
+
+	return idx
+}
+
+// writeDirIndex writes the dir index to the file set in dirIndex, if any
+// and if changed. An index kept in memory only, see cacheDirListings, is not
+// written.
+func (c *commandeer) writeDirIndex() error {
+	if c.dirIndex == nil || !c.dirIndex.Dirty() || c.Cfg.GetString("dirIndex") == "" {
+		return nil
+	}
+
+	var b bytes.Buffer
+	if err := c.dirIndex.Write(&b); err != nil {
+		return err
+	}
+
+	filename := c.hugo.PathSpec.AbsPathify(c.Cfg.GetString("dirIndex"))
+
+	return errors.Wrap(helpers.WriteToDisk(filename, &b, c.Fs.Source), "failed to write dir index")
+}
+
+// loadHashCache loads the hash cache from the given file. Any error is logged
+// and an empty cache returned, the hashes will be recomputed.
+func (c *commandeer) loadHashCache(fs afero.Fs, filename string) *hugofs.HashCache {
+	f, err := fs.Open(filename)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			c.logger.WARN.Printf("Failed to open hash cache: %s", err)
+		}
+		return hugofs.NewHashCache()
+	}
+	defer f.Close()
+
+	hc, err := hugofs.ReadHashCache(f)
+	if err != nil {
+		c.logger.WARN.Printf("Failed to load hash cache %q: %s", filename, err)
+		return hugofs.NewHashCache()
+	}
+
+	return hc
+}
+
+// writeHashCache writes the hash cache to the file set in hashCache, if any
+// and if changed.
+func (c *commandeer) writeHashCache() error {
+	if c.hashCache == nil || !c.hashCache.Dirty() {
+		return nil
+	}
+
+	var b bytes.Buffer
+	if err := c.hashCache.Write(&b); err != nil {
+		return err
+	}
+
+	filename := c.hugo.PathSpec.AbsPathify(c.Cfg.GetString("hashCache"))
+
+	return errors.Wrap(helpers.WriteToDisk(filename, &b, c.Fs.Source), "failed to write hash cache")
 }
 
 func (c *commandeer) fullRebuild() {
@@ -845,7 +1035,12 @@ func (c *commandeer) newWatcher(dirList ...string) (*watcher.Batcher, error) {
 		return nil, err
 	}
 
-	watcher, err := watcher.New(1 * time.Second)
+	interval, err := time.ParseDuration(c.Cfg.GetString("watchDebounce"))
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid watchDebounce")
+	}
+
+	watcher, err := watcher.New(interval)
 
 	if err != nil {
 		return nil, err
@@ -866,6 +1061,11 @@ func (c *commandeer) newWatcher(dirList ...string) (*watcher.Batcher, error) {
 		configSet[configFile] = true
 	}
 
+	c.configWatch = config.NewWatchableProvider(newConfigSnapshot(c.Cfg))
+	c.configWatch.Subscribe(func(change config.Change) {
+		c.logger.INFO.Printf("Config changed in %q: %v", change.Filename, change.Keys)
+	})
+
 	go func() {
 		for {
 			select {
@@ -886,11 +1086,75 @@ func (c *commandeer) newWatcher(dirList ...string) (*watcher.Batcher, error) {
 	return watcher, nil
 }
 
+// handleMountChanges updates the watched directories and syncs the static
+// files again when the source directories change, e.g. when contentDir or
+// staticDir is changed in the config. The sites are rebuilt from scratch on
+// config changes, so nothing else needs to be done.
+func (c *commandeer) handleMountChanges(watcher *watcher.Batcher, changes filesystems.MountChanges) {
+	if changes.IsZero() {
+		return
+	}
+
+	c.logger.INFO.Printf("Source directories changed, added: %v, removed: %v", changes.Added, changes.Removed)
+
+	for _, dir := range changes.Removed {
+		for _, d := range c.walkDirs(dir) {
+			_ = watcher.Remove(d)
+		}
+		if c.dirIndex != nil {
+			c.dirIndex.Forget(dir)
+		}
+	}
+
+	for _, dir := range changes.Added {
+		for _, d := range c.walkDirs(dir) {
+			_ = watcher.Add(d)
+		}
+		if c.dirIndex != nil {
+			c.dirIndex.Forget(dir)
+		}
+	}
+
+	if changes.StaticChanged {
+		if _, err := c.copyStatic(); err != nil && !os.IsNotExist(err) {
+			c.logger.ERROR.Println("Error copying static files:", err)
+		}
+	}
+}
+
+// walkDirs returns root and all the directories below it.
+func (c *commandeer) walkDirs(root string) []string {
+	var dirs []string
+	_ = helpers.SymbolicWalk(c.Fs.Source, root, func(path string, fi os.FileInfo, err error) error {
+		if err != nil || !fi.IsDir() {
+			return nil
+		}
+		if fi.Name() == ".git" || fi.Name() == "node_modules" || fi.Name() == "bower_components" {
+			return filepath.SkipDir
+		}
+		dirs = append(dirs, path)
+		return nil
+	})
+	return dirs
+}
+
 func (c *commandeer) handleEvents(watcher *watcher.Batcher,
 	staticSyncer *staticSyncer,
 	evs []fsnotify.Event,
 	configSet map[string]bool) {
 
+	if c.dirIndex != nil {
+		for _, ev := range evs {
+			c.dirIndex.Forget(ev.Name)
+		}
+	}
+
+	if c.hashCache != nil {
+		for _, ev := range evs {
+			c.hashCache.Forget(ev.Name)
+		}
+	}
+
 	for _, ev := range evs {
 		isConfig := configSet[ev.Name]
 		if !isConfig {
@@ -918,8 +1182,19 @@ func (c *commandeer) handleEvents(watcher *watcher.Batcher,
 					}
 				}
 			}
+			var oldBaseFs *filesystems.BaseFs
+			if c.hugo != nil {
+				oldBaseFs = c.hugo.BaseFs
+			}
+
 			// Config file(s) changed. Need full rebuild.
 			c.fullRebuild()
+			if !c.paused {
+				if c.configWatch != nil {
+					c.configWatch.Update(newConfigSnapshot(c.Cfg), ev.Name)
+				}
+				c.handleMountChanges(watcher, c.hugo.BaseFs.DiffMounts(oldBaseFs))
+			}
 			break
 		}
 	}
@@ -1096,6 +1371,14 @@ func (c *commandeer) handleEvents(watcher *watcher.Batcher,
 			c.handleBuildErr(err, "Rebuild failed")
 		}
 
+		if err := c.writeDirIndex(); err != nil {
+			c.logger.ERROR.Println(err)
+		}
+
+		if err := c.writeHashCache(); err != nil {
+			c.logger.ERROR.Println(err)
+		}
+
 		if doLiveReload {
 			if len(partitionedEvents.ContentEvents) == 0 && len(partitionedEvents.AssetEvents) > 0 {
 				changed := c.changeDetector.changed()
@@ -14,9 +14,14 @@
 package commands
 
 import (
+	"io/ioutil"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/gohugoio/hugo/helpers"
+	"github.com/spf13/afero"
 	"github.com/stretchr/testify/require"
 )
 
@@ -50,3 +55,107 @@ contentDir = "thisdoesnotexist"
 	assert.NoError(err)
 
 }
+
+func TestHugoWorkspaceThemeWatched(t *testing.T) {
+	assert := require.New(t)
+
+	workDir, err := ioutil.TempDir("", "hugo-cli-workspace")
+	assert.NoError(err)
+	defer os.RemoveAll(workDir)
+
+	workDir, err = filepath.EvalSymlinks(workDir)
+	assert.NoError(err)
+
+	siteDir := filepath.Join(workDir, "site")
+	themeDir := filepath.Join(workDir, "mytheme")
+
+	writeFile(t, filepath.Join(workDir, "hugo.work"), "use ./mytheme")
+	writeFile(t, filepath.Join(siteDir, "config.toml"), `
+baseURL = "https://example.org"
+theme = "mytheme"
+workspace = "../hugo.work"
+`)
+	writeFile(t, filepath.Join(themeDir, "config", "production", "config.toml"), `
+[params]
+themeParam = "fromWorkspace"
+`)
+	writeFile(t, filepath.Join(themeDir, "layouts", "index.html"), "Home")
+	// Not mounted, but watched so new component folders are picked up.
+	writeFile(t, filepath.Join(themeDir, "components", "gallery", "README.md"), "Gallery")
+
+	c, err := newCommandeer(false, false, &hugoBuilderCommon{source: siteDir}, nil, nil)
+	assert.NoError(err)
+
+	assert.Equal("fromWorkspace", c.Cfg.GetString("params.themeParam"))
+	assert.Contains(c.configFiles, filepath.Join(workDir, "hugo.work"))
+	assert.Contains(c.configFiles, filepath.Join(themeDir, "config", "production"))
+
+	dirs, err := c.getDirList()
+	assert.NoError(err)
+	assert.Contains(dirs, filepath.Join(themeDir, "layouts"))
+	assert.Contains(dirs, filepath.Join(themeDir, "components", "gallery"))
+}
+
+func TestHugoFingerprintOnConfigReload(t *testing.T) {
+	assert := require.New(t)
+
+	siteDir, err := ioutil.TempDir("", "hugo-cli-fingerprint")
+	assert.NoError(err)
+	defer os.RemoveAll(siteDir)
+
+	writeFile(t, filepath.Join(siteDir, "config.toml"), `
+baseURL = "https://example.org"
+[fingerprint]
+patterns = ["**.css"]
+`)
+
+	c, err := newCommandeer(false, true, &hugoBuilderCommon{source: siteDir}, nil, nil)
+	assert.NoError(err)
+
+	// Same as in fullRebuild.
+	c.commandeerHugoState = &commandeerHugoState{}
+	assert.NoError(c.loadConfig(true, true))
+
+	publishDir := c.hugo.PathSpec.AbsPublishDir
+	filename := filepath.Join(publishDir, "css", "style.css")
+	assert.NoError(helpers.WriteToDisk(filename, strings.NewReader("body {}"), c.destinationFs))
+
+	mappings := c.fingerprintFs.Mappings()
+	assert.Len(mappings, 1)
+	hashed := mappings["css/style.css"]
+	assert.Regexp(`^css/style\.[0-9a-f]{8}\.css$`, hashed)
+
+	files, err := afero.ReadDir(c.publishFs, filepath.Join(publishDir, "css"))
+	assert.NoError(err)
+	assert.Len(files, 1)
+	assert.Equal(filepath.Base(hashed), files[0].Name())
+}
+
+func TestHugoBuildManifestOnConfigReload(t *testing.T) {
+	assert := require.New(t)
+
+	siteDir, err := ioutil.TempDir("", "hugo-cli-manifest")
+	assert.NoError(err)
+	defer os.RemoveAll(siteDir)
+
+	writeFile(t, filepath.Join(siteDir, "config.toml"), `
+baseURL = "https://example.org"
+buildManifest = "manifest.json"
+`)
+
+	c, err := newCommandeer(false, false, &hugoBuilderCommon{source: siteDir}, nil, nil)
+	assert.NoError(err)
+
+	c.commandeerHugoState = &commandeerHugoState{}
+	assert.NoError(c.loadConfig(true, false))
+
+	// The manifest must not wrap the one from the previous config load.
+	assert.Equal(c.publishFs, c.manifestFs.Fs)
+
+	filename := filepath.Join(c.hugo.PathSpec.AbsPublishDir, "index.html")
+	assert.NoError(helpers.WriteToDisk(filename, strings.NewReader("Home"), c.destinationFs))
+
+	manifest := c.manifestFs.Manifest()
+	assert.Len(manifest.Written, 1)
+	assert.Equal("index.html", manifest.Written[0].Path)
+}
@@ -16,11 +16,13 @@ package commands
 import (
 	"encoding/csv"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gohugoio/hugo/hugolib"
+	"github.com/gohugoio/hugo/hugolib/paths"
 	"github.com/gohugoio/hugo/resources/resource"
 	"github.com/spf13/cobra"
 	jww "github.com/spf13/jwalterweatherman"
@@ -31,6 +33,8 @@ var _ cmder = (*listCmd)(nil)
 type listCmd struct {
 	hugoBuilderCommon
 	*baseCmd
+
+	bom bool
 }
 
 func (lc *listCmd) buildSites(config map[string]interface{}) (*hugolib.HugoSites, error) {
@@ -197,11 +201,112 @@ List requires a subcommand, e.g. ` + "`hugo list drafts`.",
 					}
 				}
 
+				return nil
+			},
+		},
+		&cobra.Command{
+			Use:   "mounts",
+			Short: "List all mounted source directories",
+			Long:  `List all of the directories mounted into the content, layouts, static etc. source filesystems, from the project and the themes, in order of precedence.`,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				c, err := initializeConfig(true, false, &cc.hugoBuilderCommon, cc, nil)
+				if err != nil {
+					return err
+				}
+
+				sites, err := hugolib.NewHugoSites(*c.DepsCfg)
+				if err != nil {
+					return newSystemError("Error creating sites", err)
+				}
+
+				writer := csv.NewWriter(os.Stdout)
+				defer writer.Flush()
+
+				writer.Write([]string{
+					"component",
+					"source",
+					"theme",
+					"lang",
+					"target",
+				})
+				for _, m := range sites.BaseFs.Mounts() {
+					err := writer.Write([]string{
+						m.Component,
+						strings.TrimPrefix(m.Source, sites.WorkingDir+string(os.PathSeparator)),
+						m.Theme,
+						m.Lang,
+						filepath.ToSlash(m.Target),
+					})
+					if err != nil {
+						return newSystemError("Error writing mounts to stdout", err)
+					}
+				}
+
 				return nil
 			},
 		},
 	)
 
+	licensesCmd := &cobra.Command{
+		Use:   "licenses",
+		Short: "List the licenses of all themes used",
+		Long: `List the license, version and origin of all themes used by the project.
+
+The license is read from the theme's theme.toml, or detected from a LICENSE
+file in the theme. Use --bom to print a CycloneDX bill of materials instead.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := initializeConfig(true, false, &cc.hugoBuilderCommon, cc, nil)
+			if err != nil {
+				return err
+			}
+
+			sites, err := hugolib.NewHugoSites(*c.DepsCfg)
+			if err != nil {
+				return newSystemError("Error creating sites", err)
+			}
+
+			licenses, err := paths.ThemeLicenses(sites.Fs.Source, sites.PathSpec.AllThemes)
+			if err != nil {
+				return newSystemError("Error reading theme licenses", err)
+			}
+
+			if cc.bom {
+				return paths.WriteCycloneDX(os.Stdout, licenses)
+			}
+
+			writer := csv.NewWriter(os.Stdout)
+			defer writer.Flush()
+
+			writer.Write([]string{
+				"theme",
+				"version",
+				"license",
+				"licenseSource",
+				"origin",
+			})
+			for _, l := range licenses {
+				source := l.LicenseSource
+				if l.LicenseFile != "" {
+					source += ":" + l.LicenseFile
+				}
+				err := writer.Write([]string{
+					l.Name,
+					l.Version,
+					l.License,
+					source,
+					l.Origin,
+				})
+				if err != nil {
+					return newSystemError("Error writing licenses to stdout", err)
+				}
+			}
+
+			return nil
+		},
+	}
+	licensesCmd.Flags().BoolVar(&cc.bom, "bom", false, "print a CycloneDX JSON bill of materials")
+	cc.cmd.AddCommand(licensesCmd)
+
 	cc.cmd.PersistentFlags().StringVarP(&cc.source, "source", "s", "", "filesystem path to read files relative from")
 	cc.cmd.PersistentFlags().SetAnnotation("source", cobra.BashCompSubdirsInDir, []string{})
 
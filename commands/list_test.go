@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/csv"
 	"io"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strings"
@@ -68,3 +69,71 @@ func TestListAll(t *testing.T) {
 		"false", "https://example.org/p1/",
 	}, record)
 }
+
+func TestListMounts(t *testing.T) {
+	assert := require.New(t)
+	dir, err := createSimpleTestSite(t, testSiteConfig{})
+
+	assert.NoError(err)
+
+	hugoCmd := newCommandsBuilder().addAll().build()
+	cmd := hugoCmd.getCommand()
+
+	defer func() {
+		os.RemoveAll(dir)
+	}()
+
+	cmd.SetArgs([]string{"-s=" + dir, "list", "mounts"})
+
+	out, err := captureStdout(cmd.ExecuteC)
+	assert.NoError(err)
+
+	r := csv.NewReader(strings.NewReader(out))
+
+	header, err := r.Read()
+	assert.NoError(err)
+	assert.Equal([]string{"component", "source", "theme", "lang", "target"}, header)
+
+	record, err := r.Read()
+	assert.NoError(err)
+	assert.Equal([]string{"content", "content", "", "en", ""}, record)
+}
+
+func TestListLicenses(t *testing.T) {
+	assert := require.New(t)
+	dir, err := createSimpleTestSite(t, testSiteConfig{})
+
+	assert.NoError(err)
+
+	defer func() {
+		os.RemoveAll(dir)
+	}()
+
+	themeDir := filepath.Join(dir, "themes", "mytheme")
+	assert.NoError(os.MkdirAll(themeDir, 0777))
+	cfg, err := ioutil.ReadFile(filepath.Join(dir, "config.toml"))
+	assert.NoError(err)
+	writeFile(t, filepath.Join(dir, "config.toml"), "theme = \"mytheme\"\n"+string(cfg))
+	writeFile(t, filepath.Join(themeDir, "theme.toml"), `
+license = "MIT"
+homepage = "https://example.org/mytheme"
+`)
+
+	hugoCmd := newCommandsBuilder().addAll().build()
+	cmd := hugoCmd.getCommand()
+
+	cmd.SetArgs([]string{"-s=" + dir, "list", "licenses"})
+
+	out, err := captureStdout(cmd.ExecuteC)
+	assert.NoError(err)
+
+	r := csv.NewReader(strings.NewReader(out))
+
+	header, err := r.Read()
+	assert.NoError(err)
+	assert.Equal([]string{"theme", "version", "license", "licenseSource", "origin"}, header)
+
+	record, err := r.Read()
+	assert.NoError(err)
+	assert.Equal([]string{"mytheme", "", "MIT", "metadata", "https://example.org/mytheme"}, record)
+}
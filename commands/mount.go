@@ -0,0 +1,89 @@
+// +build fuse
+
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/gohugoio/hugo/hugolib"
+	"github.com/spf13/cobra"
+	jww "github.com/spf13/jwalterweatherman"
+)
+
+var _ cmder = (*mountCmd)(nil)
+
+type mountCmd struct {
+	hugoBuilderCommon
+	*baseCmd
+
+	lang string
+}
+
+func createMounter() cmder {
+	// Note: This command needs FUSE and must be built with "-tags fuse".
+	cc := &mountCmd{}
+
+	cc.baseCmd = newBaseCmd(&cobra.Command{
+		Use:   "mount [mountpoint]",
+		Short: "Mount the source filesystems read-only for debugging",
+		Long: `Mount the source filesystems as Hugo sees them, with themes, overrides
+and language merging applied, read-only at the given mountpoint.
+
+Press Ctrl+C to unmount.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return errors.New("mountpoint required")
+			}
+			return cc.mount(args[0])
+		},
+	})
+
+	cc.cmd.Flags().StringVarP(&cc.source, "source", "s", "", "filesystem path to read files relative from")
+	cc.cmd.Flags().StringVarP(&cc.lang, "lang", "l", "", "only include the content files in this language")
+
+	return cc
+}
+
+func (cc *mountCmd) mount(mountpoint string) error {
+	c, err := initializeConfig(true, false, &cc.hugoBuilderCommon, cc, nil)
+	if err != nil {
+		return err
+	}
+
+	sites, err := hugolib.NewHugoSites(*c.DepsCfg)
+	if err != nil {
+		return newSystemError("Error creating sites", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-sigs
+		cancel()
+	}()
+
+	jww.FEEDBACK.Println("Mounted the source filesystems at", mountpoint)
+	jww.FEEDBACK.Println("Press Ctrl+C to unmount")
+
+	return sites.BaseFs.MountFUSE(ctx, mountpoint, cc.lang)
+}
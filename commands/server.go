@@ -15,6 +15,7 @@ package commands
 
 import (
 	"bytes"
+	"expvar"
 	"fmt"
 	"net"
 	"net/http"
@@ -32,6 +33,8 @@ import (
 
 	"github.com/pkg/errors"
 
+	"github.com/gohugoio/hugo/common/hmetrics"
+	"github.com/gohugoio/hugo/hugolib/filesystems"
 	"github.com/gohugoio/hugo/livereload"
 	"github.com/gohugoio/hugo/tpl"
 
@@ -337,7 +340,7 @@ func (f *fileServer) createEndpoint(i int) (*http.ServeMux, string, string, erro
 					}
 					port = 1313
 					if !f.c.paused {
-						port = f.c.Cfg.GetInt("liveReloadPort")
+						port = f.c.liveConfig().GetInt("liveReloadPort")
 					}
 					fmt.Fprint(w, injectLiveReloadScript(&b, port))
 
@@ -430,6 +433,11 @@ func (c *commandeer) serve(s *serverCmd) error {
 		livereload.Initialize()
 	}
 
+	serverMetrics := c.Cfg.GetBool("serverMetrics")
+	if serverMetrics {
+		hmetrics.DefaultRegistry.Publish("hugo")
+	}
+
 	var sigs = make(chan os.Signal, 1)
 	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
 
@@ -440,6 +448,18 @@ func (c *commandeer) serve(s *serverCmd) error {
 			mu.HandleFunc("/livereload.js", livereload.ServeJS)
 			mu.HandleFunc("/livereload", livereload.Handler)
 		}
+		if serverMetrics {
+			mu.Handle("/__hugo/metrics", hmetrics.DefaultRegistry.Handler())
+			mu.Handle("/__hugo/vars", expvar.Handler())
+		}
+		if c.Cfg.GetBool("serveSourceFs") {
+			// Browse the source files as Hugo sees them.
+			mu.Handle("/__hugo/fs/", http.StripPrefix("/__hugo/fs", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				// The filesystems are recreated on config changes.
+				c.currentBaseFs().HTTPHandler().ServeHTTP(w, r)
+			})))
+			mu.Handle("/__hugo/dav/", filesystems.NewWebDAVHandler("/__hugo/dav", c.currentBaseFs))
+		}
 		jww.FEEDBACK.Printf("Web Server is available at %s (bind address %s)\n", serverURL, s.serverInterface)
 		go func() {
 			err = http.ListenAndServe(endpoint, mu)
@@ -461,6 +481,8 @@ func (c *commandeer) serve(s *serverCmd) error {
 		<-sigs
 	}
 
+	c.cancelBuild()
+
 	return nil
 }
 
@@ -22,6 +22,8 @@ import (
 	"testing"
 	"time"
 
+	"github.com/gohugoio/hugo/config"
+	"github.com/gohugoio/hugo/deps"
 	"github.com/gohugoio/hugo/helpers"
 
 	"github.com/spf13/viper"
@@ -75,6 +77,25 @@ func TestServer(t *testing.T) {
 
 }
 
+func TestLiveConfig(t *testing.T) {
+	assert := require.New(t)
+
+	v := viper.New()
+	v.Set("liveReloadPort", 1313)
+
+	c := &commandeer{commandeerHugoState: &commandeerHugoState{DepsCfg: &deps.DepsCfg{Cfg: v}}}
+	assert.Equal(v, c.liveConfig())
+
+	c.configWatch = config.NewWatchableProvider(newConfigSnapshot(c.Cfg))
+
+	// A rebuild in progress does not change what the server reads.
+	v.Set("liveReloadPort", 1314)
+	assert.Equal(1313, c.liveConfig().GetInt("liveReloadPort"))
+
+	c.configWatch.Update(newConfigSnapshot(c.Cfg), "config.toml")
+	assert.Equal(1314, c.liveConfig().GetInt("liveReloadPort"))
+}
+
 func TestFixURL(t *testing.T) {
 	type data struct {
 		TestName   string
@@ -21,7 +21,7 @@ import (
 
 	"github.com/fsnotify/fsnotify"
 	"github.com/gohugoio/hugo/helpers"
-	"github.com/spf13/fsync"
+	"github.com/gohugoio/hugo/hugofs"
 )
 
 type staticSyncer struct {
@@ -50,11 +50,9 @@ func (s *staticSyncer) syncsStaticEvents(staticEvents []fsnotify.Event) error {
 			publishDir = filepath.Join(publishDir, sourceFs.PublishFolder)
 		}
 
-		syncer := fsync.NewSyncer()
+		syncer := hugofs.NewSyncer(sourceFs.Fs, c.Fs.Destination)
 		syncer.NoTimes = c.Cfg.GetBool("noTimes")
 		syncer.NoChmod = c.Cfg.GetBool("noChmod")
-		syncer.SrcFs = sourceFs.Fs
-		syncer.DestFs = c.Fs.Destination
 
 		// prevent spamming the log on changes
 		logger := helpers.NewDistinctFeedbackLogger()
@@ -104,7 +102,7 @@ func (s *staticSyncer) syncsStaticEvents(staticEvents []fsnotify.Event) error {
 					// If file still exists, sync it
 					logger.Println("Syncing", relPath, "to", publishDir)
 
-					if err := syncer.Sync(filepath.Join(publishDir, relPath), relPath); err != nil {
+					if _, err := syncer.Sync(filepath.Join(publishDir, relPath), relPath); err != nil {
 						c.logger.ERROR.Println(err)
 					}
 				} else {
@@ -116,7 +114,7 @@ func (s *staticSyncer) syncsStaticEvents(staticEvents []fsnotify.Event) error {
 
 			// For all other event operations Hugo will sync static.
 			logger.Println("Syncing", relPath, "to", publishDir)
-			if err := syncer.Sync(filepath.Join(publishDir, relPath), relPath); err != nil {
+			if _, err := syncer.Sync(filepath.Join(publishDir, relPath), relPath); err != nil {
 				c.logger.ERROR.Println(err)
 			}
 		}
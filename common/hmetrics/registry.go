@@ -0,0 +1,271 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package hmetrics provides counters and timers for monitoring Hugo.
+package hmetrics
+
+import (
+	"expvar"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultRegistry is the registry used by Hugo's filesystems and caches.
+var DefaultRegistry = NewRegistry()
+
+// Registry holds a set of operational counters and timers, e.g. the
+// operations done on a filesystem or the hit rate of a cache, so long running
+// servers can be monitored. It can be exported with expvar, see Publish, or
+// in the Prometheus text format, see WritePrometheus.
+//
+// A metric is identified by its name and any labels, given as key/value
+// pairs, e.g. Counter("hugofs_ops_total", "fs", "content", "op", "open").
+type Registry struct {
+	mu       sync.RWMutex
+	counters map[string]*Counter
+	timers   map[string]*Timer
+}
+
+// NewRegistry creates a new empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		counters: make(map[string]*Counter),
+		timers:   make(map[string]*Timer),
+	}
+}
+
+// Counter is a monotonically increasing count.
+type Counter struct {
+	metricID
+	v int64
+}
+
+// Inc increments the counter by one.
+func (c *Counter) Inc() {
+	atomic.AddInt64(&c.v, 1)
+}
+
+// Add adds n to the counter.
+func (c *Counter) Add(n int64) {
+	atomic.AddInt64(&c.v, n)
+}
+
+// Value returns the current count.
+func (c *Counter) Value() int64 {
+	return atomic.LoadInt64(&c.v)
+}
+
+// Timer tracks the number and total duration of some operation.
+type Timer struct {
+	metricID
+	count int64
+	sum   int64
+}
+
+// Observe adds a measurement of d.
+func (t *Timer) Observe(d time.Duration) {
+	atomic.AddInt64(&t.count, 1)
+	atomic.AddInt64(&t.sum, int64(d))
+}
+
+// ObserveSince adds a measurement of the time elapsed since start.
+// Used with defer and time.Now().
+func (t *Timer) ObserveSince(start time.Time) {
+	t.Observe(time.Since(start))
+}
+
+// Count returns the number of measurements.
+func (t *Timer) Count() int64 {
+	return atomic.LoadInt64(&t.count)
+}
+
+// Sum returns the total duration measured.
+func (t *Timer) Sum() time.Duration {
+	return time.Duration(atomic.LoadInt64(&t.sum))
+}
+
+type metricID struct {
+	name   string
+	labels string
+}
+
+func newMetricID(name string, labels []string) metricID {
+	if len(labels)%2 != 0 {
+		panic(fmt.Sprintf("metric %q: labels must be key/value pairs", name))
+	}
+
+	var pairs []string
+	for i := 0; i < len(labels); i += 2 {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", sanitizeMetricName(labels[i]), labels[i+1]))
+	}
+	sort.Strings(pairs)
+
+	return metricID{name: sanitizeMetricName(name), labels: strings.Join(pairs, ",")}
+}
+
+func (id metricID) String() string {
+	if id.labels == "" {
+		return id.name
+	}
+	return id.name + "{" + id.labels + "}"
+}
+
+// Counter returns the counter with the given name and labels, creating it if
+// needed.
+func (r *Registry) Counter(name string, labels ...string) *Counter {
+	id := newMetricID(name, labels)
+	key := id.String()
+
+	r.mu.RLock()
+	c, found := r.counters[key]
+	r.mu.RUnlock()
+	if found {
+		return c
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if c, found = r.counters[key]; !found {
+		c = &Counter{metricID: id}
+		r.counters[key] = c
+	}
+
+	return c
+}
+
+// Timer returns the timer with the given name and labels, creating it if
+// needed.
+func (r *Registry) Timer(name string, labels ...string) *Timer {
+	id := newMetricID(name, labels)
+	key := id.String()
+
+	r.mu.RLock()
+	t, found := r.timers[key]
+	r.mu.RUnlock()
+	if found {
+		return t
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if t, found = r.timers[key]; !found {
+		t = &Timer{metricID: id}
+		r.timers[key] = t
+	}
+
+	return t
+}
+
+// Vars returns a snapshot of all the metrics keyed by name and labels. Counters
+// are represented by their value, timers by their count and total duration in
+// seconds.
+func (r *Registry) Vars() map[string]interface{} {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	vars := make(map[string]interface{})
+	for key, c := range r.counters {
+		vars[key] = c.Value()
+	}
+	for key, t := range r.timers {
+		vars[key] = map[string]interface{}{
+			"count":       t.Count(),
+			"sum_seconds": t.Sum().Seconds(),
+		}
+	}
+
+	return vars
+}
+
+// Publish publishes the metrics as an expvar variable with the given name.
+// Note that, as with expvar.Publish, it panics if the name is already in use.
+func (r *Registry) Publish(name string) {
+	expvar.Publish(name, expvar.Func(func() interface{} {
+		return r.Vars()
+	}))
+}
+
+// WritePrometheus writes the metrics to w in the Prometheus text format.
+// Timers are written as summaries in seconds.
+func (r *Registry) WritePrometheus(w io.Writer) error {
+	r.mu.RLock()
+	counters := make([]*Counter, 0, len(r.counters))
+	for _, c := range r.counters {
+		counters = append(counters, c)
+	}
+	timers := make([]*Timer, 0, len(r.timers))
+	for _, t := range r.timers {
+		timers = append(timers, t)
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(counters, func(i, j int) bool { return counters[i].String() < counters[j].String() })
+	sort.Slice(timers, func(i, j int) bool { return timers[i].String() < timers[j].String() })
+
+	var lastName string
+	for _, c := range counters {
+		if c.name != lastName {
+			if _, err := fmt.Fprintf(w, "# TYPE %s counter\n", c.name); err != nil {
+				return err
+			}
+			lastName = c.name
+		}
+		if _, err := fmt.Fprintf(w, "%s %d\n", c, c.Value()); err != nil {
+			return err
+		}
+	}
+
+	lastName = ""
+	for _, t := range timers {
+		name := t.name + "_seconds"
+		if name != lastName {
+			if _, err := fmt.Fprintf(w, "# TYPE %s summary\n", name); err != nil {
+				return err
+			}
+			lastName = name
+		}
+		sum := metricID{name: name + "_sum", labels: t.labels}
+		count := metricID{name: name + "_count", labels: t.labels}
+		if _, err := fmt.Fprintf(w, "%s %g\n%s %d\n", sum, t.Sum().Seconds(), count, t.Count()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Handler returns a http.Handler serving the metrics in the Prometheus
+// text format.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		r.WritePrometheus(w)
+	})
+}
+
+// sanitizeMetricName replaces any character not valid in a Prometheus metric
+// or label name with an underscore.
+func sanitizeMetricName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, name)
+}
@@ -0,0 +1,83 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hmetrics
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry(t *testing.T) {
+	assert := require.New(t)
+
+	r := NewRegistry()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.Counter("ops_total", "op", "open").Inc()
+			r.Counter("ops_total", "op", "stat").Add(2)
+			r.Timer("op_duration", "op", "open").Observe(100 * time.Millisecond)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(int64(10), r.Counter("ops_total", "op", "open").Value())
+	assert.Equal(int64(20), r.Counter("ops_total", "op", "stat").Value())
+	timer := r.Timer("op_duration", "op", "open")
+	assert.Equal(int64(10), timer.Count())
+	assert.Equal(time.Second, timer.Sum())
+
+	// Labels are sorted.
+	assert.Equal(r.Counter("c", "b", "1", "a", "2"), r.Counter("c", "a", "2", "b", "1"))
+
+	vars := r.Vars()
+	assert.Equal(int64(10), vars[`ops_total{op="open"}`])
+	assert.Equal(int64(10), vars[`op_duration{op="open"}`].(map[string]interface{})["count"])
+
+	assert.Panics(func() { r.Counter("c", "a") })
+}
+
+func TestRegistryWritePrometheus(t *testing.T) {
+	assert := require.New(t)
+
+	r := NewRegistry()
+	r.Counter("hugofs_ops_total", "fs", "content", "op", "open").Add(3)
+	r.Counter("hugofs_ops_total", "fs", "content", "op", "stat").Add(5)
+	r.Counter("filecache.hits").Inc()
+	r.Timer("hugofs_op_duration", "op", "open").Observe(1500 * time.Millisecond)
+
+	var b bytes.Buffer
+	assert.NoError(r.WritePrometheus(&b))
+	assert.Equal(`# TYPE filecache_hits counter
+filecache_hits 1
+# TYPE hugofs_ops_total counter
+hugofs_ops_total{fs="content",op="open"} 3
+hugofs_ops_total{fs="content",op="stat"} 5
+# TYPE hugofs_op_duration_seconds summary
+hugofs_op_duration_seconds_sum{op="open"} 1.5
+hugofs_op_duration_seconds_count{op="open"} 1
+`, b.String())
+
+	rec := httptest.NewRecorder()
+	r.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Equal(b.String(), rec.Body.String())
+}
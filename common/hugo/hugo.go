@@ -54,6 +54,12 @@ func (i Info) Generator() template.HTML {
 	return template.HTML(fmt.Sprintf(`<meta name="generator" content="Hugo %s" />`, CurrentVersion.String()))
 }
 
+// IsExtended reports whether this is the extended Hugo build, e.g. with
+// SCSS/SASS support.
+func IsExtended() bool {
+	return isExtended
+}
+
 // NewInfo creates a new Hugo Info object.
 func NewInfo(environment string) Info {
 	if environment == "" {
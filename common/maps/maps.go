@@ -83,7 +83,13 @@ func (r KeyRenamer) getNewKey(keyPath string) string {
 // Rename renames the keys in the given map according
 // to the patterns in the current KeyRenamer.
 func (r KeyRenamer) Rename(m map[string]interface{}) {
-	r.renamePath("", m)
+	r.renamePath("", m, nil)
+}
+
+// RenameFunc is like Rename, but calls f for every key renamed with the
+// lower case, "/" separated path to the old key and the new key.
+func (r KeyRenamer) RenameFunc(m map[string]interface{}, f func(keyPath, newKey string)) {
+	r.renamePath("", m, f)
 }
 
 func (KeyRenamer) keyPath(k1, k2 string) string {
@@ -95,15 +101,15 @@ func (KeyRenamer) keyPath(k1, k2 string) string {
 	}
 }
 
-func (r KeyRenamer) renamePath(parentKeyPath string, m map[string]interface{}) {
+func (r KeyRenamer) renamePath(parentKeyPath string, m map[string]interface{}, f func(keyPath, newKey string)) {
 	for key, val := range m {
 		keyPath := r.keyPath(parentKeyPath, key)
 		switch val.(type) {
 		case map[interface{}]interface{}:
 			val = cast.ToStringMap(val)
-			r.renamePath(keyPath, val.(map[string]interface{}))
+			r.renamePath(keyPath, val.(map[string]interface{}), f)
 		case map[string]interface{}:
-			r.renamePath(keyPath, val.(map[string]interface{}))
+			r.renamePath(keyPath, val.(map[string]interface{}), f)
 		}
 
 		newKey := r.getNewKey(keyPath)
@@ -111,6 +117,9 @@ func (r KeyRenamer) renamePath(parentKeyPath string, m map[string]interface{}) {
 		if newKey != "" {
 			delete(m, key)
 			m[newKey] = val
+			if f != nil {
+				f(keyPath, newKey)
+			}
 		}
 	}
 }
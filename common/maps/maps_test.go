@@ -15,6 +15,7 @@ package maps
 
 import (
 	"reflect"
+	"sort"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -121,3 +122,27 @@ func TestRenameKeys(t *testing.T) {
 	}
 
 }
+
+func TestRenameKeysFunc(t *testing.T) {
+	assert := require.New(t)
+
+	m := map[string]interface{}{
+		"a":   32,
+		"Ren": "m1",
+		"sub": map[string]interface{}{
+			"ren": "m2",
+		},
+	}
+
+	renamer, err := NewKeyRenamer("{ren,sub/ren}", "new")
+	assert.NoError(err)
+
+	var renamed []string
+	renamer.RenameFunc(m, func(keyPath, newKey string) {
+		renamed = append(renamed, keyPath+" => "+newKey)
+	})
+
+	sort.Strings(renamed)
+	assert.Equal([]string{"ren => new", "sub/ren => new"}, renamed)
+	assert.Equal("m1", m["new"])
+}
@@ -71,10 +71,29 @@ func FromFile(fs afero.Fs, filename string) (Provider, error) {
 	return v, nil
 }
 
+// FromFiles loads and merges the configuration from the given filenames,
+// from least to most specific.
+func FromFiles(fs afero.Fs, filenames ...string) (Provider, error) {
+	v := newViper()
+
+	for _, filename := range filenames {
+		m, err := loadConfigFromFile(fs, filename)
+		if err != nil {
+			return nil, err
+		}
+
+		if err = v.MergeConfigMap(m); err != nil {
+			return nil, err
+		}
+	}
+
+	return v, nil
+}
+
 // FromFileToMap is the same as FromFile, but it returns the config values
-// as a simple map.
+// as a simple map. The keys are not renamed, see DeprecatedKeys.
 func FromFileToMap(fs afero.Fs, filename string) (map[string]interface{}, error) {
-	return loadConfigFromFile(fs, filename)
+	return metadecoders.Default.UnmarshalFileToMap(fs, filename)
 }
 
 func readConfig(format metadecoders.Format, data []byte) (map[string]interface{}, error) {
@@ -90,7 +109,7 @@ func readConfig(format metadecoders.Format, data []byte) (map[string]interface{}
 }
 
 func loadConfigFromFile(fs afero.Fs, filename string) (map[string]interface{}, error) {
-	m, err := metadecoders.Default.UnmarshalFileToMap(fs, filename)
+	m, err := FromFileToMap(fs, filename)
 	if err != nil {
 		return nil, err
 	}
@@ -14,6 +14,8 @@
 package config
 
 import (
+	"strings"
+
 	"github.com/spf13/cast"
 )
 
@@ -41,6 +43,99 @@ func GetStringSlicePreserveString(cfg Provider, key string) []string {
 	return cast.ToStringSlice(sd)
 }
 
+// GetStringDefault returns the string value for the given key, or def if
+// the key is not set or its value is empty.
+func GetStringDefault(cfg Provider, key, def string) string {
+	if s := cfg.GetString(key); s != "" {
+		return s
+	}
+	return def
+}
+
+// GetIntDefault returns the int value for the given key, or def if the key
+// is not set or its value cannot be converted to an int.
+func GetIntDefault(cfg Provider, key string, def int) int {
+	if !cfg.IsSet(key) {
+		return def
+	}
+	i, err := cast.ToIntE(cfg.Get(key))
+	if err != nil {
+		return def
+	}
+	return i
+}
+
+// GetBoolDefault returns the bool value for the given key, or def if the key
+// is not set or its value cannot be converted to a bool.
+func GetBoolDefault(cfg Provider, key string, def bool) bool {
+	if !cfg.IsSet(key) {
+		return def
+	}
+	b, err := cast.ToBoolE(cfg.Get(key))
+	if err != nil {
+		return def
+	}
+	return b
+}
+
+// GetStringSliceDefault returns the string slice for the given key, or def
+// if the key is not set. As in GetStringSlicePreserveString, a single string
+// value is not split into fields.
+func GetStringSliceDefault(cfg Provider, key string, def []string) []string {
+	if !cfg.IsSet(key) {
+		return def
+	}
+	return GetStringSlicePreserveString(cfg, key)
+}
+
+// GetNested returns the value for the given dotted key, e.g.
+// "params.author.name". Every part of the key is case-insensitive, also
+// in maps with mixed case keys that the Provider does not normalize, e.g.
+// maps set in code. It returns nil if the key is not found.
+func GetNested(cfg Provider, key string) interface{} {
+	parts := strings.Split(strings.ToLower(key), ".")
+	return lookupNested(cfg.Get(parts[0]), parts[1:])
+}
+
+// GetNestedStringMap returns the map for the given dotted key as looked up
+// by GetNested, or nil if not found or not a map.
+func GetNestedStringMap(cfg Provider, key string) map[string]interface{} {
+	m, err := cast.ToStringMapE(GetNested(cfg, key))
+	if err != nil {
+		return nil
+	}
+	return m
+}
+
+// lookupNested walks down the maps in v following the lower case key parts.
+func lookupNested(v interface{}, parts []string) interface{} {
+	for _, part := range parts {
+		if v == nil {
+			return nil
+		}
+		m, err := cast.ToStringMapE(v)
+		if err != nil {
+			return nil
+		}
+		v = lookupStringMap(m, part)
+	}
+	return v
+}
+
+// lookupStringMap returns the value for the lower case key in m, matching
+// the keys in m case-insensitively.
+func lookupStringMap(m map[string]interface{}, key string) interface{} {
+	if v, found := m[key]; found {
+		return v
+	}
+	for k, v := range m {
+		if strings.EqualFold(k, key) {
+			return v
+		}
+	}
+	return nil
+}
+
 // SetBaseTestDefaults provides some common config defaults used in tests.
 func SetBaseTestDefaults(cfg Provider) {
 	cfg.Set("resourceDir", "resources")
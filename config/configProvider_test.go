@@ -34,3 +34,53 @@ func TestGetStringSlicePreserveString(t *testing.T) {
 	assert.Equal(sSlice, GetStringSlicePreserveString(cfg, "s2"))
 	assert.Nil(GetStringSlicePreserveString(cfg, "s3"))
 }
+
+func TestGetDefault(t *testing.T) {
+	assert := require.New(t)
+	cfg := viper.New()
+
+	cfg.Set("s", "str")
+	cfg.Set("empty", "")
+	cfg.Set("i", "32")
+	cfg.Set("b", "true")
+	cfg.Set("invalid", "abc")
+	cfg.Set("slice", []string{"a", "b"})
+
+	assert.Equal("str", GetStringDefault(cfg, "S", "def"))
+	assert.Equal("def", GetStringDefault(cfg, "empty", "def"))
+	assert.Equal("def", GetStringDefault(cfg, "nope", "def"))
+
+	assert.Equal(32, GetIntDefault(cfg, "i", 10))
+	assert.Equal(10, GetIntDefault(cfg, "invalid", 10))
+	assert.Equal(10, GetIntDefault(cfg, "nope", 10))
+
+	assert.True(GetBoolDefault(cfg, "b", false))
+	assert.True(GetBoolDefault(cfg, "invalid", true))
+	assert.True(GetBoolDefault(cfg, "nope", true))
+
+	assert.Equal([]string{"a", "b"}, GetStringSliceDefault(cfg, "slice", []string{"c"}))
+	assert.Equal([]string{"str"}, GetStringSliceDefault(cfg, "s", []string{"c"}))
+	assert.Equal([]string{"c"}, GetStringSliceDefault(cfg, "nope", []string{"c"}))
+}
+
+func TestGetNested(t *testing.T) {
+	assert := require.New(t)
+	cfg := viper.New()
+
+	cfg.Set("params", map[string]interface{}{
+		"Author": map[string]interface{}{
+			"Name":   "Jo",
+			"social": map[interface{}]interface{}{"twitter": "@jo"},
+		},
+	})
+
+	assert.Equal("Jo", GetNested(cfg, "params.author.name"))
+	assert.Equal("Jo", GetNested(cfg, "Params.Author.NAME"))
+	assert.Equal("@jo", GetNested(cfg, "params.author.social.twitter"))
+	assert.Nil(GetNested(cfg, "params.author.name.first"))
+	assert.Nil(GetNested(cfg, "params.nope.name"))
+	assert.Nil(GetNested(cfg, "nope"))
+
+	assert.Equal(map[string]interface{}{"twitter": "@jo"}, GetNestedStringMap(cfg, "params.author.social"))
+	assert.Nil(GetNestedStringMap(cfg, "params.author.name"))
+}
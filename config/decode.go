@@ -0,0 +1,90 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/gobwas/glob"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+	"github.com/spf13/cast"
+)
+
+// Decode decodes the value of the given key into target, a pointer to e.g.
+// a struct. The target is left untouched if the key is not set.
+// See DecodeValue.
+func Decode(cfg Provider, key string, target interface{}) error {
+	if !cfg.IsSet(key) {
+		return nil
+	}
+	if err := DecodeValue(cfg.Get(key), target); err != nil {
+		return errors.Wrapf(err, "failed to decode %q", key)
+	}
+	return nil
+}
+
+// DecodeValue decodes input into target using weakly typed input, as in
+// mapstructure.WeakDecode, and these conversions:
+//
+// * Strings to time.Duration, e.g. "1h".
+// * Strings to glob.Glob with "/" as separator, e.g. "**.css".
+// * Maps to maps with string keys get their keys lower cased, as
+// configuration keys, e.g. language codes, are case-insensitive.
+func DecodeValue(input, target interface{}) error {
+	dc := &mapstructure.DecoderConfig{
+		Result:           target,
+		WeaklyTypedInput: true,
+		DecodeHook: mapstructure.ComposeDecodeHookFunc(
+			mapstructure.StringToTimeDurationHookFunc(),
+			stringToGlobHookFunc,
+			lowerMapKeysHookFunc,
+		),
+	}
+
+	decoder, err := mapstructure.NewDecoder(dc)
+	if err != nil {
+		return err
+	}
+
+	return decoder.Decode(input)
+}
+
+var globType = reflect.TypeOf((*glob.Glob)(nil)).Elem()
+
+func stringToGlobHookFunc(f reflect.Type, t reflect.Type, data interface{}) (interface{}, error) {
+	if f.Kind() != reflect.String || t != globType {
+		return data, nil
+	}
+	return glob.Compile(data.(string), '/')
+}
+
+func lowerMapKeysHookFunc(f reflect.Type, t reflect.Type, data interface{}) (interface{}, error) {
+	if f.Kind() != reflect.Map || t.Kind() != reflect.Map || t.Key().Kind() != reflect.String {
+		return data, nil
+	}
+
+	m, err := cast.ToStringMapE(data)
+	if err != nil {
+		return data, nil
+	}
+
+	lower := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		lower[strings.ToLower(k)] = v
+	}
+
+	return lower, nil
+}
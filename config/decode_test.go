@@ -0,0 +1,64 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gobwas/glob"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecode(t *testing.T) {
+	assert := require.New(t)
+
+	cfg := viper.New()
+	cfg.Set("mysection", map[string]interface{}{
+		"maxAge":   "2h",
+		"pattern":  "**.css",
+		"count":    "32",
+		"enabled":  "true",
+		"titles":   map[string]interface{}{"en-US": "Hello", "NB": "Hei"},
+		"excluded": "drafts",
+	})
+
+	var c struct {
+		MaxAge   time.Duration
+		Pattern  glob.Glob
+		Count    int
+		Enabled  bool
+		Titles   map[string]string
+		Excluded []string
+	}
+
+	assert.NoError(Decode(cfg, "mySection", &c))
+	assert.Equal(2*time.Hour, c.MaxAge)
+	assert.True(c.Pattern.Match("scss/main.css"))
+	assert.Equal(32, c.Count)
+	assert.True(c.Enabled)
+	assert.Equal(map[string]string{"en-us": "Hello", "nb": "Hei"}, c.Titles)
+	assert.Equal([]string{"drafts"}, c.Excluded)
+
+	// Not set.
+	c.Count = 42
+	assert.NoError(Decode(cfg, "nope", &c))
+	assert.Equal(42, c.Count)
+
+	cfg.Set("invalid", map[string]interface{}{"maxAge": "2 weeks"})
+	err := Decode(cfg, "invalid", &c)
+	assert.Error(err)
+	assert.Contains(err.Error(), `failed to decode "invalid"`)
+}
@@ -0,0 +1,111 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gohugoio/hugo/common/maps"
+)
+
+// DeprecatedKeys translates deprecated configuration keys to their
+// replacements, so renaming a key does not break existing sites.
+type DeprecatedKeys struct {
+	renamer maps.KeyRenamer
+}
+
+// NewDeprecatedKeys creates a new DeprecatedKeys given a list of pattern and
+// replacement key pairs, as in maps.NewKeyRenamer, e.g.
+// "{oldKey,languages/*/oldKey}", "newKey".
+func NewDeprecatedKeys(patternKeys ...string) (*DeprecatedKeys, error) {
+	renamer, err := maps.NewKeyRenamer(patternKeys...)
+	if err != nil {
+		return nil, err
+	}
+	return &DeprecatedKeys{renamer: renamer}, nil
+}
+
+// Translate renames the deprecated keys in m, loaded from origin, e.g. a
+// config filename, and records every key renamed in log, if set.
+func (d *DeprecatedKeys) Translate(origin string, m map[string]interface{}, log *DeprecationLog) {
+	d.renamer.RenameFunc(m, func(keyPath, newKey string) {
+		if log == nil {
+			return
+		}
+		key := strings.Replace(keyPath, "/", ".", -1)
+		replacement := newKey
+		if i := strings.LastIndex(key, "."); i != -1 {
+			replacement = key[:i+1] + newKey
+		}
+		log.Add(DeprecatedKeyUse{Key: key, Replacement: strings.ToLower(replacement), Origin: origin})
+	})
+}
+
+// DeprecatedKeyUse is a deprecated key found in the configuration.
+type DeprecatedKeyUse struct {
+	Key         string
+	Replacement string
+	Origin      string
+}
+
+// DeprecationLog collects the deprecated keys used while loading the
+// configuration, to be reported in one warning.
+type DeprecationLog struct {
+	mu   sync.Mutex
+	uses []DeprecatedKeyUse
+}
+
+// Add records the given uses.
+func (l *DeprecationLog) Add(uses ...DeprecatedKeyUse) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.uses = append(l.uses, uses...)
+}
+
+// Uses returns the recorded uses sorted by origin and key.
+func (l *DeprecationLog) Uses() []DeprecatedKeyUse {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	uses := make([]DeprecatedKeyUse, len(l.uses))
+	copy(uses, l.uses)
+
+	sort.Slice(uses, func(i, j int) bool {
+		if uses[i].Origin != uses[j].Origin {
+			return uses[i].Origin < uses[j].Origin
+		}
+		return uses[i].Key < uses[j].Key
+	})
+
+	return uses
+}
+
+// Warning returns a warning listing all the recorded uses, empty if none.
+func (l *DeprecationLog) Warning() string {
+	uses := l.Uses()
+	if len(uses) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("Deprecated config keys are used and will stop working in a future release. Rename them:")
+	for _, u := range uses {
+		fmt.Fprintf(&b, "\n  %s => %s (%s)", u.Key, u.Replacement, u.Origin)
+	}
+
+	return b.String()
+}
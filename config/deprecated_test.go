@@ -0,0 +1,59 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeprecatedKeys(t *testing.T) {
+	assert := require.New(t)
+
+	d, err := NewDeprecatedKeys("{oldKey,languages/*/oldKey}", "newKey")
+	assert.NoError(err)
+
+	var log DeprecationLog
+	assert.Equal("", log.Warning())
+
+	m := map[string]interface{}{
+		"oldKey": "v1",
+		"languages": map[string]interface{}{
+			"en": map[string]interface{}{"oldkey": "v2"},
+		},
+	}
+
+	d.Translate("config.toml", m, &log)
+	d.Translate("config/_default/config.toml", map[string]interface{}{"oldkey": "v3"}, &log)
+
+	assert.Equal("v1", m["newKey"])
+	assert.Equal("v2", m["languages"].(map[string]interface{})["en"].(map[string]interface{})["newKey"])
+
+	assert.Equal([]DeprecatedKeyUse{
+		{Key: "languages.en.oldkey", Replacement: "languages.en.newkey", Origin: "config.toml"},
+		{Key: "oldkey", Replacement: "newkey", Origin: "config.toml"},
+		{Key: "oldkey", Replacement: "newkey", Origin: "config/_default/config.toml"},
+	}, log.Uses())
+
+	assert.Equal(`Deprecated config keys are used and will stop working in a future release. Rename them:
+  languages.en.oldkey => languages.en.newkey (config.toml)
+  oldkey => newkey (config.toml)
+  oldkey => newkey (config/_default/config.toml)`, log.Warning())
+
+	// Translate without a log.
+	m = map[string]interface{}{"oldKey": "v1"}
+	d.Translate("config.toml", m, nil)
+	assert.Equal("v1", m["newKey"])
+}
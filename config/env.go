@@ -17,6 +17,9 @@ import (
 	"os"
 	"runtime"
 	"strconv"
+	"strings"
+
+	"github.com/gohugoio/hugo/common/hugo"
 )
 
 // GetNumWorkerMultiplier returns the base value used to calculate the number
@@ -31,3 +34,19 @@ func GetNumWorkerMultiplier() int {
 	}
 	return runtime.NumCPU()
 }
+
+// GetEnvironment returns the build environment the given config is loaded
+// for, e.g. "development" or a custom one such as "staging". It returns
+// "production" if not set.
+func GetEnvironment(cfg Provider) string {
+	if env := cfg.GetString("environment"); env != "" {
+		return env
+	}
+	return hugo.EnvironmentProduction
+}
+
+// IsEnvironment reports whether env is the build environment the given config
+// is loaded for. The comparison is case-insensitive.
+func IsEnvironment(cfg Provider, env string) bool {
+	return strings.EqualFold(GetEnvironment(cfg), env)
+}
@@ -0,0 +1,34 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetEnvironment(t *testing.T) {
+	assert := require.New(t)
+
+	cfg := viper.New()
+	assert.Equal("production", GetEnvironment(cfg))
+	assert.True(IsEnvironment(cfg, "production"))
+
+	cfg.Set("environment", "staging")
+	assert.Equal("staging", GetEnvironment(cfg))
+	assert.True(IsEnvironment(cfg, "Staging"))
+	assert.False(IsEnvironment(cfg, "production"))
+}
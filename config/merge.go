@@ -0,0 +1,123 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"strings"
+
+	"github.com/spf13/cast"
+)
+
+// MergeStrategy defines how a configuration value is merged into a more
+// specific configuration that may already have a value for the same key.
+type MergeStrategy int
+
+const (
+	// MergeShallow adds the value only if the key is not already set.
+	MergeShallow MergeStrategy = iota
+
+	// MergeDeep merges maps recursively, adding the missing keys at every
+	// level. Values already set win on conflicts.
+	MergeDeep
+
+	// MergeNone never adds the value.
+	MergeNone
+)
+
+// Merger merges configuration providers. The precedence is, from most to
+// least specific: the project's configuration, the environment's
+// configuration and the themes' configuration; Merge should be called in
+// that order, with the most specific configuration as the destination.
+type Merger struct {
+	defaultStrategy MergeStrategy
+	strategies      map[string]MergeStrategy
+}
+
+// NewMerger creates a new Merger using the given strategy for the keys
+// without a strategy of their own.
+func NewMerger(defaultStrategy MergeStrategy) *Merger {
+	return &Merger{defaultStrategy: defaultStrategy, strategies: make(map[string]MergeStrategy)}
+}
+
+// WithStrategy sets the merge strategy to use for the given key.
+// The key is case-insensitive and may be dotted, e.g. "params.social".
+func (m *Merger) WithStrategy(key string, strategy MergeStrategy) *Merger {
+	m.strategies[strings.ToLower(key)] = strategy
+	return m
+}
+
+// Strategy returns the merge strategy for the given key.
+func (m *Merger) Strategy(key string) MergeStrategy {
+	if strategy, found := m.strategies[strings.ToLower(key)]; found {
+		return strategy
+	}
+	return m.defaultStrategy
+}
+
+// Merge merges the values of the given keys from the less specific src
+// into dst.
+func (m *Merger) Merge(dst, src Provider, keys ...string) {
+	for _, key := range keys {
+		if !src.IsSet(key) {
+			continue
+		}
+
+		strategy := m.Strategy(key)
+		if strategy == MergeNone {
+			continue
+		}
+
+		if !dst.IsSet(key) {
+			dst.Set(key, src.Get(key))
+			continue
+		}
+
+		if strategy != MergeDeep {
+			continue
+		}
+
+		m1, err1 := cast.ToStringMapE(dst.Get(key))
+		m2, err2 := cast.ToStringMapE(src.Get(key))
+		if err1 != nil || err2 != nil {
+			// Not maps, keep the value set.
+			continue
+		}
+
+		dst.Set(key, mergeStringMaps(m1, m2))
+	}
+}
+
+// mergeStringMaps returns a copy of dst with the keys in src missing in dst
+// added, recursively.
+func mergeStringMaps(dst, src map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{})
+	for k, v := range dst {
+		merged[k] = v
+	}
+
+	for k, v2 := range src {
+		v1, found := merged[k]
+		if !found {
+			merged[k] = v2
+			continue
+		}
+		m1, err1 := cast.ToStringMapE(v1)
+		m2, err2 := cast.ToStringMapE(v2)
+		if err1 == nil && err2 == nil {
+			merged[k] = mergeStringMaps(m1, m2)
+		}
+	}
+
+	return merged
+}
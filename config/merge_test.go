@@ -0,0 +1,74 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMerger(t *testing.T) {
+	assert := require.New(t)
+
+	newSrc := func() Provider {
+		src := viper.New()
+		src.Set("title", "Theme Title")
+		src.Set("copyright", "Theme Copyright")
+		src.Set("baseURL", "https://theme.example.com")
+		src.Set("params", map[string]interface{}{
+			"color": "blue",
+			"social": map[string]interface{}{
+				"twitter": "theme",
+				"github":  "theme",
+			},
+		})
+		return src
+	}
+
+	newDst := func() Provider {
+		dst := viper.New()
+		dst.Set("title", "Project Title")
+		dst.Set("params", map[string]interface{}{
+			"social": map[string]interface{}{
+				"twitter": "project",
+			},
+		})
+		return dst
+	}
+
+	dst := newDst()
+	NewMerger(MergeShallow).
+		WithStrategy("Params", MergeDeep).
+		WithStrategy("baseURL", MergeNone).
+		Merge(dst, newSrc(), "title", "copyright", "baseURL", "params", "missing")
+
+	assert.Equal("Project Title", dst.GetString("title"))
+	assert.Equal("Theme Copyright", dst.GetString("copyright"))
+	assert.False(dst.IsSet("baseURL"))
+	assert.False(dst.IsSet("missing"))
+	assert.Equal("blue", dst.GetString("params.color"))
+	assert.Equal("project", dst.GetString("params.social.twitter"))
+	assert.Equal("theme", dst.GetString("params.social.github"))
+
+	dst = newDst()
+	NewMerger(MergeShallow).Merge(dst, newSrc(), "params")
+	assert.False(dst.IsSet("params.color"))
+	assert.False(dst.IsSet("params.social.github"))
+
+	merger := NewMerger(MergeDeep).WithStrategy("title", MergeNone)
+	assert.Equal(MergeDeep, merger.Strategy("params"))
+	assert.Equal(MergeNone, merger.Strategy("TITLE"))
+}
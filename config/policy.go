@@ -0,0 +1,95 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Policy restricts which top-level configuration keys a less trusted
+// configuration, e.g. a theme's, may set.
+type Policy struct {
+	allow map[string]bool
+	deny  map[string]bool
+}
+
+// NewPolicy creates a new Policy allowing only the given keys. If no keys
+// are given, all keys not denied are allowed.
+func NewPolicy(allow ...string) *Policy {
+	return &Policy{allow: keySet(allow), deny: make(map[string]bool)}
+}
+
+// Deny denies the given keys. A denied key is never allowed.
+func (p *Policy) Deny(keys ...string) *Policy {
+	for k := range keySet(keys) {
+		p.deny[k] = true
+	}
+	return p
+}
+
+// Allowed returns whether the given top-level key may be set.
+// The key is case-insensitive.
+func (p *Policy) Allowed(key string) bool {
+	key = strings.ToLower(key)
+	if p.deny[key] {
+		return false
+	}
+	return len(p.allow) == 0 || p.allow[key]
+}
+
+// Check returns a *PolicyError listing the keys set in cfg that are not
+// allowed, nil if none. Origin describes cfg, e.g. `theme "mytheme"`.
+func (p *Policy) Check(origin string, cfg Provider) error {
+	s, ok := cfg.(interface {
+		AllSettings() map[string]interface{}
+	})
+	if !ok {
+		return nil
+	}
+
+	var keys []string
+	for k := range s.AllSettings() {
+		if !p.Allowed(k) {
+			keys = append(keys, strings.ToLower(k))
+		}
+	}
+
+	if len(keys) == 0 {
+		return nil
+	}
+
+	sort.Strings(keys)
+
+	return &PolicyError{Origin: origin, Keys: keys}
+}
+
+// PolicyError lists the configuration keys not allowed by a Policy.
+type PolicyError struct {
+	Origin string
+	Keys   []string
+}
+
+func (e *PolicyError) Error() string {
+	return fmt.Sprintf("%s: config keys not allowed: %s", e.Origin, strings.Join(e.Keys, ", "))
+}
+
+func keySet(keys []string) map[string]bool {
+	m := make(map[string]bool)
+	for _, k := range keys {
+		m[strings.ToLower(k)] = true
+	}
+	return m
+}
@@ -0,0 +1,55 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicy(t *testing.T) {
+	assert := require.New(t)
+
+	p := NewPolicy("params", "menus", "baseURL").Deny("baseURL", "publishDir")
+
+	assert.True(p.Allowed("Params"))
+	assert.False(p.Allowed("baseurl"))
+	assert.False(p.Allowed("title"))
+
+	p = NewPolicy().Deny("baseURL")
+	assert.True(p.Allowed("title"))
+	assert.False(p.Allowed("baseURL"))
+}
+
+func TestPolicyCheck(t *testing.T) {
+	assert := require.New(t)
+
+	p := NewPolicy("params", "menus")
+
+	v := viper.New()
+	v.Set("params", map[string]interface{}{"p1": "v1"})
+	assert.NoError(p.Check(`theme "mytheme"`, v))
+
+	v.Set("baseURL", "https://example.org")
+	v.Set("publishDir", "docs")
+
+	err := p.Check(`theme "mytheme"`, v)
+	assert.Error(err)
+	perr, ok := err.(*PolicyError)
+	assert.True(ok)
+	assert.Equal([]string{"baseurl", "publishdir"}, perr.Keys)
+	assert.Equal(`theme "mytheme": config keys not allowed: baseurl, publishdir`, err.Error())
+}
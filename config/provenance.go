@@ -0,0 +1,129 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cast"
+)
+
+// OriginDefault is the origin reported for values with no recorded origin,
+// i.e. Hugo's defaults and values set from command line flags.
+const OriginDefault = "default"
+
+// Provenance tracks where the configuration values were set, e.g. in
+// "config.toml" or in the config of theme "mytheme". The origins are
+// recorded per value, keyed by the lower case dotted path to the value,
+// e.g. "params.social.twitter".
+type Provenance struct {
+	origins map[string]string
+}
+
+// NewProvenance creates a new, empty Provenance.
+func NewProvenance() *Provenance {
+	return &Provenance{origins: make(map[string]string)}
+}
+
+// Record records origin for all the values in m, overriding any origin
+// already recorded. Use this when merging configuration with a higher
+// precedence, e.g. an environment's config over the project's.
+func (p *Provenance) Record(origin string, m map[string]interface{}) {
+	p.record(origin, m, true)
+}
+
+// RecordMissing records origin for the values in m with no origin
+// recorded. Use this when merging configuration with a lower precedence,
+// e.g. a theme's config.
+func (p *Provenance) RecordMissing(origin string, m map[string]interface{}) {
+	p.record(origin, m, false)
+}
+
+// RecordKey records origin for the given key, e.g. "params.author".
+func (p *Provenance) RecordKey(origin, key string) {
+	p.origins[strings.ToLower(key)] = origin
+}
+
+func (p *Provenance) record(origin string, m map[string]interface{}, override bool) {
+	for key := range flatten("", m) {
+		if _, found := p.origins[key]; found && !override {
+			continue
+		}
+		p.origins[key] = origin
+	}
+}
+
+// Origin returns the origin of the value with the given key. If no origin
+// is recorded for the key itself, the origin of the closest parent is
+// used, falling back to OriginDefault.
+func (p *Provenance) Origin(key string) string {
+	key = strings.ToLower(key)
+	for {
+		if origin, found := p.origins[key]; found {
+			return origin
+		}
+		i := strings.LastIndex(key, ".")
+		if i == -1 {
+			return OriginDefault
+		}
+		key = key[:i]
+	}
+}
+
+// Dump writes all the values in settings, e.g. from viper.AllSettings, to w,
+// one per line and sorted by key, annotated with their origin. Secret values
+// are redacted by the DefaultRedactor.
+func (p *Provenance) Dump(w io.Writer, settings map[string]interface{}) error {
+	flat := flatten("", DefaultRedactor.Redact(settings))
+
+	keys := make([]string, 0, len(flat))
+	for k := range flat {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		v := flat[k]
+		if s, ok := v.(string); ok {
+			v = fmt.Sprintf("%q", s)
+		}
+		if _, err := fmt.Fprintf(w, "%s = %v # %s\n", k, v, p.Origin(k)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// flatten returns the values in m keyed by their lower case dotted path.
+func flatten(prefix string, m map[string]interface{}) map[string]interface{} {
+	flat := make(map[string]interface{})
+	for k, v := range m {
+		key := strings.ToLower(k)
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		if nested, err := cast.ToStringMapE(v); err == nil && len(nested) > 0 {
+			for kk, vv := range flatten(key, nested) {
+				flat[kk] = vv
+			}
+			continue
+		}
+		flat[key] = v
+	}
+	return flat
+}
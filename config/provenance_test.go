@@ -0,0 +1,73 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestProvenance(t *testing.T) {
+	assert := require.New(t)
+
+	p := NewProvenance()
+
+	p.Record("config.toml", map[string]interface{}{
+		"title": "Project",
+		"Params": map[string]interface{}{
+			"author": "project",
+			"social": map[string]interface{}{
+				"twitter": "project",
+			},
+		},
+	})
+	p.Record("config/production/params.toml", map[string]interface{}{
+		"params": map[string]interface{}{
+			"author": "production",
+		},
+	})
+	p.RecordMissing("theme mytheme", map[string]interface{}{
+		"params": map[string]interface{}{
+			"author": "theme",
+			"social": map[string]interface{}{
+				"twitter": "theme",
+				"github":  "theme",
+			},
+		},
+	})
+	p.RecordKey("env HUGO_BASEURL", "baseURL")
+
+	assert.Equal("config.toml", p.Origin("title"))
+	assert.Equal("config/production/params.toml", p.Origin("params.author"))
+	assert.Equal("config.toml", p.Origin("params.social.twitter"))
+	assert.Equal("theme mytheme", p.Origin("Params.Social.GitHub"))
+	assert.Equal("env HUGO_BASEURL", p.Origin("baseurl"))
+	assert.Equal(OriginDefault, p.Origin("paginate"))
+
+	var b bytes.Buffer
+	assert.NoError(p.Dump(&b, map[string]interface{}{
+		"title":    "Project",
+		"paginate": 10,
+		"params": map[string]interface{}{
+			"author": "production",
+		},
+	}))
+
+	assert.Equal(`paginate = 10 # default
+params.author = "production" # config/production/params.toml
+title = "Project" # config.toml
+`, b.String())
+}
@@ -0,0 +1,82 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"path"
+	"strings"
+
+	"github.com/spf13/cast"
+)
+
+// Redacted replaces the value of secret settings in config output.
+const Redacted = "[REDACTED]"
+
+// DefaultRedactor redacts the settings that usually hold credentials.
+var DefaultRedactor = NewRedactor("*token*", "*secret*", "*key*", "*password*")
+
+// Redactor hides the values of secret settings, e.g. API tokens put in
+// params, whenever the configuration is printed or logged.
+type Redactor struct {
+	patterns []string
+}
+
+// NewRedactor creates a new Redactor for the given key patterns, e.g.
+// "*token*". See path.Match for the pattern syntax. The patterns are
+// case-insensitive and matched against every part of a dotted key.
+func NewRedactor(patterns ...string) *Redactor {
+	r := &Redactor{}
+	for _, p := range patterns {
+		r.patterns = append(r.patterns, strings.ToLower(p))
+	}
+	return r
+}
+
+// IsSecret returns whether the value of the given dotted key should be
+// redacted.
+func (r *Redactor) IsSecret(key string) bool {
+	for _, part := range strings.Split(strings.ToLower(key), ".") {
+		if r.isSecretPart(part) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Redactor) isSecretPart(part string) bool {
+	for _, p := range r.patterns {
+		if match, _ := path.Match(p, part); match {
+			return true
+		}
+	}
+	return false
+}
+
+// Redact returns a copy of settings with the secret values replaced with
+// Redacted.
+func (r *Redactor) Redact(settings map[string]interface{}) map[string]interface{} {
+	redacted := make(map[string]interface{}, len(settings))
+	for k, v := range settings {
+		if r.isSecretPart(strings.ToLower(k)) {
+			redacted[k] = Redacted
+			continue
+		}
+		if m, err := cast.ToStringMapE(v); err == nil {
+			redacted[k] = r.Redact(m)
+			continue
+		}
+		redacted[k] = v
+	}
+	return redacted
+}
@@ -0,0 +1,72 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedactor(t *testing.T) {
+	assert := require.New(t)
+
+	r := DefaultRedactor
+
+	assert.True(r.IsSecret("params.apiToken"))
+	assert.True(r.IsSecret("params.algolia.secretKey"))
+	assert.True(r.IsSecret("params.secrets.a"))
+	assert.False(r.IsSecret("params.author"))
+	assert.False(r.IsSecret("title"))
+
+	settings := map[string]interface{}{
+		"title": "Title",
+		"params": map[string]interface{}{
+			"author":   "Jo",
+			"apiToken": "abc",
+			"algolia": map[string]interface{}{
+				"appID":     "app",
+				"secretKey": "def",
+			},
+		},
+	}
+
+	redacted := r.Redact(settings)
+
+	assert.Equal("Title", redacted["title"])
+	params := redacted["params"].(map[string]interface{})
+	assert.Equal("Jo", params["author"])
+	assert.Equal(Redacted, params["apiToken"])
+	assert.Equal("app", params["algolia"].(map[string]interface{})["appID"])
+	assert.Equal(Redacted, params["algolia"].(map[string]interface{})["secretKey"])
+
+	// The original is left untouched.
+	assert.Equal("abc", settings["params"].(map[string]interface{})["apiToken"])
+
+	r = NewRedactor("*Pass*")
+	assert.True(r.IsSecret("params.smtpPassword"))
+	assert.False(r.IsSecret("params.apiToken"))
+}
+
+func TestProvenanceDumpRedacted(t *testing.T) {
+	assert := require.New(t)
+
+	p := NewProvenance()
+	p.Record("config.toml", map[string]interface{}{"params": map[string]interface{}{"apiToken": "abc"}})
+
+	var b bytes.Buffer
+	assert.NoError(p.Dump(&b, map[string]interface{}{"params": map[string]interface{}{"apiToken": "abc"}}))
+	assert.Equal("params.apitoken = \"[REDACTED]\" # config.toml\n", b.String())
+}
@@ -0,0 +1,170 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gohugoio/hugo/parser/metadecoders"
+	"github.com/pkg/errors"
+	"github.com/spf13/afero"
+)
+
+// RemoteSource is a configuration document fetched over HTTP(S), e.g.
+// defaults shared by many similar sites.
+type RemoteSource struct {
+	// The URL of the document. The format is taken from the file extension
+	// in the URL path, e.g. "https://example.org/defaults.toml".
+	URL string
+
+	// The hex encoded SHA-256 digest of the document. If set, a document
+	// that does not match is rejected.
+	SHA256 string
+
+	// The cache dir. The last document fetched is stored here and used if
+	// the URL cannot be reached. If empty, nothing is cached.
+	CacheDir string
+
+	// A cached document younger than MaxAge is used without fetching
+	// the URL again.
+	MaxAge time.Duration
+
+	// The filesystem holding CacheDir.
+	Fs afero.Fs
+
+	// The HTTP client to use. Defaults to http.DefaultClient.
+	Client *http.Client
+}
+
+// Load fetches, verifies and decodes the remote configuration document.
+// The keys are not renamed, see DeprecatedKeys.
+func (r RemoteSource) Load() (map[string]interface{}, error) {
+	u, err := url.Parse(r.URL)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid remote config URL %q", r.URL)
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, errors.Errorf("remote config URL %q: must be http or https", r.URL)
+	}
+
+	ext := path.Ext(u.Path)
+	format := metadecoders.FormatFromString(strings.TrimPrefix(ext, "."))
+	if format == "" {
+		return nil, errors.Errorf("remote config URL %q: unknown config format", r.URL)
+	}
+
+	b, err := r.read(r.cacheFilename(ext))
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := metadecoders.Default.UnmarshalToMap(b, format)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to decode remote config %q", r.URL)
+	}
+
+	return m, nil
+}
+
+func (r RemoteSource) read(cacheFilename string) ([]byte, error) {
+	var (
+		cached    []byte
+		cachedErr error
+	)
+
+	if cacheFilename != "" {
+		if fi, err := r.Fs.Stat(cacheFilename); err == nil {
+			cached, err = afero.ReadFile(r.Fs, cacheFilename)
+			if err == nil {
+				cachedErr = r.verify(cached)
+				if cachedErr == nil && r.MaxAge > 0 && time.Since(fi.ModTime()) < r.MaxAge {
+					return cached, nil
+				}
+			}
+		}
+	}
+
+	b, err := r.fetch()
+	if err == nil {
+		err = r.verify(b)
+	}
+
+	if err != nil {
+		if cached != nil && cachedErr == nil {
+			// Use the last known good copy.
+			return cached, nil
+		}
+		return nil, err
+	}
+
+	if cacheFilename != "" {
+		if err := r.Fs.MkdirAll(filepath.Dir(cacheFilename), 0777); err != nil {
+			return nil, err
+		}
+		if err := afero.WriteFile(r.Fs, cacheFilename, b, 0666); err != nil {
+			return nil, err
+		}
+	}
+
+	return b, nil
+}
+
+func (r RemoteSource) fetch() ([]byte, error) {
+	client := r.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	res, err := client.Get(r.URL)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to fetch remote config %q", r.URL)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return nil, errors.Errorf("failed to fetch remote config %q: %s", r.URL, res.Status)
+	}
+
+	return ioutil.ReadAll(res.Body)
+}
+
+func (r RemoteSource) verify(b []byte) error {
+	if r.SHA256 == "" {
+		return nil
+	}
+
+	sum := sha256.Sum256(b)
+	if got := hex.EncodeToString(sum[:]); !strings.EqualFold(got, r.SHA256) {
+		return errors.Errorf("remote config %q: SHA-256 mismatch: expected %s, got %s", r.URL, r.SHA256, got)
+	}
+
+	return nil
+}
+
+func (r RemoteSource) cacheFilename(ext string) string {
+	if r.CacheDir == "" || r.Fs == nil {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(r.URL))
+	return filepath.Join(r.CacheDir, "remoteconfig", hex.EncodeToString(sum[:8])+ext)
+}
@@ -0,0 +1,93 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRemoteSource(t *testing.T) {
+	assert := require.New(t)
+
+	doc := `
+title = "Remote"
+[params]
+p1 = "remote"
+`
+	sum := sha256.Sum256([]byte(doc))
+	hash := hex.EncodeToString(sum[:])
+
+	var (
+		requests int
+		down     bool
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if down {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, doc)
+	}))
+	defer srv.Close()
+
+	fs := afero.NewMemMapFs()
+
+	r := RemoteSource{URL: srv.URL + "/defaults.toml?v=1", SHA256: hash, CacheDir: "/cache", Fs: fs}
+
+	m, err := r.Load()
+	assert.NoError(err)
+	assert.Equal("Remote", m["title"])
+	assert.Equal(1, requests)
+
+	// Falls back to the cached copy.
+	down = true
+	m, err = r.Load()
+	assert.NoError(err)
+	assert.Equal("Remote", m["title"])
+	assert.Equal(2, requests)
+
+	// Fresh cached copy.
+	r.MaxAge = time.Hour
+	_, err = r.Load()
+	assert.NoError(err)
+	assert.Equal(2, requests)
+
+	// No cache.
+	r.CacheDir = ""
+	_, err = r.Load()
+	assert.Error(err)
+
+	// Wrong hash.
+	down = false
+	r.SHA256 = "abcd"
+	_, err = r.Load()
+	assert.Error(err)
+	assert.Contains(err.Error(), "SHA-256 mismatch")
+
+	_, err = RemoteSource{URL: srv.URL + "/defaults.txt"}.Load()
+	assert.Error(err)
+	_, err = RemoteSource{URL: "file:///defaults.toml"}.Load()
+	assert.Error(err)
+}
@@ -0,0 +1,208 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cast"
+)
+
+// Kind is the kind of value expected for a configuration key.
+type Kind int
+
+const (
+	// KindAny accepts any value.
+	KindAny Kind = iota
+	KindString
+	KindBool
+	KindInt
+	// KindStringSlice accepts a slice or a single string.
+	KindStringSlice
+	KindMap
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindString:
+		return "string"
+	case KindBool:
+		return "bool"
+	case KindInt:
+		return "int"
+	case KindStringSlice:
+		return "string slice"
+	case KindMap:
+		return "map"
+	default:
+		return "any"
+	}
+}
+
+// Schema maps the known top-level configuration keys, lower case, to the
+// kind of value they expect.
+type Schema map[string]Kind
+
+// KeyTypeError is returned when a configuration value is not of the kind
+// expected for its key.
+type KeyTypeError struct {
+	Key      string
+	Expected Kind
+	Value    interface{}
+}
+
+func (e *KeyTypeError) Error() string {
+	return fmt.Sprintf("config key %q: expected %s, got %T", e.Key, e.Expected, e.Value)
+}
+
+// UnknownKeyError is returned for a top-level configuration key not in the
+// schema. Suggestion holds the most similar known key, if any.
+type UnknownKeyError struct {
+	Key        string
+	Suggestion string
+}
+
+func (e *UnknownKeyError) Error() string {
+	if e.Suggestion != "" {
+		return fmt.Sprintf("unknown config key %q: did you mean %q?", e.Key, e.Suggestion)
+	}
+	return fmt.Sprintf("unknown config key %q", e.Key)
+}
+
+// ValidationError holds all the problems found validating a configuration,
+// each of them a *KeyTypeError or an *UnknownKeyError.
+type ValidationError struct {
+	Errors []error
+}
+
+func (e *ValidationError) Error() string {
+	var msgs []string
+	for _, err := range e.Errors {
+		msgs = append(msgs, err.Error())
+	}
+	return "invalid configuration:\n" + strings.Join(msgs, "\n")
+}
+
+// Validate validates the given top-level settings, e.g. from
+// viper.AllSettings, against the schema. It returns a *ValidationError
+// listing all problems found, or nil.
+func (s Schema) Validate(settings map[string]interface{}) error {
+	keys := make([]string, 0, len(settings))
+	for k := range settings {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var errs []error
+
+	for _, k := range keys {
+		lk := strings.ToLower(k)
+		kind, found := s[lk]
+		if !found {
+			errs = append(errs, &UnknownKeyError{Key: k, Suggestion: s.suggest(lk)})
+			continue
+		}
+		if v := settings[k]; !isKind(v, kind) {
+			errs = append(errs, &KeyTypeError{Key: k, Expected: kind, Value: v})
+		}
+	}
+
+	if len(errs) > 0 {
+		return &ValidationError{Errors: errs}
+	}
+
+	return nil
+}
+
+// suggest returns the known key most similar to key, or an empty string if
+// none is close enough.
+func (s Schema) suggest(key string) string {
+	var (
+		best     string
+		bestDist = len(key)/3 + 2
+	)
+	for candidate := range s {
+		d := levenshtein(key, candidate)
+		if d < bestDist || (d == bestDist && best != "" && candidate < best) {
+			best, bestDist = candidate, d
+		}
+	}
+	return best
+}
+
+func isKind(v interface{}, kind Kind) bool {
+	if v == nil {
+		return true
+	}
+
+	var err error
+
+	switch kind {
+	case KindString:
+		switch reflect.ValueOf(v).Kind() {
+		case reflect.Map, reflect.Slice, reflect.Array, reflect.Struct:
+			return false
+		}
+	case KindBool:
+		_, err = cast.ToBoolE(v)
+	case KindInt:
+		_, err = cast.ToIntE(v)
+	case KindStringSlice:
+		switch reflect.ValueOf(v).Kind() {
+		case reflect.String, reflect.Slice, reflect.Array:
+			return true
+		}
+		return false
+	case KindMap:
+		return reflect.ValueOf(v).Kind() == reflect.Map
+	}
+
+	return err == nil
+}
+
+// levenshtein returns the edit distance between a and b.
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = minInt(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(b)]
+}
+
+func minInt(first int, rest ...int) int {
+	m := first
+	for _, v := range rest {
+		if v < m {
+			m = v
+		}
+	}
+	return m
+}
@@ -0,0 +1,92 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"encoding/json"
+	"io"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// JSONSchemaDraft is the JSON Schema version written by WriteJSONSchema.
+const JSONSchemaDraft = "http://json-schema.org/draft-07/schema#"
+
+// JSONSchema returns a JSON Schema describing a configuration with the keys
+// in this schema, e.g. for editors to validate configuration files as they
+// are written. The keys are matched case insensitive, as in Hugo. Keys not
+// in the schema are allowed, as they may be used in templates.
+func (s Schema) JSONSchema(title string) map[string]interface{} {
+	properties := make(map[string]interface{})
+	for key, kind := range s {
+		properties[caseInsensitivePattern(key)] = kind.jsonSchema()
+	}
+
+	return map[string]interface{}{
+		"$schema":              JSONSchemaDraft,
+		"title":                title,
+		"type":                 "object",
+		"patternProperties":    properties,
+		"additionalProperties": true,
+	}
+}
+
+// WriteJSONSchema writes the JSON Schema for this schema, indented, to w.
+func (s Schema) WriteJSONSchema(w io.Writer, title string) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(s.JSONSchema(title))
+}
+
+func (k Kind) jsonSchema() map[string]interface{} {
+	switch k {
+	case KindString:
+		return map[string]interface{}{"type": "string"}
+	case KindBool:
+		return map[string]interface{}{"type": "boolean"}
+	case KindInt:
+		return map[string]interface{}{"type": "integer"}
+	case KindStringSlice:
+		return map[string]interface{}{
+			"type":  []string{"string", "array"},
+			"items": map[string]interface{}{"type": "string"},
+		}
+	case KindMap:
+		return map[string]interface{}{"type": "object"}
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+// caseInsensitivePattern returns a regular expression matching key in any
+// case, e.g. "^[bB][aA][sS][eE]$" for "base", as JSON Schema has no case
+// insensitive flag.
+func caseInsensitivePattern(key string) string {
+	var b strings.Builder
+	b.WriteString("^")
+	for _, r := range key {
+		upper, lower := unicode.ToUpper(r), unicode.ToLower(r)
+		if upper == lower {
+			b.WriteString(regexp.QuoteMeta(string(r)))
+			continue
+		}
+		b.WriteString("[")
+		b.WriteRune(lower)
+		b.WriteRune(upper)
+		b.WriteString("]")
+	}
+	b.WriteString("$")
+	return b.String()
+}
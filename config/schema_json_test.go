@@ -0,0 +1,71 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchemaJSONSchema(t *testing.T) {
+	assert := require.New(t)
+
+	schema := Schema{
+		"baseurl":  KindString,
+		"paginate": KindInt,
+		"theme":    KindStringSlice,
+		"params":   KindMap,
+		"sitemap":  KindAny,
+		"i18ndir":  KindString,
+	}
+
+	var b bytes.Buffer
+	assert.NoError(schema.WriteJSONSchema(&b, "Site config"))
+
+	var m map[string]interface{}
+	assert.NoError(json.Unmarshal(b.Bytes(), &m))
+
+	assert.Equal(JSONSchemaDraft, m["$schema"])
+	assert.Equal("Site config", m["title"])
+	assert.Equal(true, m["additionalProperties"])
+
+	properties := m["patternProperties"].(map[string]interface{})
+	assert.Len(properties, 6)
+	assert.Equal(map[string]interface{}{"type": "integer"}, properties["^[pP][aA][gG][iI][nN][aA][tT][eE]$"])
+	assert.Equal(map[string]interface{}{
+		"type":  []interface{}{"string", "array"},
+		"items": map[string]interface{}{"type": "string"},
+	}, properties["^[tT][hH][eE][mM][eE]$"])
+	assert.Equal(map[string]interface{}{}, properties["^[sS][iI][tT][eE][mM][aA][pP]$"])
+
+	matches := func(key string) int {
+		n := 0
+		for pattern := range properties {
+			if regexp.MustCompile(pattern).MatchString(key) {
+				n++
+			}
+		}
+		return n
+	}
+
+	assert.Equal(1, matches("baseURL"))
+	assert.Equal(1, matches("BASEURL"))
+	assert.Equal(1, matches("i18nDir"))
+	assert.Equal(0, matches("baseURLs"))
+	assert.Equal(0, matches("mybaseurl"))
+}
@@ -0,0 +1,80 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchemaValidate(t *testing.T) {
+	assert := require.New(t)
+
+	schema := Schema{
+		"title":       KindString,
+		"paginate":    KindInt,
+		"builddrafts": KindBool,
+		"theme":       KindStringSlice,
+		"params":      KindMap,
+		"sitemap":     KindAny,
+	}
+
+	assert.NoError(schema.Validate(map[string]interface{}{
+		"title":       "My Site",
+		"paginate":    "10",
+		"builddrafts": true,
+		"theme":       "mytheme",
+		"params":      map[string]interface{}{"a": "b"},
+		"sitemap":     struct{}{},
+	}))
+
+	assert.NoError(schema.Validate(map[string]interface{}{
+		"theme": []interface{}{"a", "b"},
+	}))
+
+	err := schema.Validate(map[string]interface{}{
+		"title":    []string{"a"},
+		"paginate": "ten",
+		"params":   "p",
+		"titel":    "My Site",
+		"foo":      "bar",
+	})
+
+	assert.Error(err)
+	verr, ok := err.(*ValidationError)
+	assert.True(ok)
+	assert.Len(verr.Errors, 5)
+
+	msg := err.Error()
+	assert.Contains(msg, `config key "title": expected string, got []string`)
+	assert.Contains(msg, `config key "paginate": expected int, got string`)
+	assert.Contains(msg, `config key "params": expected map, got string`)
+	assert.Contains(msg, `unknown config key "titel": did you mean "title"?`)
+	assert.Contains(msg, "unknown config key \"foo\"\n")
+
+	unknown, ok := verr.Errors[3].(*UnknownKeyError)
+	assert.True(ok)
+	assert.Equal("titel", unknown.Key)
+	assert.Equal("title", unknown.Suggestion)
+}
+
+func TestLevenshtein(t *testing.T) {
+	assert := require.New(t)
+
+	assert.Equal(0, levenshtein("title", "title"))
+	assert.Equal(2, levenshtein("titel", "title"))
+	assert.Equal(3, levenshtein("kitten", "sitting"))
+	assert.Equal(5, levenshtein("", "title"))
+}
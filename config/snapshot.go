@@ -0,0 +1,130 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"strings"
+
+	"github.com/spf13/cast"
+)
+
+var _ Provider = (*Snapshot)(nil)
+
+// Snapshot is a frozen copy of a configuration. It is safe for concurrent
+// use and is not affected by changes to the configuration it was created
+// from, so the build can keep reading a consistent view while a new
+// configuration is being assembled, e.g. on live reload.
+//
+// Maps and slices are copied on read, so callers cannot modify the
+// snapshot. Set does nothing.
+type Snapshot struct {
+	settings map[string]interface{}
+}
+
+// NewSnapshot creates a new Snapshot of the given settings, typically
+// the result of AllSettings on a *viper.Viper.
+func NewSnapshot(settings map[string]interface{}) *Snapshot {
+	return &Snapshot{settings: copyStringMap(settings)}
+}
+
+// AllSettings returns a copy of all the settings in this snapshot.
+func (s *Snapshot) AllSettings() map[string]interface{} {
+	return copyStringMap(s.settings)
+}
+
+func (s *Snapshot) GetString(key string) string {
+	return cast.ToString(s.lookup(key))
+}
+
+func (s *Snapshot) GetInt(key string) int {
+	return cast.ToInt(s.lookup(key))
+}
+
+func (s *Snapshot) GetBool(key string) bool {
+	return cast.ToBool(s.lookup(key))
+}
+
+func (s *Snapshot) GetStringMap(key string) map[string]interface{} {
+	return cast.ToStringMap(s.Get(key))
+}
+
+func (s *Snapshot) GetStringMapString(key string) map[string]string {
+	return cast.ToStringMapString(s.lookup(key))
+}
+
+func (s *Snapshot) GetStringSlice(key string) []string {
+	return cast.ToStringSlice(s.Get(key))
+}
+
+func (s *Snapshot) Get(key string) interface{} {
+	return copyValue(s.lookup(key))
+}
+
+// Set ignores the write, a Snapshot is immutable.
+func (s *Snapshot) Set(key string, value interface{}) {
+}
+
+func (s *Snapshot) IsSet(key string) bool {
+	return s.lookup(key) != nil
+}
+
+// lookup finds the value of the given dotted key. Keys are
+// case-insensitive.
+func (s *Snapshot) lookup(key string) interface{} {
+	return lookupNested(s.settings, strings.Split(strings.ToLower(key), "."))
+}
+
+func copyStringMap(m map[string]interface{}) map[string]interface{} {
+	c := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		c[k] = copyValue(v)
+	}
+	return c
+}
+
+// copyValue returns a deep copy of the maps and slices in v.
+func copyValue(v interface{}) interface{} {
+	switch vv := v.(type) {
+	case map[string]interface{}:
+		return copyStringMap(vv)
+	case map[interface{}]interface{}:
+		c := make(map[interface{}]interface{}, len(vv))
+		for k, v := range vv {
+			c[k] = copyValue(v)
+		}
+		return c
+	case map[string]string:
+		c := make(map[string]string, len(vv))
+		for k, v := range vv {
+			c[k] = v
+		}
+		return c
+	case []interface{}:
+		c := make([]interface{}, len(vv))
+		for i, v := range vv {
+			c[i] = copyValue(v)
+		}
+		return c
+	case []string:
+		return append([]string(nil), vv...)
+	case []map[string]interface{}:
+		c := make([]map[string]interface{}, len(vv))
+		for i, m := range vv {
+			c[i] = copyStringMap(m)
+		}
+		return c
+	default:
+		return v
+	}
+}
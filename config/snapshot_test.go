@@ -0,0 +1,64 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshot(t *testing.T) {
+	assert := require.New(t)
+
+	v := viper.New()
+	v.Set("title", "Title")
+	v.Set("paginate", 10)
+	v.Set("buildDrafts", true)
+	v.Set("disableKinds", []string{"taxonomy"})
+	v.Set("params", map[string]interface{}{
+		"author": map[string]interface{}{"Name": "Jo"},
+		"tags":   []interface{}{"a", "b"},
+	})
+
+	s := NewSnapshot(v.AllSettings())
+
+	assert.Equal("Title", s.GetString("TITLE"))
+	assert.Equal(10, s.GetInt("paginate"))
+	assert.True(s.GetBool("buildDrafts"))
+	assert.Equal([]string{"taxonomy"}, s.GetStringSlice("disableKinds"))
+	assert.Equal("Jo", s.GetString("params.author.name"))
+	assert.Equal(map[string]string{"name": "Jo"}, s.GetStringMapString("params.author"))
+	assert.True(s.IsSet("params.tags"))
+	assert.False(s.IsSet("params.nope"))
+	assert.False(s.IsSet("title.nope"))
+
+	// Changes to the source do not affect the snapshot.
+	v.Set("title", "New Title")
+	v.GetStringMap("params")["tags"].([]interface{})[0] = "c"
+	assert.Equal("Title", s.GetString("title"))
+	assert.Equal([]string{"a", "b"}, s.GetStringSlice("params.tags"))
+
+	// Neither do changes to the values read.
+	s.GetStringMap("params")["author"] = "Bo"
+	s.Get("params.tags").([]interface{})[1] = "d"
+	s.AllSettings()["title"] = "Other"
+	assert.Equal("Jo", s.GetString("params.author.name"))
+	assert.Equal([]string{"a", "b"}, s.GetStringSlice("params.tags"))
+	assert.Equal("Title", s.GetString("title"))
+
+	s.Set("title", "Ignored")
+	assert.Equal("Title", s.GetString("title"))
+}
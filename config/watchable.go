@@ -0,0 +1,157 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"reflect"
+	"sort"
+	"sync"
+)
+
+var _ Provider = (*WatchableProvider)(nil)
+
+// Change describes a change to the configuration.
+type Change struct {
+	// The top-level keys added, removed or changed, lower case and sorted.
+	// This is nil if the keys could not be determined, which should be
+	// treated as if everything changed.
+	Keys []string
+
+	// The config file that caused the change, if known.
+	Filename string
+}
+
+// Has returns whether the given top-level key, lower case, changed.
+func (c Change) Has(key string) bool {
+	if c.Keys == nil {
+		return true
+	}
+	i := sort.SearchStrings(c.Keys, key)
+	return i < len(c.Keys) && c.Keys[i] == key
+}
+
+// WatchableProvider is a Provider that can be subscribed to for changes,
+// e.g. when the config file is edited while the server is running.
+type WatchableProvider struct {
+	mu          sync.RWMutex
+	cfg         Provider
+	subscribers []func(c Change)
+}
+
+// NewWatchableProvider creates a new WatchableProvider starting with cfg.
+func NewWatchableProvider(cfg Provider) *WatchableProvider {
+	return &WatchableProvider{cfg: cfg}
+}
+
+// Subscribe registers f to be called on every change.
+func (w *WatchableProvider) Subscribe(f func(c Change)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.subscribers = append(w.subscribers, f)
+}
+
+// Update replaces the configuration with cfg and notifies the subscribers
+// if anything changed. The filename is the config file that caused the
+// change, if known.
+func (w *WatchableProvider) Update(cfg Provider, filename string) {
+	w.mu.Lock()
+	old := w.cfg
+	w.cfg = cfg
+	subscribers := make([]func(c Change), len(w.subscribers))
+	copy(subscribers, w.subscribers)
+	w.mu.Unlock()
+
+	change := Change{Keys: changedKeys(old, cfg), Filename: filename}
+	if change.Keys != nil && len(change.Keys) == 0 {
+		return
+	}
+
+	for _, f := range subscribers {
+		f(change)
+	}
+}
+
+// changedKeys returns the top-level keys that differ between the two
+// configurations, or nil if they cannot be listed.
+func changedKeys(old, cfg Provider) []string {
+	type allSettingser interface {
+		AllSettings() map[string]interface{}
+	}
+
+	s1, ok1 := old.(allSettingser)
+	s2, ok2 := cfg.(allSettingser)
+	if !ok1 || !ok2 {
+		return nil
+	}
+
+	m1, m2 := s1.AllSettings(), s2.AllSettings()
+	keys := make([]string, 0)
+
+	for k, v1 := range m1 {
+		if v2, found := m2[k]; !found || !reflect.DeepEqual(v1, v2) {
+			keys = append(keys, k)
+		}
+	}
+	for k := range m2 {
+		if _, found := m1[k]; !found {
+			keys = append(keys, k)
+		}
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+func (w *WatchableProvider) provider() Provider {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.cfg
+}
+
+func (w *WatchableProvider) GetString(key string) string {
+	return w.provider().GetString(key)
+}
+
+func (w *WatchableProvider) GetInt(key string) int {
+	return w.provider().GetInt(key)
+}
+
+func (w *WatchableProvider) GetBool(key string) bool {
+	return w.provider().GetBool(key)
+}
+
+func (w *WatchableProvider) GetStringMap(key string) map[string]interface{} {
+	return w.provider().GetStringMap(key)
+}
+
+func (w *WatchableProvider) GetStringMapString(key string) map[string]string {
+	return w.provider().GetStringMapString(key)
+}
+
+func (w *WatchableProvider) GetStringSlice(key string) []string {
+	return w.provider().GetStringSlice(key)
+}
+
+func (w *WatchableProvider) Get(key string) interface{} {
+	return w.provider().Get(key)
+}
+
+func (w *WatchableProvider) Set(key string, value interface{}) {
+	w.provider().Set(key, value)
+}
+
+func (w *WatchableProvider) IsSet(key string) bool {
+	return w.provider().IsSet(key)
+}
@@ -0,0 +1,65 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatchableProvider(t *testing.T) {
+	assert := require.New(t)
+
+	v1 := viper.New()
+	v1.Set("title", "Title")
+	v1.Set("params", map[string]interface{}{"p1": "v1"})
+	v1.Set("paginate", 10)
+
+	w := NewWatchableProvider(v1)
+	assert.Equal("Title", w.GetString("title"))
+
+	var changes []Change
+	w.Subscribe(func(c Change) {
+		changes = append(changes, c)
+	})
+
+	v2 := viper.New()
+	v2.Set("title", "Title")
+	v2.Set("params", map[string]interface{}{"p1": "v2"})
+	v2.Set("baseURL", "https://example.org")
+
+	w.Update(v2, "config.toml")
+	assert.Len(changes, 1)
+	assert.Equal([]string{"baseurl", "paginate", "params"}, changes[0].Keys)
+	assert.Equal("config.toml", changes[0].Filename)
+	assert.True(changes[0].Has("params"))
+	assert.False(changes[0].Has("title"))
+	assert.Equal("v2", w.GetString("params.p1"))
+
+	// No changes, no notification.
+	w.Update(v2, "config.toml")
+	assert.Len(changes, 1)
+
+	// Keys unknown.
+	w.Update(&testProvider{v2}, "")
+	assert.Len(changes, 2)
+	assert.Nil(changes[1].Keys)
+	assert.True(changes[1].Has("title"))
+}
+
+type testProvider struct {
+	Provider
+}
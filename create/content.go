@@ -45,8 +45,8 @@ func NewContent(
 
 	jww.INFO.Printf("attempting to create %q of %q of ext %q", targetPath, kind, ext)
 
-	archetypeFilename, isDir := findArchetype(ps, kind, ext)
 	contentPath, s := resolveContentPath(sites, sourceFs, targetPath)
+	archetypeFilename, isDir := ps.BaseFs.ArchetypeFs().Lookup(kind, ext, s.Language().Lang)
 
 	if isDir {
 
@@ -289,25 +289,3 @@ func resolveContentPath(sites *hugolib.HugoSites, fs afero.Fs, targetPath string
 	}
 
 }
-
-// FindArchetype takes a given kind/archetype of content and returns the path
-// to the archetype in the archetype filesystem, blank if none found.
-func findArchetype(ps *helpers.PathSpec, kind, ext string) (outpath string, isDir bool) {
-	fs := ps.BaseFs.Archetypes.Fs
-
-	var pathsToCheck []string
-
-	if kind != "" {
-		pathsToCheck = append(pathsToCheck, kind+ext)
-	}
-	pathsToCheck = append(pathsToCheck, "default"+ext, "default")
-
-	for _, p := range pathsToCheck {
-		fi, err := fs.Stat(p)
-		if err == nil {
-			return p, fi.IsDir()
-		}
-	}
-
-	return "", false
-}
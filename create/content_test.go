@@ -45,9 +45,10 @@ func TestNewContent(t *testing.T) {
 		{"post", "post/sample-1.md", []string{`title = "Post Arch title"`, `test = "test1"`, "date = \"2015-01-12T19:20:04-07:00\""}},
 		{"post", "post/org-1.org", []string{`#+title: ORG-1`}},
 		{"emptydate", "post/sample-ed.md", []string{`title = "Empty Date Arch title"`, `test = "test1"`}},
-		{"stump", "stump/sample-2.md", []string{`title: "Sample 2"`}},      // no archetype file
-		{"", "sample-3.md", []string{`title: "Sample 3"`}},                 // no archetype
-		{"product", "product/sample-4.md", []string{`title = "SAMPLE-4"`}}, // empty archetype front matter
+		{"stump", "stump/sample-2.md", []string{`title: "Sample 2"`}},                  // no archetype file
+		{"", "sample-3.md", []string{`title: "Sample 3"`}},                             // no archetype
+		{"product", "product/sample-4.md", []string{`title = "SAMPLE-4"`}},             // empty archetype front matter
+		{"product", "product/sample-5.nn.md", []string{`archetype = "product.nn.md"`}}, // language specific archetype
 		{"lang", "post/lang-1.md", []string{`Site Lang: en|Name: Lang 1|i18n: Hugo Rocks!`}},
 		{"lang", "post/lang-2.en.md", []string{`Site Lang: en|Name: Lang 2|i18n: Hugo Rocks!`}},
 		{"lang", "post/lang-3.nn.md", []string{`Site Lang: nn|Name: Lang 3|i18n: Hugo Rokkar!`}},
@@ -169,6 +170,13 @@ func initFs(fs *hugofs.Fs) error {
 			path: filepath.Join("archetypes", "product.md"),
 			content: `+++
 title = "{{ .BaseFileName  | upper }}"
++++`,
+		},
+		{
+			path: filepath.Join("archetypes", "product.nn.md"),
+			content: `+++
+title = "{{ .BaseFileName  | upper }}"
+archetype = "product.nn.md"
 +++`,
 		},
 		{
@@ -0,0 +1,161 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deploy
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/gobwas/glob"
+	"github.com/pkg/errors"
+	"github.com/spf13/afero"
+)
+
+// RemoteDiff holds the changes needed to make a remote target match the
+// local publish dir. All paths are "/" separated and sorted.
+type RemoteDiff struct {
+	// Files not found in the remote.
+	Uploads []string
+
+	// Files found in the remote with a different MD5 sum.
+	Updates []string
+
+	// Files in the remote not found locally.
+	Deletes []string
+}
+
+// DiffOptions configures DiffRemote.
+type DiffOptions struct {
+	// If set, only the paths matching this glob pattern are diffed, e.g.
+	// "**.html".
+	Include string
+
+	// If set, the paths matching this glob pattern are not diffed, neither
+	// uploaded nor deleted.
+	Exclude string
+}
+
+// DiffRemote diffs the files in localFs, typically the publish dir, with the
+// remote files given as a map of "/" separated paths to hex encoded MD5 sums,
+// e.g. from the manifest of a previous build.
+func DiffRemote(localFs afero.Fs, remote map[string]string, opts DiffOptions) (RemoteDiff, error) {
+	var diff RemoteDiff
+
+	include, err := compileDiffGlob(opts.Include)
+	if err != nil {
+		return diff, err
+	}
+	exclude, err := compileDiffGlob(opts.Exclude)
+	if err != nil {
+		return diff, err
+	}
+
+	matches := func(path string) bool {
+		if include != nil && !include.Match(path) {
+			return false
+		}
+		return exclude == nil || !exclude.Match(path)
+	}
+
+	found := make(map[string]bool)
+
+	err = afero.Walk(localFs, "", func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			// Skip hidden directories.
+			if path != "" && strings.HasPrefix(info.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		// .DS_Store is an internal MacOS attribute file; skip it.
+		if info.Name() == ".DS_Store" {
+			return nil
+		}
+
+		slashpath := strings.TrimPrefix(filepath.ToSlash(path), "/")
+		if !matches(slashpath) {
+			return nil
+		}
+
+		found[slashpath] = true
+
+		remoteSum, ok := remote[slashpath]
+		if !ok {
+			diff.Uploads = append(diff.Uploads, slashpath)
+			return nil
+		}
+
+		sum, err := md5File(localFs, path)
+		if err != nil {
+			return err
+		}
+
+		if !strings.EqualFold(sum, remoteSum) {
+			diff.Updates = append(diff.Updates, slashpath)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return diff, err
+	}
+
+	for path := range remote {
+		if !found[path] && matches(path) {
+			diff.Deletes = append(diff.Deletes, path)
+		}
+	}
+
+	sort.Strings(diff.Uploads)
+	sort.Strings(diff.Updates)
+	sort.Strings(diff.Deletes)
+
+	return diff, nil
+}
+
+func compileDiffGlob(pattern string) (glob.Glob, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+	g, err := glob.Compile(pattern, '/')
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid glob pattern %q", pattern)
+	}
+	return g, nil
+}
+
+func md5File(fs afero.Fs, filename string) (string, error) {
+	f, err := fs.Open(filename)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := md5.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
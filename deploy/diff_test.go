@@ -0,0 +1,106 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deploy
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/afero"
+)
+
+func TestDiffRemote(t *testing.T) {
+	sum := func(s string) string {
+		h := md5.Sum([]byte(s))
+		return hex.EncodeToString(h[:])
+	}
+
+	fs := afero.NewMemMapFs()
+	for path, content := range map[string]string{
+		"index.html":         "home",
+		"posts/p1.html":      "p1",
+		"posts/p2.html":      "p2 updated",
+		"css/style.css":      "css",
+		".git/HEAD":          "ref",
+		"posts/.DS_Store":    "mac",
+		"drafts/draft.html":  "draft",
+		"images/logo.png":    "png",
+		"images/big/big.png": "big",
+	} {
+		if err := afero.WriteFile(fs, filepath.FromSlash(path), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	remote := map[string]string{
+		"index.html":      sum("home"),
+		"posts/p2.html":   sum("p2"),
+		"css/style.css":   sum("css"),
+		"old.html":        sum("old"),
+		"drafts/old.html": sum("old draft"),
+		"images/logo.png": sum("png"),
+	}
+
+	tests := []struct {
+		Description string
+		Opts        DiffOptions
+		Want        RemoteDiff
+	}{
+		{
+			Description: "no patterns",
+			Want: RemoteDiff{
+				Uploads: []string{"drafts/draft.html", "images/big/big.png", "posts/p1.html"},
+				Updates: []string{"posts/p2.html"},
+				Deletes: []string{"drafts/old.html", "old.html"},
+			},
+		},
+		{
+			Description: "include",
+			Opts:        DiffOptions{Include: "**.html"},
+			Want: RemoteDiff{
+				Uploads: []string{"drafts/draft.html", "posts/p1.html"},
+				Updates: []string{"posts/p2.html"},
+				Deletes: []string{"drafts/old.html", "old.html"},
+			},
+		},
+		{
+			Description: "include and exclude",
+			Opts:        DiffOptions{Include: "**.html", Exclude: "drafts/**"},
+			Want: RemoteDiff{
+				Uploads: []string{"posts/p1.html"},
+				Updates: []string{"posts/p2.html"},
+				Deletes: []string{"old.html"},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.Description, func(t *testing.T) {
+			got, err := DiffRemote(fs, remote, tc.Opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.Want, got); diff != "" {
+				t.Errorf("diff mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	if _, err := DiffRemote(fs, remote, DiffOptions{Include: "[a"}); err == nil {
+		t.Error("expected an error for an invalid pattern")
+	}
+}
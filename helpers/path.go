@@ -176,6 +176,9 @@ func ReplaceExtension(path string, newExt string) string {
 // If there is no theme, returns the empty string.
 func (p *PathSpec) GetFirstThemeDir() string {
 	if p.ThemeSet() {
+		if len(p.AllThemes) > 0 {
+			return p.AllThemes[0].Dir
+		}
 		return p.AbsPathify(filepath.Join(p.ThemesDir, p.Themes()[0]))
 	}
 	return ""
@@ -536,8 +539,21 @@ func SafeWriteToDisk(inpath string, r io.Reader, fs afero.Fs) (err error) {
 }
 
 // WriteToDisk writes content to disk.
+// The error from closing the file is returned, as some filesystems only
+// write the content on close.
 func WriteToDisk(inpath string, r io.Reader, fs afero.Fs) (err error) {
-	return afero.WriteReader(fs, inpath, r)
+	f, err := OpenFileForWriting(fs, inpath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+	}()
+
+	_, err = io.Copy(f, r)
+	return
 }
 
 // OpenFilesForWriting opens all the given filenames for writing.
@@ -30,6 +30,16 @@ type RealFilenameInfo interface {
 type realFilenameInfo struct {
 	os.FileInfo
 	realFilename string
+
+	// Overrides the name of the FileInfo if set.
+	name string
+}
+
+func (f *realFilenameInfo) Name() string {
+	if f.name != "" {
+		return f.name
+	}
+	return f.FileInfo.Name()
 }
 
 func (f *realFilenameInfo) RealFilename() string {
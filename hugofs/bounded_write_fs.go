@@ -0,0 +1,267 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugofs
+
+import (
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+var (
+	_ afero.Fs   = (*BoundedWriteFs)(nil)
+	_ afero.File = (*boundedWriteFile)(nil)
+	_ Reseter    = (*BoundedWriteFs)(nil)
+)
+
+// BoundedWriteFs limits the number of concurrent writes to the delegate
+// filesystem, to avoid overwhelming e.g. network filesystems when publishing
+// from many goroutines. A writer slot is held for each write to the delegate
+// only, not while a file is open, so a file left open does not block the
+// other writers. Small writes to a file are coalesced in memory and written
+// in one go when the buffer is full or the file is closed, so write errors
+// for small files are returned from Close.
+type BoundedWriteFs struct {
+	afero.Fs
+
+	sem chan struct{}
+
+	files   uint64
+	bytes   uint64
+	startNs int64
+}
+
+// NewBoundedWriteFs creates a new BoundedWriteFs writing at most maxWriters
+// files concurrently.
+func NewBoundedWriteFs(delegate afero.Fs, maxWriters int) *BoundedWriteFs {
+	if maxWriters < 1 {
+		maxWriters = 1
+	}
+	fs := &BoundedWriteFs{Fs: delegate, sem: make(chan struct{}, maxWriters)}
+	fs.Reset()
+	return fs
+}
+
+// WriteStats holds the throughput of a BoundedWriteFs.
+type WriteStats struct {
+	Files    uint64
+	Bytes    uint64
+	Duration time.Duration
+}
+
+// FilesPerSecond returns the number of files written per second.
+func (s WriteStats) FilesPerSecond() float64 {
+	if s.Duration <= 0 {
+		return 0
+	}
+	return float64(s.Files) / s.Duration.Seconds()
+}
+
+// BytesPerSecond returns the number of bytes written per second.
+func (s WriteStats) BytesPerSecond() float64 {
+	if s.Duration <= 0 {
+		return 0
+	}
+	return float64(s.Bytes) / s.Duration.Seconds()
+}
+
+// Stats returns the files and bytes written since the last Reset.
+func (fs *BoundedWriteFs) Stats() WriteStats {
+	return WriteStats{
+		Files:    atomic.LoadUint64(&fs.files),
+		Bytes:    atomic.LoadUint64(&fs.bytes),
+		Duration: time.Duration(time.Now().UnixNano() - atomic.LoadInt64(&fs.startNs)),
+	}
+}
+
+// Reset resets the stats, e.g. before a new build.
+func (fs *BoundedWriteFs) Reset() {
+	atomic.StoreUint64(&fs.files, 0)
+	atomic.StoreUint64(&fs.bytes, 0)
+	atomic.StoreInt64(&fs.startNs, time.Now().UnixNano())
+}
+
+func (fs *BoundedWriteFs) Create(name string) (afero.File, error) {
+	fs.acquire()
+	f, err := fs.Fs.Create(name)
+	fs.release()
+	if err != nil {
+		return nil, err
+	}
+	return &boundedWriteFile{File: f, fs: fs}, nil
+}
+
+func (fs *BoundedWriteFs) OpenFile(name string, flag int, perm os.FileMode) (afero.File, error) {
+	if !isWrite(flag) {
+		return fs.Fs.OpenFile(name, flag, perm)
+	}
+
+	fs.acquire()
+	f, err := fs.Fs.OpenFile(name, flag, perm)
+	fs.release()
+	if err != nil {
+		return nil, err
+	}
+	return &boundedWriteFile{File: f, fs: fs}, nil
+}
+
+func (fs *BoundedWriteFs) Name() string {
+	return "BoundedWriteFs"
+}
+
+func (fs *BoundedWriteFs) acquire() {
+	fs.sem <- struct{}{}
+}
+
+func (fs *BoundedWriteFs) release() {
+	<-fs.sem
+}
+
+// write runs the given write to the delegate in a writer slot.
+func (fs *BoundedWriteFs) write(write func() (int, error)) (int, error) {
+	fs.acquire()
+	defer fs.release()
+	n, err := write()
+	atomic.AddUint64(&fs.bytes, uint64(n))
+	return n, err
+}
+
+// coalesceSize is the size up to which the writes to a file are buffered.
+const coalesceSize = 32 * 1024
+
+// boundedWriteFile is a file open for writing. Writes smaller than
+// coalesceSize are buffered until the buffer is full or another operation
+// needs the content written.
+type boundedWriteFile struct {
+	afero.File
+	fs *BoundedWriteFs
+
+	mu     sync.Mutex
+	buf    []byte
+	closed bool
+}
+
+func (f *boundedWriteFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if len(f.buf)+len(p) > coalesceSize {
+		if err := f.flush(); err != nil {
+			return 0, err
+		}
+	}
+
+	if len(p) < coalesceSize {
+		f.buf = append(f.buf, p...)
+		return len(p), nil
+	}
+
+	return f.fs.write(func() (int, error) { return f.File.Write(p) })
+}
+
+func (f *boundedWriteFile) WriteString(s string) (int, error) {
+	return f.Write([]byte(s))
+}
+
+func (f *boundedWriteFile) WriteAt(p []byte, off int64) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.flush(); err != nil {
+		return 0, err
+	}
+	return f.fs.write(func() (int, error) { return f.File.WriteAt(p, off) })
+}
+
+func (f *boundedWriteFile) Read(p []byte) (int, error) {
+	if err := f.Flush(); err != nil {
+		return 0, err
+	}
+	return f.File.Read(p)
+}
+
+func (f *boundedWriteFile) ReadAt(p []byte, off int64) (int, error) {
+	if err := f.Flush(); err != nil {
+		return 0, err
+	}
+	return f.File.ReadAt(p, off)
+}
+
+func (f *boundedWriteFile) Seek(offset int64, whence int) (int64, error) {
+	if err := f.Flush(); err != nil {
+		return 0, err
+	}
+	return f.File.Seek(offset, whence)
+}
+
+func (f *boundedWriteFile) Stat() (os.FileInfo, error) {
+	if err := f.Flush(); err != nil {
+		return nil, err
+	}
+	return f.File.Stat()
+}
+
+func (f *boundedWriteFile) Sync() error {
+	if err := f.Flush(); err != nil {
+		return err
+	}
+	return f.File.Sync()
+}
+
+func (f *boundedWriteFile) Truncate(size int64) error {
+	if err := f.Flush(); err != nil {
+		return err
+	}
+	return f.File.Truncate(size)
+}
+
+// Flush writes the buffered content to the delegate.
+func (f *boundedWriteFile) Flush() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.flush()
+}
+
+func (f *boundedWriteFile) flush() error {
+	if len(f.buf) == 0 {
+		return nil
+	}
+	buf := f.buf
+	f.buf = nil
+	_, err := f.fs.write(func() (int, error) { return f.File.Write(buf) })
+	return err
+}
+
+// Close writes the buffered content and closes the file.
+func (f *boundedWriteFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return f.File.Close()
+	}
+	f.closed = true
+
+	err := f.flush()
+	if cerr := f.File.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		atomic.AddUint64(&f.fs.files, 1)
+	}
+	return err
+}
@@ -0,0 +1,199 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugofs
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+// concurrencyCountingFs records the max number of concurrent writes and
+// the total number of writes.
+type concurrencyCountingFs struct {
+	afero.Fs
+	current, max, writes int32
+}
+
+func (fs *concurrencyCountingFs) Create(name string) (afero.File, error) {
+	f, err := fs.Fs.Create(name)
+	if err != nil {
+		return nil, err
+	}
+	return &concurrencyCountingFile{File: f, fs: fs}, nil
+}
+
+type concurrencyCountingFile struct {
+	afero.File
+	fs *concurrencyCountingFs
+}
+
+func (f *concurrencyCountingFile) Write(p []byte) (int, error) {
+	atomic.AddInt32(&f.fs.writes, 1)
+	current := atomic.AddInt32(&f.fs.current, 1)
+	defer atomic.AddInt32(&f.fs.current, -1)
+	for {
+		max := atomic.LoadInt32(&f.fs.max)
+		if current <= max || atomic.CompareAndSwapInt32(&f.fs.max, max, current) {
+			break
+		}
+	}
+	time.Sleep(time.Millisecond)
+	return f.File.Write(p)
+}
+
+// failingWriteFs creates files failing on Write, e.g. on a full disk.
+type failingWriteFs struct {
+	afero.Fs
+}
+
+func (fs failingWriteFs) Create(name string) (afero.File, error) {
+	f, err := fs.Fs.Create(name)
+	if err != nil {
+		return nil, err
+	}
+	return failingWriteFile{f}, nil
+}
+
+type failingWriteFile struct {
+	afero.File
+}
+
+func (f failingWriteFile) Write(p []byte) (int, error) {
+	return 0, syscall.ENOSPC
+}
+
+func TestBoundedWriteFs(t *testing.T) {
+	assert := require.New(t)
+
+	counting := &concurrencyCountingFs{Fs: afero.NewMemMapFs()}
+	fs := NewBoundedWriteFs(counting, 3)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			f, err := fs.Create(fmt.Sprintf("/public/p%d.html", i))
+			assert.NoError(err)
+			for j := 0; j < 10; j++ {
+				_, err = f.Write([]byte("abcd"))
+				assert.NoError(err)
+			}
+			assert.NoError(f.Close())
+		}(i)
+	}
+	wg.Wait()
+
+	assert.True(atomic.LoadInt32(&counting.max) <= 3)
+	// The small writes are coalesced into one per file.
+	assert.Equal(int32(50), atomic.LoadInt32(&counting.writes))
+
+	b, err := afero.ReadFile(counting, "/public/p7.html")
+	assert.NoError(err)
+	assert.Equal(40, len(b))
+
+	stats := fs.Stats()
+	assert.Equal(uint64(50), stats.Files)
+	assert.Equal(uint64(50*40), stats.Bytes)
+	assert.True(stats.BytesPerSecond() > 0)
+	assert.True(stats.FilesPerSecond() > 0)
+
+	// Writes larger than the buffer are not coalesced.
+	f, err := fs.Create("/public/large.html")
+	assert.NoError(err)
+	_, err = f.Write([]byte("small"))
+	assert.NoError(err)
+	_, err = f.Write(make([]byte, coalesceSize))
+	assert.NoError(err)
+	assert.NoError(f.Close())
+	assert.Equal(int32(52), atomic.LoadInt32(&counting.writes))
+	fi, err := counting.Stat("/public/large.html")
+	assert.NoError(err)
+	assert.Equal(int64(coalesceSize+5), fi.Size())
+
+	// Content written can be read back before Close.
+	f, err = fs.OpenFile("/public/rw.html", os.O_RDWR|os.O_CREATE, 0755)
+	assert.NoError(err)
+	_, err = f.WriteString("content")
+	assert.NoError(err)
+	fi, err = f.Stat()
+	assert.NoError(err)
+	assert.Equal(int64(7), fi.Size())
+	assert.NoError(f.Close())
+
+	fs.Reset()
+	assert.Equal(uint64(0), fs.Stats().Files)
+}
+
+func TestBoundedWriteFsUnclosedFile(t *testing.T) {
+	assert := require.New(t)
+
+	fs := NewBoundedWriteFs(afero.NewMemMapFs(), 1)
+
+	// Left open, e.g. on an error path.
+	unclosed, err := fs.Create("/public/unclosed.html")
+	assert.NoError(err)
+	_, err = unclosed.Write(make([]byte, coalesceSize))
+	assert.NoError(err)
+
+	done := make(chan error)
+	go func() {
+		f, err := fs.Create("/public/index.html")
+		if err != nil {
+			done <- err
+			return
+		}
+		if _, err := f.Write([]byte("Home")); err != nil {
+			done <- err
+			return
+		}
+		done <- f.Close()
+	}()
+
+	select {
+	case err := <-done:
+		assert.NoError(err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("blocked by the unclosed file")
+	}
+}
+
+func TestBoundedWriteFsWriteError(t *testing.T) {
+	assert := require.New(t)
+
+	fs := NewBoundedWriteFs(failingWriteFs{afero.NewMemMapFs()}, 1)
+
+	for i := 0; i < 2; i++ {
+		// The small writes are flushed on Close.
+		f, err := fs.Create("/public/index.html")
+		assert.NoError(err)
+		_, err = f.Write([]byte("abc"))
+		assert.NoError(err)
+		assert.Equal(syscall.ENOSPC, f.Close())
+
+		f, err = fs.Create("/public/large.html")
+		assert.NoError(err)
+		_, err = f.Write(make([]byte, coalesceSize))
+		assert.Equal(syscall.ENOSPC, err)
+		assert.NoError(f.Close())
+	}
+}
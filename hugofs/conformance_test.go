@@ -0,0 +1,157 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugofs
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gohugoio/hugo/common/hmetrics"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+// TestFsConformance invokes the full afero surface on every composite
+// filesystem in this package, making sure none of them panics. An operation
+// may fail, but not crash a third-party afero utility calling it.
+func TestFsConformance(t *testing.T) {
+	filename := filepath.FromSlash("dir/file.txt")
+
+	for _, test := range []struct {
+		name  string
+		names []string
+		fs    func(base afero.Fs) (afero.Fs, error)
+	}{
+		{"BasePathRealFilenameFs", nil, func(base afero.Fs) (afero.Fs, error) {
+			return NewBasePathRealFilenameFs(afero.NewBasePathFs(base, "/").(*afero.BasePathFs)), nil
+		}},
+		{"BoundedWriteFs", nil, func(base afero.Fs) (afero.Fs, error) { return NewBoundedWriteFs(base, 2), nil }},
+		{"CreateCountingFs", nil, func(base afero.Fs) (afero.Fs, error) { return NewCreateCountingFs(base), nil }},
+		{"DirIndexFs", nil, func(base afero.Fs) (afero.Fs, error) { return NewDirIndex().Fs(base, "/", "en"), nil }},
+		{"FingerprintFs", nil, func(base afero.Fs) (afero.Fs, error) { return NewFingerprintFs(base, "", "**.txt") }},
+		{"HashingFs", nil, func(base afero.Fs) (afero.Fs, error) { return NewHashingFs(base, nopHashReceiver{}), nil }},
+		{"LanguageFs", nil, func(base afero.Fs) (afero.Fs, error) {
+			return NewLanguageFs("en", newTestLanguageSet(map[string]bool{"en": true}), afero.NewBasePathFs(base, "/")), nil
+		}},
+		{"LanguageCompositeFs", nil, func(base afero.Fs) (afero.Fs, error) {
+			return NewLanguageCompositeFs(afero.NewMemMapFs(), NewLanguageFs("en", newTestLanguageSet(map[string]bool{"en": true}), afero.NewBasePathFs(base, "/"))), nil
+		}},
+		{"LanguageMetaFs", nil, func(base afero.Fs) (afero.Fs, error) { return NewLanguageMetaFs("en", base), nil }},
+		{"ManifestFs", nil, func(base afero.Fs) (afero.Fs, error) { return NewManifestFs(base, ""), nil }},
+		{"MetricsFs", nil, func(base afero.Fs) (afero.Fs, error) { return NewMetricsFs(base, hmetrics.NewRegistry(), "test"), nil }},
+		{"NoLstatFs", nil, func(base afero.Fs) (afero.Fs, error) { return NewNoLstatFs(base), nil }},
+		{"NoOpFs", nil, func(base afero.Fs) (afero.Fs, error) { return NoOpFs, nil }},
+		{"OrderedCopyOnWriteFs", nil, func(base afero.Fs) (afero.Fs, error) { return NewOrderedCopyOnWriteFs(base, afero.NewMemMapFs()), nil }},
+		{"PrefetchFs", nil, func(base afero.Fs) (afero.Fs, error) { return NewPrefetchFs(base, 1024), nil }},
+		{"QuotaFs", nil, func(base afero.Fs) (afero.Fs, error) { return NewQuotaFs(base, Quota{}), nil }},
+		{"RootMappingFs", []string{"", "v", filepath.FromSlash("v/w/file.txt")}, func(base afero.Fs) (afero.Fs, error) {
+			return NewRootMappingFs(base, "dir", "dir", filepath.FromSlash("v/w"), "dir")
+		}},
+		{"SanitizingFs", nil, func(base afero.Fs) (afero.Fs, error) { return NewSanitizingFs(base), nil }},
+		{"StacktracerFs", nil, func(base afero.Fs) (afero.Fs, error) { return NewStacktracerFs(base, "nomatch"), nil }},
+		{"TimeoutFs", nil, func(base afero.Fs) (afero.Fs, error) { return NewTimeoutFs(base, Timeouts{}), nil }},
+	} {
+		for _, name := range append([]string{"dir", filename}, test.names...) {
+			base := afero.NewMemMapFs()
+			require.NoError(t, afero.WriteFile(base, filename, []byte("content"), 0755))
+			fs, err := test.fs(base)
+			require.NoError(t, err)
+			exerciseFs(t, fmt.Sprintf("%s %q", test.name, name), fs, name)
+		}
+	}
+}
+
+func exerciseFs(t *testing.T, id string, fs afero.Fs, name string) {
+	call := func(op string, fn func()) {
+		defer func() {
+			if r := recover(); r != nil {
+				t.Errorf("%s: %s panics: %v", id, op, r)
+			}
+		}()
+		fn()
+	}
+
+	checkFileInfo := func(op string, fi os.FileInfo) {
+		if fi == nil {
+			return
+		}
+		call(op+".Name", func() { fi.Name() })
+		call(op+".Size", func() { fi.Size() })
+		call(op+".Mode", func() { fi.Mode() })
+		call(op+".ModTime", func() { fi.ModTime() })
+		call(op+".IsDir", func() { fi.IsDir() })
+		call(op+".Sys", func() { fi.Sys() })
+	}
+
+	call("Name", func() { fs.Name() })
+	call("Stat", func() {
+		fi, _ := fs.Stat(name)
+		checkFileInfo("Stat", fi)
+	})
+	if ls, ok := fs.(afero.Lstater); ok {
+		call("LstatIfPossible", func() {
+			fi, _, _ := ls.LstatIfPossible(name)
+			checkFileInfo("LstatIfPossible", fi)
+		})
+	}
+
+	exerciseFile := func(op string, open func() (afero.File, error)) {
+		var f afero.File
+		call(op, func() { f, _ = open() })
+		if f == nil {
+			return
+		}
+		call(op+".Name", func() { f.Name() })
+		call(op+".Stat", func() {
+			fi, _ := f.Stat()
+			checkFileInfo(op+".Stat", fi)
+		})
+		call(op+".Readdir", func() {
+			fis, _ := f.Readdir(-1)
+			for _, fi := range fis {
+				checkFileInfo(op+".Readdir", fi)
+			}
+		})
+		call(op+".Readdirnames", func() { f.Readdirnames(-1) })
+		call(op+".Read", func() { f.Read(make([]byte, 2)) })
+		call(op+".ReadAt", func() { f.ReadAt(make([]byte, 2), 1) })
+		call(op+".Seek", func() { f.Seek(0, 0) })
+		call(op+".Write", func() { f.Write([]byte("ab")) })
+		call(op+".WriteAt", func() { f.WriteAt([]byte("ab"), 1) })
+		call(op+".WriteString", func() { f.WriteString("ab") })
+		call(op+".Sync", func() { f.Sync() })
+		call(op+".Truncate", func() { f.Truncate(1) })
+		call(op+".Close", func() { f.Close() })
+	}
+
+	exerciseFile("Open", func() (afero.File, error) { return fs.Open(name) })
+	exerciseFile("OpenFile", func() (afero.File, error) { return fs.OpenFile(name, os.O_RDWR, 0755) })
+
+	now := time.Now()
+	call("Chmod", func() { fs.Chmod(name, 0700) })
+	call("Chtimes", func() { fs.Chtimes(name, now, now) })
+	call("Mkdir", func() { fs.Mkdir(filepath.Join(name, "new"), 0755) })
+	call("MkdirAll", func() { fs.MkdirAll(filepath.Join(name, "a", "b"), 0755) })
+	exerciseFile("Create", func() (afero.File, error) { return fs.Create(name + ".new") })
+	call("Rename", func() { fs.Rename(name, name+".renamed") })
+	call("Remove", func() { fs.Remove(name + ".renamed") })
+	call("RemoveAll", func() { fs.RemoveAll(name) })
+}
+
+type nopHashReceiver struct{}
+
+func (nopHashReceiver) OnFileClose(name, md5sum string) {}
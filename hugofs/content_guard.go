@@ -0,0 +1,202 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugofs
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/spf13/afero"
+)
+
+// DefaultContentGuardDeny is the default list of file extensions flagged by
+// a ContentGuard.
+var DefaultContentGuardDeny = []string{
+	"7z", "apk", "bin", "bz2", "deb", "dll", "dmg", "dylib", "exe", "gz",
+	"jar", "msi", "rar", "rpm", "so", "tar", "tgz", "xz", "zip",
+}
+
+// The magic numbers of the binary formats sniffed by a ContentGuard.
+var contentGuardMagics = []struct {
+	offset int
+	magic  []byte
+	format string
+}{
+	{0, []byte("\x7fELF"), "ELF executable"},
+	{0, []byte("MZ"), "Windows executable"},
+	{0, []byte{0xfe, 0xed, 0xfa, 0xce}, "Mach-O executable"},
+	{0, []byte{0xfe, 0xed, 0xfa, 0xcf}, "Mach-O executable"},
+	{0, []byte{0xce, 0xfa, 0xed, 0xfe}, "Mach-O executable"},
+	{0, []byte{0xcf, 0xfa, 0xed, 0xfe}, "Mach-O executable"},
+	{0, []byte("PK\x03\x04"), "zip archive"},
+	{0, []byte{0x1f, 0x8b}, "gzip archive"},
+	{0, []byte("BZh"), "bzip2 archive"},
+	{0, []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}, "xz archive"},
+	{0, []byte{'7', 'z', 0xbc, 0xaf, 0x27, 0x1c}, "7z archive"},
+	{0, []byte("Rar!\x1a\x07"), "rar archive"},
+	{257, []byte("ustar"), "tar archive"},
+}
+
+// ContentGuardOffender is a file flagged by a ContentGuard.
+type ContentGuardOffender struct {
+	// The absolute filename.
+	Filename string `json:"filename"`
+
+	// The content dir the file is in.
+	Mount string `json:"mount"`
+
+	// Why the file was flagged, e.g. "ELF executable".
+	Reason string `json:"reason"`
+
+	// Whether the file is hidden from the content filesystem.
+	Excluded bool `json:"excluded"`
+}
+
+// ContentGuard flags files of unexpected binary formats in the content dirs,
+// e.g. executables and archives in a content dir mounted from an external
+// repository. A file is flagged if its extension is in Deny or, unless its
+// extension is in Allow, it starts with the magic number of an executable
+// or archive format.
+type ContentGuard struct {
+	// Exclude hides the flagged files from the content filesystem. They are
+	// only reported if not set.
+	Exclude bool
+
+	// File extensions, without the dot, never flagged.
+	Allow []string
+
+	// File extensions, without the dot, always flagged.
+	Deny []string
+
+	sniffed fileValueCache
+
+	mu        sync.Mutex
+	offenders map[string]ContentGuardOffender
+}
+
+// NewContentGuard creates a new ContentGuard flagging the files with an
+// extension in deny, DefaultContentGuardDeny if nil, or with a binary format
+// and an extension not in allow.
+func NewContentGuard(exclude bool, allow, deny []string) *ContentGuard {
+	if deny == nil {
+		deny = DefaultContentGuardDeny
+	}
+	return &ContentGuard{Exclude: exclude, Allow: normalizeExts(allow), Deny: normalizeExts(deny)}
+}
+
+func normalizeExts(exts []string) []string {
+	normalized := make([]string, len(exts))
+	for i, ext := range exts {
+		normalized[i] = strings.ToLower(strings.TrimPrefix(ext, "."))
+	}
+	return normalized
+}
+
+// Offenders returns the files flagged so far, sorted by filename.
+func (g *ContentGuard) Offenders() []ContentGuardOffender {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	offenders := make([]ContentGuardOffender, 0, len(g.offenders))
+	for _, o := range g.offenders {
+		offenders = append(offenders, o)
+	}
+	sort.Slice(offenders, func(i, j int) bool {
+		return offenders[i].Filename < offenders[j].Filename
+	})
+
+	return offenders
+}
+
+// check reports whether the file with the given name in fs should be
+// hidden, recording it as an offender if flagged. The realFilename and mount
+// are used in the report.
+func (g *ContentGuard) check(fs afero.Fs, name, realFilename, mount string, fi os.FileInfo) bool {
+	if fi.IsDir() {
+		return false
+	}
+
+	reason, err := g.reason(fs, name, fi)
+	if err != nil || reason == "" {
+		return false
+	}
+
+	g.mu.Lock()
+	if g.offenders == nil {
+		g.offenders = make(map[string]ContentGuardOffender)
+	}
+	g.offenders[realFilename] = ContentGuardOffender{Filename: realFilename, Mount: mount, Reason: reason, Excluded: g.Exclude}
+	g.mu.Unlock()
+
+	return g.Exclude
+}
+
+func (g *ContentGuard) reason(fs afero.Fs, name string, fi os.FileInfo) (string, error) {
+	ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(name), "."))
+	if ext != "" {
+		for _, deny := range g.Deny {
+			if ext == deny {
+				return fmt.Sprintf("extension %q", ext), nil
+			}
+		}
+		for _, allow := range g.Allow {
+			if ext == allow {
+				return "", nil
+			}
+		}
+	}
+
+	if reason, found := g.sniffed.get(name, fi); found {
+		return reason, nil
+	}
+
+	reason, err := sniffBinaryFormat(fs, name)
+	if err != nil {
+		return "", err
+	}
+	g.sniffed.set(name, fi, reason)
+
+	return reason, nil
+}
+
+// sniffBinaryFormat returns the executable or archive format of the given
+// file, an empty string if none.
+func sniffBinaryFormat(fs afero.Fs, name string) (string, error) {
+	f, err := fs.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	head := make([]byte, 262)
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", err
+	}
+	head = head[:n]
+
+	for _, m := range contentGuardMagics {
+		if len(head) >= m.offset+len(m.magic) && bytes.Equal(head[m.offset:m.offset+len(m.magic)], m.magic) {
+			return m.format, nil
+		}
+	}
+
+	return "", nil
+}
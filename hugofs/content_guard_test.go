@@ -0,0 +1,99 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugofs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContentGuard(t *testing.T) {
+	assert := require.New(t)
+
+	for _, exclude := range []bool{false, true} {
+		m := afero.NewMemMapFs()
+		guard := NewContentGuard(exclude, []string{".docx"}, nil)
+		enFs := NewLanguageFs("en", newTestLanguageSet(map[string]bool{"en": true}), afero.NewBasePathFs(m, filepath.FromSlash("/content"))).WithContentGuard(guard)
+
+		for filename, content := range map[string]string{
+			"sect/page.md":     "+++\ntitle = \"Page\"\n+++\n",
+			"sect/tool":        "\x7fELF\x02\x01\x01",
+			"sect/setup.exe":   "MZ",
+			"sect/report.docx": "PK\x03\x04",
+			"sect/data.bin2":   "PK\x03\x04",
+		} {
+			assert.NoError(afero.WriteFile(enFs, filepath.FromSlash(filename), []byte(content), 0777))
+		}
+
+		_, err := enFs.Stat(filepath.FromSlash("sect/page.md"))
+		assert.NoError(err)
+		_, err = enFs.Stat(filepath.FromSlash("sect/report.docx"))
+		assert.NoError(err)
+
+		for _, filename := range []string{"sect/tool", "sect/setup.exe", "sect/data.bin2"} {
+			_, err = enFs.Stat(filepath.FromSlash(filename))
+			assert.Equal(exclude, os.IsNotExist(err), filename)
+			_, err = enFs.Open(filepath.FromSlash(filename))
+			assert.Equal(exclude, os.IsNotExist(err), filename)
+		}
+
+		fis, err := afero.ReadDir(enFs, "sect")
+		assert.NoError(err)
+		if exclude {
+			assert.Len(fis, 2)
+		} else {
+			assert.Len(fis, 5)
+		}
+
+		offenders := guard.Offenders()
+		assert.Len(offenders, 3)
+		assert.Equal(ContentGuardOffender{
+			Filename: filepath.FromSlash("/content/sect/data.bin2"),
+			Mount:    filepath.FromSlash("/content"),
+			Reason:   "zip archive",
+			Excluded: exclude,
+		}, offenders[0])
+		assert.Equal(`extension "exe"`, offenders[1].Reason)
+		assert.Equal("ELF executable", offenders[2].Reason)
+	}
+}
+
+func TestSniffBinaryFormat(t *testing.T) {
+	assert := require.New(t)
+	fs := afero.NewMemMapFs()
+
+	tarHeader := make([]byte, 300)
+	copy(tarHeader[257:], "ustar")
+
+	for _, test := range []struct {
+		content string
+		expect  string
+	}{
+		{"", ""},
+		{"# Title", ""},
+		{"\x1f\x8b\x08", "gzip archive"},
+		{"Rar!\x1a\x07\x00", "rar archive"},
+		{string(tarHeader), "tar archive"},
+		{"\xcf\xfa\xed\xfe", "Mach-O executable"},
+	} {
+		assert.NoError(afero.WriteFile(fs, "f", []byte(test.content), 0777))
+		format, err := sniffBinaryFormat(fs, "f")
+		assert.NoError(err)
+		assert.Equal(test.expect, format)
+	}
+}
@@ -0,0 +1,120 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugofs
+
+import (
+	"context"
+	"os"
+
+	"github.com/spf13/afero"
+)
+
+// ContextFs is implemented by filesystems that can abort an Open or Stat
+// when the given context is done, e.g. when the build is aborted.
+type ContextFs interface {
+	OpenContext(ctx context.Context, name string) (afero.File, error)
+	StatContext(ctx context.Context, name string) (os.FileInfo, error)
+}
+
+// ContextFile is implemented by files that can abort a Readdir when the given
+// context is done.
+type ContextFile interface {
+	ReaddirContext(ctx context.Context, count int) ([]os.FileInfo, error)
+}
+
+// OpenContext opens name in fs, returning ctx.Err() if ctx is done before the
+// open returns. If fs does not implement ContextFs, the open is left running
+// in the background and the file closed when it returns.
+func OpenContext(ctx context.Context, fs afero.Fs, name string) (afero.File, error) {
+	if cfs, ok := fs.(ContextFs); ok {
+		return cfs.OpenContext(ctx, name)
+	}
+	v, err := runContext(ctx, func() (interface{}, error) {
+		return fs.Open(name)
+	}, closeFile)
+	f, _ := v.(afero.File)
+	return f, err
+}
+
+// StatContext returns the os.FileInfo describing name in fs, returning
+// ctx.Err() if ctx is done before the stat returns.
+func StatContext(ctx context.Context, fs afero.Fs, name string) (os.FileInfo, error) {
+	if cfs, ok := fs.(ContextFs); ok {
+		return cfs.StatContext(ctx, name)
+	}
+	v, err := runContext(ctx, func() (interface{}, error) {
+		return fs.Stat(name)
+	}, nil)
+	fi, _ := v.(os.FileInfo)
+	return fi, err
+}
+
+// ReaddirContext reads the directory f, returning ctx.Err() if ctx is done
+// before the read returns.
+func ReaddirContext(ctx context.Context, f afero.File, count int) ([]os.FileInfo, error) {
+	if cf, ok := f.(ContextFile); ok {
+		return cf.ReaddirContext(ctx, count)
+	}
+	v, err := runContext(ctx, func() (interface{}, error) {
+		return f.Readdir(count)
+	}, nil)
+	fis, _ := v.([]os.FileInfo)
+	return fis, err
+}
+
+// runContext runs op, giving up with ctx.Err() when ctx is done. If op
+// returns after that without error, its result is passed to late, if set.
+func runContext(ctx context.Context, op func() (interface{}, error), late func(v interface{})) (interface{}, error) {
+	if ctx.Done() == nil {
+		// Can never be cancelled.
+		return op()
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	type result struct {
+		v   interface{}
+		err error
+	}
+
+	// Buffered so a late op does not block forever.
+	c := make(chan result, 1)
+
+	go func() {
+		v, err := op()
+		c <- result{v: v, err: err}
+	}()
+
+	select {
+	case r := <-c:
+		return r.v, r.err
+	case <-ctx.Done():
+		if late != nil {
+			go func() {
+				if r := <-c; r.err == nil {
+					late(r.v)
+				}
+			}()
+		}
+		return nil, ctx.Err()
+	}
+}
+
+func closeFile(v interface{}) {
+	if f, ok := v.(afero.File); ok && f != nil {
+		f.Close()
+	}
+}
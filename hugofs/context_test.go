@@ -0,0 +1,92 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugofs
+
+import (
+	"context"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContextFallback(t *testing.T) {
+	assert := require.New(t)
+
+	mfs := afero.NewMemMapFs()
+	assert.NoError(afero.WriteFile(mfs, "/content/a.txt", []byte("abc"), 0755))
+	assert.NoError(afero.WriteFile(mfs, "/content/dead.txt", []byte("def"), 0755))
+
+	hfs := &hangingFs{Fs: mfs, hang: "/content/dead.txt", release: make(chan struct{})}
+	defer close(hfs.release)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	f, err := OpenContext(ctx, hfs, "/content")
+	assert.NoError(err)
+	fis, err := ReaddirContext(ctx, f, -1)
+	assert.NoError(err)
+	assert.Len(fis, 2)
+	f.Close()
+
+	fi, err := StatContext(ctx, hfs, "/content/a.txt")
+	assert.NoError(err)
+	assert.Equal(int64(3), fi.Size())
+
+	done := make(chan error)
+	go func() {
+		_, err := OpenContext(ctx, hfs, "/content/dead.txt")
+		done <- err
+	}()
+	cancel()
+	assert.Equal(context.Canceled, <-done)
+
+	_, err = StatContext(ctx, hfs, "/content/a.txt")
+	assert.Equal(context.Canceled, err)
+}
+
+func TestRootMappingFsContext(t *testing.T) {
+	assert := require.New(t)
+
+	mfs := afero.NewMemMapFs()
+	assert.NoError(afero.WriteFile(mfs, "/mycontent/a.txt", []byte("abc"), 0755))
+	assert.NoError(afero.WriteFile(mfs, "/mycontent/dead.txt", []byte("def"), 0755))
+
+	hfs := &hangingFs{Fs: mfs, hang: "/mycontent/dead.txt", release: make(chan struct{})}
+	defer close(hfs.release)
+
+	rfs, err := NewRootMappingFs(hfs, "content", "/mycontent")
+	assert.NoError(err)
+
+	var cfs ContextFs = rfs
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	f, err := cfs.OpenContext(ctx, "content")
+	assert.NoError(err)
+	fis, err := ReaddirContext(ctx, f, -1)
+	assert.NoError(err)
+	assert.Len(fis, 2)
+
+	fi, err := cfs.StatContext(ctx, "content/a.txt")
+	assert.NoError(err)
+	assert.Equal("a.txt", fi.Name())
+
+	cancel()
+
+	_, err = cfs.StatContext(ctx, "content/dead.txt")
+	assert.Equal(context.Canceled, err)
+	_, err = ReaddirContext(ctx, f, -1)
+	assert.Equal(context.Canceled, err)
+}
@@ -21,6 +21,8 @@ import (
 	"sync"
 
 	"github.com/spf13/afero"
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
 )
 
 // Reseter is implemented by some of the stateful filesystems.
@@ -31,10 +33,39 @@ type Reseter interface {
 // DuplicatesReporter reports about duplicate filenames.
 type DuplicatesReporter interface {
 	ReportDuplicates() string
+
+	// ReportConflicts reports different filenames that are equal when
+	// folded, see PathFolding.
+	ReportConflicts() string
+}
+
+// PathFolding configures how filenames are folded when looking for
+// conflicting filenames, i.e. filenames that differ, but will most likely
+// end up as the same file on some systems.
+type PathFolding struct {
+	// Fold case, e.g. "Post.html" and "post.html".
+	Case bool
+
+	// Apply Unicode normalization (NFC), e.g. "é" as one code point and as
+	// "e" followed by a combining accent.
+	Unicode bool
+}
+
+func (p PathFolding) enabled() bool {
+	return p.Case || p.Unicode
 }
 
 func NewCreateCountingFs(fs afero.Fs) afero.Fs {
-	return &createCountingFs{Fs: fs, fileCount: make(map[string]int)}
+	return NewCreateCountingFsWithFolding(fs, PathFolding{})
+}
+
+// NewCreateCountingFsWithFolding creates a new filesystem counting the
+// created files that also reports the filenames that conflict with the given
+// folding applied.
+func NewCreateCountingFsWithFolding(fs afero.Fs, folding PathFolding) afero.Fs {
+	c := &createCountingFs{Fs: fs, folding: folding}
+	c.Reset()
+	return c
 }
 
 // ReportDuplicates reports filenames written more than once.
@@ -59,13 +90,47 @@ func (c *createCountingFs) ReportDuplicates() string {
 	return strings.Join(dupes, ", ")
 }
 
+// ReportConflicts reports the filenames written that are different, but
+// equal when folded. Each conflict is listed as the filenames joined by " ~ ".
+func (c *createCountingFs) ReportConflicts() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var conflicts []string
+
+	for _, names := range c.folded {
+		if len(names) > 1 {
+			sorted := make([]string, 0, len(names))
+			for name := range names {
+				sorted = append(sorted, name)
+			}
+			sort.Strings(sorted)
+			conflicts = append(conflicts, strings.Join(sorted, " ~ "))
+		}
+	}
+
+	if len(conflicts) == 0 {
+		return ""
+	}
+
+	sort.Strings(conflicts)
+
+	return strings.Join(conflicts, ", ")
+}
+
 // createCountingFs counts filenames of created files or files opened
 // for writing.
 type createCountingFs struct {
 	afero.Fs
 
+	folding PathFolding
+	caser   cases.Caser
+
 	mu        sync.Mutex
 	fileCount map[string]int
+
+	// Maps the folded filename to the filenames written.
+	folded map[string]map[string]bool
 }
 
 func (c *createCountingFs) Reset() {
@@ -73,6 +138,10 @@ func (c *createCountingFs) Reset() {
 	defer c.mu.Unlock()
 
 	c.fileCount = make(map[string]int)
+	c.folded = make(map[string]map[string]bool)
+	if c.folding.Case {
+		c.caser = cases.Fold()
+	}
 }
 
 func (fs *createCountingFs) onCreate(filename string) {
@@ -80,6 +149,28 @@ func (fs *createCountingFs) onCreate(filename string) {
 	defer fs.mu.Unlock()
 
 	fs.fileCount[filename] = fs.fileCount[filename] + 1
+
+	if !fs.folding.enabled() {
+		return
+	}
+
+	key := fs.fold(filename)
+	names, found := fs.folded[key]
+	if !found {
+		names = make(map[string]bool)
+		fs.folded[key] = names
+	}
+	names[filename] = true
+}
+
+func (fs *createCountingFs) fold(filename string) string {
+	if fs.folding.Unicode {
+		filename = norm.NFC.String(filename)
+	}
+	if fs.folding.Case {
+		filename = fs.caser.String(filename)
+	}
+	return filename
 }
 
 func (fs *createCountingFs) Create(name string) (afero.File, error) {
@@ -0,0 +1,59 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugofs
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateCountingFsReportConflicts(t *testing.T) {
+	assert := require.New(t)
+
+	nfc := "caf\u00e9.html"
+	nfd := "cafe\u0301.html"
+
+	write := func(fs afero.Fs, filenames ...string) {
+		for _, filename := range filenames {
+			assert.NoError(afero.WriteFile(fs, filepath.FromSlash(filename), []byte("content"), 0755))
+		}
+	}
+
+	filenames := []string{"post/index.html", "Post/index.html", nfc, nfd, "a.html", "a.html"}
+
+	fs := NewCreateCountingFs(afero.NewMemMapFs()).(DuplicatesReporter)
+	write(fs.(afero.Fs), filenames...)
+	assert.Equal("a.html (2)", fs.ReportDuplicates())
+	assert.Equal("", fs.ReportConflicts())
+
+	fs = NewCreateCountingFsWithFolding(afero.NewMemMapFs(), PathFolding{Case: true}).(DuplicatesReporter)
+	write(fs.(afero.Fs), filenames...)
+	assert.Equal(filepath.FromSlash("Post/index.html ~ post/index.html"), fs.ReportConflicts())
+
+	fs = NewCreateCountingFsWithFolding(afero.NewMemMapFs(), PathFolding{Unicode: true}).(DuplicatesReporter)
+	write(fs.(afero.Fs), filenames...)
+	assert.Equal(nfd+" ~ "+nfc, fs.ReportConflicts())
+
+	fs = NewCreateCountingFsWithFolding(afero.NewMemMapFs(), PathFolding{Case: true, Unicode: true}).(DuplicatesReporter)
+	write(fs.(afero.Fs), filenames...)
+	write(fs.(afero.Fs), "CAFÉ.html")
+	assert.Equal("CAFÉ.html ~ "+nfd+" ~ "+nfc+", "+filepath.FromSlash("Post/index.html ~ post/index.html"), fs.ReportConflicts())
+
+	fs.(Reseter).Reset()
+	assert.Equal("", fs.ReportConflicts())
+	assert.Equal("", fs.ReportDuplicates())
+}
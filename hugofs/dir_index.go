@@ -0,0 +1,544 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugofs
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/afero"
+)
+
+var (
+	_ afero.Fs      = (*dirIndexFs)(nil)
+	_ afero.Lstater = (*dirIndexFs)(nil)
+	_ afero.File    = (*dirIndexDir)(nil)
+)
+
+// Bump this when the index format changes. Indexes in other versions are
+// discarded on read.
+const dirIndexVersion = 1
+
+var errDirIndexIsDir = errors.New("is a directory")
+
+// DirIndex is an index of the directory listings (names, sizes, modes and
+// modification times) below one or more root directories, or mounts, e.g.
+// the content dirs. It is meant to be written at the end of a build and read
+// at the next startup, so a warm start can skip reading the directories that
+// have not changed since.
+//
+// A directory listing is considered stale when the directory's modification
+// time has changed, which is the case when files are added to, removed from
+// or renamed in it, or when the size, mode or modification time of any of
+// its files has changed, e.g. when edited in place. This is checked once per
+// directory, the first time it is used. Use Forget to invalidate the
+// listings of the files changed after that, e.g. on file system events.
+//
+// A DirIndex that is never written is an in-memory cache of the listings,
+// which saves repeated walks of the same directories from reading them from
+// disk again, e.g. on rebuilds in server mode.
+type DirIndex struct {
+	mu     sync.Mutex
+	mounts map[string]*dirIndexMount
+	dirty  bool
+}
+
+type dirIndexMount struct {
+	Lang string                   `json:"lang"`
+	Dirs map[string]*dirIndexList `json:"dirs"`
+}
+
+type dirIndexList struct {
+	ModTime time.Time       `json:"modTime"`
+	Entries []dirIndexEntry `json:"entries"`
+
+	// Set when ModTime is verified against the directory on disk.
+	verified bool
+}
+
+type dirIndexEntry struct {
+	Name    string      `json:"name"`
+	Size    int64       `json:"size"`
+	Mode    os.FileMode `json:"mode"`
+	ModTime time.Time   `json:"modTime"`
+}
+
+type dirIndexFile struct {
+	Version int                       `json:"version"`
+	Mounts  map[string]*dirIndexMount `json:"mounts"`
+}
+
+// NewDirIndex creates a new empty DirIndex.
+func NewDirIndex() *DirIndex {
+	return &DirIndex{mounts: make(map[string]*dirIndexMount)}
+}
+
+// ReadDirIndex reads a DirIndex written with Write. An index written by
+// another version of Hugo is discarded, and an empty index returned.
+func ReadDirIndex(r io.Reader) (*DirIndex, error) {
+	var f dirIndexFile
+	if err := json.NewDecoder(r).Decode(&f); err != nil {
+		return nil, errors.Wrap(err, "failed to read dir index")
+	}
+
+	idx := NewDirIndex()
+	if f.Version != dirIndexVersion {
+		return idx, nil
+	}
+
+	for root, mount := range f.Mounts {
+		if mount.Dirs == nil {
+			mount.Dirs = make(map[string]*dirIndexList)
+		}
+		idx.mounts[root] = mount
+	}
+
+	return idx, nil
+}
+
+// Write writes the index as JSON to w.
+func (i *DirIndex) Write(w io.Writer) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	b, err := json.Marshal(dirIndexFile{Version: dirIndexVersion, Mounts: i.mounts})
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(b); err != nil {
+		return err
+	}
+	i.dirty = false
+
+	return nil
+}
+
+// Dirty returns whether the index has changed since it was created, read or
+// last written.
+func (i *DirIndex) Dirty() bool {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.dirty
+}
+
+// Forget removes the listings of the given file or directory, its parent
+// directory and any directory below it from the index, so they are read from
+// disk on next access.
+func (i *DirIndex) Forget(filename string) {
+	filename = filepath.Clean(filename)
+	parent := filepath.Dir(filename)
+	prefix := filename + string(os.PathSeparator)
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	for _, mount := range i.mounts {
+		for dir := range mount.Dirs {
+			if dir == filename || dir == parent || strings.HasPrefix(dir, prefix) {
+				delete(mount.Dirs, dir)
+				i.dirty = true
+			}
+		}
+	}
+}
+
+// Fs returns a filesystem serving the directories below root in fs from the
+// index when possible. The paths used with it must be absolute. Any listings
+// recorded for root in another language are discarded.
+func (i *DirIndex) Fs(fs afero.Fs, root, lang string) afero.Fs {
+	root = filepath.Clean(root)
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	mount, found := i.mounts[root]
+	if !found || mount.Lang != lang {
+		mount = &dirIndexMount{Lang: lang, Dirs: make(map[string]*dirIndexList)}
+		i.mounts[root] = mount
+		i.dirty = true
+	}
+
+	return &dirIndexFs{Fs: fs, index: i, mount: mount}
+}
+
+// dirIndexFs is a filesystem for one mount in a DirIndex.
+type dirIndexFs struct {
+	index *DirIndex
+	mount *dirIndexMount
+	afero.Fs
+}
+
+// Name returns the name of this filesystem.
+func (fs *dirIndexFs) Name() string {
+	return "dirIndexFs"
+}
+
+// Open opens the named file or directory. Directories with a valid listing
+// in the index are served from it.
+func (fs *dirIndexFs) Open(name string) (afero.File, error) {
+	name = filepath.Clean(name)
+
+	if list := fs.verifiedList(name); list != nil {
+		if fi, err := fs.dirInfo(name); err == nil {
+			return &dirIndexDir{name: name, fi: fi, entries: list.Entries}, nil
+		}
+	}
+
+	f, err := fs.Fs.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	if !fi.IsDir() {
+		return f, nil
+	}
+
+	return &dirIndexRecordingFile{File: f, fs: fs, modTime: fi.ModTime()}, nil
+}
+
+// Stat returns the os.FileInfo of the named file, from the index when its
+// directory has a valid listing.
+func (fs *dirIndexFs) Stat(name string) (os.FileInfo, error) {
+	fi, found, err := fs.lookup("stat", name)
+	if found && (err != nil || fi.Mode()&os.ModeSymlink == 0) {
+		return fi, err
+	}
+	// Symbolic links need to be followed.
+	return fs.Fs.Stat(name)
+}
+
+// LstatIfPossible returns the os.FileInfo of the named file without following
+// any symbolic link, from the index when its directory has a valid listing.
+func (fs *dirIndexFs) LstatIfPossible(name string) (os.FileInfo, bool, error) {
+	if fi, found, err := fs.lookup("lstat", name); found {
+		return fi, true, err
+	}
+
+	if lstater, ok := fs.Fs.(afero.Lstater); ok {
+		return lstater.LstatIfPossible(name)
+	}
+
+	fi, err := fs.Fs.Stat(name)
+	return fi, false, err
+}
+
+// The operations below all modify the filesystem, so the affected listings
+// are removed from the index first.
+
+func (fs *dirIndexFs) Create(name string) (afero.File, error) {
+	fs.index.Forget(name)
+	return fs.Fs.Create(name)
+}
+
+func (fs *dirIndexFs) OpenFile(name string, flag int, perm os.FileMode) (afero.File, error) {
+	if isWrite(flag) {
+		fs.index.Forget(name)
+	}
+	return fs.Fs.OpenFile(name, flag, perm)
+}
+
+func (fs *dirIndexFs) Mkdir(name string, perm os.FileMode) error {
+	fs.index.Forget(name)
+	return fs.Fs.Mkdir(name, perm)
+}
+
+func (fs *dirIndexFs) MkdirAll(path string, perm os.FileMode) error {
+	fs.index.Forget(path)
+	return fs.Fs.MkdirAll(path, perm)
+}
+
+func (fs *dirIndexFs) Remove(name string) error {
+	fs.index.Forget(name)
+	return fs.Fs.Remove(name)
+}
+
+func (fs *dirIndexFs) RemoveAll(path string) error {
+	fs.index.Forget(path)
+	return fs.Fs.RemoveAll(path)
+}
+
+func (fs *dirIndexFs) Rename(oldname, newname string) error {
+	fs.index.Forget(oldname)
+	fs.index.Forget(newname)
+	return fs.Fs.Rename(oldname, newname)
+}
+
+func (fs *dirIndexFs) Chmod(name string, mode os.FileMode) error {
+	fs.index.Forget(name)
+	return fs.Fs.Chmod(name, mode)
+}
+
+func (fs *dirIndexFs) Chtimes(name string, atime time.Time, mtime time.Time) error {
+	fs.index.Forget(name)
+	return fs.Fs.Chtimes(name, atime, mtime)
+}
+
+// lookup looks up the named file in its directory's listing. It returns
+// whether the listing was found and valid, in which case an error is returned
+// if the file does not exist.
+func (fs *dirIndexFs) lookup(op, name string) (os.FileInfo, bool, error) {
+	name = filepath.Clean(name)
+	dir, base := filepath.Split(name)
+
+	list := fs.verifiedList(filepath.Clean(dir))
+	if list == nil {
+		return nil, false, nil
+	}
+
+	i := sort.Search(len(list.Entries), func(i int) bool { return list.Entries[i].Name >= base })
+	if i == len(list.Entries) || list.Entries[i].Name != base {
+		return nil, true, &os.PathError{Op: op, Path: name, Err: os.ErrNotExist}
+	}
+
+	return dirIndexFileInfo{list.Entries[i]}, true, nil
+}
+
+// dirInfo returns the os.FileInfo of the named directory, from its parent's
+// listing when valid.
+func (fs *dirIndexFs) dirInfo(name string) (os.FileInfo, error) {
+	if fi, found, err := fs.lookup("stat", name); found && err == nil && fi.IsDir() {
+		return fi, nil
+	}
+	return fs.Fs.Stat(name)
+}
+
+// verifiedList returns the listing of dirname if found in the index and still
+// valid.
+func (fs *dirIndexFs) verifiedList(dirname string) *dirIndexList {
+	fs.index.mu.Lock()
+	list, found := fs.mount.Dirs[dirname]
+	verified := found && list.verified
+	fs.index.mu.Unlock()
+
+	if !found || verified {
+		return list
+	}
+
+	fi, err := fs.Fs.Stat(dirname)
+	stale := err != nil || !fi.IsDir() || !fi.ModTime().Equal(list.ModTime) || fs.staleEntries(dirname, list)
+
+	fs.index.mu.Lock()
+	defer fs.index.mu.Unlock()
+
+	if stale {
+		delete(fs.mount.Dirs, dirname)
+		fs.index.dirty = true
+		return nil
+	}
+	list.verified = true
+
+	return list
+}
+
+// staleEntries returns whether any of the files in list has changed on disk,
+// e.g. edited in place, which does not touch the directory. Directories are
+// only checked to still be directories, they have listings of their own.
+func (fs *dirIndexFs) staleEntries(dirname string, list *dirIndexList) bool {
+	lstater, _ := fs.Fs.(afero.Lstater)
+
+	for _, e := range list.Entries {
+		filename := filepath.Join(dirname, e.Name)
+
+		var fi os.FileInfo
+		var err error
+		if lstater != nil {
+			fi, _, err = lstater.LstatIfPossible(filename)
+		} else {
+			fi, err = fs.Fs.Stat(filename)
+		}
+		if err != nil {
+			return true
+		}
+
+		if e.Mode.IsDir() {
+			if !fi.IsDir() {
+				return true
+			}
+			continue
+		}
+
+		if fi.IsDir() || fi.Size() != e.Size || fi.Mode() != e.Mode || !fi.ModTime().Equal(e.ModTime) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (fs *dirIndexFs) record(dirname string, modTime time.Time, fis []os.FileInfo) {
+	entries := make([]dirIndexEntry, len(fis))
+	for i, fi := range fis {
+		mode := fi.Mode()
+		if fi.IsDir() {
+			// Not all filesystems set this, e.g. afero.MemMapFs.
+			mode |= os.ModeDir
+		}
+		entries[i] = dirIndexEntry{Name: fi.Name(), Size: fi.Size(), Mode: mode, ModTime: fi.ModTime()}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+
+	fs.index.mu.Lock()
+	defer fs.index.mu.Unlock()
+
+	fs.mount.Dirs[dirname] = &dirIndexList{ModTime: modTime, Entries: entries, verified: true}
+	fs.index.dirty = true
+}
+
+// dirIndexRecordingFile records the full listing of a directory read from
+// disk in the index.
+type dirIndexRecordingFile struct {
+	afero.File
+	fs      *dirIndexFs
+	modTime time.Time
+}
+
+func (f *dirIndexRecordingFile) Readdir(count int) ([]os.FileInfo, error) {
+	fis, err := f.File.Readdir(count)
+	if err == nil && count <= 0 {
+		f.fs.record(filepath.Clean(f.Name()), f.modTime, fis)
+	}
+	return fis, err
+}
+
+func (f *dirIndexRecordingFile) Readdirnames(count int) ([]string, error) {
+	if count > 0 {
+		return f.File.Readdirnames(count)
+	}
+
+	fis, err := f.Readdir(count)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, len(fis))
+	for i, fi := range fis {
+		names[i] = fi.Name()
+	}
+
+	return names, nil
+}
+
+// dirIndexDir is a directory served from the index.
+type dirIndexDir struct {
+	name    string
+	fi      os.FileInfo
+	entries []dirIndexEntry
+	offset  int
+}
+
+func (d *dirIndexDir) Readdir(count int) ([]os.FileInfo, error) {
+	entries := d.entries[d.offset:]
+	if count > 0 {
+		if len(entries) == 0 {
+			return nil, io.EOF
+		}
+		if count < len(entries) {
+			entries = entries[:count]
+		}
+	}
+	d.offset += len(entries)
+
+	fis := make([]os.FileInfo, len(entries))
+	for i, e := range entries {
+		fis[i] = dirIndexFileInfo{e}
+	}
+
+	return fis, nil
+}
+
+func (d *dirIndexDir) Readdirnames(count int) ([]string, error) {
+	fis, err := d.Readdir(count)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, len(fis))
+	for i, fi := range fis {
+		names[i] = fi.Name()
+	}
+
+	return names, nil
+}
+
+func (d *dirIndexDir) Name() string {
+	return d.name
+}
+
+func (d *dirIndexDir) Stat() (os.FileInfo, error) {
+	return d.fi, nil
+}
+
+func (d *dirIndexDir) Close() error {
+	return nil
+}
+
+func (d *dirIndexDir) Sync() error {
+	return nil
+}
+
+func (d *dirIndexDir) Read(p []byte) (int, error) {
+	return 0, d.isDirErr("read")
+}
+
+func (d *dirIndexDir) ReadAt(p []byte, off int64) (int, error) {
+	return 0, d.isDirErr("read")
+}
+
+func (d *dirIndexDir) Seek(offset int64, whence int) (int64, error) {
+	return 0, d.isDirErr("seek")
+}
+
+func (d *dirIndexDir) Write(p []byte) (int, error) {
+	return 0, d.isDirErr("write")
+}
+
+func (d *dirIndexDir) WriteAt(p []byte, off int64) (int, error) {
+	return 0, d.isDirErr("write")
+}
+
+func (d *dirIndexDir) WriteString(s string) (int, error) {
+	return 0, d.isDirErr("write")
+}
+
+func (d *dirIndexDir) Truncate(size int64) error {
+	return d.isDirErr("truncate")
+}
+
+func (d *dirIndexDir) isDirErr(op string) error {
+	return &os.PathError{Op: op, Path: d.name, Err: errDirIndexIsDir}
+}
+
+type dirIndexFileInfo struct {
+	e dirIndexEntry
+}
+
+func (fi dirIndexFileInfo) Name() string       { return fi.e.Name }
+func (fi dirIndexFileInfo) Size() int64        { return fi.e.Size }
+func (fi dirIndexFileInfo) Mode() os.FileMode  { return fi.e.Mode }
+func (fi dirIndexFileInfo) ModTime() time.Time { return fi.e.ModTime }
+func (fi dirIndexFileInfo) IsDir() bool        { return fi.e.Mode.IsDir() }
+func (fi dirIndexFileInfo) Sys() interface{}   { return nil }
@@ -0,0 +1,228 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugofs
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func dirIndexWalk(t *testing.T, fs afero.Fs, root string) []string {
+	var filenames []string
+	err := afero.Walk(fs, root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			filenames = append(filenames, filepath.ToSlash(strings.TrimPrefix(path, root)))
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	sort.Strings(filenames)
+	return filenames
+}
+
+func newTestDirIndexFs(t *testing.T) afero.Fs {
+	fs := afero.NewMemMapFs()
+	for _, filename := range []string{"/content/a.md", "/content/sect/b.md", "/content/sect/c.md"} {
+		require.NoError(t, afero.WriteFile(fs, filepath.FromSlash(filename), []byte("abc"), 0777))
+	}
+	return fs
+}
+
+func TestDirIndex(t *testing.T) {
+	assert := require.New(t)
+	fs := newTestDirIndexFs(t)
+	root := filepath.FromSlash("/content")
+	sect := filepath.Join(root, "sect")
+
+	modTime := time.Now().Add(-time.Hour)
+	assert.NoError(fs.Chtimes(sect, modTime, modTime))
+
+	idx := NewDirIndex()
+	assert.Equal([]string{"/a.md", "/sect/b.md", "/sect/c.md"}, dirIndexWalk(t, idx.Fs(fs, root, "en"), root))
+	assert.True(idx.Dirty())
+
+	var b bytes.Buffer
+	assert.NoError(idx.Write(&b))
+	assert.False(idx.Dirty())
+
+	idx, err := ReadDirIndex(bytes.NewReader(b.Bytes()))
+	assert.NoError(err)
+
+	// Nothing has changed, so the listings are still valid.
+	ifs := idx.Fs(fs, root, "en")
+	assert.Equal([]string{"/a.md", "/sect/b.md", "/sect/c.md"}, dirIndexWalk(t, ifs, root))
+	assert.False(idx.Dirty())
+
+	fi, err := ifs.Stat(filepath.Join(sect, "b.md"))
+	assert.NoError(err)
+	assert.Equal(int64(3), fi.Size())
+	assert.False(fi.IsDir())
+	fi, _, err = ifs.(afero.Lstater).LstatIfPossible(sect)
+	assert.NoError(err)
+	assert.True(fi.IsDir())
+	_, err = ifs.Stat(filepath.Join(sect, "d.md"))
+	assert.True(os.IsNotExist(err))
+
+	// A file edited in place, e.g. by a git pull between two runs, does not
+	// touch its directory, but invalidates the listing.
+	assert.NoError(afero.WriteFile(fs, filepath.Join(sect, "b.md"), []byte("abcdef"), 0777))
+	assert.NoError(fs.Chtimes(sect, modTime, modTime))
+	idx, err = ReadDirIndex(bytes.NewReader(b.Bytes()))
+	assert.NoError(err)
+	ifs = idx.Fs(fs, root, "en")
+	fi, err = ifs.Stat(filepath.Join(sect, "b.md"))
+	assert.NoError(err)
+	assert.Equal(int64(6), fi.Size())
+	assert.True(idx.Dirty())
+
+	// So does a file removed behind the index's back.
+	assert.NoError(fs.Remove(filepath.Join(sect, "c.md")))
+	assert.NoError(fs.Chtimes(sect, modTime, modTime))
+	idx, err = ReadDirIndex(bytes.NewReader(b.Bytes()))
+	assert.NoError(err)
+	assert.Equal([]string{"/a.md", "/sect/b.md"}, dirIndexWalk(t, idx.Fs(fs, root, "en"), root))
+
+	// Touching the directory invalidates its listing.
+	assert.NoError(fs.Chtimes(sect, time.Now(), time.Now()))
+	idx, err = ReadDirIndex(bytes.NewReader(b.Bytes()))
+	assert.NoError(err)
+	assert.Equal([]string{"/a.md", "/sect/b.md"}, dirIndexWalk(t, idx.Fs(fs, root, "en"), root))
+	assert.True(idx.Dirty())
+}
+
+func TestDirIndexForget(t *testing.T) {
+	assert := require.New(t)
+	fs := newTestDirIndexFs(t)
+	root := filepath.FromSlash("/content")
+	sect := filepath.Join(root, "sect")
+
+	idx := NewDirIndex()
+	ifs := idx.Fs(fs, root, "en")
+	dirIndexWalk(t, ifs, root)
+
+	assert.NoError(fs.Remove(filepath.Join(sect, "c.md")))
+	assert.Equal([]string{"/a.md", "/sect/b.md", "/sect/c.md"}, dirIndexWalk(t, ifs, root))
+
+	idx.Forget(filepath.Join(sect, "c.md"))
+	assert.Equal([]string{"/a.md", "/sect/b.md"}, dirIndexWalk(t, ifs, root))
+
+	// Writes through the filesystem keep the index up to date.
+	assert.NoError(afero.WriteFile(ifs, filepath.Join(sect, "d.md"), []byte("abc"), 0777))
+	assert.Equal([]string{"/a.md", "/sect/b.md", "/sect/d.md"}, dirIndexWalk(t, ifs, root))
+}
+
+type dirIndexCountingFs struct {
+	afero.Fs
+	calls int
+}
+
+func (fs *dirIndexCountingFs) Open(name string) (afero.File, error) {
+	fs.calls++
+	return fs.Fs.Open(name)
+}
+
+func (fs *dirIndexCountingFs) Stat(name string) (os.FileInfo, error) {
+	fs.calls++
+	return fs.Fs.Stat(name)
+}
+
+func TestDirIndexCachedWalks(t *testing.T) {
+	assert := require.New(t)
+	fs := &dirIndexCountingFs{Fs: newTestDirIndexFs(t)}
+	root := filepath.FromSlash("/content")
+	sect := filepath.Join(root, "sect")
+
+	idx := NewDirIndex()
+	ifs := idx.Fs(fs, root, "en")
+	expected := []string{"/a.md", "/sect/b.md", "/sect/c.md"}
+	assert.Equal(expected, dirIndexWalk(t, ifs, root))
+
+	// Only the root, which is not in a listing, is read from the source again.
+	fs.calls = 0
+	assert.Equal(expected, dirIndexWalk(t, ifs, root))
+	assert.True(fs.calls > 0)
+	fs.calls = 0
+	assert.Equal([]string{"/b.md", "/c.md"}, dirIndexWalk(t, ifs, sect))
+	assert.Equal(0, fs.calls)
+
+	idx.Forget(filepath.Join(sect, "b.md"))
+	fs.calls = 0
+	assert.Equal([]string{"/b.md", "/c.md"}, dirIndexWalk(t, ifs, sect))
+	assert.True(fs.calls > 0)
+}
+
+func TestDirIndexMounts(t *testing.T) {
+	assert := require.New(t)
+	fs := newTestDirIndexFs(t)
+	root := filepath.FromSlash("/content")
+
+	idx := NewDirIndex()
+	dirIndexWalk(t, idx.Fs(fs, root, "en"), root)
+	assert.NoError(fs.Remove(filepath.FromSlash("/content/a.md")))
+
+	// Same language, the index is used.
+	assert.Equal([]string{"/a.md", "/sect/b.md", "/sect/c.md"}, dirIndexWalk(t, idx.Fs(fs, root, "en"), root))
+
+	// The mount's language has changed, start over.
+	assert.Equal([]string{"/sect/b.md", "/sect/c.md"}, dirIndexWalk(t, idx.Fs(fs, root, "nn"), root))
+}
+
+func TestDirIndexLanguageFs(t *testing.T) {
+	assert := require.New(t)
+	fs := newTestDirIndexFs(t)
+	root := filepath.FromSlash("/content")
+	languages := newTestLanguageSet(map[string]bool{"en": true})
+
+	idx := NewDirIndex()
+	lfs := NewLanguageFs("en", languages, afero.NewBasePathFs(idx.Fs(fs, root, "en"), root))
+
+	dir, err := lfs.Open("sect")
+	assert.NoError(err)
+	fis, err := dir.Readdir(-1)
+	assert.NoError(err)
+	assert.Len(fis, 2)
+
+	assert.NoError(fs.Remove(filepath.FromSlash("/content/sect/c.md")))
+
+	dir, err = lfs.Open("sect")
+	assert.NoError(err)
+	fis, err = dir.Readdir(-1)
+	assert.NoError(err)
+	assert.Len(fis, 2)
+	lfi := fis[0].(*LanguageFileInfo)
+	assert.Equal("en", lfi.Lang())
+	assert.Equal(filepath.FromSlash("/content/sect/b.md"), lfi.Filename())
+}
+
+func TestReadDirIndexOtherVersion(t *testing.T) {
+	assert := require.New(t)
+
+	idx, err := ReadDirIndex(strings.NewReader(`{"version":0,"mounts":{"/content":{"lang":"en","dirs":{}}}}`))
+	assert.NoError(err)
+	assert.Len(idx.mounts, 0)
+
+	_, err = ReadDirIndex(strings.NewReader(`{`))
+	assert.Error(err)
+}
@@ -0,0 +1,211 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugofs
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/gobwas/glob"
+	"github.com/pkg/errors"
+	"github.com/spf13/afero"
+)
+
+var (
+	_ afero.Fs = (*FingerprintFs)(nil)
+	_ Reseter  = (*FingerprintFs)(nil)
+)
+
+// FingerprintFs writes the files matching any of its patterns under names
+// with a hash of their content, e.g. css/style.css as
+// css/style.1a2b3c4d.css, so they can be cached forever. The original and
+// hashed names are recorded, see Mappings.
+type FingerprintFs struct {
+	afero.Fs
+
+	baseDir  string
+	patterns []glob.Glob
+	cache    *HashCache
+
+	mu       sync.Mutex
+	mappings map[string]string
+}
+
+// NewFingerprintFs creates a new FingerprintFs. The glob patterns, e.g.
+// "**.css", are matched against the "/" separated filenames relative to
+// baseDir, typically the publish dir.
+func NewFingerprintFs(delegate afero.Fs, baseDir string, patterns ...string) (*FingerprintFs, error) {
+	return NewFingerprintFsWithCache(delegate, baseDir, nil, patterns...)
+}
+
+// NewFingerprintFsWithCache is the same as NewFingerprintFs, but the SHA-256
+// sums of the fingerprinted files are stored in cache. A file already
+// published with the same content is then kept as is instead of replaced.
+func NewFingerprintFsWithCache(delegate afero.Fs, baseDir string, cache *HashCache, patterns ...string) (*FingerprintFs, error) {
+	fs := &FingerprintFs{Fs: delegate, baseDir: baseDir, cache: cache}
+	for _, pattern := range patterns {
+		g, err := glob.Compile(pattern, '/')
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid fingerprint pattern %q", pattern)
+		}
+		fs.patterns = append(fs.patterns, g)
+	}
+	fs.Reset()
+	return fs, nil
+}
+
+// Reset clears the recorded mappings.
+func (fs *FingerprintFs) Reset() {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	fs.mappings = make(map[string]string)
+}
+
+func (fs *FingerprintFs) Create(name string) (afero.File, error) {
+	f, err := fs.Fs.Create(name)
+	if err == nil && fs.matches(name) {
+		f = fs.wrapFile(f, name)
+	}
+	return f, err
+}
+
+func (fs *FingerprintFs) OpenFile(name string, flag int, perm os.FileMode) (afero.File, error) {
+	f, err := fs.Fs.OpenFile(name, flag, perm)
+	if err == nil && isWrite(flag) && fs.matches(name) {
+		f = fs.wrapFile(f, name)
+	}
+	return f, err
+}
+
+func (fs *FingerprintFs) Name() string {
+	return "FingerprintFs"
+}
+
+// Mappings returns the "/" separated original filenames mapped to their
+// fingerprinted filenames, both relative to the base dir.
+func (fs *FingerprintFs) Mappings() map[string]string {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	m := make(map[string]string, len(fs.mappings))
+	for k, v := range fs.mappings {
+		m[k] = v
+	}
+	return m
+}
+
+// WriteManifest writes the Mappings to w as a JSON object.
+func (fs *FingerprintFs) WriteManifest(w io.Writer) error {
+	b, err := json.MarshalIndent(fs.Mappings(), "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+func (fs *FingerprintFs) relPath(name string) string {
+	name = filepath.Clean(name)
+	if fs.baseDir != "" {
+		if rel, err := filepath.Rel(fs.baseDir, name); err == nil && !strings.HasPrefix(rel, "..") {
+			name = rel
+		}
+	}
+	return strings.TrimPrefix(filepath.ToSlash(name), "/")
+}
+
+func (fs *FingerprintFs) matches(name string) bool {
+	path := fs.relPath(name)
+	for _, g := range fs.patterns {
+		if g.Match(path) {
+			return true
+		}
+	}
+	return false
+}
+
+func (fs *FingerprintFs) wrapFile(f afero.File, name string) afero.File {
+	return &fingerprintFile{File: f, fs: fs, name: name, h: sha256.New()}
+}
+
+// fingerprint renames the written file to its fingerprinted name, or removes
+// it if that file is already published with the same content.
+func (fs *FingerprintFs) fingerprint(name, sum string) error {
+	ext := filepath.Ext(name)
+	hashedName := strings.TrimSuffix(name, ext) + "." + sum[:8] + ext
+
+	if fs.cache != nil && fs.isPublished(hashedName, sum) {
+		if err := fs.Fs.Remove(name); err != nil {
+			return err
+		}
+	} else {
+		// Rename fails on some platforms if the target exists.
+		fs.Fs.Remove(hashedName)
+
+		if err := fs.Fs.Rename(name, hashedName); err != nil {
+			return err
+		}
+
+		if fs.cache != nil {
+			if fi, err := fs.Fs.Stat(hashedName); err == nil {
+				fs.cache.Add("sha256", filepath.Clean(hashedName), fi, sum)
+			}
+		}
+	}
+
+	fs.mu.Lock()
+	fs.mappings[fs.relPath(name)] = fs.relPath(hashedName)
+	fs.mu.Unlock()
+
+	return nil
+}
+
+// isPublished reports whether hashedName exists with the content with the
+// given SHA-256 sum, according to the cache.
+func (fs *FingerprintFs) isPublished(hashedName, sum string) bool {
+	fi, err := fs.Fs.Stat(hashedName)
+	if err != nil {
+		return false
+	}
+	cached, found := fs.cache.Lookup("sha256", filepath.Clean(hashedName), fi)
+	return found && cached == sum
+}
+
+type fingerprintFile struct {
+	afero.File
+	fs   *FingerprintFs
+	name string
+	h    hash.Hash
+}
+
+func (f *fingerprintFile) Write(p []byte) (n int, err error) {
+	n, err = f.File.Write(p)
+	if err != nil {
+		return
+	}
+	return f.h.Write(p)
+}
+
+func (f *fingerprintFile) Close() error {
+	if err := f.File.Close(); err != nil {
+		return err
+	}
+	return f.fs.fingerprint(f.name, hex.EncodeToString(f.h.Sum(nil)))
+}
@@ -0,0 +1,92 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugofs
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFingerprintFs(t *testing.T) {
+	assert := require.New(t)
+
+	mm := afero.NewMemMapFs()
+	publishDir := filepath.FromSlash("/public")
+
+	fs, err := NewFingerprintFs(mm, publishDir, "**.css", "js/*.js")
+	assert.NoError(err)
+
+	assert.NoError(afero.WriteFile(fs, filepath.Join(publishDir, "css", "style.css"), []byte("body {}"), 0755))
+	assert.NoError(afero.WriteFile(fs, filepath.Join(publishDir, "js", "main.js"), []byte("main"), 0755))
+	assert.NoError(afero.WriteFile(fs, filepath.Join(publishDir, "js", "vendor", "lib.js"), []byte("lib"), 0755))
+	assert.NoError(afero.WriteFile(fs, filepath.Join(publishDir, "index.html"), []byte("home"), 0755))
+
+	mappings := fs.Mappings()
+	assert.Equal(map[string]string{
+		"css/style.css": "css/style.62368a1a.css",
+		"js/main.js":    "js/main.0d6e4079.js",
+	}, mappings)
+
+	for _, filename := range []string{"css/style.62368a1a.css", "js/main.0d6e4079.js", "js/vendor/lib.js", "index.html"} {
+		exists, _ := afero.Exists(mm, filepath.Join(publishDir, filepath.FromSlash(filename)))
+		assert.True(exists, filename)
+	}
+	exists, _ := afero.Exists(mm, filepath.Join(publishDir, "css", "style.css"))
+	assert.False(exists)
+
+	var b bytes.Buffer
+	assert.NoError(fs.WriteManifest(&b))
+	assert.Contains(b.String(), `"css/style.css": "css/style.62368a1a.css"`)
+
+	fs.Reset()
+	assert.Len(fs.Mappings(), 0)
+
+	_, err = NewFingerprintFs(mm, publishDir, "[a")
+	assert.Error(err)
+}
+
+func TestFingerprintFsWithCache(t *testing.T) {
+	assert := require.New(t)
+
+	mm := afero.NewMemMapFs()
+	publishDir := filepath.FromSlash("/public")
+	filename := filepath.Join(publishDir, "css", "style.css")
+	hashedFilename := filepath.Join(publishDir, "css", "style.62368a1a.css")
+
+	cache := NewHashCache()
+	fs, err := NewFingerprintFsWithCache(mm, publishDir, cache, "**.css")
+	assert.NoError(err)
+
+	assert.NoError(afero.WriteFile(fs, filename, []byte("body {}"), 0755))
+	fi, err := mm.Stat(hashedFilename)
+	assert.NoError(err)
+	sum, found := cache.Lookup("sha256", hashedFilename, fi)
+	assert.True(found)
+	assert.Equal("62368a1a", sum[:8])
+
+	// Published before with the same content, so kept as is.
+	fs, err = NewFingerprintFsWithCache(mm, publishDir, cache, "**.css")
+	assert.NoError(err)
+	assert.NoError(afero.WriteFile(fs, filename, []byte("body {}"), 0755))
+	fi2, err := mm.Stat(hashedFilename)
+	assert.NoError(err)
+	assert.Equal(fi.ModTime(), fi2.ModTime())
+	exists, _ := afero.Exists(mm, filename)
+	assert.False(exists)
+	assert.Equal(map[string]string{"css/style.css": "css/style.62368a1a.css"}, fs.Mappings())
+}
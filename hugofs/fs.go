@@ -12,11 +12,18 @@
 // limitations under the License.
 
 // Package hugofs provides the file systems used by Hugo.
+//
+// All the composite filesystems in this package list the entries of a
+// directory sorted by name, see SortFileInfos, so the result of a build does
+// not depend on the order of the underlying filesystems. The one exception
+// is the root of a RootMappingFs, which is listed in the order the roots
+// were configured.
 package hugofs
 
 import (
 	"os"
 
+	"github.com/gohugoio/hugo/common/hmetrics"
 	"github.com/gohugoio/hugo/config"
 	"github.com/spf13/afero"
 )
@@ -40,6 +47,42 @@ type Fs struct {
 	// WorkingDir is a read-only file system
 	// restricted to the project working dir.
 	WorkingDir *afero.BasePathFs
+
+	// DirIndex is, if set, used to serve the content dirs' listings.
+	// See DirIndex.
+	DirIndex *DirIndex
+
+	// HashCache is, if set, used to cache the hashes of the source files.
+	// See HashCache.
+	HashCache *HashCache
+
+	// Metrics is, if set, where the operations on the content dirs are
+	// recorded. See MetricsFs.
+	Metrics *hmetrics.Registry
+
+	// Logger is, if set, where the diagnostics from the filesystems are sent.
+	Logger Logger
+}
+
+// FilesystemUnwrapper is implemented by the filesystems decorating another
+// filesystem, so the filesystem below the decorators can be inspected.
+type FilesystemUnwrapper interface {
+	UnwrapFilesystem() afero.Fs
+}
+
+// IsOsFs returns whether fs is the OS file system, possibly decorated with
+// one or more FilesystemUnwrapper.
+func IsOsFs(fs afero.Fs) bool {
+	for {
+		switch v := fs.(type) {
+		case *afero.OsFs:
+			return true
+		case FilesystemUnwrapper:
+			fs = v.UnwrapFilesystem()
+		default:
+			return false
+		}
+	}
 }
 
 // NewDefault creates a new Fs with the OS file system
@@ -58,3 +58,11 @@ func TestWorkingDir(t *testing.T) {
 	assert.NotNil(t, f.WorkingDir)
 	assert.IsType(t, new(afero.BasePathFs), f.WorkingDir)
 }
+
+func TestIsOsFs(t *testing.T) {
+	assert.True(t, IsOsFs(Os))
+	assert.True(t, IsOsFs(NewQuotaFs(NewPrefetchFs(NewTimeoutFs(Os, Timeouts{}), 10), Quota{})))
+	assert.False(t, IsOsFs(afero.NewMemMapFs()))
+	assert.False(t, IsOsFs(NewTimeoutFs(afero.NewMemMapFs(), Timeouts{})))
+	assert.False(t, IsOsFs(afero.NewBasePathFs(Os, "/a")))
+}
@@ -0,0 +1,287 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package fstest provides a harness to test that afero filesystems, usually
+// Hugo's composite filesystems and decorators, are safe for concurrent use.
+package fstest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+// StressOptions configures Stress.
+type StressOptions struct {
+	// The directory to start from. Default is "", the root of most of Hugo's
+	// filesystems.
+	Root string
+
+	// The number of goroutines. Default is 10.
+	Workers int
+
+	// The number of times each goroutine goes through the filesystem.
+	// Default is 10.
+	Iterations int
+}
+
+// Stress hammers fs with concurrent Open, Readdir, Readdirnames, Stat and
+// Walk operations on all the files below the root, and verifies that:
+//
+//   - no directory listing has duplicate names
+//   - every directory is listed, by both Readdir and Readdirnames, with the
+//     same names in the same order every time
+//   - every file listed can be stat'ed, with the same name, type and size as listed
+//   - every walk visits the same files
+//
+// The expected state is established by going through the filesystem once
+// before the concurrent part. Run with the race detector enabled to also catch
+// any data races.
+func Stress(t testing.TB, fs afero.Fs, opts StressOptions) {
+	if opts.Workers <= 0 {
+		opts.Workers = 10
+	}
+	if opts.Iterations <= 0 {
+		opts.Iterations = 10
+	}
+
+	expected, err := snapshot(fs, opts.Root)
+	if err != nil {
+		t.Fatalf("failed to read %q: %s", opts.Root, err)
+	}
+	if len(expected.dirs) == 0 {
+		t.Fatalf("no directories found in %q", opts.Root)
+	}
+
+	for dir, fis := range expected.dirs {
+		if dupe := findDuplicate(fis); dupe != "" {
+			t.Errorf("%q: %q listed more than once", dir, dupe)
+		}
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < opts.Workers; i++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for j := 0; j < opts.Iterations; j++ {
+				if err := expected.verify(fs, opts.Root, worker%3); err != nil {
+					t.Errorf("worker %d, iteration %d: %s", worker, j, err)
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+type fsSnapshot struct {
+	// Maps a directory to its listing.
+	dirs map[string][]os.FileInfo
+
+	// Maps a directory to its names as listed by Readdirnames. Note that
+	// these may differ from the names in the FileInfos, as some of Hugo's
+	// filesystems decorate the names in Readdir.
+	dirnames map[string][]string
+
+	// All the files found walking from the root.
+	walked []string
+}
+
+func snapshot(fs afero.Fs, root string) (*fsSnapshot, error) {
+	s := &fsSnapshot{dirs: make(map[string][]os.FileInfo), dirnames: make(map[string][]string)}
+
+	walked, err := walk(fs, root)
+	if err != nil {
+		return nil, err
+	}
+	s.walked = walked
+
+	if err := s.readDirs(fs, root); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func (s *fsSnapshot) readDirs(fs afero.Fs, dir string) error {
+	fis, err := readDir(fs, dir)
+	if err != nil {
+		return err
+	}
+	s.dirs[dir] = fis
+
+	names, err := readDirnames(fs, dir)
+	if err != nil {
+		return err
+	}
+	s.dirnames[dir] = names
+
+	for _, fi := range fis {
+		if fi.IsDir() {
+			if err := s.readDirs(fs, filepath.Join(dir, fi.Name())); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// verify runs through the filesystem comparing it with s. The variant decides
+// the order of the operations, to get some variation between goroutines.
+func (s *fsSnapshot) verify(fs afero.Fs, root string, variant int) error {
+	dirs := make([]string, 0, len(s.dirs))
+	for dir := range s.dirs {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+	if variant == 1 {
+		for i, j := 0, len(dirs)-1; i < j; i, j = i+1, j-1 {
+			dirs[i], dirs[j] = dirs[j], dirs[i]
+		}
+	}
+
+	if variant == 2 {
+		if err := s.verifyWalk(fs, root); err != nil {
+			return err
+		}
+	}
+
+	for _, dir := range dirs {
+		if err := s.verifyDir(fs, dir); err != nil {
+			return err
+		}
+	}
+
+	if variant != 2 {
+		return s.verifyWalk(fs, root)
+	}
+
+	return nil
+}
+
+func (s *fsSnapshot) verifyDir(fs afero.Fs, dir string) error {
+	expected := s.dirs[dir]
+
+	fis, err := readDir(fs, dir)
+	if err != nil {
+		return err
+	}
+	if err := compareNames(dir, namesOf(expected), namesOf(fis)); err != nil {
+		return err
+	}
+
+	names, err := readDirnames(fs, dir)
+	if err != nil {
+		return err
+	}
+	if err := compareNames(dir, s.dirnames[dir], names); err != nil {
+		return err
+	}
+
+	for _, efi := range expected {
+		filename := filepath.Join(dir, efi.Name())
+		fi, err := fs.Stat(filename)
+		if err != nil {
+			return fmt.Errorf("stat %q: %s", filename, err)
+		}
+		if fi.Name() != efi.Name() {
+			return fmt.Errorf("stat %q: got name %q", filename, fi.Name())
+		}
+		if fi.IsDir() != efi.IsDir() {
+			return fmt.Errorf("stat %q: got IsDir %t, listed as %t", filename, fi.IsDir(), efi.IsDir())
+		}
+		if !fi.IsDir() && fi.Size() != efi.Size() {
+			return fmt.Errorf("stat %q: got size %d, listed as %d", filename, fi.Size(), efi.Size())
+		}
+	}
+
+	return nil
+}
+
+func (s *fsSnapshot) verifyWalk(fs afero.Fs, root string) error {
+	walked, err := walk(fs, root)
+	if err != nil {
+		return err
+	}
+	return compareNames("walk "+root, s.walked, walked)
+}
+
+func walk(fs afero.Fs, root string) ([]string, error) {
+	var filenames []string
+	err := afero.Walk(fs, root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			filenames = append(filenames, path)
+		}
+		return nil
+	})
+	return filenames, err
+}
+
+func readDir(fs afero.Fs, dir string) ([]os.FileInfo, error) {
+	f, err := fs.Open(dir)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return f.Readdir(-1)
+}
+
+func readDirnames(fs afero.Fs, dir string) ([]string, error) {
+	f, err := fs.Open(dir)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return f.Readdirnames(-1)
+}
+
+func namesOf(fis []os.FileInfo) []string {
+	names := make([]string, len(fis))
+	for i, fi := range fis {
+		names[i] = fi.Name()
+	}
+	return names
+}
+
+func findDuplicate(fis []os.FileInfo) string {
+	seen := make(map[string]bool)
+	for _, fi := range fis {
+		if seen[fi.Name()] {
+			return fi.Name()
+		}
+		seen[fi.Name()] = true
+	}
+	return ""
+}
+
+func compareNames(what string, expected, got []string) error {
+	if len(expected) != len(got) {
+		return fmt.Errorf("%s: got %d entries %v, expected %d %v", what, len(got), got, len(expected), expected)
+	}
+	for i := range expected {
+		if expected[i] != got[i] {
+			return fmt.Errorf("%s: entry %d is %q, expected %q (got %v, expected %v)", what, i, got[i], expected[i], got, expected)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,218 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugofs
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Bump this when the cache format changes. Caches in other versions are
+// discarded on read.
+const hashCacheVersion = 1
+
+// HashCache is a cache of file content hashes. It is meant to be written at
+// the end of a build and read at the next startup, so the hash of a file that
+// has not changed since is a lookup rather than a full read.
+//
+// A file is identified by its real filename, see RealFilenameInfo, and a
+// cached hash is only used if the file's size and modification time are
+// unchanged. A file may have hashes of more than one kind, e.g. "md5".
+type HashCache struct {
+	mu      sync.Mutex
+	entries map[string]*hashCacheEntry
+	dirty   bool
+
+	// The filenames looked up or added since the last Prune.
+	touched map[string]bool
+}
+
+type hashCacheEntry struct {
+	Size    int64             `json:"size"`
+	ModTime time.Time         `json:"modTime"`
+	Hashes  map[string]string `json:"hashes"`
+}
+
+type hashCacheFile struct {
+	Version int                        `json:"version"`
+	Entries map[string]*hashCacheEntry `json:"entries"`
+}
+
+// NewHashCache creates a new empty HashCache.
+func NewHashCache() *HashCache {
+	return &HashCache{entries: make(map[string]*hashCacheEntry), touched: make(map[string]bool)}
+}
+
+// ReadHashCache reads a HashCache written with Write. A cache written by
+// another version of Hugo is discarded, and an empty cache returned.
+func ReadHashCache(r io.Reader) (*HashCache, error) {
+	var f hashCacheFile
+	if err := json.NewDecoder(r).Decode(&f); err != nil {
+		return nil, errors.Wrap(err, "failed to read hash cache")
+	}
+
+	c := NewHashCache()
+	if f.Version != hashCacheVersion {
+		return c, nil
+	}
+
+	for filename, entry := range f.Entries {
+		if entry.Hashes == nil {
+			entry.Hashes = make(map[string]string)
+		}
+		c.entries[filename] = entry
+	}
+
+	return c, nil
+}
+
+// Write writes the cache as JSON to w.
+func (c *HashCache) Write(w io.Writer) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	b, err := json.Marshal(hashCacheFile{Version: hashCacheVersion, Entries: c.entries})
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(b); err != nil {
+		return err
+	}
+	c.dirty = false
+
+	return nil
+}
+
+// Dirty returns whether the cache has changed since it was created, read or
+// last written.
+func (c *HashCache) Dirty() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.dirty
+}
+
+// Forget removes the hashes of the given file, or of any file below it if it
+// is a directory, from the cache.
+func (c *HashCache) Forget(filename string) {
+	filename = filepath.Clean(filename)
+	prefix := filename + string(os.PathSeparator)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for name := range c.entries {
+		if name == filename || strings.HasPrefix(name, prefix) {
+			delete(c.entries, name)
+			c.dirty = true
+		}
+	}
+}
+
+// Prune removes the entries for the files not looked up or added since the
+// cache was created or last pruned, e.g. files deleted since the last run.
+// It should only be called after a full build. It is safe to call Prune on a
+// nil HashCache.
+func (c *HashCache) Prune() {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for name := range c.entries {
+		if !c.touched[name] {
+			delete(c.entries, name)
+			c.dirty = true
+		}
+	}
+	c.touched = make(map[string]bool)
+}
+
+// Lookup returns the cached hash of the given kind for filename, if the
+// file's size and modification time, from fi, are unchanged. It is safe to
+// call Lookup on a nil HashCache, which never finds anything.
+func (c *HashCache) Lookup(kind, filename string, fi os.FileInfo) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.touched[filename] = true
+
+	entry, found := c.entries[filename]
+	if !found || entry.Size != fi.Size() || !entry.ModTime.Equal(fi.ModTime()) {
+		return "", false
+	}
+	h, found := entry.Hashes[kind]
+	return h, found
+}
+
+// Add stores the hash of the given kind for filename, described by fi, e.g.
+// computed while the file was written. It is safe to call Add on a nil
+// HashCache, which does nothing.
+func (c *HashCache) Add(kind, filename string, fi os.FileInfo, h string) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.touched[filename] = true
+
+	entry, found := c.entries[filename]
+	if !found || entry.Size != fi.Size() || !entry.ModTime.Equal(fi.ModTime()) {
+		entry = &hashCacheEntry{Size: fi.Size(), ModTime: fi.ModTime(), Hashes: make(map[string]string)}
+		c.entries[filename] = entry
+	}
+	if entry.Hashes[kind] != h {
+		entry.Hashes[kind] = h
+		c.dirty = true
+	}
+}
+
+// Hash returns the hash of the given kind for the file described by fi,
+// which must be a RealFilenameInfo to be cached. If the cache has no valid
+// hash, it is computed with hash and stored. It is safe to call Hash on a nil
+// HashCache, which will always compute the hash.
+func (c *HashCache) Hash(kind string, fi os.FileInfo, hash func() (string, error)) (string, error) {
+	rfi, ok := fi.(RealFilenameInfo)
+	if c == nil || !ok {
+		return hash()
+	}
+	filename := rfi.RealFilename()
+
+	if h, found := c.Lookup(kind, filename, fi); found {
+		return h, nil
+	}
+
+	h, err := hash()
+	if err != nil {
+		return "", err
+	}
+
+	c.Add(kind, filename, fi, h)
+
+	return h, nil
+}
@@ -0,0 +1,136 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugofs
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHashCache(t *testing.T) {
+	assert := require.New(t)
+
+	fs := NewBasePathRealFilenameFs(afero.NewBasePathFs(afero.NewMemMapFs(), filepath.FromSlash("/assets")).(*afero.BasePathFs))
+	assert.NoError(afero.WriteFile(fs, "a.txt", []byte("abc"), 0777))
+
+	counter := 0
+	hash := func(h string) func() (string, error) {
+		return func() (string, error) {
+			counter++
+			return h, nil
+		}
+	}
+
+	stat := func() *realFilenameInfo {
+		fi, err := fs.Stat("a.txt")
+		assert.NoError(err)
+		return fi.(*realFilenameInfo)
+	}
+
+	c := NewHashCache()
+	assert.False(c.Dirty())
+
+	h, err := c.Hash("md5", stat(), hash("h1"))
+	assert.NoError(err)
+	assert.Equal("h1", h)
+	assert.True(c.Dirty())
+
+	h, _ = c.Hash("md5", stat(), hash("h2"))
+	assert.Equal("h1", h)
+	assert.Equal(1, counter)
+
+	// Another kind.
+	h, _ = c.Hash("sha256", stat(), hash("s1"))
+	assert.Equal("s1", h)
+	assert.Equal(2, counter)
+
+	var b bytes.Buffer
+	assert.NoError(c.Write(&b))
+	assert.False(c.Dirty())
+
+	c, err = ReadHashCache(&b)
+	assert.NoError(err)
+	h, _ = c.Hash("md5", stat(), hash("h2"))
+	assert.Equal("h1", h)
+	h, _ = c.Hash("sha256", stat(), hash("s2"))
+	assert.Equal("s1", h)
+	assert.Equal(2, counter)
+	assert.False(c.Dirty())
+
+	// Changed modification time.
+	modTime := time.Now().Add(time.Hour)
+	assert.NoError(fs.Chtimes("a.txt", modTime, modTime))
+	h, _ = c.Hash("md5", stat(), hash("h3"))
+	assert.Equal("h3", h)
+	h, _ = c.Hash("sha256", stat(), hash("s3"))
+	assert.Equal("s3", h)
+	assert.Equal(4, counter)
+
+	// Changed size.
+	assert.NoError(afero.WriteFile(fs, "a.txt", []byte("abcd"), 0777))
+	assert.NoError(fs.Chtimes("a.txt", modTime, modTime))
+	h, _ = c.Hash("md5", stat(), hash("h4"))
+	assert.Equal("h4", h)
+
+	c.Forget(filepath.FromSlash("/assets"))
+	h, _ = c.Hash("md5", stat(), hash("h5"))
+	assert.Equal("h5", h)
+
+	// No real filename, no caching.
+	fi, err := afero.NewMemMapFs().Create("b.txt")
+	assert.NoError(err)
+	bfi, err := fi.Stat()
+	assert.NoError(err)
+	h, _ = c.Hash("md5", bfi, hash("b1"))
+	assert.Equal("b1", h)
+	h, _ = c.Hash("md5", bfi, hash("b2"))
+	assert.Equal("b2", h)
+
+	// Nil cache.
+	var nc *HashCache
+	h, _ = nc.Hash("md5", stat(), hash("n1"))
+	assert.Equal("n1", h)
+
+	// Hashes added for files written, keyed on any filename.
+	c.Add("md5", filepath.FromSlash("/public/index.html"), bfi, "p1")
+	h, found := c.Lookup("md5", filepath.FromSlash("/public/index.html"), bfi)
+	assert.True(found)
+	assert.Equal("p1", h)
+	_, found = c.Lookup("sha256", filepath.FromSlash("/public/index.html"), bfi)
+	assert.False(found)
+	_, found = nc.Lookup("md5", filepath.FromSlash("/public/index.html"), bfi)
+	assert.False(found)
+
+	// Entries not used since the last Prune are removed.
+	c.Prune()
+	assert.NoError(c.Write(&b))
+	c.Prune()
+	assert.True(c.Dirty())
+	_, found = c.Lookup("md5", filepath.FromSlash("/public/index.html"), bfi)
+	assert.False(found)
+	h, _ = c.Hash("md5", stat(), hash("h6"))
+	assert.Equal("h6", h)
+	nc.Prune()
+
+	// Other versions are discarded.
+	c, err = ReadHashCache(bytes.NewBufferString(`{"version":0,"entries":{"/assets/a.txt":{"size":4,"hashes":{"md5":"h0"}}}}`))
+	assert.NoError(err)
+	h, _ = c.Hash("md5", stat(), hash("h7"))
+	assert.Equal("h7", h)
+}
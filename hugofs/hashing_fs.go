@@ -18,6 +18,7 @@ import (
 	"encoding/hex"
 	"hash"
 	"os"
+	"path/filepath"
 
 	"github.com/spf13/afero"
 )
@@ -31,9 +32,17 @@ type FileHashReceiver interface {
 	OnFileClose(name, md5sum string)
 }
 
+// PrevFileHashReceiver is a FileHashReceiver that is also told the MD5 sum
+// of a file's previous content before it is overwritten, if cached.
+type PrevFileHashReceiver interface {
+	FileHashReceiver
+	OnFileOpen(name, prevMD5sum string)
+}
+
 type md5HashingFs struct {
 	afero.Fs
 	hashReceiver FileHashReceiver
+	cache        *HashCache
 }
 
 // NewHashingFs creates a new filesystem that will receive MD5 checksums of
@@ -44,27 +53,54 @@ type md5HashingFs struct {
 // Note that this will only work for file operations that use the io.Writer
 // to write content to file, but that is fine for the "publish content" use case.
 func NewHashingFs(delegate afero.Fs, hashReceiver FileHashReceiver) afero.Fs {
-	return &md5HashingFs{Fs: delegate, hashReceiver: hashReceiver}
+	return NewHashingFsWithCache(delegate, hashReceiver, nil)
+}
+
+// NewHashingFsWithCache is the same as NewHashingFs, but the MD5 sums of the
+// written files are also stored in cache, so the sum of a file's previous
+// content is known after a restart, see PrevFileHashReceiver.
+func NewHashingFsWithCache(delegate afero.Fs, hashReceiver FileHashReceiver, cache *HashCache) afero.Fs {
+	return &md5HashingFs{Fs: delegate, hashReceiver: hashReceiver, cache: cache}
 }
 
 func (fs *md5HashingFs) Create(name string) (afero.File, error) {
+	fs.onOpen(name)
 	f, err := fs.Fs.Create(name)
 	if err == nil {
-		f = fs.wrapFile(f)
+		f = fs.wrapFile(f, name)
 	}
 	return f, err
 }
 
 func (fs *md5HashingFs) OpenFile(name string, flag int, perm os.FileMode) (afero.File, error) {
+	if isWrite(flag) {
+		fs.onOpen(name)
+	}
 	f, err := fs.Fs.OpenFile(name, flag, perm)
 	if err == nil && isWrite(flag) {
-		f = fs.wrapFile(f)
+		f = fs.wrapFile(f, name)
 	}
 	return f, err
 }
 
-func (fs *md5HashingFs) wrapFile(f afero.File) afero.File {
-	return &hashingFile{File: f, h: md5.New(), hashReceiver: fs.hashReceiver}
+// onOpen tells the receiver the cached MD5 sum of the file's current content,
+// if any, before it is written.
+func (fs *md5HashingFs) onOpen(name string) {
+	r, ok := fs.hashReceiver.(PrevFileHashReceiver)
+	if !ok || fs.cache == nil {
+		return
+	}
+	fi, err := fs.Fs.Stat(name)
+	if err != nil {
+		return
+	}
+	if sum, found := fs.cache.Lookup("md5", filepath.Clean(name), fi); found {
+		r.OnFileOpen(name, sum)
+	}
+}
+
+func (fs *md5HashingFs) wrapFile(f afero.File, name string) afero.File {
+	return &hashingFile{File: f, h: md5.New(), hashReceiver: fs.hashReceiver, fs: fs, name: name}
 }
 
 func (fs *md5HashingFs) Name() string {
@@ -74,6 +110,8 @@ func (fs *md5HashingFs) Name() string {
 type hashingFile struct {
 	hashReceiver FileHashReceiver
 	h            hash.Hash
+	fs           *md5HashingFs
+	name         string
 	afero.File
 }
 
@@ -88,5 +126,15 @@ func (h *hashingFile) Write(p []byte) (n int, err error) {
 func (h *hashingFile) Close() error {
 	sum := hex.EncodeToString(h.h.Sum(nil))
 	h.hashReceiver.OnFileClose(h.Name(), sum)
-	return h.File.Close()
+	if err := h.File.Close(); err != nil {
+		return err
+	}
+
+	if h.fs.cache != nil {
+		if fi, err := h.fs.Fs.Stat(h.name); err == nil {
+			h.fs.cache.Add("md5", filepath.Clean(h.name), fi, sum)
+		}
+	}
+
+	return nil
 }
@@ -21,8 +21,13 @@ import (
 )
 
 type testHashReceiver struct {
-	sum  string
-	name string
+	sum     string
+	name    string
+	prevSum string
+}
+
+func (t *testHashReceiver) OnFileOpen(name, prevMD5sum string) {
+	t.prevSum = prevMD5sum
 }
 
 func (t *testHashReceiver) OnFileClose(name, md5hash string) {
@@ -51,3 +56,25 @@ func TestHashingFs(t *testing.T) {
 	assert.Equal("d41d8cd98f00b204e9800998ecf8427e", observer.sum)
 
 }
+
+func TestHashingFsWithCache(t *testing.T) {
+	assert := require.New(t)
+
+	fs := afero.NewMemMapFs()
+	cache := NewHashCache()
+
+	observer := &testHashReceiver{}
+	assert.NoError(afero.WriteFile(NewHashingFsWithCache(fs, observer, cache), "hashme", []byte("content"), 0755))
+	assert.Equal("", observer.prevSum)
+
+	// E.g. after a restart.
+	observer = &testHashReceiver{}
+	assert.NoError(afero.WriteFile(NewHashingFsWithCache(fs, observer, cache), "hashme", []byte("new content"), 0755))
+	assert.Equal("9a0364b9e99bb480dd25e1f0284c8555", observer.prevSum)
+
+	// Changed outside of the filesystem, no longer valid.
+	assert.NoError(afero.WriteFile(fs, "hashme", []byte("changed"), 0755))
+	observer = &testHashReceiver{}
+	assert.NoError(afero.WriteFile(NewHashingFsWithCache(fs, observer, cache), "hashme", []byte("content"), 0755))
+	assert.Equal("", observer.prevSum)
+}
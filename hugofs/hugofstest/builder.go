@@ -0,0 +1,150 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package hugofstest provides a builder for the filesystems used in tests.
+package hugofstest
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/gohugoio/hugo/hugofs"
+	"github.com/gohugoio/hugo/langs"
+	"github.com/spf13/afero"
+)
+
+// Builder assembles MemMapFs backed filesystems for tests, e.g.:
+//
+//	fs := hugofstest.New(t).
+//		WithLang("en").WithLang("nn").
+//		WithMount("content/en", "en").WithMount("content/nn", "nn").
+//		Add("content/en/blog/a.md", "some en").
+//		Add("content/nn/blog/a.md", "some nn").
+//		Build()
+//
+// All filenames are "/" separated and relative to the root of the source
+// filesystem.
+type Builder struct {
+	t testing.TB
+
+	source    afero.Fs
+	languages langs.Languages
+	mounts    []mount
+	subdirs   map[string]string
+}
+
+type mount struct {
+	dir  string
+	lang string
+	fs   *hugofs.LanguageFs
+}
+
+// New creates a new Builder with an empty source filesystem.
+func New(t testing.TB) *Builder {
+	return &Builder{t: t, source: afero.NewMemMapFs()}
+}
+
+// Add writes a file with the given content to the source filesystem.
+func (b *Builder) Add(filename, content string) *Builder {
+	if err := afero.WriteFile(b.source, b.path(filename), []byte(content), 0777); err != nil {
+		b.t.Fatalf("failed to add %q: %s", filename, err)
+	}
+	return b
+}
+
+// WithLang adds an enabled language with the given code and aliases.
+func (b *Builder) WithLang(lang string, aliases ...string) *Builder {
+	b.languages = append(b.languages, &langs.Language{Lang: lang, Aliases: aliases})
+	return b
+}
+
+// WithFallbacks sets the fallback languages of a language already added,
+// see langs.Language.Fallbacks.
+func (b *Builder) WithFallbacks(lang string, fallbacks ...string) *Builder {
+	for _, language := range b.languages {
+		if language.Lang == lang {
+			language.Fallbacks = fallbacks
+			return b
+		}
+	}
+	b.t.Fatalf("no language %q", lang)
+	return b
+}
+
+// WithDisabledLang adds a disabled language.
+func (b *Builder) WithDisabledLang(lang string) *Builder {
+	b.languages = append(b.languages, &langs.Language{Lang: lang, Disabled: true})
+	return b
+}
+
+// WithLangSubdirs sets the sub directory each language is published to,
+// see hugofs.LanguageFs.WithLanguageSubdirs.
+func (b *Builder) WithLangSubdirs(subdirs map[string]string) *Builder {
+	b.subdirs = subdirs
+	return b
+}
+
+// WithMount adds a directory in the source filesystem as a content dir in
+// lang. The first mount added is the top layer in Build.
+func (b *Builder) WithMount(dir, lang string) *Builder {
+	b.mounts = append(b.mounts, mount{dir: b.path(dir), lang: lang})
+	return b
+}
+
+// Source returns the source filesystem.
+func (b *Builder) Source() afero.Fs {
+	return b.source
+}
+
+// Languages returns the languages added, keyed by their codes and aliases.
+func (b *Builder) Languages() langs.LanguageSet {
+	return b.languages.AsSet()
+}
+
+// LanguageFs returns the language filesystem of the mount in dir.
+func (b *Builder) LanguageFs(dir string) *hugofs.LanguageFs {
+	dir = b.path(dir)
+	for i, m := range b.mounts {
+		if m.dir != dir {
+			continue
+		}
+		if m.fs == nil {
+			b.mounts[i].fs = hugofs.NewLanguageFs(m.lang, b.Languages(), afero.NewBasePathFs(b.source, m.dir)).
+				WithLanguageSubdirs(b.subdirs).
+				WithLanguageFallbacks(b.languages.FallbackChains())
+		}
+		return b.mounts[i].fs
+	}
+	b.t.Fatalf("no mount in %q", dir)
+	return nil
+}
+
+// Build composes the mounts into one filesystem as Hugo does for the
+// content dirs, see hugofs.NewLanguageCompositeFs. With one mount, its
+// language filesystem is returned.
+func (b *Builder) Build() afero.Fs {
+	if len(b.mounts) == 0 {
+		b.t.Fatal("no mounts")
+	}
+
+	var fs afero.Fs = b.LanguageFs(b.mounts[len(b.mounts)-1].dir)
+	for i := len(b.mounts) - 2; i >= 0; i-- {
+		fs = hugofs.NewLanguageCompositeFs(fs, b.LanguageFs(b.mounts[i].dir))
+	}
+
+	return fs
+}
+
+func (b *Builder) path(filename string) string {
+	return filepath.Join(string(filepath.Separator), filepath.FromSlash(filename))
+}
@@ -0,0 +1,69 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugofs
+
+import (
+	"sync"
+)
+
+var (
+	internedStrings   sync.Map
+	internedFileMetas sync.Map
+)
+
+// intern returns a canonical instance of s, so the same directory, language
+// etc. is stored once no matter how many file infos reference it.
+func intern(s string) string {
+	if s == "" {
+		return s
+	}
+	if v, found := internedStrings.Load(s); found {
+		return v.(string)
+	}
+	v, _ := internedStrings.LoadOrStore(s, s)
+	return v.(string)
+}
+
+// languageFileMeta holds the language meta data of a file. There are only a
+// handful of distinct combinations in a site, so they are interned and shared
+// by all the file infos decorated with them, keeping the per file overhead to
+// one pointer.
+type languageFileMeta struct {
+	lang       string
+	baseDir    string
+	langSubdir string
+
+	// Set if this file is borrowed from another language, see MergeTranslations.
+	borrowedFrom string
+
+	// The language of the filesystem holding the file. The files in their
+	// own language's content directory win, see fsRank.
+	fsLang string
+}
+
+// internLanguageFileMeta returns the shared instance of m.
+func internLanguageFileMeta(m languageFileMeta) *languageFileMeta {
+	if v, found := internedFileMetas.Load(m); found {
+		return v.(*languageFileMeta)
+	}
+
+	m.lang = intern(m.lang)
+	m.fsLang = intern(m.fsLang)
+	m.baseDir = intern(m.baseDir)
+	m.langSubdir = intern(m.langSubdir)
+	m.borrowedFrom = intern(m.borrowedFrom)
+
+	v, _ := internedFileMetas.LoadOrStore(m, &m)
+	return v.(*languageFileMeta)
+}
@@ -0,0 +1,125 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugofs
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gohugoio/hugo/parser/metadecoders"
+	"github.com/gohugoio/hugo/parser/pageparser"
+	"github.com/spf13/afero"
+	"github.com/spf13/cast"
+)
+
+// LanguageClassifier returns the language of the given file, a file with no
+// language in its name, or an empty string if it cannot tell.
+type LanguageClassifier func(fs afero.Fs, filename string) (string, error)
+
+// The front matter delimiters, see pageparser.
+var frontMatterStarts = [][]byte{[]byte("---"), []byte("+++"), []byte("{"), []byte("#+")}
+
+// FrontMatterLanguageClassifier is a LanguageClassifier reading the "lang"
+// field in the front matter of a content file. Files not starting with front
+// matter are not read any further.
+func FrontMatterLanguageClassifier(fs afero.Fs, filename string) (string, error) {
+	f, err := fs.Open(filename)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	head := make([]byte, 3)
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		if err == io.EOF {
+			return "", nil
+		}
+		return "", err
+	}
+	head = head[:n]
+
+	var hasFrontMatter bool
+	for _, start := range frontMatterStarts {
+		if bytes.HasPrefix(head, start) {
+			hasFrontMatter = true
+			break
+		}
+	}
+	if !hasFrontMatter {
+		return "", nil
+	}
+
+	psr, err := pageparser.Parse(io.MultiReader(bytes.NewReader(head), f), pageparser.Config{})
+	if err != nil {
+		return "", err
+	}
+
+	iter := psr.Iterator()
+	for {
+		it := iter.Next()
+		if it.IsDone() {
+			return "", nil
+		}
+		if it.IsFrontMatter() {
+			m, err := metadecoders.Default.UnmarshalToMap(it.Val, metadecoders.FormatFromFrontMatterType(it.Type))
+			if err != nil {
+				return "", err
+			}
+			for k, v := range m {
+				if strings.EqualFold(k, "lang") {
+					return cast.ToString(v), nil
+				}
+			}
+			return "", nil
+		}
+	}
+}
+
+type fileValue struct {
+	modTime time.Time
+	size    int64
+	value   string
+}
+
+// fileValueCache caches a value computed from a file's content, e.g. its
+// classified language, by filename. An entry is used as long as the file's
+// size and modification time match.
+type fileValueCache struct {
+	mu     sync.RWMutex
+	values map[string]fileValue
+}
+
+func (c *fileValueCache) get(filename string, fi os.FileInfo) (string, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	v, found := c.values[filename]
+	if !found || v.size != fi.Size() || !v.modTime.Equal(fi.ModTime()) {
+		return "", false
+	}
+	return v.value, true
+}
+
+func (c *fileValueCache) set(filename string, fi os.FileInfo, value string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.values == nil {
+		c.values = make(map[string]fileValue)
+	}
+	c.values[filename] = fileValue{modTime: fi.ModTime(), size: fi.Size(), value: value}
+}
@@ -14,6 +14,9 @@
 package hugofs
 
 import (
+	"os"
+	"syscall"
+
 	"github.com/spf13/afero"
 )
 
@@ -24,19 +27,57 @@ var (
 
 type languageCompositeFs struct {
 	*afero.CopyOnWriteFs
+
+	// The language filesystems in this composite, top layer first.
+	fss []*LanguageFs
+}
+
+// readOnlyLanguageCompositeFs is the read-only languageCompositeFs returned
+// by NewLanguageCompositeFs. It keeps a reference to the composite so it can
+// be used as the base of another.
+type readOnlyLanguageCompositeFs struct {
+	*afero.ReadOnlyFs
+	fs *languageCompositeFs
 }
 
 // NewLanguageCompositeFs creates a composite and language aware filesystem.
 // This is a hybrid filesystem. To get a specific file in Open, Stat etc., use the full filename
 // to the target filesystem. This information is available in Readdir, Stat etc. via the
 // special LanguageFileInfo FileInfo implementation.
+//
+// A regular file opened by its path in the composite, e.g. "blog/post.en.md",
+// is picked from the filesystems of its language first, then from those of
+// its fallback languages (see LanguageFs.WithLanguageFallbacks), then from
+// the others, top layer first. The file returned implements
+// LanguageAnnouncer.
+//
+// Directories are merged and sorted by name, or with the collator of the
+// overlay if set, see LanguageFs.WithCollator. Of the entries with the same
+// name, the one from the filesystem of its language wins, then the ones from
+// the filesystems of its fallback languages, in order.
 func NewLanguageCompositeFs(base afero.Fs, overlay *LanguageFs) afero.Fs {
-	return afero.NewReadOnlyFs(&languageCompositeFs{afero.NewCopyOnWriteFs(base, overlay).(*afero.CopyOnWriteFs)})
+	fss := []*LanguageFs{overlay}
+	switch b := base.(type) {
+	case *LanguageFs:
+		fss = append(fss, b)
+	case *readOnlyLanguageCompositeFs:
+		fss = append(fss, b.fs.fss...)
+	}
+
+	fs := &languageCompositeFs{CopyOnWriteFs: afero.NewCopyOnWriteFs(base, overlay).(*afero.CopyOnWriteFs), fss: fss}
+
+	return &readOnlyLanguageCompositeFs{ReadOnlyFs: afero.NewReadOnlyFs(fs).(*afero.ReadOnlyFs), fs: fs}
 }
 
 // Open takes the full path to the file in the target filesystem. If it is a directory, it gets merged
 // using the language as a weight.
 func (fs *languageCompositeFs) Open(name string) (afero.File, error) {
+	if lfs, _, err := fs.pick(name); err != nil {
+		return nil, err
+	} else if lfs != nil {
+		return lfs.Open(name)
+	}
+
 	f, err := fs.CopyOnWriteFs.Open(name)
 	if err != nil {
 		return nil, err
@@ -45,7 +86,89 @@ func (fs *languageCompositeFs) Open(name string) (afero.File, error) {
 	fu, ok := f.(*afero.UnionFile)
 	if ok {
 		// This is a directory: Merge it.
-		fu.Merger = LanguageDirsMerger
+		fu.Merger = languageDirsMerger(fs.fss[0].fallbacks, fs.fss[0].collator)
+		return &mergedDir{File: fu}, nil
 	}
 	return f, nil
 }
+
+// OpenFile opens the named file for reading, see Open. The filesystem is
+// read-only, so any flag but os.O_RDONLY is rejected.
+func (fs *languageCompositeFs) OpenFile(name string, flag int, perm os.FileMode) (afero.File, error) {
+	if flag&(os.O_WRONLY|os.O_RDWR|os.O_APPEND|os.O_CREATE|os.O_TRUNC) != 0 {
+		return nil, &os.PathError{Op: "open", Path: name, Err: syscall.EPERM}
+	}
+	return fs.Open(name)
+}
+
+// Stat returns the os.FileInfo of the file Open would open.
+func (fs *languageCompositeFs) Stat(name string) (os.FileInfo, error) {
+	lfs, fi, err := fs.pick(name)
+	if err != nil {
+		return nil, err
+	}
+	if lfs != nil {
+		return fi, nil
+	}
+	return fs.CopyOnWriteFs.Stat(name)
+}
+
+// LstatIfPossible returns the os.FileInfo of the file Open would open, see
+// afero.Lstater.
+func (fs *languageCompositeFs) LstatIfPossible(name string) (os.FileInfo, bool, error) {
+	lfs, _, err := fs.pick(name)
+	if err != nil {
+		return nil, false, err
+	}
+	if lfs != nil {
+		return lfs.LstatIfPossible(name)
+	}
+	return fs.CopyOnWriteFs.LstatIfPossible(name)
+}
+
+// pick returns the language filesystem to get the regular file name from,
+// see PickLanguageFileInfo, falling back to the first one holding it, top
+// layer first. It returns a nil filesystem if name is a directory or is not
+// in any of the language filesystems, e.g. when the base of the composite is
+// another kind of filesystem, leaving it to the afero.CopyOnWriteFs.
+func (fs *languageCompositeFs) pick(name string) (*LanguageFs, os.FileInfo, error) {
+	var (
+		candidates []os.FileInfo
+		owners     = make(map[os.FileInfo]*LanguageFs)
+	)
+
+	for _, lfs := range fs.fss {
+		fi, err := lfs.Stat(name)
+		if err != nil {
+			if isNotExist(err) {
+				continue
+			}
+			return nil, nil, err
+		}
+		if fi.IsDir() {
+			return nil, nil, nil
+		}
+		candidates = append(candidates, fi)
+		owners[fi] = lfs
+	}
+
+	if len(candidates) == 0 {
+		return nil, nil, nil
+	}
+
+	lang, _ := fs.fss[0].FileLang(name)
+	if fi := PickLanguageFileInfo(lang, fs.fss[0].fallbacks[lang], candidates); fi != nil {
+		return owners[fi], fi, nil
+	}
+
+	return owners[candidates[0]], candidates[0], nil
+}
+
+// isNotExist reports whether err tells that a file does not exist, including
+// when a parent in its path is a file, as afero.CopyOnWriteFs does.
+func isNotExist(err error) bool {
+	if e, ok := err.(*os.PathError); ok {
+		err = e.Err
+	}
+	return os.IsNotExist(err) || err == syscall.ENOTDIR
+}
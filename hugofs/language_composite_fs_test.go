@@ -11,15 +11,21 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
-package hugofs
+package hugofs_test
 
 import (
+	"fmt"
+	"io"
+	"os"
 	"path/filepath"
 
 	"strings"
 
 	"testing"
 
+	"github.com/gohugoio/hugo/hugofs"
+	"github.com/gohugoio/hugo/hugofs/hugofstest"
+	"github.com/gohugoio/hugo/langs"
 	"github.com/spf13/afero"
 	"github.com/stretchr/testify/require"
 )
@@ -27,43 +33,32 @@ import (
 func TestCompositeLanguagFsTest(t *testing.T) {
 	assert := require.New(t)
 
-	languages := map[string]bool{
-		"sv": true,
-		"en": true,
-		"nn": true,
-	}
-	msv := afero.NewMemMapFs()
-	baseSv := "/content/sv"
-	lfssv := NewLanguageFs("sv", languages, afero.NewBasePathFs(msv, baseSv))
-	mnn := afero.NewMemMapFs()
-	baseNn := "/content/nn"
-	lfsnn := NewLanguageFs("nn", languages, afero.NewBasePathFs(mnn, baseNn))
-	men := afero.NewMemMapFs()
-	baseEn := "/content/en"
-	lfsen := NewLanguageFs("en", languages, afero.NewBasePathFs(men, baseEn))
-
 	// The order will be sv, en, nn
-	composite := NewLanguageCompositeFs(lfsnn, lfsen)
-	composite = NewLanguageCompositeFs(composite, lfssv)
+	b := hugofstest.New(t).
+		WithLang("sv").WithLang("en").WithLang("nn").
+		WithMount("content/sv", "sv").
+		WithMount("content/en", "en").
+		WithMount("content/nn", "nn")
+	composite := b.Build()
 
-	afero.WriteFile(msv, filepath.Join(baseSv, "f1.txt"), []byte("some sv"), 0755)
-	afero.WriteFile(mnn, filepath.Join(baseNn, "f1.txt"), []byte("some nn"), 0755)
-	afero.WriteFile(men, filepath.Join(baseEn, "f1.txt"), []byte("some en"), 0755)
+	b.Add("content/sv/f1.txt", "some sv").
+		Add("content/nn/f1.txt", "some nn").
+		Add("content/en/f1.txt", "some en")
 
 	// Swedish is the top layer.
 	assertLangFile(t, composite, "f1.txt", "sv")
 
-	afero.WriteFile(msv, filepath.Join(baseSv, "f2.en.txt"), []byte("some sv"), 0755)
-	afero.WriteFile(mnn, filepath.Join(baseNn, "f2.en.txt"), []byte("some nn"), 0755)
-	afero.WriteFile(men, filepath.Join(baseEn, "f2.en.txt"), []byte("some en"), 0755)
+	b.Add("content/sv/f2.en.txt", "some sv").
+		Add("content/nn/f2.en.txt", "some nn").
+		Add("content/en/f2.en.txt", "some en")
 
 	// English is in the middle, but the most specific language match wins.
-	//assertLangFile(t, composite, "f2.en.txt", "en")
+	assertLangFile(t, composite, "f2.en.txt", "en")
 
 	// Fetch some specific language versions
-	assertLangFile(t, composite, filepath.Join(baseNn, "f2.en.txt"), "nn")
-	assertLangFile(t, composite, filepath.Join(baseEn, "f2.en.txt"), "en")
-	assertLangFile(t, composite, filepath.Join(baseSv, "f2.en.txt"), "sv")
+	assertLangFile(t, composite, filepath.FromSlash("/content/nn/f2.en.txt"), "nn")
+	assertLangFile(t, composite, filepath.FromSlash("/content/en/f2.en.txt"), "en")
+	assertLangFile(t, composite, filepath.FromSlash("/content/sv/f2.en.txt"), "sv")
 
 	// Read the root
 	f, err := composite.Open("/")
@@ -81,13 +76,294 @@ func TestCompositeLanguagFsTest(t *testing.T) {
 	got := make(map[string]bool)
 
 	for _, fi := range files {
-		fil, ok := fi.(*LanguageFileInfo)
+		fil, ok := fi.(*hugofs.LanguageFileInfo)
 		assert.True(ok)
 		got[fil.Filename()] = true
 	}
 	assert.Equal(expected, got)
 }
 
+func TestCompositeLanguageFsOpenFallback(t *testing.T) {
+	assert := require.New(t)
+
+	b := hugofstest.New(t).
+		WithLang("sv").WithLang("en").WithLang("nn").
+		WithMount("content/sv", "sv").
+		WithMount("content/en", "en").
+		WithMount("content/nn", "nn")
+	composite := b.Build()
+
+	b.Add("content/sv/blog/lingo.txt", "lingo sv").
+		Add("content/en/blog/lingo.txt", "lingo en").
+		Add("content/nn/blog/lingo.nn.txt", "lingo nn").
+		Add("content/en/blog/lingo.nn.txt", "lingo nn in en").
+		Add("content/en/blog/only.sv.txt", "only sv in en")
+
+	for _, test := range []struct {
+		name  string
+		match string
+		lang  string
+	}{
+		{"blog/lingo.txt", "lingo sv", "sv"},
+		{"blog/lingo.nn.txt", "lingo nn", "nn"},
+		// Not in the Swedish filesystem, so it falls back to the others.
+		{"blog/only.sv.txt", "only sv in en", "sv"},
+	} {
+		assertLangFile(t, composite, filepath.FromSlash(test.name), test.match)
+
+		f, err := composite.Open(filepath.FromSlash(test.name))
+		assert.NoError(err)
+		la, ok := f.(hugofs.LanguageAnnouncer)
+		assert.True(ok, test.name)
+		assert.Equal(test.lang, la.Lang(), test.name)
+		f.Close()
+
+		fi, err := composite.Stat(filepath.FromSlash(test.name))
+		assert.NoError(err)
+		assert.Equal(int64(len(test.match)), fi.Size(), test.name)
+	}
+
+	_, err := composite.Open(filepath.FromSlash("blog/missing.txt"))
+	assert.True(os.IsNotExist(err))
+
+	// Directories are still merged.
+	f, err := composite.Open("blog")
+	assert.NoError(err)
+	defer f.Close()
+	fis, err := f.Readdir(-1)
+	assert.NoError(err)
+	assert.Len(fis, 4)
+}
+
+func TestCompositeLanguageFsOpenFallbackChain(t *testing.T) {
+	assert := require.New(t)
+
+	// The order will be nn, en, nb, but nn falls back to nb before en.
+	b := hugofstest.New(t).
+		WithLang("nn").WithLang("en").WithLang("nb").
+		WithFallbacks("nn", "nb", "en").
+		WithMount("content/nn", "nn").
+		WithMount("content/en", "en").
+		WithMount("content/nb", "nb")
+	composite := b.Build()
+
+	b.Add("content/en/blog/post.md", "post en").
+		Add("content/nb/blog/post.md", "post nb").
+		Add("content/en/blog/about.md", "about en")
+
+	assertLangFile(t, composite, filepath.FromSlash("blog/post.md"), "post nb")
+	assertLangFile(t, composite, filepath.FromSlash("blog/about.md"), "about en")
+
+	b.Add("content/nn/blog/post.md", "post nn")
+	assertLangFile(t, composite, filepath.FromSlash("blog/post.md"), "post nn")
+
+	fi, err := composite.Stat(filepath.FromSlash("blog/about.md"))
+	assert.NoError(err)
+	assert.Equal("en", fi.(*hugofs.LanguageFileInfo).Lang())
+
+	// A Nynorsk file in both the English and the Bokmål content dirs is
+	// picked, and listed, from the Bokmål dir.
+	b.Add("content/en/blog/news.nn.md", "news nn in en").
+		Add("content/nb/blog/news.nn.md", "news nn in nb")
+	assertLangFile(t, composite, filepath.FromSlash("blog/news.nn.md"), "news nn in nb")
+
+	fis, err := afero.ReadDir(composite, "blog")
+	assert.NoError(err)
+	var news []string
+	for _, fi := range fis {
+		if lfi := fi.(*hugofs.LanguageFileInfo); lfi.RealName() == "news.nn.md" {
+			news = append(news, filepath.ToSlash(lfi.Filename()))
+		}
+	}
+	assert.Len(news, 1)
+	assert.Contains(news[0], "content/nb/blog/news.nn.md")
+}
+
+func TestCompositeLanguageFsReaddirOrder(t *testing.T) {
+	assert := require.New(t)
+
+	readdir := func(fs afero.Fs) []string {
+		f, err := fs.Open("/")
+		assert.NoError(err)
+		defer f.Close()
+		fis, err := f.Readdir(-1)
+		assert.NoError(err)
+		var names []string
+		for _, fi := range fis {
+			names = append(names, fi.(*hugofs.LanguageFileInfo).RealName())
+		}
+		return names
+	}
+
+	newBuilder := func() *hugofstest.Builder {
+		return hugofstest.New(t).
+			WithLang("nn").WithLang("en").
+			WithMount("content/nn", "nn").
+			WithMount("content/en", "en").
+			Add("content/nn/ø.md", "ø").
+			Add("content/en/z.md", "z").
+			Add("content/nn/a.md", "a").
+			Add("content/en/a.md", "a")
+	}
+
+	// Sorted by name, which groups the files by content dir.
+	composite := newBuilder().Build()
+	assert.Equal([]string{"a.md", "z.md", "a.md", "ø.md"}, readdir(composite))
+
+	b := newBuilder()
+	b.LanguageFs("content/nn").WithCollator((&langs.Language{Lang: "nn"}).Collator())
+	composite = b.Build()
+
+	for i := 0; i < 3; i++ {
+		names := readdir(composite)
+		assert.Equal([]string{"a.md", "a.md", "z.md", "ø.md"}, names)
+	}
+}
+
+func TestCompositeLanguageFsAferoHelpers(t *testing.T) {
+	assert := require.New(t)
+
+	b := hugofstest.New(t).
+		WithLang("sv").WithLang("en").
+		WithMount("content/sv", "sv").
+		WithMount("content/en", "en").
+		Add("content/sv/blog/a.txt", "a sv").
+		Add("content/en/blog/a.en.txt", "a en").
+		Add("content/sv/blog/a.en.txt", "a en in sv").
+		Add("content/en/blog/b.txt", "b en")
+	composite := b.Build()
+
+	fis, err := afero.ReadDir(composite, "blog")
+	assert.NoError(err)
+	assert.Len(fis, 3)
+
+	var walked []string
+	assert.NoError(afero.Walk(composite, "", func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !fi.IsDir() {
+			walked = append(walked, fi.(*hugofs.LanguageFileInfo).Filename())
+		}
+		return nil
+	}))
+	assert.Len(walked, 3)
+
+	// OpenFile is Open for reading.
+	f, err := composite.OpenFile(filepath.FromSlash("blog/a.en.txt"), os.O_RDONLY, 0)
+	assert.NoError(err)
+	content, err := afero.ReadAll(f)
+	f.Close()
+	assert.NoError(err)
+	assert.Equal("a en", string(content))
+
+	f, err = composite.OpenFile("blog", os.O_RDONLY, 0)
+	assert.NoError(err)
+	fis, err = f.Readdir(-1)
+	f.Close()
+	assert.NoError(err)
+	assert.Len(fis, 3)
+
+	_, err = composite.OpenFile(filepath.FromSlash("blog/a.txt"), os.O_RDWR, 0)
+	assert.Error(err)
+}
+
+func TestCompositeLanguageFsMergedDirs(t *testing.T) {
+	assert := require.New(t)
+
+	b := hugofstest.New(t).
+		WithLang("sv").WithLang("en").WithLang("nn").
+		WithMount("content/sv", "sv").
+		WithMount("content/en", "en").
+		WithMount("content/nn", "nn").
+		Add("content/sv/blog/a.txt", "a").
+		Add("content/en/blog/b.txt", "b").
+		Add("content/nn/blog/sub/c.txt", "c").
+		Add("content/en/blog/sub/d.txt", "d")
+	composite := b.Build()
+
+	// One entry for the blog dir in all three content dirs.
+	fis, err := afero.ReadDir(composite, "/")
+	assert.NoError(err)
+	assert.Len(fis, 1)
+	blog := fis[0].(*hugofs.LanguageFileInfo)
+	assert.True(blog.IsDir())
+	assert.Equal(filepath.FromSlash("/blog"), blog.Filename())
+
+	// Its content is merged from all of them, recursively.
+	fis, err = afero.ReadDir(composite, blog.Filename())
+	assert.NoError(err)
+	assert.Len(fis, 3)
+
+	fis, err = afero.ReadDir(composite, filepath.FromSlash("/blog/sub"))
+	assert.NoError(err)
+	assert.Len(fis, 2)
+}
+
+func TestCompositeLanguageFsReaddirPaged(t *testing.T) {
+	assert := require.New(t)
+
+	b := hugofstest.New(t).
+		WithLang("sv").WithLang("en").
+		WithMount("content/sv", "sv").
+		WithMount("content/en", "en")
+	composite := b.Build()
+
+	for i := 0; i < 7; i++ {
+		b.Add(fmt.Sprintf("content/sv/blog/p%d.en.txt", i), "sv")
+		b.Add(fmt.Sprintf("content/en/blog/p%d.en.txt", i), "en")
+	}
+
+	all, err := afero.ReadDir(composite, "blog")
+	assert.NoError(err)
+	assert.Len(all, 7)
+
+	f, err := composite.Open("blog")
+	assert.NoError(err)
+	defer f.Close()
+
+	var paged []os.FileInfo
+	for {
+		fis, err := f.Readdir(3)
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(err)
+		assert.True(len(fis) > 0 && len(fis) <= 3)
+		paged = append(paged, fis...)
+	}
+
+	assert.Equal(len(all), len(paged))
+	for i, fi := range paged {
+		assert.Equal(all[i].(*hugofs.LanguageFileInfo).Filename(), fi.(*hugofs.LanguageFileInfo).Filename())
+	}
+
+	// Read the rest.
+	fis, err := f.Readdir(-1)
+	assert.NoError(err)
+	assert.Empty(fis)
+}
+
+func TestCompositeLanguageFsOtherBase(t *testing.T) {
+	assert := require.New(t)
+
+	base := afero.NewMemMapFs()
+	assert.NoError(afero.WriteFile(base, "a.txt", []byte("base"), 0777))
+
+	b := hugofstest.New(t).WithLang("en").WithMount("content/en", "en")
+	composite := hugofs.NewLanguageCompositeFs(base, b.LanguageFs("content/en"))
+
+	assertLangFile(t, composite, "a.txt", "base")
+	fi, err := composite.Stat("a.txt")
+	assert.NoError(err)
+	assert.Equal(int64(4), fi.Size())
+
+	_, err = composite.Open("b.txt")
+	assert.True(os.IsNotExist(err))
+	_, err = composite.Stat("b.txt")
+	assert.True(os.IsNotExist(err))
+}
+
 func assertLangFile(t testing.TB, fs afero.Fs, filename, match string) {
 	f, err := fs.Open(filename)
 	if err != nil {
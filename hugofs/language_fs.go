@@ -15,10 +15,13 @@ package hugofs
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
+	"github.com/gohugoio/hugo/langs"
 	"github.com/spf13/afero"
 )
 
@@ -28,6 +31,7 @@ var (
 	_ LanguageAnnouncer = (*LanguageFileInfo)(nil)
 	_ FilePather        = (*LanguageFileInfo)(nil)
 	_ afero.Lstater     = (*LanguageFs)(nil)
+	_ LanguageAnnouncer = (*languageFile)(nil)
 )
 
 // LanguageAnnouncer is aware of its language.
@@ -52,8 +56,50 @@ type FilePather interface {
 }
 
 // LanguageDirsMerger implements the afero.DirsMerger interface, which is used
-// to merge two directories.
+// to merge two directories. The result is sorted by name, see SortFileInfos.
 var LanguageDirsMerger = func(lofi, bofi []os.FileInfo) ([]os.FileInfo, error) {
+	return mergeLanguageDirs(lofi, bofi, nil, SortFileInfos)
+}
+
+// CollatedLanguageDirsMerger returns a LanguageDirsMerger that sorts the
+// result by the real file names using the collation rules of c, e.g. "ø"
+// after "z" in Norwegian. Files with the same real name, e.g. a file in
+// different content dirs, are sorted by name.
+func CollatedLanguageDirsMerger(c *langs.Collator) afero.DirsMerger {
+	return func(lofi, bofi []os.FileInfo) ([]os.FileInfo, error) {
+		return mergeLanguageDirs(lofi, bofi, nil, collatedSorter(c))
+	}
+}
+
+// collatedSorter sorts by the real file names using the collation rules of c.
+func collatedSorter(c *langs.Collator) func([]os.FileInfo) {
+	return func(fis []os.FileInfo) {
+		sort.Slice(fis, func(i, j int) bool {
+			ni, nj := fis[i].(*LanguageFileInfo).RealName(), fis[j].(*LanguageFileInfo).RealName()
+			if cmp := c.CompareStrings(ni, nj); cmp != 0 {
+				return cmp < 0
+			}
+			return fis[i].Name() < fis[j].Name()
+		})
+	}
+}
+
+// languageDirsMerger returns a directory merger for a composite with the
+// given language fallback chains, sorting with c, if set. Of the entries with
+// the same name, the one from the filesystem of the entry's language wins,
+// then the one from the filesystem of its first fallback language, and so on,
+// see fsRank.
+func languageDirsMerger(fallbacks map[string][]string, c *langs.Collator) afero.DirsMerger {
+	sortFileInfos := SortFileInfos
+	if c != nil {
+		sortFileInfos = collatedSorter(c)
+	}
+	return func(lofi, bofi []os.FileInfo) ([]os.FileInfo, error) {
+		return mergeLanguageDirs(lofi, bofi, fallbacks, sortFileInfos)
+	}
+}
+
+func mergeLanguageDirs(lofi, bofi []os.FileInfo, fallbacks map[string][]string, sortFileInfos func([]os.FileInfo)) ([]os.FileInfo, error) {
 	m := make(map[string]*LanguageFileInfo)
 
 	for _, fi := range lofi {
@@ -71,7 +117,7 @@ var LanguageDirsMerger = func(lofi, bofi []os.FileInfo) ([]os.FileInfo, error) {
 		}
 		existing, found := m[fil.virtualName]
 
-		if !found || existing.weight < fil.weight {
+		if !found || fil.fsRank(fallbacks[fil.meta.lang]) < existing.fsRank(fallbacks[existing.meta.lang]) {
 			m[fil.virtualName] = fil
 		}
 	}
@@ -83,24 +129,90 @@ var LanguageDirsMerger = func(lofi, bofi []os.FileInfo) ([]os.FileInfo, error) {
 		i++
 	}
 
+	sortFileInfos(merged)
+
 	return merged, nil
 }
 
+// PickLanguageFileInfo picks the best match for lang among the candidates,
+// usually translations of the same file (see TranslationBaseName).
+// A file in lang itself wins, then a file in one of the fallback languages, in
+// the order given. If more than one file is in the same language, the one from
+// the filesystem ranked first wins, see fsRank.
+// It returns nil if there is no match.
+func PickLanguageFileInfo(lang string, fallbacks []string, candidates []os.FileInfo) *LanguageFileInfo {
+	for _, l := range append([]string{lang}, fallbacks...) {
+		var picked *LanguageFileInfo
+		for _, fi := range candidates {
+			lfi, ok := fi.(*LanguageFileInfo)
+			if !ok || lfi.meta.lang != l {
+				continue
+			}
+			if picked == nil || lfi.fsRank(fallbacks) < picked.fsRank(fallbacks) {
+				picked = lfi
+			}
+		}
+		if picked != nil {
+			return picked
+		}
+	}
+
+	return nil
+}
+
+// MergeTranslations returns the files in lang among fis, usually a directory
+// listing, with any gaps filled by the files in the from language, i.e. the
+// files in from with no translation in lang. Files filled in are copies
+// moved to lang, marked as borrowed from the other language.
+// Directories and files in other languages are left out.
+func MergeTranslations(lang, from string, fis []os.FileInfo) []os.FileInfo {
+	translationKey := func(fi *LanguageFileInfo) string {
+		return fi.translationBaseName + filepath.Ext(fi.realName)
+	}
+
+	var merged []os.FileInfo
+	found := make(map[string]bool)
+
+	for _, fi := range fis {
+		lfi, ok := fi.(*LanguageFileInfo)
+		if !ok || lfi.IsDir() || lfi.meta.lang != lang {
+			continue
+		}
+		found[translationKey(lfi)] = true
+		merged = append(merged, lfi)
+	}
+
+	if from == "" || from == lang {
+		return merged
+	}
+
+	for _, fi := range fis {
+		lfi, ok := fi.(*LanguageFileInfo)
+		if !ok || lfi.IsDir() || lfi.meta.lang != from || found[translationKey(lfi)] {
+			continue
+		}
+		meta := *lfi.meta
+		meta.lang = lang
+		meta.borrowedFrom = from
+		borrowed := *lfi
+		borrowed.meta = internLanguageFileMeta(meta)
+		merged = append(merged, &borrowed)
+	}
+
+	return merged
+}
+
 // LanguageFileInfo is a super-set of os.FileInfo with additional information
 // about the file in relation to its Hugo language.
 type LanguageFileInfo struct {
 	os.FileInfo
-	lang                string
-	baseDir             string
+	meta                *languageFileMeta
 	realFilename        string
 	relFilename         string
 	name                string
 	realName            string
 	virtualName         string
 	translationBaseName string
-
-	// We add some weight to the files in their own language's content directory.
-	weight int
 }
 
 // Filename returns a file's real filename including the base (ie.
@@ -121,12 +233,26 @@ func (fi *LanguageFileInfo) RealName() string {
 
 // BaseDir returns a file's base directory (ie. "/my/base").
 func (fi *LanguageFileInfo) BaseDir() string {
-	return fi.baseDir
+	return fi.meta.baseDir
 }
 
 // Lang returns a file's language (ie. "sv").
 func (fi *LanguageFileInfo) Lang() string {
-	return fi.lang
+	return fi.meta.lang
+}
+
+// LangSubdir returns the sub directory below the publish root a file's
+// language is published to (ie. "sv"), or an empty string if published to
+// the root, e.g. the default content language when not configured with
+// defaultContentLanguageInSubdir.
+func (fi *LanguageFileInfo) LangSubdir() string {
+	return fi.meta.langSubdir
+}
+
+// PublishPath returns a file's path relative to the publish root, including
+// any language sub directory (ie. "sv/sect/page.md").
+func (fi *LanguageFileInfo) PublishPath() string {
+	return filepath.Join(fi.meta.langSubdir, fi.relFilename)
 }
 
 // TranslationBaseName returns the base filename without any extension or language
@@ -135,6 +261,29 @@ func (fi *LanguageFileInfo) TranslationBaseName() string {
 	return fi.translationBaseName
 }
 
+// BorrowedFrom returns the language a file was borrowed from to fill a gap
+// in its language's content (ie. "en"), or an empty string if it is not
+// borrowed. See MergeTranslations.
+func (fi *LanguageFileInfo) BorrowedFrom() string {
+	return fi.meta.borrowedFrom
+}
+
+// fsRank ranks the filesystem holding this file among the copies of it in
+// other filesystems, lower is better. A file in its own language's filesystem
+// comes first, then those in the filesystems of the given fallback languages,
+// in order, then the rest.
+func (fi *LanguageFileInfo) fsRank(fallbacks []string) int {
+	if fi.meta.fsLang == fi.meta.lang {
+		return 0
+	}
+	for i, l := range fallbacks {
+		if l == fi.meta.fsLang {
+			return i + 1
+		}
+	}
+	return len(fallbacks) + 1
+}
+
 // Name is the name of the file within this filesystem without any path info.
 // It will be marked with language information so we can identify it as ours
 // (ie. "__hugofs_sv_page.md").
@@ -148,24 +297,97 @@ type languageFile struct {
 }
 
 // Readdir creates FileInfo entries by calling Lstat if possible.
+// Files in disabled languages are skipped, and more are read in their place
+// when c > 0.
 func (l *languageFile) Readdir(c int) (ofi []os.FileInfo, err error) {
-	names, err := l.File.Readdirnames(c)
-	if err != nil {
-		return nil, err
+	var fis []os.FileInfo
+
+	for {
+		n := c
+		if c > 0 {
+			n = c - len(fis)
+		}
+		names, err := l.File.Readdirnames(n)
+		if err != nil {
+			if err == io.EOF && len(fis) > 0 {
+				return fis, nil
+			}
+			return nil, err
+		}
+
+		visible, err := l.lstatAll(names)
+		if err != nil {
+			return nil, err
+		}
+		fis = append(fis, visible...)
+
+		if c <= 0 || len(fis) >= c || len(names) == 0 {
+			return fis, nil
+		}
 	}
+}
 
-	fis := make([]os.FileInfo, len(names))
+func (l *languageFile) lstatAll(names []string) ([]os.FileInfo, error) {
+	fis := make([]os.FileInfo, 0, len(names))
 
-	for i, name := range names {
+	for _, name := range names {
 		fi, _, err := l.fs.LstatIfPossible(filepath.Join(l.Name(), name))
 
 		if err != nil {
+			if os.IsNotExist(err) {
+				// Hidden, or removed since the directory was read.
+				continue
+			}
+			l.fs.logger.Log(LogLevelWarn, "readdir failed", LogFieldOp, "readdir", LogFieldPath, filepath.Join(l.Name(), name), LogFieldError, err)
 			return nil, err
 		}
-		fis[i] = fi
+		fis = append(fis, fi)
 	}
 
-	return fis, err
+	return fis, nil
+}
+
+// Readdirnames returns the names of the entries returned by Readdir, so the
+// two agree on what files are visible.
+func (l *languageFile) Readdirnames(c int) ([]string, error) {
+	fis, err := l.Readdir(c)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, len(fis))
+	for i, fi := range fis {
+		names[i] = fi.Name()
+	}
+	return names, nil
+}
+
+// Lang returns the file's language (ie. "sv"), see LanguageFileInfo.
+func (l *languageFile) Lang() string {
+	if lfi := l.languageFileInfo(); lfi != nil {
+		return lfi.Lang()
+	}
+	return l.fs.Lang()
+}
+
+// TranslationBaseName returns the base filename without any extension or
+// language identifiers (ie. "page"), see LanguageFileInfo.
+func (l *languageFile) TranslationBaseName() string {
+	if lfi := l.languageFileInfo(); lfi != nil {
+		return lfi.TranslationBaseName()
+	}
+	return ""
+}
+
+func (l *languageFile) languageFileInfo() *LanguageFileInfo {
+	fi, err := l.File.Stat()
+	if err != nil || fi.IsDir() {
+		return nil
+	}
+	lfi, err := l.fs.newLanguageFileInfo(l.Name(), fi)
+	if err != nil {
+		return nil
+	}
+	return lfi
 }
 
 // LanguageFs represents a language filesystem.
@@ -174,12 +396,34 @@ type LanguageFs struct {
 	basePath   string
 	lang       string
 	nameMarker string
-	languages  map[string]bool
+	languages  langs.LanguageSet
+	subdirs    map[string]string
+	fallbacks  map[string][]string
+	collator   *langs.Collator
+
+	// The shared file meta data, keyed by language.
+	metas map[string]*languageFileMeta
+
+	hasDisabledLanguages bool
+
+	// Optional classifier of the files without a language in their name.
+	classifier      LanguageClassifier
+	classifiedLangs *fileValueCache
+
+	// Optional guard against unexpected binary files.
+	guard *ContentGuard
+
+	logger Logger
+
 	afero.Fs
 }
 
 // NewLanguageFs creates a new language filesystem.
-func NewLanguageFs(lang string, languages map[string]bool, fs afero.Fs) *LanguageFs {
+// The languages set holds all the configured languages, including any
+// aliases (e.g. "mypost.iw.md" for "he"). Files in a disabled language,
+// either by their file name (e.g. "mypost.fr.md") or by the language of this
+// filesystem, are hidden.
+func NewLanguageFs(lang string, languages langs.LanguageSet, fs afero.Fs) *LanguageFs {
 	if lang == "" {
 		panic("no lang set for the language fs")
 	}
@@ -191,7 +435,87 @@ func NewLanguageFs(lang string, languages map[string]bool, fs afero.Fs) *Languag
 
 	marker := hugoFsMarker + "_" + lang + "_"
 
-	return &LanguageFs{lang: lang, languages: languages, basePath: basePath, Fs: fs, nameMarker: marker}
+	var hasDisabledLanguages bool
+	for _, language := range languages {
+		if language.Disabled {
+			hasDisabledLanguages = true
+			break
+		}
+	}
+
+	lfs := &LanguageFs{lang: lang, languages: languages, hasDisabledLanguages: hasDisabledLanguages, basePath: basePath, Fs: fs, nameMarker: marker, logger: NopLogger}
+	lfs.initMetas()
+
+	return lfs
+}
+
+// WithLanguageSubdirs sets the sub directory each language is published to,
+// keyed by language code. See langs.Languages.LangSubdirs.
+func (fs *LanguageFs) WithLanguageSubdirs(subdirs map[string]string) *LanguageFs {
+	fs.subdirs = subdirs
+	fs.initMetas()
+	return fs
+}
+
+// WithLanguageFallbacks sets the languages to try, in order, when a file is
+// missing in a language, keyed by language code. See
+// langs.Languages.FallbackChains. This is used when a file is picked from a
+// composite, see NewLanguageCompositeFs.
+func (fs *LanguageFs) WithLanguageFallbacks(fallbacks map[string][]string) *LanguageFs {
+	fs.fallbacks = fallbacks
+	return fs
+}
+
+// WithCollator sets the collator used to sort the merged directories of a
+// composite with this filesystem on top, see CollatedLanguageDirsMerger and
+// NewLanguageCompositeFs. Without it, they are sorted by name.
+func (fs *LanguageFs) WithCollator(c *langs.Collator) *LanguageFs {
+	fs.collator = c
+	return fs
+}
+
+// WithLanguageClassifier sets a classifier used to decide the language of
+// the files without a valid language in their name, e.g. "mypost.md" and not
+// "mypost.fr.md". If the classifier returns an unknown language or an empty
+// string, the file gets the language of this filesystem. The results are
+// cached until the file changes.
+func (fs *LanguageFs) WithLanguageClassifier(classifier LanguageClassifier) *LanguageFs {
+	fs.classifier = classifier
+	fs.classifiedLangs = &fileValueCache{}
+	return fs
+}
+
+// WithContentGuard sets a guard flagging the unexpected binary files in this
+// filesystem, see ContentGuard.
+func (fs *LanguageFs) WithContentGuard(guard *ContentGuard) *LanguageFs {
+	fs.guard = guard
+	return fs
+}
+
+// WithLogger sets the logger to send the diagnostics of this filesystem to,
+// e.g. about the files hidden because of their language.
+func (fs *LanguageFs) WithLogger(logger Logger) *LanguageFs {
+	fs.logger = loggerOrNop(logger)
+	return fs
+}
+
+func (fs *LanguageFs) initMetas() {
+	fs.metas = make(map[string]*languageFileMeta)
+	fs.metas[fs.lang] = fs.newMeta(fs.lang)
+	for _, language := range fs.languages {
+		fs.metas[language.Lang] = fs.newMeta(language.Lang)
+	}
+}
+
+func (fs *LanguageFs) newMeta(lang string) *languageFileMeta {
+	return internLanguageFileMeta(languageFileMeta{lang: lang, fsLang: fs.lang, baseDir: fs.basePath, langSubdir: fs.subdirs[lang]})
+}
+
+func (fs *LanguageFs) meta(lang string) *languageFileMeta {
+	if m, found := fs.metas[lang]; found {
+		return m
+	}
+	return fs.newMeta(lang)
 }
 
 // Lang returns a language filesystem's language (ie. "sv").
@@ -199,6 +523,28 @@ func (fs *LanguageFs) Lang() string {
 	return fs.lang
 }
 
+// FileLang returns the language of the given file in this filesystem and
+// its base name without any language and extension, e.g. "fr" and "mypost"
+// for "mypost.fr.md". Any valid language identificator in the name will win
+// over the language set on the file system. The file does not need to exist.
+func (fs *LanguageFs) FileLang(filename string) (lang, translationBaseName string) {
+	lang = fs.Lang()
+
+	baseName := filepath.Base(filename)
+	ext := filepath.Ext(baseName)
+	baseNameNoExt := strings.TrimSuffix(baseName, ext)
+
+	fileLangExt := filepath.Ext(baseNameNoExt)
+	fileLang := strings.TrimPrefix(fileLangExt, ".")
+
+	if language := fs.languages.Get(fileLang); language != nil {
+		lang = language.Lang
+		baseNameNoExt = strings.TrimSuffix(baseNameNoExt, fileLangExt)
+	}
+
+	return lang, baseNameNoExt
+}
+
 // Stat returns the os.FileInfo of a given file.
 func (fs *LanguageFs) Stat(name string) (os.FileInfo, error) {
 	name, err := fs.realName(name)
@@ -211,11 +557,32 @@ func (fs *LanguageFs) Stat(name string) (os.FileInfo, error) {
 		return nil, err
 	}
 
-	return fs.newLanguageFileInfo(name, fi)
+	lfi, err := fs.newLanguageFileInfo(name, fi)
+	if err != nil {
+		return nil, err
+	}
+
+	if fs.isHidden(lfi) {
+		fs.logHidden("stat", lfi)
+		return nil, &os.PathError{Op: "stat", Path: name, Err: os.ErrNotExist}
+	}
+
+	if fs.isGuarded(name, lfi) {
+		return nil, &os.PathError{Op: "stat", Path: name, Err: os.ErrNotExist}
+	}
+
+	return lfi, nil
 }
 
 // Open opens the named file for reading.
 func (fs *LanguageFs) Open(name string) (afero.File, error) {
+	if fs.hasDisabledLanguages || (fs.guard != nil && fs.guard.Exclude) {
+		// Make sure that we don't open any hidden file.
+		if _, err := fs.Stat(name); err != nil {
+			return nil, err
+		}
+	}
+
 	name, err := fs.realName(name)
 	if err != nil {
 		return nil, err
@@ -251,8 +618,41 @@ func (fs *LanguageFs) LstatIfPossible(name string) (os.FileInfo, bool, error) {
 	}
 
 	lfi, err := fs.newLanguageFileInfo(name, fi)
+	if err != nil {
+		return nil, b, err
+	}
+
+	if fs.isHidden(lfi) {
+		fs.logHidden("lstat", lfi)
+		return nil, b, &os.PathError{Op: "lstat", Path: name, Err: os.ErrNotExist}
+	}
+
+	if fs.isGuarded(name, lfi) {
+		return nil, b, &os.PathError{Op: "lstat", Path: name, Err: os.ErrNotExist}
+	}
+
+	return lfi, b, nil
+}
+
+// isHidden returns whether the given file is in a disabled language.
+func (fs *LanguageFs) isHidden(fi *LanguageFileInfo) bool {
+	if fi.IsDir() {
+		return false
+	}
+	return fs.languages.Has(fi.meta.lang) && !fs.languages.Enabled(fi.meta.lang)
+}
+
+// isGuarded returns whether the given file is hidden by the content guard.
+func (fs *LanguageFs) isGuarded(name string, fi *LanguageFileInfo) bool {
+	if fs.guard == nil || !fs.guard.check(fs.Fs, name, fi.Filename(), fs.basePath, fi) {
+		return false
+	}
+	fs.logger.Log(LogLevelDebug, "file hidden by content guard", LogFieldPath, fi.Path(), LogFieldMount, fs.basePath)
+	return true
+}
 
-	return lfi, b, err
+func (fs *LanguageFs) logHidden(op string, fi *LanguageFileInfo) {
+	fs.logger.Log(LogLevelDebug, "file in disabled language hidden", LogFieldOp, op, LogFieldPath, fi.Path(), LogFieldMount, fi.BaseDir(), "lang", fi.Lang())
 }
 
 func (fs *LanguageFs) realPath(name string) (string, error) {
@@ -277,6 +677,27 @@ func (fs *LanguageFs) realName(name string) (string, error) {
 	return strings.TrimPrefix(name, fs.basePath), nil
 }
 
+// classify returns the language of the given file as decided by the
+// classifier, or lang if it cannot tell.
+func (fs *LanguageFs) classify(filename string, fi os.FileInfo, lang string) string {
+	classified, found := fs.classifiedLangs.get(filename, fi)
+	if !found {
+		var err error
+		classified, err = fs.classifier(fs.Fs, filename)
+		if err != nil {
+			fs.logger.Log(LogLevelWarn, "language classification failed", LogFieldOp, "classify", LogFieldPath, filename, LogFieldMount, fs.basePath, LogFieldError, err)
+			classified = ""
+		}
+		fs.classifiedLangs.set(filename, fi, classified)
+	}
+
+	if language := fs.languages.Get(classified); language != nil {
+		return language.Lang
+	}
+
+	return lang
+}
+
 func (fs *LanguageFs) newLanguageFileInfo(filename string, fi os.FileInfo) (*LanguageFileInfo, error) {
 	filename = filepath.Clean(filename)
 	_, name := filepath.Split(filename)
@@ -294,53 +715,30 @@ func (fs *LanguageFs) newLanguageFileInfo(filename string, fi os.FileInfo) (*Lan
 	baseNameNoExt := ""
 
 	if !fi.IsDir() {
+		lang, baseNameNoExt = fs.FileLang(name)
 
-		// Try to extract the language from the file name.
-		// Any valid language identificator in the name will win over the
-		// language set on the file system, e.g. "mypost.en.md".
-		baseName := filepath.Base(name)
-		ext := filepath.Ext(baseName)
-		baseNameNoExt = baseName
-
-		if ext != "" {
-			baseNameNoExt = strings.TrimSuffix(baseNameNoExt, ext)
-		}
-
-		fileLangExt := filepath.Ext(baseNameNoExt)
-		fileLang := strings.TrimPrefix(fileLangExt, ".")
-
-		if fs.languages[fileLang] {
-			lang = fileLang
-			baseNameNoExt = strings.TrimSuffix(baseNameNoExt, fileLangExt)
+		if fs.classifier != nil && baseNameNoExt == strings.TrimSuffix(name, filepath.Ext(name)) {
+			lang = fs.classify(filename, fi, lang)
 		}
 
 		// This connects the filename to the filesystem, not the language.
-		virtualName = baseNameNoExt + "." + lang + ext
+		virtualName = baseNameNoExt + "." + lang + filepath.Ext(name)
 
 		name = fs.nameMarker + name
 	}
 
-	weight := 1
-	// If this file's language belongs in this directory, add some weight to it
-	// to make it more important.
-	if lang == fs.Lang() {
-		weight = 2
-	}
-
 	if fi.IsDir() {
 		// For directories we always want to start from the union view.
 		realPath = strings.TrimPrefix(realPath, fs.basePath)
 	}
 
 	return &LanguageFileInfo{
-		lang:                lang,
-		weight:              weight,
+		meta:                fs.meta(lang),
 		realFilename:        realPath,
 		realName:            realName,
 		relFilename:         strings.TrimPrefix(strings.TrimPrefix(realPath, fs.basePath), string(os.PathSeparator)),
 		name:                name,
 		virtualName:         virtualName,
 		translationBaseName: baseNameNoExt,
-		baseDir:             fs.basePath,
 		FileInfo:            fi}, nil
 }
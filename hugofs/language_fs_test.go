@@ -14,13 +14,25 @@
 package hugofs
 
 import (
+	"fmt"
+	"io"
+	"os"
 	"path/filepath"
 	"testing"
 
+	"github.com/gohugoio/hugo/langs"
 	"github.com/spf13/afero"
 	"github.com/stretchr/testify/require"
 )
 
+func newTestLanguageSet(languages map[string]bool) langs.LanguageSet {
+	set := make(langs.LanguageSet)
+	for lang, enabled := range languages {
+		set[lang] = &langs.Language{Lang: lang, Disabled: !enabled}
+	}
+	return set
+}
+
 func TestLanguagFs(t *testing.T) {
 	languages := map[string]bool{
 		"sv": true,
@@ -29,7 +41,7 @@ func TestLanguagFs(t *testing.T) {
 	assert := require.New(t)
 	m := afero.NewMemMapFs()
 	bfs := afero.NewBasePathFs(m, base)
-	lfs := NewLanguageFs("sv", languages, bfs)
+	lfs := NewLanguageFs("sv", newTestLanguageSet(languages), bfs)
 	assert.NotNil(lfs)
 	assert.Equal("sv", lfs.Lang())
 	err := afero.WriteFile(lfs, filepath.FromSlash("sect/page.md"), []byte("abc"), 0777)
@@ -64,7 +76,7 @@ func TestFilenamesHandling(t *testing.T) {
 	assert := require.New(t)
 	m := afero.NewMemMapFs()
 	bfs := afero.NewBasePathFs(m, base)
-	lfs := NewLanguageFs("sv", languages, bfs)
+	lfs := NewLanguageFs("sv", newTestLanguageSet(languages), bfs)
 	assert.NotNil(lfs)
 	assert.Equal("sv", lfs.Lang())
 
@@ -98,3 +110,284 @@ func TestFilenamesHandling(t *testing.T) {
 	}
 
 }
+
+func TestPickLanguageFileInfo(t *testing.T) {
+	languages := map[string]bool{
+		"en": true,
+		"nb": true,
+		"nn": true,
+	}
+	assert := require.New(t)
+	m := afero.NewMemMapFs()
+	enFs := NewLanguageFs("en", newTestLanguageSet(languages), afero.NewBasePathFs(m, filepath.FromSlash("/content/en")))
+	nbFs := NewLanguageFs("nb", newTestLanguageSet(languages), afero.NewBasePathFs(m, filepath.FromSlash("/content/nb")))
+
+	var candidates []os.FileInfo
+	for _, f := range []struct {
+		fs       *LanguageFs
+		filename string
+	}{
+		{enFs, "page.md"},
+		{enFs, "page.nb.md"},
+		{nbFs, "page.md"},
+	} {
+		assert.NoError(afero.WriteFile(f.fs, f.filename, []byte("abc"), 0777))
+		fi, err := f.fs.Stat(f.filename)
+		assert.NoError(err)
+		candidates = append(candidates, fi)
+	}
+
+	fi := PickLanguageFileInfo("nb", nil, candidates)
+	assert.NotNil(fi)
+	assert.Equal("nb", fi.Lang())
+	assert.Equal(filepath.FromSlash("/content/nb/page.md"), fi.Filename())
+
+	fi = PickLanguageFileInfo("nn", []string{"nb", "en"}, candidates)
+	assert.NotNil(fi)
+	assert.Equal(filepath.FromSlash("/content/nb/page.md"), fi.Filename())
+
+	fi = PickLanguageFileInfo("nn", []string{"en"}, candidates)
+	assert.NotNil(fi)
+	assert.Equal("en", fi.Lang())
+
+	assert.Nil(PickLanguageFileInfo("nn", nil, candidates))
+}
+
+func TestLanguageFsDisabledLanguages(t *testing.T) {
+	languages := map[string]bool{
+		"en": true,
+		"fr": false,
+	}
+	assert := require.New(t)
+	m := afero.NewMemMapFs()
+	enFs := NewLanguageFs("en", newTestLanguageSet(languages), afero.NewBasePathFs(m, filepath.FromSlash("/content/en")))
+	frFs := NewLanguageFs("fr", newTestLanguageSet(languages), afero.NewBasePathFs(m, filepath.FromSlash("/content/fr")))
+
+	for _, filename := range []string{"sect/page.md", "sect/page.fr.md"} {
+		assert.NoError(afero.WriteFile(enFs, filepath.FromSlash(filename), []byte("abc"), 0777))
+		assert.NoError(afero.WriteFile(frFs, filepath.FromSlash(filename), []byte("abc"), 0777))
+	}
+
+	fi, err := enFs.Stat(filepath.FromSlash("sect/page.md"))
+	assert.NoError(err)
+	assert.Equal("en", fi.(*LanguageFileInfo).Lang())
+
+	// Tagged for a disabled language, not attributed to the filesystem's language.
+	_, err = enFs.Stat(filepath.FromSlash("sect/page.fr.md"))
+	assert.True(os.IsNotExist(err))
+	_, err = enFs.Open(filepath.FromSlash("sect/page.fr.md"))
+	assert.True(os.IsNotExist(err))
+
+	// The filesystem of a disabled language.
+	_, err = frFs.Stat(filepath.FromSlash("sect/page.md"))
+	assert.True(os.IsNotExist(err))
+	_, err = frFs.Stat("sect")
+	assert.NoError(err)
+
+	for _, lfs := range []*LanguageFs{enFs, frFs} {
+		d, err := lfs.Open("sect")
+		assert.NoError(err)
+		fis, err := d.Readdir(-1)
+		assert.NoError(err)
+		var names []string
+		for _, fi := range fis {
+			names = append(names, fi.(*LanguageFileInfo).RealName())
+		}
+		if lfs == enFs {
+			assert.Equal([]string{"page.md"}, names)
+		} else {
+			assert.Empty(names)
+		}
+	}
+
+	// The hidden files do not take up room in a page.
+	for i := 0; i < 3; i++ {
+		assert.NoError(afero.WriteFile(enFs, filepath.FromSlash(fmt.Sprintf("sect/p%d.fr.md", i)), []byte("abc"), 0777))
+	}
+	d, err := enFs.Open("sect")
+	assert.NoError(err)
+	defer d.Close()
+	fis, err := d.Readdir(1)
+	assert.NoError(err)
+	assert.Len(fis, 1)
+	assert.Equal("page.md", fis[0].(*LanguageFileInfo).RealName())
+	_, err = d.Readdir(1)
+	assert.Equal(io.EOF, err)
+}
+
+func TestLanguageFsAliases(t *testing.T) {
+	languages := langs.Languages{
+		{Lang: "he", Aliases: []string{"iw"}},
+		{Lang: "en"},
+	}
+	assert := require.New(t)
+	m := afero.NewMemMapFs()
+	enFs := NewLanguageFs("en", languages.AsSet(), afero.NewBasePathFs(m, filepath.FromSlash("/content")))
+
+	assert.NoError(afero.WriteFile(enFs, filepath.FromSlash("sect/page.iw.md"), []byte("abc"), 0777))
+
+	fi, err := enFs.Stat(filepath.FromSlash("sect/page.iw.md"))
+	assert.NoError(err)
+	lfi := fi.(*LanguageFileInfo)
+	assert.Equal("he", lfi.Lang())
+	assert.Equal("page", lfi.TranslationBaseName())
+}
+
+func TestLanguageFsLangSubdirs(t *testing.T) {
+	languages := map[string]bool{
+		"en": true,
+		"sv": true,
+	}
+	assert := require.New(t)
+	m := afero.NewMemMapFs()
+	enFs := NewLanguageFs("en", newTestLanguageSet(languages), afero.NewBasePathFs(m, filepath.FromSlash("/content"))).WithLanguageSubdirs(map[string]string{"en": "", "sv": "sv"})
+
+	for _, filename := range []string{"sect/page.md", "sect/page.sv.md"} {
+		assert.NoError(afero.WriteFile(enFs, filepath.FromSlash(filename), []byte("abc"), 0777))
+	}
+
+	fi, err := enFs.Stat(filepath.FromSlash("sect/page.md"))
+	assert.NoError(err)
+	assert.Equal("", fi.(*LanguageFileInfo).LangSubdir())
+	assert.Equal(filepath.FromSlash("sect/page.md"), fi.(*LanguageFileInfo).PublishPath())
+
+	fi, err = enFs.Stat(filepath.FromSlash("sect/page.sv.md"))
+	assert.NoError(err)
+	assert.Equal("sv", fi.(*LanguageFileInfo).LangSubdir())
+	assert.Equal(filepath.FromSlash("sv/sect/page.sv.md"), fi.(*LanguageFileInfo).PublishPath())
+}
+
+func TestMergeTranslations(t *testing.T) {
+	languages := map[string]bool{
+		"en":    true,
+		"en-gb": true,
+		"nn":    true,
+	}
+	assert := require.New(t)
+	m := afero.NewMemMapFs()
+	fs := NewLanguageFs("en-gb", newTestLanguageSet(languages), afero.NewBasePathFs(m, filepath.FromSlash("/content")))
+
+	for _, filename := range []string{"sect/colour.md", "sect/colour.en.md", "sect/about.en.md", "sect/about.nn.md", "sect/contact.en.md", "sect/sub/p.md"} {
+		assert.NoError(afero.WriteFile(fs, filepath.FromSlash(filename), []byte("abc"), 0777))
+	}
+
+	dir, err := fs.Open("sect")
+	assert.NoError(err)
+	fis, err := dir.Readdir(-1)
+	assert.NoError(err)
+
+	merged := MergeTranslations("en-gb", "en", fis)
+	got := make(map[string]string)
+	for _, fi := range merged {
+		lfi := fi.(*LanguageFileInfo)
+		assert.Equal("en-gb", lfi.Lang())
+		got[lfi.RealName()] = lfi.BorrowedFrom()
+	}
+
+	assert.Equal(map[string]string{
+		"colour.md":     "",
+		"about.en.md":   "en",
+		"contact.en.md": "en",
+	}, got)
+
+	assert.Len(MergeTranslations("en-gb", "", fis), 1)
+}
+
+func BenchmarkLanguageFsReaddir(b *testing.B) {
+	languages := map[string]bool{
+		"en": true,
+		"nn": true,
+		"sv": true,
+	}
+	m := afero.NewMemMapFs()
+	fs := NewLanguageFs("en", newTestLanguageSet(languages), afero.NewBasePathFs(m, filepath.FromSlash("/my/base/content")))
+	fs.WithLanguageSubdirs(map[string]string{"nn": "nn", "sv": "sv"})
+
+	for i := 0; i < 500; i++ {
+		for _, lang := range []string{"", ".nn", ".sv"} {
+			filename := filepath.Join("blog", "sect", fmt.Sprintf("page%d%s.md", i, lang))
+			if err := afero.WriteFile(fs, filename, []byte("abc"), 0777); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		dir, err := fs.Open(filepath.Join("blog", "sect"))
+		if err != nil {
+			b.Fatal(err)
+		}
+		fis, err := dir.Readdir(-1)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if len(fis) != 1500 {
+			b.Fatalf("got %d files", len(fis))
+		}
+		dir.Close()
+	}
+}
+
+func TestLanguageFsLanguageClassifier(t *testing.T) {
+	assert := require.New(t)
+
+	languages := newTestLanguageSet(map[string]bool{"en": true, "nn": true, "sv": true})
+	m := afero.NewMemMapFs()
+	bfs := afero.NewBasePathFs(m, "/my/base")
+
+	for filename, content := range map[string]string{
+		"toml.md":      "+++\nlang = \"nn\"\n+++\nContent.",
+		"yaml.md":      "---\nLang: sv\n---\nContent.",
+		"suffix.en.md": "---\nlang: nn\n---\nContent.",
+		"unknown.md":   "---\nlang: xx\n---\nContent.",
+		"none.md":      "No front matter.",
+		"bad.md":       "---\nlang: [\n---\nContent.",
+	} {
+		assert.NoError(afero.WriteFile(bfs, filename, []byte(content), 0777))
+	}
+
+	var calls int
+	classifier := func(fs afero.Fs, filename string) (string, error) {
+		calls++
+		return FrontMatterLanguageClassifier(fs, filename)
+	}
+
+	lfs := NewLanguageFs("en", languages, bfs).WithLanguageClassifier(classifier)
+
+	for _, test := range []struct {
+		filename string
+		expect   string
+	}{
+		{"toml.md", "nn"},
+		{"yaml.md", "sv"},
+		{"suffix.en.md", "en"},
+		{"unknown.md", "en"},
+		{"none.md", "en"},
+		{"bad.md", "en"},
+	} {
+		fi, err := lfs.Stat(test.filename)
+		assert.NoError(err)
+		assert.Equal(test.expect, fi.(*LanguageFileInfo).Lang(), test.filename)
+	}
+
+	// The language in the file name wins without reading the file.
+	assert.Equal(5, calls)
+
+	_, err := lfs.Stat("toml.md")
+	assert.NoError(err)
+	assert.Equal(5, calls)
+
+	// Edited.
+	assert.NoError(afero.WriteFile(bfs, "toml.md", []byte("+++\nlang = \"sv\"\n+++\nEdited content."), 0777))
+	fi, err := lfs.Stat("toml.md")
+	assert.NoError(err)
+	assert.Equal("sv", fi.(*LanguageFileInfo).Lang())
+	assert.Equal(6, calls)
+
+	// Without a classifier.
+	fi, err = NewLanguageFs("en", languages, bfs).Stat("yaml.md")
+	assert.NoError(err)
+	assert.Equal("en", fi.(*LanguageFileInfo).Lang())
+}
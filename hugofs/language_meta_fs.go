@@ -0,0 +1,146 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugofs
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/afero"
+)
+
+var (
+	_ afero.Fs          = (*LanguageMetaFs)(nil)
+	_ afero.Lstater     = (*LanguageMetaFs)(nil)
+	_ LanguageAnnouncer = (*languageMetaFileInfo)(nil)
+	_ RealFilenameInfo  = (*languageMetaRealFilenameInfo)(nil)
+)
+
+// LanguageMetaFs decorates the os.FileInfo of a filesystem without language
+// information of its own (e.g. static) with a language, so every FileInfo
+// implements LanguageAnnouncer. Files that already know their language are
+// returned as is.
+type LanguageMetaFs struct {
+	lang string
+	meta *languageFileMeta
+	afero.Fs
+}
+
+// NewLanguageMetaFs creates a new LanguageMetaFs putting the files in fs
+// in lang.
+func NewLanguageMetaFs(lang string, fs afero.Fs) *LanguageMetaFs {
+	if lang == "" {
+		panic("no lang set for the language meta fs")
+	}
+	return &LanguageMetaFs{lang: lang, meta: internLanguageFileMeta(languageFileMeta{lang: lang}), Fs: fs}
+}
+
+// Stat returns the os.FileInfo of a given file.
+func (fs *LanguageMetaFs) Stat(name string) (os.FileInfo, error) {
+	fi, err := fs.Fs.Stat(name)
+	if err != nil {
+		return nil, err
+	}
+	return fs.decorate(fi), nil
+}
+
+// LstatIfPossible returns the os.FileInfo structure describing a given file.
+func (fs *LanguageMetaFs) LstatIfPossible(name string) (os.FileInfo, bool, error) {
+	lstater, ok := fs.Fs.(afero.Lstater)
+	if !ok {
+		fi, err := fs.Stat(name)
+		return fi, false, err
+	}
+
+	fi, ok, err := lstater.LstatIfPossible(name)
+	if err != nil {
+		return nil, false, err
+	}
+	return fs.decorate(fi), ok, nil
+}
+
+// Open opens the named file for reading.
+func (fs *LanguageMetaFs) Open(name string) (afero.File, error) {
+	f, err := fs.Fs.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &languageMetaFile{File: f, fs: fs}, nil
+}
+
+// Name returns the name of this filesystem.
+func (fs *LanguageMetaFs) Name() string {
+	return "LanguageMetaFs"
+}
+
+func (fs *LanguageMetaFs) decorate(fi os.FileInfo) os.FileInfo {
+	if _, ok := fi.(LanguageAnnouncer); ok {
+		return fi
+	}
+
+	lfi := &languageMetaFileInfo{FileInfo: fi, meta: fs.meta}
+	if rfi, ok := fi.(RealFilenameInfo); ok {
+		return &languageMetaRealFilenameInfo{languageMetaFileInfo: lfi, realFilename: rfi.RealFilename()}
+	}
+
+	return lfi
+}
+
+type languageMetaFile struct {
+	afero.File
+	fs *LanguageMetaFs
+}
+
+func (f *languageMetaFile) Readdir(c int) ([]os.FileInfo, error) {
+	fis, err := f.File.Readdir(c)
+	for i, fi := range fis {
+		fis[i] = f.fs.decorate(fi)
+	}
+	return fis, err
+}
+
+func (f *languageMetaFile) Stat() (os.FileInfo, error) {
+	fi, err := f.File.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return f.fs.decorate(fi), nil
+}
+
+type languageMetaFileInfo struct {
+	os.FileInfo
+	meta *languageFileMeta
+}
+
+// Lang returns the file's language (ie. "sv").
+func (fi *languageMetaFileInfo) Lang() string {
+	return fi.meta.lang
+}
+
+// TranslationBaseName returns the base filename without any extension
+// (ie. "page").
+func (fi *languageMetaFileInfo) TranslationBaseName() string {
+	name := fi.Name()
+	return strings.TrimSuffix(name, filepath.Ext(name))
+}
+
+type languageMetaRealFilenameInfo struct {
+	*languageMetaFileInfo
+	realFilename string
+}
+
+func (fi *languageMetaRealFilenameInfo) RealFilename() string {
+	return fi.realFilename
+}
@@ -0,0 +1,51 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugofs
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLanguageMetaFs(t *testing.T) {
+	assert := require.New(t)
+	m := afero.NewMemMapFs()
+	assert.NoError(afero.WriteFile(m, filepath.FromSlash("/static/css/main.css"), []byte("body{}"), 0777))
+
+	fs := NewLanguageMetaFs("nn", NewBasePathRealFilenameFs(afero.NewBasePathFs(m, "/static").(*afero.BasePathFs)))
+
+	fi, err := fs.Stat(filepath.FromSlash("css/main.css"))
+	assert.NoError(err)
+	lfi := fi.(LanguageAnnouncer)
+	assert.Equal("nn", lfi.Lang())
+	assert.Equal("main", lfi.TranslationBaseName())
+	assert.Equal(filepath.FromSlash("/static/css/main.css"), fi.(RealFilenameInfo).RealFilename())
+
+	dir, err := fs.Open("css")
+	assert.NoError(err)
+	fis, err := dir.Readdir(-1)
+	assert.NoError(err)
+	assert.Len(fis, 1)
+	assert.Equal("nn", fis[0].(LanguageAnnouncer).Lang())
+
+	// Files aware of their own language are left alone.
+	languages := map[string]bool{"en": true, "nn": true}
+	fs = NewLanguageMetaFs("nn", NewLanguageFs("en", newTestLanguageSet(languages), afero.NewBasePathFs(m, "/static")))
+	fi, err = fs.Stat(filepath.FromSlash("css/main.css"))
+	assert.NoError(err)
+	assert.Equal("en", fi.(LanguageAnnouncer).Lang())
+}
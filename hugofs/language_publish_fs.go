@@ -0,0 +1,36 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugofs
+
+import (
+	"path/filepath"
+
+	"github.com/gohugoio/hugo/langs"
+	"github.com/spf13/afero"
+)
+
+// NewLanguagePublishFs maps each of the given languages to the filesystem it
+// is published to, keyed by language code. In multihost mode, every language
+// gets its own root below publishFs, else they all share publishFs.
+func NewLanguagePublishFs(publishFs afero.Fs, languages langs.Languages) map[string]afero.Fs {
+	m := make(map[string]afero.Fs)
+	for lang, targetPath := range languages.TargetPaths() {
+		if targetPath == "" {
+			m[lang] = publishFs
+			continue
+		}
+		m[lang] = afero.NewBasePathFs(publishFs, filepath.FromSlash(targetPath))
+	}
+	return m
+}
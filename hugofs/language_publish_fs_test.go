@@ -0,0 +1,49 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugofs
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/gohugoio/hugo/langs"
+	"github.com/spf13/afero"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewLanguagePublishFs(t *testing.T) {
+	assert := require.New(t)
+
+	v := viper.New()
+	v.Set("contentDir", "content")
+
+	en, nn := langs.NewLanguage("en", v), langs.NewLanguage("nn", v)
+	languages := langs.Languages{en, nn}
+
+	publishFs := afero.NewMemMapFs()
+
+	m := NewLanguagePublishFs(publishFs, languages)
+	assert.Len(m, 2)
+	assert.Equal(publishFs, m["en"])
+
+	en.Set("baseURL", "https://example.com")
+	nn.Set("baseURL", "https://example.no")
+
+	m = NewLanguagePublishFs(publishFs, languages)
+	assert.NoError(afero.WriteFile(m["nn"], "index.html", []byte("nn"), 0777))
+	b, err := afero.ReadFile(publishFs, filepath.FromSlash("nn/index.html"))
+	assert.NoError(err)
+	assert.Equal("nn", string(b))
+}
@@ -0,0 +1,128 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugofs
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/gohugoio/hugo/common/loggers"
+)
+
+// Field names used in the log entries of the filesystems.
+const (
+	// The operation, e.g. "open".
+	LogFieldOp = "op"
+
+	// The filename as seen by the user of the filesystem.
+	LogFieldPath = "path"
+
+	// The real filename, or directory, a path maps to.
+	LogFieldMount = "mount"
+
+	// The time spent on the operation.
+	LogFieldDuration = "duration"
+
+	// The error, if any.
+	LogFieldError = "error"
+)
+
+// LogLevel is the severity of a log entry.
+type LogLevel int
+
+const (
+	LogLevelDebug LogLevel = iota
+	LogLevelInfo
+	LogLevelWarn
+	LogLevelError
+)
+
+func (l LogLevel) String() string {
+	switch l {
+	case LogLevelDebug:
+		return "debug"
+	case LogLevelInfo:
+		return "info"
+	case LogLevelWarn:
+		return "warn"
+	case LogLevelError:
+		return "error"
+	}
+	return fmt.Sprintf("level(%d)", int(l))
+}
+
+// Logger receives the diagnostics from the filesystems, so they can be routed
+// into any logger. The fields are key/value pairs, see the LogField*
+// constants.
+type Logger interface {
+	Log(level LogLevel, msg string, fields ...interface{})
+}
+
+// LoggerFunc is an adapter to use an ordinary function as a Logger.
+type LoggerFunc func(level LogLevel, msg string, fields ...interface{})
+
+// Log calls f.
+func (f LoggerFunc) Log(level LogLevel, msg string, fields ...interface{}) {
+	f(level, msg, fields...)
+}
+
+// NopLogger discards all log entries.
+var NopLogger Logger = nopLogger{}
+
+type nopLogger struct{}
+
+func (nopLogger) Log(level LogLevel, msg string, fields ...interface{}) {}
+
+// NewLogger creates a Logger writing to the given Hugo logger, formatted as
+// the message followed by the fields, e.g. "stat failed op=stat path=sect/page.md".
+func NewLogger(l *loggers.Logger) Logger {
+	return LoggerFunc(func(level LogLevel, msg string, fields ...interface{}) {
+		s := FormatLogEntry(msg, fields...)
+		switch level {
+		case LogLevelDebug:
+			l.DEBUG.Println(s)
+		case LogLevelInfo:
+			l.INFO.Println(s)
+		case LogLevelWarn:
+			l.WARN.Println(s)
+		default:
+			l.ERROR.Println(s)
+		}
+	})
+}
+
+// FormatLogEntry formats msg and the key/value pairs in fields on one line.
+func FormatLogEntry(msg string, fields ...interface{}) string {
+	var b bytes.Buffer
+	b.WriteString(msg)
+	for i := 0; i < len(fields); i += 2 {
+		var v interface{} = "(missing)"
+		if i+1 < len(fields) {
+			v = fields[i+1]
+		}
+		if d, ok := v.(time.Duration); ok {
+			v = d.String()
+		}
+		fmt.Fprintf(&b, " %v=%v", fields[i], v)
+	}
+	return b.String()
+}
+
+func loggerOrNop(l Logger) Logger {
+	if l == nil {
+		return NopLogger
+	}
+	return l
+}
@@ -0,0 +1,97 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugofs
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+type testLogger struct {
+	mu      sync.Mutex
+	entries []string
+}
+
+func (l *testLogger) Log(level LogLevel, msg string, fields ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	// Durations vary, leave them out.
+	var stable []interface{}
+	for i := 0; i+1 < len(fields); i += 2 {
+		if fields[i] != LogFieldDuration {
+			stable = append(stable, fields[i], fields[i+1])
+		}
+	}
+	l.entries = append(l.entries, fmt.Sprintf("%s: %s", level, FormatLogEntry(msg, stable...)))
+}
+
+func TestFormatLogEntry(t *testing.T) {
+	assert := require.New(t)
+
+	assert.Equal("msg", FormatLogEntry("msg"))
+	assert.Equal("msg op=open path=a.md duration=2s", FormatLogEntry("msg", LogFieldOp, "open", LogFieldPath, "a.md", LogFieldDuration, 2*time.Second))
+	assert.Equal("msg op=(missing)", FormatLogEntry("msg", LogFieldOp))
+	assert.Equal("warn", LogLevelWarn.String())
+}
+
+func TestRootMappingFsLogger(t *testing.T) {
+	assert := require.New(t)
+
+	fs := afero.NewMemMapFs()
+	assert.NoError(afero.WriteFile(fs, filepath.FromSlash("/real/a.txt"), []byte("abc"), 0755))
+
+	logger := &testLogger{}
+	rfs, err := NewRootMappingFs(fs, "static", filepath.FromSlash("/real"))
+	assert.NoError(err)
+	rfs.WithLogger(logger)
+
+	_, err = rfs.Stat(filepath.FromSlash("static/a.txt"))
+	assert.NoError(err)
+	_, err = rfs.Stat(filepath.FromSlash("static/b.txt"))
+	assert.True(os.IsNotExist(err))
+
+	assert.Equal([]string{
+		fmt.Sprintf("debug: root mapping op=stat path=%s mount=%s", filepath.FromSlash("static/a.txt"), filepath.FromSlash("/real/a.txt")),
+		fmt.Sprintf("debug: root mapping op=stat path=%s mount=%s", filepath.FromSlash("static/b.txt"), filepath.FromSlash("/real/b.txt")),
+	}, logger.entries)
+
+	// The default is to log nothing.
+	rfs.WithLogger(nil)
+	_, err = rfs.Stat(filepath.FromSlash("static/a.txt"))
+	assert.NoError(err)
+	assert.Len(logger.entries, 2)
+}
+
+func TestLanguageFsLogger(t *testing.T) {
+	assert := require.New(t)
+
+	logger := &testLogger{}
+	languages := newTestLanguageSet(map[string]bool{"en": true, "nn": false})
+	fs := NewLanguageFs("en", languages, afero.NewBasePathFs(afero.NewMemMapFs(), filepath.FromSlash("/content"))).WithLogger(logger)
+
+	assert.NoError(afero.WriteFile(fs, "page.nn.md", []byte("abc"), 0777))
+	_, err := fs.Stat("page.nn.md")
+	assert.True(os.IsNotExist(err))
+
+	assert.Equal([]string{
+		fmt.Sprintf("debug: file in disabled language hidden op=stat path=page.nn.md mount=%s lang=nn", filepath.FromSlash("/content")),
+	}, logger.entries)
+}
@@ -0,0 +1,286 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugofs
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/spf13/afero"
+)
+
+var (
+	_ afero.Fs = (*ManifestFs)(nil)
+	_ Reseter  = (*ManifestFs)(nil)
+)
+
+// Manifest lists the files written, written unchanged and deleted in a
+// ManifestFs, e.g. during a build. The paths are relative to the publish
+// dir and "/" separated.
+type Manifest struct {
+	Written   []ManifestEntry `json:"written"`
+	Unchanged []ManifestEntry `json:"unchanged"`
+	Deleted   []string        `json:"deleted"`
+}
+
+// ManifestEntry is a file in a Manifest with the MD5 sum of its content.
+type ManifestEntry struct {
+	Path string `json:"path"`
+	Hash string `json:"hash"`
+}
+
+// ManifestFs records the files written to and deleted from the delegate
+// filesystem, so deploy scripts can upload only what changed in a build.
+// A file written with the same content it already had is recorded as
+// unchanged. Note that, as in the hashing filesystem, only writes done
+// through the io.Writer are hashed.
+type ManifestFs struct {
+	afero.Fs
+
+	baseDir string
+
+	mu sync.Mutex
+	// Maps the path to its new MD5 sum and whether the content changed.
+	written map[string]manifestWrite
+	deleted map[string]bool
+}
+
+type manifestWrite struct {
+	hash    string
+	changed bool
+	// Whether the file existed before it was first written.
+	existed bool
+}
+
+// NewManifestFs creates a new ManifestFs. The paths in the manifest are
+// relative to baseDir, typically the publish dir.
+func NewManifestFs(delegate afero.Fs, baseDir string) *ManifestFs {
+	fs := &ManifestFs{Fs: delegate, baseDir: baseDir}
+	fs.Reset()
+	return fs
+}
+
+// Reset clears the files recorded, e.g. before a new build.
+func (fs *ManifestFs) Reset() {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	fs.written = make(map[string]manifestWrite)
+	fs.deleted = make(map[string]bool)
+}
+
+func (fs *ManifestFs) Create(name string) (afero.File, error) {
+	oldSum := fs.sum(name)
+	f, err := fs.Fs.Create(name)
+	if err == nil {
+		f = fs.wrapFile(f, oldSum)
+	}
+	return f, err
+}
+
+func (fs *ManifestFs) OpenFile(name string, flag int, perm os.FileMode) (afero.File, error) {
+	if !isWrite(flag) {
+		return fs.Fs.OpenFile(name, flag, perm)
+	}
+	oldSum := fs.sum(name)
+	f, err := fs.Fs.OpenFile(name, flag, perm)
+	if err == nil {
+		f = fs.wrapFile(f, oldSum)
+	}
+	return f, err
+}
+
+func (fs *ManifestFs) Remove(name string) error {
+	isFile := fs.isFile(name)
+	if err := fs.Fs.Remove(name); err != nil {
+		return err
+	}
+	if isFile {
+		fs.onDelete(name)
+	}
+	return nil
+}
+
+func (fs *ManifestFs) RemoveAll(path string) error {
+	var filenames []string
+	afero.Walk(fs.Fs, path, func(filename string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			filenames = append(filenames, filename)
+		}
+		return nil
+	})
+
+	if err := fs.Fs.RemoveAll(path); err != nil {
+		return err
+	}
+
+	for _, filename := range filenames {
+		fs.onDelete(filename)
+	}
+
+	return nil
+}
+
+func (fs *ManifestFs) Rename(oldname, newname string) error {
+	sum := fs.sum(oldname)
+	oldSum := fs.sum(newname)
+	if err := fs.Fs.Rename(oldname, newname); err != nil {
+		return err
+	}
+	if sum != "" {
+		fs.onMove(oldname)
+		fs.onWrite(newname, oldSum, sum)
+	}
+	return nil
+}
+
+func (fs *ManifestFs) Name() string {
+	return "ManifestFs"
+}
+
+// Manifest returns the files recorded since the last Reset, sorted by path.
+func (fs *ManifestFs) Manifest() Manifest {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	m := Manifest{
+		Written:   make([]ManifestEntry, 0),
+		Unchanged: make([]ManifestEntry, 0),
+		Deleted:   make([]string, 0),
+	}
+
+	for path, w := range fs.written {
+		entry := ManifestEntry{Path: path, Hash: w.hash}
+		if w.changed {
+			m.Written = append(m.Written, entry)
+		} else {
+			m.Unchanged = append(m.Unchanged, entry)
+		}
+	}
+
+	for path := range fs.deleted {
+		m.Deleted = append(m.Deleted, path)
+	}
+
+	sort.Slice(m.Written, func(i, j int) bool { return m.Written[i].Path < m.Written[j].Path })
+	sort.Slice(m.Unchanged, func(i, j int) bool { return m.Unchanged[i].Path < m.Unchanged[j].Path })
+	sort.Strings(m.Deleted)
+
+	return m
+}
+
+// WriteManifest writes the Manifest to w as JSON.
+func (fs *ManifestFs) WriteManifest(w io.Writer) error {
+	b, err := json.MarshalIndent(fs.Manifest(), "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+// onWrite records the file written with the given old and new MD5 sums.
+func (fs *ManifestFs) onWrite(name, oldSum, sum string) {
+	path := fs.relPath(name)
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	wasDeleted := fs.deleted[path]
+	delete(fs.deleted, path)
+	w, found := fs.written[path]
+	// A file written more than once in a build changed if any write did.
+	changed := oldSum != sum || (found && w.changed)
+	existed := oldSum != "" || wasDeleted || (found && w.existed)
+	fs.written[path] = manifestWrite{hash: sum, changed: changed, existed: existed}
+}
+
+func (fs *ManifestFs) onDelete(name string) {
+	path := fs.relPath(name)
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	delete(fs.written, path)
+	fs.deleted[path] = true
+}
+
+// onMove records the file moved away from name. Files created in this
+// build and then moved are not recorded as deleted.
+func (fs *ManifestFs) onMove(name string) {
+	path := fs.relPath(name)
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	w, found := fs.written[path]
+	delete(fs.written, path)
+	if !found || w.existed {
+		fs.deleted[path] = true
+	}
+}
+
+func (fs *ManifestFs) relPath(name string) string {
+	name = filepath.Clean(name)
+	if fs.baseDir != "" {
+		if rel, err := filepath.Rel(fs.baseDir, name); err == nil && !strings.HasPrefix(rel, "..") {
+			name = rel
+		}
+	}
+	return strings.TrimPrefix(filepath.ToSlash(name), "/")
+}
+
+func (fs *ManifestFs) isFile(name string) bool {
+	fi, err := fs.Fs.Stat(name)
+	return err == nil && !fi.IsDir()
+}
+
+// sum returns the MD5 sum of the given file, empty if not found.
+func (fs *ManifestFs) sum(name string) string {
+	f, err := fs.Fs.Open(name)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	if fi, err := f.Stat(); err != nil || fi.IsDir() {
+		return ""
+	}
+
+	h := md5.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return ""
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (fs *ManifestFs) wrapFile(f afero.File, oldSum string) afero.File {
+	return &hashingFile{File: f, h: md5.New(), hashReceiver: &manifestHashReceiver{fs: fs, oldSum: oldSum}}
+}
+
+type manifestHashReceiver struct {
+	fs     *ManifestFs
+	oldSum string
+}
+
+func (r *manifestHashReceiver) OnFileClose(name, md5sum string) {
+	r.fs.onWrite(name, r.oldSum, md5sum)
+}
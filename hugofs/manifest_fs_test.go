@@ -0,0 +1,78 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugofs
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManifestFs(t *testing.T) {
+	assert := require.New(t)
+
+	mm := afero.NewMemMapFs()
+	publishDir := filepath.FromSlash("/public")
+
+	assert.NoError(afero.WriteFile(mm, filepath.Join(publishDir, "same.html"), []byte("same"), 0755))
+	assert.NoError(afero.WriteFile(mm, filepath.Join(publishDir, "changed.html"), []byte("old"), 0755))
+	assert.NoError(afero.WriteFile(mm, filepath.Join(publishDir, "old", "a.html"), []byte("a"), 0755))
+	assert.NoError(afero.WriteFile(mm, filepath.Join(publishDir, "old", "b.html"), []byte("b"), 0755))
+
+	fs := NewManifestFs(mm, publishDir)
+
+	assert.NoError(afero.WriteFile(fs, filepath.Join(publishDir, "same.html"), []byte("same"), 0755))
+	assert.NoError(afero.WriteFile(fs, filepath.Join(publishDir, "changed.html"), []byte("new"), 0755))
+	assert.NoError(afero.WriteFile(fs, filepath.Join(publishDir, "posts", "new.html"), []byte("new"), 0755))
+	assert.NoError(fs.RemoveAll(filepath.Join(publishDir, "old")))
+
+	m := fs.Manifest()
+	assert.Equal([]ManifestEntry{
+		{Path: "changed.html", Hash: "22af645d1859cb5ca6da0c484f1f37ea"},
+		{Path: "posts/new.html", Hash: "22af645d1859cb5ca6da0c484f1f37ea"},
+	}, m.Written)
+	assert.Equal([]ManifestEntry{{Path: "same.html", Hash: "51037a4a37730f52c8732586d3aaa316"}}, m.Unchanged)
+	assert.Equal([]string{"old/a.html", "old/b.html"}, m.Deleted)
+
+	var b bytes.Buffer
+	assert.NoError(fs.WriteManifest(&b))
+	assert.Contains(b.String(), `"path": "posts/new.html"`)
+
+	// Written back after a delete.
+	assert.NoError(afero.WriteFile(fs, filepath.Join(publishDir, "old", "a.html"), []byte("a"), 0755))
+	assert.Equal([]string{"old/b.html"}, fs.Manifest().Deleted)
+
+	// Renamed files.
+	assert.NoError(afero.WriteFile(fs, filepath.Join(publishDir, "tmp.css"), []byte("css"), 0755))
+	assert.NoError(fs.Rename(filepath.Join(publishDir, "tmp.css"), filepath.Join(publishDir, "style.css")))
+	assert.NoError(fs.Rename(filepath.Join(publishDir, "same.html"), filepath.Join(publishDir, "moved.html")))
+	m = fs.Manifest()
+	assert.Equal([]string{"old/b.html", "same.html"}, m.Deleted)
+	assert.Equal([]ManifestEntry{
+		{Path: "changed.html", Hash: "22af645d1859cb5ca6da0c484f1f37ea"},
+		{Path: "moved.html", Hash: "51037a4a37730f52c8732586d3aaa316"},
+		{Path: "old/a.html", Hash: "0cc175b9c0f1b6a831c399e269772661"},
+		{Path: "posts/new.html", Hash: "22af645d1859cb5ca6da0c484f1f37ea"},
+		{Path: "style.css", Hash: "c7a628cba22e28eb17b5f5c6ae2a266a"},
+	}, m.Written)
+
+	fs.Reset()
+	m = fs.Manifest()
+	assert.Len(m.Written, 0)
+	assert.Len(m.Unchanged, 0)
+	assert.Len(m.Deleted, 0)
+}
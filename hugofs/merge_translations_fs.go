@@ -0,0 +1,100 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugofs
+
+import (
+	"os"
+	"sort"
+
+	"github.com/spf13/afero"
+)
+
+var (
+	_ afero.Fs      = (*mergeTranslationsFs)(nil)
+	_ afero.Lstater = (*mergeTranslationsFs)(nil)
+)
+
+type mergeTranslationsFs struct {
+	afero.Fs
+
+	// Maps a language to the language whose content fills its gaps.
+	from map[string]string
+	// The languages in from, sorted.
+	langs []string
+}
+
+// NewMergeTranslationsFs creates a filesystem listing the directories in fs,
+// usually a language composite, with the gaps in a language's content filled
+// by the files of another language, see MergeTranslations. The from map
+// holds the language to take the files from, keyed by the language to fill,
+// e.g. "en" for "en-gb". The files filled in are marked as borrowed, see
+// LanguageFileInfo.BorrowedFrom. Opening or stat'ing a file is left to fs.
+func NewMergeTranslationsFs(fs afero.Fs, from map[string]string) afero.Fs {
+	var langs []string
+	for lang := range from {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	return &mergeTranslationsFs{Fs: fs, from: from, langs: langs}
+}
+
+// Open opens the named file or directory. A directory lists the borrowed
+// files after the others.
+func (fs *mergeTranslationsFs) Open(name string) (afero.File, error) {
+	f, err := fs.Fs.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	fi, err := f.Stat()
+	if err != nil || !fi.IsDir() {
+		return f, err
+	}
+
+	return &mergedDir{File: &mergeTranslationsDir{File: f, fs: fs}}, nil
+}
+
+// LstatIfPossible returns the os.FileInfo of the named file, see
+// afero.Lstater.
+func (fs *mergeTranslationsFs) LstatIfPossible(name string) (os.FileInfo, bool, error) {
+	if lstater, ok := fs.Fs.(afero.Lstater); ok {
+		return lstater.LstatIfPossible(name)
+	}
+	fi, err := fs.Fs.Stat(name)
+	return fi, false, err
+}
+
+type mergeTranslationsDir struct {
+	afero.File
+	fs *mergeTranslationsFs
+}
+
+// Readdir reads the whole directory, count is ignored, see mergedDir.
+func (d *mergeTranslationsDir) Readdir(count int) ([]os.FileInfo, error) {
+	fis, err := d.File.Readdir(-1)
+	if err != nil {
+		return nil, err
+	}
+
+	var borrowed []os.FileInfo
+	for _, lang := range d.fs.langs {
+		for _, fi := range MergeTranslations(lang, d.fs.from[lang], fis) {
+			if fi.(*LanguageFileInfo).BorrowedFrom() != "" {
+				borrowed = append(borrowed, fi)
+			}
+		}
+	}
+
+	return append(fis, borrowed...), nil
+}
@@ -0,0 +1,77 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugofs_test
+
+import (
+	"io"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/gohugoio/hugo/hugofs"
+	"github.com/gohugoio/hugo/hugofs/hugofstest"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeTranslationsFs(t *testing.T) {
+	assert := require.New(t)
+
+	b := hugofstest.New(t).
+		WithLang("en").WithLang("en-gb").WithLang("sv").
+		WithMount("content/en", "en").
+		WithMount("content/sv", "sv").
+		Add("content/en/blog/about.md", "about").
+		Add("content/en/blog/contact.md", "contact").
+		Add("content/en/blog/contact.en-gb.md", "contact gb").
+		Add("content/sv/blog/about.md", "om")
+	fs := hugofs.NewMergeTranslationsFs(b.Build(), map[string]string{"en-gb": "en"})
+
+	fis, err := afero.ReadDir(fs, "blog")
+	assert.NoError(err)
+
+	var got []string
+	for _, fi := range fis {
+		lfi := fi.(*hugofs.LanguageFileInfo)
+		got = append(got, lfi.Lang()+":"+lfi.RealName()+":"+lfi.BorrowedFrom())
+	}
+	sort.Strings(got)
+	assert.Equal([]string{
+		"en-gb:about.md:en",
+		"en-gb:contact.en-gb.md:",
+		"en:about.md:",
+		"en:contact.md:",
+		"sv:about.md:",
+	}, got)
+
+	// Paged.
+	f, err := fs.Open("blog")
+	assert.NoError(err)
+	defer f.Close()
+	var n int
+	for {
+		fis, err := f.Readdir(2)
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(err)
+		n += len(fis)
+	}
+	assert.Equal(5, n)
+
+	// Files are opened as before.
+	content, err := afero.ReadFile(fs, filepath.FromSlash("/content/en/blog/about.md"))
+	assert.NoError(err)
+	assert.Equal("about", string(content))
+}
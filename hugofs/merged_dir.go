@@ -0,0 +1,77 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugofs
+
+import (
+	"io"
+	"os"
+
+	"github.com/spf13/afero"
+)
+
+// mergedDir is a merged directory, usually an afero.UnionFile, read in
+// pages. The afero.UnionFile gets the offsets wrong in Readdir(count) after
+// the first call, so the merged entries are read once and paged here.
+type mergedDir struct {
+	afero.File
+
+	fis  []os.FileInfo
+	read bool
+	off  int
+}
+
+// Readdir reads the next count entries of the merged directory. At the end
+// of the directory, the error is io.EOF if count > 0. See os.File.Readdir.
+func (d *mergedDir) Readdir(count int) ([]os.FileInfo, error) {
+	if !d.read {
+		fis, err := d.File.Readdir(-1)
+		if err != nil {
+			return nil, err
+		}
+		d.fis = fis
+		d.read = true
+	}
+
+	if count <= 0 {
+		fis := d.fis[d.off:]
+		d.off = len(d.fis)
+		return fis, nil
+	}
+
+	if d.off >= len(d.fis) {
+		return nil, io.EOF
+	}
+
+	end := d.off + count
+	if end > len(d.fis) {
+		end = len(d.fis)
+	}
+	fis := d.fis[d.off:end]
+	d.off = end
+
+	return fis, nil
+}
+
+// Readdirnames returns the names of the entries returned by Readdir.
+func (d *mergedDir) Readdirnames(count int) ([]string, error) {
+	fis, err := d.Readdir(count)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, len(fis))
+	for i, fi := range fis {
+		names[i] = fi.Name()
+	}
+	return names, nil
+}
@@ -0,0 +1,215 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugofs
+
+import (
+	"io"
+	"os"
+	"time"
+
+	"github.com/gohugoio/hugo/common/hmetrics"
+	"github.com/spf13/afero"
+)
+
+var (
+	_ afero.Fs      = (*MetricsFs)(nil)
+	_ afero.Lstater = (*MetricsFs)(nil)
+)
+
+const (
+	metricsOpCreate    = "create"
+	metricsOpLstat     = "lstat"
+	metricsOpMkdir     = "mkdir"
+	metricsOpOpen      = "open"
+	metricsOpReaddir   = "readdir"
+	metricsOpRemove    = "remove"
+	metricsOpRename    = "rename"
+	metricsOpStat      = "stat"
+	metricsOpChmod     = "chmod"
+	metricsOpChtimes   = "chtimes"
+	metricsOpRemoveAll = "removeall"
+)
+
+// MetricsFs is a filesystem that counts and times the operations done on
+// the filesystem it wraps in a hmetrics.Registry:
+//
+//	hugofs_ops_total{fs="content",op="open"}: the number of operations.
+//	hugofs_errors_total{fs="content",op="open"}: the number of failed operations,
+//	  not counting files not found and io.EOF.
+//	hugofs_op_duration{fs="content",op="open"}: the time spent.
+type MetricsFs struct {
+	ops map[string]*metricsOp
+
+	afero.Fs
+}
+
+type metricsOp struct {
+	count    *hmetrics.Counter
+	errors   *hmetrics.Counter
+	duration *hmetrics.Timer
+}
+
+func (m *metricsOp) done(start time.Time, err error) {
+	m.count.Inc()
+	m.duration.ObserveSince(start)
+	if err != nil && err != io.EOF && !os.IsNotExist(err) {
+		m.errors.Inc()
+	}
+}
+
+// NewMetricsFs creates a new MetricsFs recording the operations on fs in r,
+// labeled with the given filesystem name, e.g. "content".
+func NewMetricsFs(fs afero.Fs, r *hmetrics.Registry, name string) *MetricsFs {
+	ops := make(map[string]*metricsOp)
+	for _, op := range []string{
+		metricsOpCreate, metricsOpLstat, metricsOpMkdir, metricsOpOpen, metricsOpReaddir,
+		metricsOpRemove, metricsOpRename, metricsOpStat, metricsOpChmod, metricsOpChtimes,
+		metricsOpRemoveAll} {
+		ops[op] = &metricsOp{
+			count:    r.Counter("hugofs_ops_total", "fs", name, "op", op),
+			errors:   r.Counter("hugofs_errors_total", "fs", name, "op", op),
+			duration: r.Timer("hugofs_op_duration", "fs", name, "op", op),
+		}
+	}
+
+	return &MetricsFs{ops: ops, Fs: fs}
+}
+
+// Name returns the name of this filesystem.
+func (fs *MetricsFs) Name() string {
+	return "MetricsFs"
+}
+
+func (fs *MetricsFs) Open(name string) (afero.File, error) {
+	f, err := fs.timed(metricsOpOpen, func() (afero.File, error) { return fs.Fs.Open(name) })
+	return fs.wrap(f, err)
+}
+
+func (fs *MetricsFs) OpenFile(name string, flag int, perm os.FileMode) (afero.File, error) {
+	op := metricsOpOpen
+	if flag&os.O_CREATE != 0 {
+		op = metricsOpCreate
+	}
+	f, err := fs.timed(op, func() (afero.File, error) { return fs.Fs.OpenFile(name, flag, perm) })
+	return fs.wrap(f, err)
+}
+
+func (fs *MetricsFs) Create(name string) (afero.File, error) {
+	f, err := fs.timed(metricsOpCreate, func() (afero.File, error) { return fs.Fs.Create(name) })
+	return fs.wrap(f, err)
+}
+
+func (fs *MetricsFs) Stat(name string) (os.FileInfo, error) {
+	start := time.Now()
+	fi, err := fs.Fs.Stat(name)
+	fs.ops[metricsOpStat].done(start, err)
+	return fi, err
+}
+
+// LstatIfPossible returns the os.FileInfo structure describing a given file.
+// It uses Lstat if supported by the wrapped filesystem.
+func (fs *MetricsFs) LstatIfPossible(name string) (os.FileInfo, bool, error) {
+	lstater, ok := fs.Fs.(afero.Lstater)
+	if !ok {
+		fi, err := fs.Stat(name)
+		return fi, false, err
+	}
+
+	start := time.Now()
+	fi, b, err := lstater.LstatIfPossible(name)
+	fs.ops[metricsOpLstat].done(start, err)
+	return fi, b, err
+}
+
+func (fs *MetricsFs) Mkdir(name string, perm os.FileMode) error {
+	start := time.Now()
+	err := fs.Fs.Mkdir(name, perm)
+	fs.ops[metricsOpMkdir].done(start, err)
+	return err
+}
+
+func (fs *MetricsFs) MkdirAll(path string, perm os.FileMode) error {
+	start := time.Now()
+	err := fs.Fs.MkdirAll(path, perm)
+	fs.ops[metricsOpMkdir].done(start, err)
+	return err
+}
+
+func (fs *MetricsFs) Remove(name string) error {
+	start := time.Now()
+	err := fs.Fs.Remove(name)
+	fs.ops[metricsOpRemove].done(start, err)
+	return err
+}
+
+func (fs *MetricsFs) RemoveAll(path string) error {
+	start := time.Now()
+	err := fs.Fs.RemoveAll(path)
+	fs.ops[metricsOpRemoveAll].done(start, err)
+	return err
+}
+
+func (fs *MetricsFs) Rename(oldname, newname string) error {
+	start := time.Now()
+	err := fs.Fs.Rename(oldname, newname)
+	fs.ops[metricsOpRename].done(start, err)
+	return err
+}
+
+func (fs *MetricsFs) Chmod(name string, mode os.FileMode) error {
+	start := time.Now()
+	err := fs.Fs.Chmod(name, mode)
+	fs.ops[metricsOpChmod].done(start, err)
+	return err
+}
+
+func (fs *MetricsFs) Chtimes(name string, atime time.Time, mtime time.Time) error {
+	start := time.Now()
+	err := fs.Fs.Chtimes(name, atime, mtime)
+	fs.ops[metricsOpChtimes].done(start, err)
+	return err
+}
+
+func (fs *MetricsFs) timed(op string, f func() (afero.File, error)) (afero.File, error) {
+	start := time.Now()
+	file, err := f()
+	fs.ops[op].done(start, err)
+	return file, err
+}
+
+func (fs *MetricsFs) wrap(f afero.File, err error) (afero.File, error) {
+	if err != nil {
+		return nil, err
+	}
+	return &metricsFile{File: f, readdir: fs.ops[metricsOpReaddir]}, nil
+}
+
+type metricsFile struct {
+	afero.File
+	readdir *metricsOp
+}
+
+func (f *metricsFile) Readdir(count int) ([]os.FileInfo, error) {
+	start := time.Now()
+	fis, err := f.File.Readdir(count)
+	f.readdir.done(start, err)
+	return fis, err
+}
+
+func (f *metricsFile) Readdirnames(count int) ([]string, error) {
+	start := time.Now()
+	names, err := f.File.Readdirnames(count)
+	f.readdir.done(start, err)
+	return names, err
+}
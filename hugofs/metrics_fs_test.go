@@ -0,0 +1,66 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugofs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gohugoio/hugo/common/hmetrics"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricsFs(t *testing.T) {
+	assert := require.New(t)
+
+	r := hmetrics.NewRegistry()
+	fs := NewMetricsFs(afero.NewMemMapFs(), r, "content")
+
+	assert.NoError(afero.WriteFile(fs, filepath.FromSlash("/sect/a.md"), []byte("abc"), 0777))
+	assert.NoError(afero.WriteFile(fs, filepath.FromSlash("/sect/b.md"), []byte("abc"), 0777))
+
+	_, err := fs.Stat(filepath.FromSlash("/sect/a.md"))
+	assert.NoError(err)
+	_, err = fs.Stat(filepath.FromSlash("/sect/c.md"))
+	assert.True(os.IsNotExist(err))
+	_, _, err = fs.LstatIfPossible(filepath.FromSlash("/sect/a.md"))
+	assert.NoError(err)
+
+	dir, err := fs.Open(filepath.FromSlash("/sect"))
+	assert.NoError(err)
+	names, err := dir.Readdirnames(-1)
+	assert.NoError(err)
+	assert.Len(names, 2)
+
+	assert.Error(fs.Rename(filepath.FromSlash("/sect/c.md"), filepath.FromSlash("/sect/d.md")))
+
+	ops := func(op string) int64 {
+		return r.Counter("hugofs_ops_total", "fs", "content", "op", op).Value()
+	}
+	errs := func(op string) int64 {
+		return r.Counter("hugofs_errors_total", "fs", "content", "op", op).Value()
+	}
+
+	assert.Equal(int64(2), ops("create"))
+	// MemMapFs does not support Lstat, so it falls back to Stat.
+	assert.Equal(int64(3), ops("stat"))
+	assert.Equal(int64(0), errs("stat"))
+	assert.Equal(int64(0), ops("lstat"))
+	assert.Equal(int64(1), ops("open"))
+	assert.Equal(int64(1), ops("readdir"))
+	assert.Equal(int64(1), ops("rename"))
+	assert.Equal(int64(1), r.Timer("hugofs_op_duration", "fs", "content", "op", "open").Count())
+}
@@ -14,7 +14,6 @@
 package hugofs
 
 import (
-	"errors"
 	"os"
 	"time"
 
@@ -22,8 +21,7 @@ import (
 )
 
 var (
-	errNoOp          = errors.New("this is a filesystem that does nothing and this operation is not supported")
-	_       afero.Fs = (*noOpFs)(nil)
+	_ afero.Fs = (*noOpFs)(nil)
 
 	// NoOpFs provides a no-op filesystem that implements the afero.Fs
 	// interface.
@@ -34,15 +32,15 @@ type noOpFs struct {
 }
 
 func (fs noOpFs) Create(name string) (afero.File, error) {
-	return nil, errNoOp
+	return nil, fs.unsupported("create", name)
 }
 
 func (fs noOpFs) Mkdir(name string, perm os.FileMode) error {
-	return errNoOp
+	return fs.unsupported("mkdir", name)
 }
 
 func (fs noOpFs) MkdirAll(path string, perm os.FileMode) error {
-	return errNoOp
+	return fs.unsupported("mkdirall", path)
 }
 
 func (fs noOpFs) Open(name string) (afero.File, error) {
@@ -54,29 +52,33 @@ func (fs noOpFs) OpenFile(name string, flag int, perm os.FileMode) (afero.File,
 }
 
 func (fs noOpFs) Remove(name string) error {
-	return errNoOp
+	return fs.unsupported("remove", name)
 }
 
 func (fs noOpFs) RemoveAll(path string) error {
-	return errNoOp
+	return fs.unsupported("removeall", path)
 }
 
 func (fs noOpFs) Rename(oldname string, newname string) error {
-	return errNoOp
+	return fs.unsupported("rename", oldname)
 }
 
 func (fs noOpFs) Stat(name string) (os.FileInfo, error) {
 	return nil, os.ErrNotExist
 }
 
+func (fs noOpFs) unsupported(op, name string) error {
+	return &ErrUnsupported{Op: op, Type: fs.Name(), Path: name}
+}
+
 func (fs noOpFs) Name() string {
 	return "noOpFs"
 }
 
 func (fs noOpFs) Chmod(name string, mode os.FileMode) error {
-	return errNoOp
+	return fs.unsupported("chmod", name)
 }
 
 func (fs noOpFs) Chtimes(name string, atime time.Time, mtime time.Time) error {
-	return errNoOp
+	return fs.unsupported("chtimes", name)
 }
@@ -0,0 +1,82 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugofs
+
+import (
+	"os"
+	"sort"
+
+	"github.com/spf13/afero"
+)
+
+var (
+	_ afero.Fs      = (*orderedCopyOnWriteFs)(nil)
+	_ afero.Lstater = (*orderedCopyOnWriteFs)(nil)
+)
+
+// SortFileInfos sorts fis by name, the order used by the composite
+// filesystems in this package.
+func SortFileInfos(fis []os.FileInfo) {
+	sort.Slice(fis, func(i, j int) bool {
+		return fis[i].Name() < fis[j].Name()
+	})
+}
+
+// SortedDirsMerger implements the afero.DirsMerger interface. As afero's
+// default, it lets the layer win when a name is in both directories, but the
+// result is sorted by name.
+var SortedDirsMerger = func(lofi, bofi []os.FileInfo) ([]os.FileInfo, error) {
+	m := make(map[string]os.FileInfo)
+
+	for _, fi := range bofi {
+		m[fi.Name()] = fi
+	}
+
+	for _, fi := range lofi {
+		m[fi.Name()] = fi
+	}
+
+	merged := make([]os.FileInfo, 0, len(m))
+	for _, fi := range m {
+		merged = append(merged, fi)
+	}
+
+	SortFileInfos(merged)
+
+	return merged, nil
+}
+
+type orderedCopyOnWriteFs struct {
+	*afero.CopyOnWriteFs
+}
+
+// NewOrderedCopyOnWriteFs creates an afero.CopyOnWriteFs that lists the
+// merged directories sorted by name. Use it in place of
+// afero.NewCopyOnWriteFs, which lists them in random order.
+func NewOrderedCopyOnWriteFs(base, layer afero.Fs) afero.Fs {
+	return &orderedCopyOnWriteFs{afero.NewCopyOnWriteFs(base, layer).(*afero.CopyOnWriteFs)}
+}
+
+func (fs *orderedCopyOnWriteFs) Open(name string) (afero.File, error) {
+	f, err := fs.CopyOnWriteFs.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if fu, ok := f.(*afero.UnionFile); ok {
+		fu.Merger = SortedDirsMerger
+		return &mergedDir{File: fu}, nil
+	}
+	return f, nil
+}
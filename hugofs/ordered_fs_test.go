@@ -0,0 +1,74 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugofs
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrderedCopyOnWriteFs(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+
+	base, layer := afero.NewMemMapFs(), afero.NewMemMapFs()
+
+	var expected []string
+	for i := 0; i < 20; i++ {
+		name := fmt.Sprintf("f%02d.txt", i)
+		expected = append(expected, name)
+		fs := base
+		if i%2 == 0 {
+			fs = layer
+		}
+		assert.NoError(afero.WriteFile(fs, filepath.Join("dir", name), []byte("base"), 0755))
+	}
+	assert.NoError(afero.WriteFile(layer, filepath.Join("dir", "f01.txt"), []byte("layer"), 0755))
+
+	fs := NewOrderedCopyOnWriteFs(base, layer)
+
+	for i := 0; i < 5; i++ {
+		f, err := fs.Open("dir")
+		assert.NoError(err)
+		names, err := f.Readdirnames(-1)
+		assert.NoError(err)
+		f.Close()
+		assert.Equal(expected, names)
+	}
+
+	// Read in pages.
+	f, err := fs.Open("dir")
+	assert.NoError(err)
+	var paged []string
+	for {
+		names, err := f.Readdirnames(3)
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(err)
+		assert.True(len(names) > 0 && len(names) <= 3)
+		paged = append(paged, names...)
+	}
+	f.Close()
+	assert.Equal(expected, paged)
+
+	b, err := afero.ReadFile(fs, filepath.Join("dir", "f01.txt"))
+	assert.NoError(err)
+	assert.Equal("layer", string(b))
+}
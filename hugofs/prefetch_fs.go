@@ -0,0 +1,258 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugofs
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/gohugoio/hugo/config"
+	"github.com/spf13/afero"
+)
+
+var (
+	_ afero.Fs            = (*PrefetchFs)(nil)
+	_ afero.Lstater       = (*PrefetchFs)(nil)
+	_ FilesystemUnwrapper = (*PrefetchFs)(nil)
+	_ afero.File          = (*prefetchDir)(nil)
+	_ afero.File          = (*prefetchedFile)(nil)
+)
+
+const (
+	// Files larger than this are never prefetched.
+	maxPrefetchSize = 1 << 20
+
+	// The max number of files waiting to be prefetched, relative to the
+	// number of files held in memory.
+	maxPendingFactor = 16
+)
+
+// PrefetchFs reads the files listed by a Readdir in the background, so the
+// I/O of the files not yet processed overlaps with the processing of the
+// current ones, e.g. on spinning disks and network mounts. A prefetched file
+// is served from memory on the next Open, as long as its size and
+// modification time are unchanged.
+type PrefetchFs struct {
+	afero.Fs
+
+	size int
+	sem  chan struct{}
+
+	mu      sync.Mutex
+	entries map[string]*prefetchEntry
+	// The entries in the order added, oldest first. May hold names of
+	// entries already taken.
+	order []string
+
+	// The files listed but not yet read.
+	pending []string
+}
+
+type prefetchEntry struct {
+	done    chan struct{}
+	b       []byte
+	modTime time.Time
+	err     error
+}
+
+// NewPrefetchFs creates a new PrefetchFs holding at most size prefetched
+// files in memory.
+func NewPrefetchFs(delegate afero.Fs, size int) *PrefetchFs {
+	workers := config.GetNumWorkerMultiplier()
+	return &PrefetchFs{
+		Fs:      delegate,
+		size:    size,
+		sem:     make(chan struct{}, workers),
+		entries: make(map[string]*prefetchEntry),
+	}
+}
+
+// UnwrapFilesystem returns the decorated filesystem.
+func (fs *PrefetchFs) UnwrapFilesystem() afero.Fs {
+	return fs.Fs
+}
+
+func (fs *PrefetchFs) Name() string {
+	return "PrefetchFs"
+}
+
+// LstatIfPossible returns the os.FileInfo structure describing a given file.
+// It uses Lstat if supported by the wrapped filesystem.
+func (fs *PrefetchFs) LstatIfPossible(name string) (os.FileInfo, bool, error) {
+	if lstater, ok := fs.Fs.(afero.Lstater); ok {
+		return lstater.LstatIfPossible(name)
+	}
+	fi, err := fs.Fs.Stat(name)
+	return fi, false, err
+}
+
+func (fs *PrefetchFs) Open(name string) (afero.File, error) {
+	e := fs.take(name)
+
+	f, err := fs.Fs.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	if fi.IsDir() {
+		return &prefetchDir{File: f, fs: fs}, nil
+	}
+
+	if e != nil {
+		<-e.done
+		if e.err == nil && int64(len(e.b)) == fi.Size() && e.modTime.Equal(fi.ModTime()) {
+			return &prefetchedFile{File: f, r: bytes.NewReader(e.b)}, nil
+		}
+	}
+
+	return f, nil
+}
+
+// take removes and returns the entry for filename, if any, and starts
+// reading the next pending file in its place.
+func (fs *PrefetchFs) take(filename string) *prefetchEntry {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	e, found := fs.entries[filename]
+	if !found {
+		return nil
+	}
+	delete(fs.entries, filename)
+	fs.fill()
+	return e
+}
+
+// prefetch queues the regular files in fis found in dirname for reading.
+func (fs *PrefetchFs) prefetch(dirname string, fis []os.FileInfo) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	for _, fi := range fis {
+		if !fi.Mode().IsRegular() || fi.Size() > maxPrefetchSize {
+			continue
+		}
+		fs.pending = append(fs.pending, filepath.Join(dirname, fi.Name()))
+	}
+
+	if max := maxPendingFactor * fs.size; len(fs.pending) > max {
+		fs.pending = fs.pending[len(fs.pending)-max:]
+	}
+
+	// Make room for the new listing, oldest first, in case the
+	// entries of a previous one never got opened.
+	i := 0
+	for ; i < len(fs.order) && len(fs.entries) >= fs.size; i++ {
+		delete(fs.entries, fs.order[i])
+	}
+	fs.order = fs.order[i:]
+
+	fs.fill()
+}
+
+// fill starts reading pending files until there are size entries. The lock
+// must be held.
+func (fs *PrefetchFs) fill() {
+	for len(fs.entries) < fs.size && len(fs.pending) > 0 {
+		filename := fs.pending[0]
+		fs.pending = fs.pending[1:]
+		if _, found := fs.entries[filename]; found {
+			continue
+		}
+
+		e := &prefetchEntry{done: make(chan struct{})}
+		fs.entries[filename] = e
+		fs.order = append(fs.order, filename)
+		if len(fs.order) > 2*fs.size {
+			fs.compactOrder()
+		}
+
+		go fs.read(filename, e)
+	}
+}
+
+// compactOrder removes the names of the entries already taken. The lock must
+// be held.
+func (fs *PrefetchFs) compactOrder() {
+	order := fs.order[:0]
+	for _, filename := range fs.order {
+		if _, found := fs.entries[filename]; found {
+			order = append(order, filename)
+		}
+	}
+	fs.order = order
+}
+
+func (fs *PrefetchFs) read(filename string, e *prefetchEntry) {
+	fs.sem <- struct{}{}
+	defer func() { <-fs.sem }()
+
+	defer close(e.done)
+
+	f, err := fs.Fs.Open(filename)
+	if err != nil {
+		e.err = err
+		return
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		e.err = err
+		return
+	}
+
+	e.modTime = fi.ModTime()
+	e.b, e.err = afero.ReadAll(f)
+}
+
+// prefetchDir starts the prefetching of the files listed in Readdir.
+type prefetchDir struct {
+	afero.File
+	fs *PrefetchFs
+}
+
+func (f *prefetchDir) Readdir(count int) ([]os.FileInfo, error) {
+	fis, err := f.File.Readdir(count)
+	if len(fis) > 0 {
+		f.fs.prefetch(f.Name(), fis)
+	}
+	return fis, err
+}
+
+// prefetchedFile reads the content from memory.
+type prefetchedFile struct {
+	afero.File
+	r *bytes.Reader
+}
+
+func (f *prefetchedFile) Read(p []byte) (int, error) {
+	return f.r.Read(p)
+}
+
+func (f *prefetchedFile) ReadAt(p []byte, off int64) (int, error) {
+	return f.r.ReadAt(p, off)
+}
+
+func (f *prefetchedFile) Seek(offset int64, whence int) (int64, error) {
+	return f.r.Seek(offset, whence)
+}
@@ -0,0 +1,95 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugofs
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+// openCountingFs counts the opens of regular files.
+type openCountingFs struct {
+	afero.Fs
+	opens int64
+}
+
+func (fs *openCountingFs) Open(name string) (afero.File, error) {
+	f, err := fs.Fs.Open(name)
+	if err == nil {
+		if fi, err := f.Stat(); err == nil && !fi.IsDir() {
+			atomic.AddInt64(&fs.opens, 1)
+		}
+	}
+	return f, err
+}
+
+func TestPrefetchFs(t *testing.T) {
+	assert := require.New(t)
+
+	mfs := afero.NewMemMapFs()
+	for i := 0; i < 10; i++ {
+		assert.NoError(afero.WriteFile(mfs, fmt.Sprintf("/content/p%d.md", i), []byte(fmt.Sprintf("page %d", i)), 0755))
+	}
+
+	counting := &openCountingFs{Fs: mfs}
+	fs := NewPrefetchFs(counting, 3)
+
+	fis, err := afero.ReadDir(fs, "/content")
+	assert.NoError(err)
+	assert.Len(fis, 10)
+
+	for i := 0; i < 10; i++ {
+		b, err := afero.ReadFile(fs, fmt.Sprintf("/content/p%d.md", i))
+		assert.NoError(err)
+		assert.Equal(fmt.Sprintf("page %d", i), string(b))
+	}
+
+	// One open to prefetch and one to serve the file.
+	assert.Equal(int64(20), atomic.LoadInt64(&counting.opens))
+
+	fs.mu.Lock()
+	assert.Len(fs.entries, 0)
+	assert.Len(fs.pending, 0)
+	fs.mu.Unlock()
+
+	// A file changed after it was prefetched is read again.
+	_, err = afero.ReadDir(fs, "/content")
+	assert.NoError(err)
+	fs.mu.Lock()
+	e := fs.entries["/content/p0.md"]
+	fs.mu.Unlock()
+	<-e.done
+	assert.NoError(afero.WriteFile(mfs, "/content/p0.md", []byte("changed"), 0755))
+	assert.NoError(mfs.Chtimes("/content/p0.md", time.Now(), time.Now().Add(time.Hour)))
+
+	b, err := afero.ReadFile(fs, "/content/p0.md")
+	assert.NoError(err)
+	assert.Equal("changed", string(b))
+
+	// Entries from a listing never read give room to the next listing.
+	_, err = afero.ReadDir(fs, "/content")
+	assert.NoError(err)
+	fs.mu.Lock()
+	assert.Len(fs.entries, 3)
+	fs.mu.Unlock()
+}
+
+func TestPrefetchFsLstat(t *testing.T) {
+	testLstatsSymlinks(t, func(base afero.Fs) afero.Fs { return NewPrefetchFs(base, 10) })
+}
@@ -0,0 +1,101 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugofs
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/afero"
+)
+
+var _ afero.Fs = (*PublishTarget)(nil)
+
+// PublishTarget is the filesystem Hugo publishes to: either the disk, or,
+// e.g. in server mode, memory, so previews do not touch the publish dir.
+// A site rendered to memory can be promoted to disk on demand.
+type PublishTarget struct {
+	afero.Fs
+
+	disk       afero.Fs
+	publishDir string
+	inMemory   bool
+}
+
+// NewPublishTarget creates a new PublishTarget. If inMemory is set, the files
+// are written to memory with the publish dir as the root, else to publishDir
+// in disk.
+func NewPublishTarget(disk afero.Fs, publishDir string, inMemory bool) *PublishTarget {
+	t := &PublishTarget{Fs: disk, disk: disk, publishDir: publishDir, inMemory: inMemory}
+	if inMemory {
+		t.Fs = afero.NewMemMapFs()
+	}
+	return t
+}
+
+// InMemory returns whether the files are written to memory.
+func (t *PublishTarget) InMemory() bool {
+	return t.inMemory
+}
+
+// Promote copies the files written to memory to the publish dir on disk.
+// This is a no-op if the files are already written to disk.
+func (t *PublishTarget) Promote() error {
+	if !t.inMemory {
+		return nil
+	}
+
+	return afero.Walk(t.Fs, "/", func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		target := filepath.Join(t.publishDir, path)
+
+		if info.IsDir() {
+			return t.disk.MkdirAll(target, 0777)
+		}
+
+		return t.copyFile(path, target)
+	})
+}
+
+func (t *PublishTarget) copyFile(from, to string) error {
+	src, err := t.Fs.Open(from)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	if err := t.disk.MkdirAll(filepath.Dir(to), 0777); err != nil {
+		return err
+	}
+
+	dst, err := t.disk.Create(to)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return err
+	}
+
+	return dst.Close()
+}
+
+func (t *PublishTarget) Name() string {
+	return "PublishTarget"
+}
@@ -0,0 +1,54 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugofs
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPublishTarget(t *testing.T) {
+	assert := require.New(t)
+
+	disk := afero.NewMemMapFs()
+	publishDir := filepath.FromSlash("/my/public")
+
+	target := NewPublishTarget(disk, publishDir, true)
+	assert.True(target.InMemory())
+
+	assert.NoError(afero.WriteFile(target, filepath.FromSlash("/index.html"), []byte("home"), 0755))
+	assert.NoError(afero.WriteFile(target, filepath.FromSlash("/posts/p1/index.html"), []byte("p1"), 0755))
+
+	exists, _ := afero.Exists(disk, filepath.Join(publishDir, "index.html"))
+	assert.False(exists)
+
+	assert.NoError(target.Promote())
+
+	b, err := afero.ReadFile(disk, filepath.Join(publishDir, "posts", "p1", "index.html"))
+	assert.NoError(err)
+	assert.Equal("p1", string(b))
+	b, err = afero.ReadFile(disk, filepath.Join(publishDir, "index.html"))
+	assert.NoError(err)
+	assert.Equal("home", string(b))
+
+	target = NewPublishTarget(disk, publishDir, false)
+	assert.False(target.InMemory())
+	assert.NoError(afero.WriteFile(target, filepath.Join(publishDir, "about.html"), []byte("about"), 0755))
+	exists, _ = afero.Exists(disk, filepath.Join(publishDir, "about.html"))
+	assert.True(exists)
+	assert.NoError(target.Promote())
+}
@@ -0,0 +1,175 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugofs
+
+import (
+	"os"
+	"sync/atomic"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/afero"
+)
+
+var (
+	_ afero.Fs            = (*QuotaFs)(nil)
+	_ afero.Lstater       = (*QuotaFs)(nil)
+	_ FilesystemUnwrapper = (*QuotaFs)(nil)
+	_ afero.File          = (*quotaFile)(nil)
+	_ Reseter             = (*QuotaFs)(nil)
+)
+
+// ErrQuotaExceeded is the cause of the errors returned by a QuotaFs when
+// one of its limits is exceeded.
+var ErrQuotaExceeded = errors.New("read quota exceeded")
+
+// Quota holds the limits enforced by a QuotaFs. A zero value means no limit.
+type Quota struct {
+	// The max number of bytes read.
+	MaxBytes int64
+
+	// The max number of files opened for reading.
+	MaxFiles int64
+}
+
+// QuotaFs limits the number of bytes read and the number of files opened
+// for reading from the delegate filesystem, e.g. to stop a runaway build
+// early on a hosted build service. The counters are shared by all
+// goroutines and are reset with Reset, e.g. before a new build.
+type QuotaFs struct {
+	afero.Fs
+
+	quota Quota
+
+	files int64
+	bytes int64
+}
+
+// NewQuotaFs creates a new QuotaFs enforcing quota on delegate.
+func NewQuotaFs(delegate afero.Fs, quota Quota) *QuotaFs {
+	return &QuotaFs{Fs: delegate, quota: quota}
+}
+
+// Usage returns the number of files opened and bytes read since the last
+// Reset.
+func (fs *QuotaFs) Usage() (files, bytes int64) {
+	return atomic.LoadInt64(&fs.files), atomic.LoadInt64(&fs.bytes)
+}
+
+// Reset resets the counters, e.g. before a new build.
+func (fs *QuotaFs) Reset() {
+	atomic.StoreInt64(&fs.files, 0)
+	atomic.StoreInt64(&fs.bytes, 0)
+}
+
+// UnwrapFilesystem returns the decorated filesystem.
+func (fs *QuotaFs) UnwrapFilesystem() afero.Fs {
+	return fs.Fs
+}
+
+func (fs *QuotaFs) Name() string {
+	return "QuotaFs"
+}
+
+// LstatIfPossible returns the os.FileInfo structure describing a given file.
+// It uses Lstat if supported by the wrapped filesystem.
+func (fs *QuotaFs) LstatIfPossible(name string) (os.FileInfo, bool, error) {
+	if lstater, ok := fs.Fs.(afero.Lstater); ok {
+		return lstater.LstatIfPossible(name)
+	}
+	fi, err := fs.Fs.Stat(name)
+	return fi, false, err
+}
+
+func (fs *QuotaFs) Open(name string) (afero.File, error) {
+	f, err := fs.Fs.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return fs.wrap(name, f)
+}
+
+func (fs *QuotaFs) OpenFile(name string, flag int, perm os.FileMode) (afero.File, error) {
+	f, err := fs.Fs.OpenFile(name, flag, perm)
+	if err != nil || isWrite(flag) {
+		return f, err
+	}
+	return fs.wrap(name, f)
+}
+
+func (fs *QuotaFs) wrap(name string, f afero.File) (afero.File, error) {
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	if fi.IsDir() {
+		// Directories are not counted.
+		return f, nil
+	}
+
+	files := atomic.AddInt64(&fs.files, 1)
+	if fs.quota.MaxFiles > 0 && files > fs.quota.MaxFiles {
+		f.Close()
+		return nil, fs.exceeded(name, "files", fs.quota.MaxFiles)
+	}
+
+	return &quotaFile{File: f, fs: fs}, nil
+}
+
+// addBytes records n bytes read from name and returns an error if that
+// exceeds the quota.
+func (fs *QuotaFs) addBytes(name string, n int) error {
+	if n <= 0 {
+		return nil
+	}
+	bytes := atomic.AddInt64(&fs.bytes, int64(n))
+	if fs.quota.MaxBytes > 0 && bytes > fs.quota.MaxBytes {
+		return fs.exceeded(name, "bytes", fs.quota.MaxBytes)
+	}
+	return nil
+}
+
+func (fs *QuotaFs) exceeded(name, what string, max int64) error {
+	return &os.PathError{Op: "read", Path: name, Err: errors.Wrapf(ErrQuotaExceeded, "the build read more than %d %s", max, what)}
+}
+
+// IsQuotaExceeded returns whether err was caused by a QuotaFs limit.
+func IsQuotaExceeded(err error) bool {
+	if pe, ok := err.(*os.PathError); ok {
+		err = pe.Err
+	}
+	return errors.Cause(err) == ErrQuotaExceeded
+}
+
+type quotaFile struct {
+	afero.File
+	fs *QuotaFs
+}
+
+func (f *quotaFile) Read(p []byte) (int, error) {
+	n, err := f.File.Read(p)
+	if qerr := f.fs.addBytes(f.Name(), n); qerr != nil {
+		return n, qerr
+	}
+	return n, err
+}
+
+func (f *quotaFile) ReadAt(p []byte, off int64) (int, error) {
+	n, err := f.File.ReadAt(p, off)
+	if qerr := f.fs.addBytes(f.Name(), n); qerr != nil {
+		return n, qerr
+	}
+	return n, err
+}
@@ -0,0 +1,114 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugofs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuotaFs(t *testing.T) {
+	assert := require.New(t)
+
+	mfs := afero.NewMemMapFs()
+	for _, name := range []string{"a.txt", "b.txt", "c.txt"} {
+		assert.NoError(afero.WriteFile(mfs, "/content/"+name, []byte("abcdefghij"), 0755))
+	}
+
+	fs := NewQuotaFs(mfs, Quota{MaxBytes: 25, MaxFiles: 2})
+
+	// Directories are not counted.
+	dirs, err := afero.ReadDir(fs, "/content")
+	assert.NoError(err)
+	assert.Len(dirs, 3)
+
+	b, err := afero.ReadFile(fs, "/content/a.txt")
+	assert.NoError(err)
+	assert.Equal("abcdefghij", string(b))
+
+	_, err = afero.ReadFile(fs, "/content/b.txt")
+	assert.NoError(err)
+
+	files, bytes := fs.Usage()
+	assert.Equal(int64(2), files)
+	assert.Equal(int64(20), bytes)
+
+	_, err = fs.Open("/content/c.txt")
+	assert.Error(err)
+	assert.True(IsQuotaExceeded(err))
+	assert.Contains(err.Error(), "more than 2 files")
+
+	fs.Reset()
+
+	fs = NewQuotaFs(mfs, Quota{MaxBytes: 15})
+	_, err = afero.ReadFile(fs, "/content/a.txt")
+	assert.NoError(err)
+	_, err = afero.ReadFile(fs, "/content/b.txt")
+	assert.Error(err)
+	assert.True(IsQuotaExceeded(err))
+	assert.Contains(err.Error(), "more than 15 bytes")
+
+	fs.Reset()
+	_, err = afero.ReadFile(fs, "/content/b.txt")
+	assert.NoError(err)
+
+	// Writes are not counted.
+	f, err := fs.Create("/content/d.txt")
+	assert.NoError(err)
+	_, err = f.Write([]byte("0123456789012345"))
+	assert.NoError(err)
+	assert.NoError(f.Close())
+
+	_, bytes = fs.Usage()
+	assert.Equal(int64(10), bytes)
+
+	assert.False(IsQuotaExceeded(nil))
+}
+
+func TestQuotaFsLstat(t *testing.T) {
+	testLstatsSymlinks(t, func(base afero.Fs) afero.Fs { return NewQuotaFs(base, Quota{}) })
+}
+
+// testLstatsSymlinks checks that the filesystem created with newFs does not
+// follow symlinks in LstatIfPossible, so they are handled by e.g. the
+// content capturer.
+func testLstatsSymlinks(t *testing.T, newFs func(base afero.Fs) afero.Fs) {
+	if runtime.GOOS == "windows" {
+		t.Skip("skip symlink test on Windows")
+	}
+
+	assert := require.New(t)
+
+	dir, err := ioutil.TempDir("", "hugofs-lstat")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	assert.NoError(ioutil.WriteFile(filepath.Join(dir, "a.md"), []byte("a"), 0755))
+	assert.NoError(os.Symlink(filepath.Join(dir, "a.md"), filepath.Join(dir, "link.md")))
+
+	fs := newFs(afero.NewBasePathFs(afero.NewOsFs(), dir))
+	lstater, ok := fs.(afero.Lstater)
+	assert.True(ok)
+
+	fi, lstatCalled, err := lstater.LstatIfPossible(filepath.FromSlash("/link.md"))
+	assert.NoError(err)
+	assert.True(lstatCalled)
+	assert.True(fi.Mode()&os.ModeSymlink != 0)
+}
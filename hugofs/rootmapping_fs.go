@@ -14,6 +14,7 @@
 package hugofs
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"strings"
@@ -27,16 +28,20 @@ var filepathSeparator = string(filepath.Separator)
 
 // A RootMappingFs maps several roots into one. Note that the root of this filesystem
 // is directories only, and they will be returned in Readdir and Readdirnames
-// in the order given.
+// in the order given. A virtual root may be nested, e.g. "vendor/libfoo", in
+// which case its parent directories are virtual directories listing their
+// children in the same order.
 type RootMappingFs struct {
 	afero.Fs
 	rootMapToReal *radix.Node
 	virtualRoots  []string
+	logger        Logger
 }
 
 type rootMappingFile struct {
 	afero.File
 	fs   *RootMappingFs
+	rm   RootMapping
 	name string
 }
 
@@ -49,15 +54,17 @@ func (fi *rootMappingFileInfo) Name() string {
 }
 
 func (fi *rootMappingFileInfo) Size() int64 {
-	panic("not implemented")
+	return 0
 }
 
 func (fi *rootMappingFileInfo) Mode() os.FileMode {
 	return os.ModeDir
 }
 
+// ModTime returns the zero time, the virtual directories have no
+// modification time.
 func (fi *rootMappingFileInfo) ModTime() time.Time {
-	panic("not implemented")
+	return time.Time{}
 }
 
 func (fi *rootMappingFileInfo) IsDir() bool {
@@ -72,42 +79,123 @@ func newRootMappingDirFileInfo(name string) *rootMappingFileInfo {
 	return &rootMappingFileInfo{name: name}
 }
 
+// RootMapping maps a virtual root to a real filename.
+type RootMapping struct {
+	// The virtual root, e.g. "content".
+	From string
+
+	// The real filename the virtual root maps to.
+	To string
+
+	// Optional filesystem holding To. If not set, the filesystem the
+	// RootMappingFs is created on is used. This allows the mappings to point
+	// into different backends, e.g. an in-memory filesystem and the OS.
+	Fs afero.Fs
+
+	// Optional modification time reported for all the files and directories
+	// in this mapping. This is useful for generated or downloaded files with
+	// meaningless modification times, so caching and lastmod work as
+	// expected.
+	ModTime time.Time
+}
+
+// applyModTime returns fi with the modification time set in rm, if any.
+func (rm RootMapping) applyModTime(fi os.FileInfo) os.FileInfo {
+	if rm.ModTime.IsZero() {
+		return fi
+	}
+	switch v := fi.(type) {
+	case *realFilenameInfo:
+		vv := *v
+		vv.FileInfo = &modTimeFileInfo{FileInfo: v.FileInfo, modTime: rm.ModTime}
+		return &vv
+	case RealFilenameInfo:
+		return &realFilenameInfo{FileInfo: &modTimeFileInfo{FileInfo: v, modTime: rm.ModTime}, realFilename: v.RealFilename()}
+	default:
+		return &modTimeFileInfo{FileInfo: fi, modTime: rm.ModTime}
+	}
+}
+
+type modTimeFileInfo struct {
+	os.FileInfo
+	modTime time.Time
+}
+
+func (fi *modTimeFileInfo) ModTime() time.Time {
+	return fi.modTime
+}
+
 // NewRootMappingFs creates a new RootMappingFs on top of the provided with
 // a list of from, to string pairs of root mappings.
 // Note that 'from' represents a virtual root that maps to the actual filename in 'to'.
 func NewRootMappingFs(fs afero.Fs, fromTo ...string) (*RootMappingFs, error) {
+	var mappings []RootMapping
+	for i := 0; i < len(fromTo); i += 2 {
+		mappings = append(mappings, RootMapping{From: fromTo[i], To: fromTo[i+1]})
+	}
+	return NewRootMappingFsFromMappings(fs, mappings...)
+}
+
+// NewRootMappingFsFromMappings creates a new RootMappingFs on top of the
+// provided with the given root mappings, in order.
+func NewRootMappingFsFromMappings(fs afero.Fs, mappings ...RootMapping) (*RootMappingFs, error) {
 	rootMapToReal := radix.New().Txn()
 	var virtualRoots []string
 
-	for i := 0; i < len(fromTo); i += 2 {
-		vr := filepath.Clean(fromTo[i])
-		rr := filepath.Clean(fromTo[i+1])
+	for _, rm := range mappings {
+		rm.From = filepath.Clean(rm.From)
+		rm.To = filepath.Clean(rm.To)
+		if rm.Fs == nil {
+			rm.Fs = fs
+		}
 
 		// We need to preserve the original order for Readdir
-		virtualRoots = append(virtualRoots, vr)
+		virtualRoots = append(virtualRoots, rm.From)
 
-		rootMapToReal.Insert([]byte(vr), rr)
+		rootMapToReal.Insert([]byte(rm.From), rm)
 	}
 
 	return &RootMappingFs{Fs: fs,
 		virtualRoots:  virtualRoots,
+		logger:        NopLogger,
 		rootMapToReal: rootMapToReal.Commit().Root()}, nil
 }
 
+// WithLogger sets the logger to send the diagnostics of this filesystem to.
+// Any failure other than a file not found is logged as a warning, everything
+// else at debug level.
+func (fs *RootMappingFs) WithLogger(logger Logger) *RootMappingFs {
+	fs.logger = loggerOrNop(logger)
+	return fs
+}
+
 // Stat returns the os.FileInfo structure describing a given file.  If there is
 // an error, it will be of type *os.PathError.
 func (fs *RootMappingFs) Stat(name string) (os.FileInfo, error) {
-	if fs.isRoot(name) {
+	return fs.StatContext(context.Background(), name)
+}
+
+// StatContext is Stat, giving up when ctx is done.
+func (fs *RootMappingFs) StatContext(ctx context.Context, name string) (os.FileInfo, error) {
+	if _, ok := fs.virtualDir(name); ok {
 		return newRootMappingDirFileInfo(name), nil
 	}
-	realName := fs.realName(name)
+	rm, realName := fs.realName(name)
 
-	fi, err := fs.Fs.Stat(realName)
+	start := time.Now()
+	fi, err := StatContext(ctx, rm.Fs, realName)
+	fs.logOp("stat", name, realName, start, err)
+	if err != nil {
+		return nil, err
+	}
+	if fs.isVirtualRoot(name) {
+		return rm.applyModTime(fs.virtualRootInfo(name, fi, realName)), nil
+	}
 	if rfi, ok := fi.(RealFilenameInfo); ok {
-		return rfi, err
+		return rm.applyModTime(rfi), nil
 	}
 
-	return &realFilenameInfo{FileInfo: fi, realFilename: realName}, err
+	return rm.applyModTime(&realFilenameInfo{FileInfo: fi, realFilename: realName}), nil
 
 }
 
@@ -116,17 +204,97 @@ func (fs *RootMappingFs) isRoot(name string) bool {
 
 }
 
+// virtualDir returns the names of the entries in the virtual directory name,
+// in the order the virtual roots were given, and whether name is a virtual
+// directory at all. The virtual directories are the root of this filesystem
+// and the parents of the nested virtual roots, e.g. "vendor" for
+// "vendor/libfoo", unless they are inside another root mapping.
+func (fs *RootMappingFs) virtualDir(name string) ([]string, bool) {
+	var prefix string
+	if !fs.isRoot(name) {
+		name = filepath.Clean(name)
+		if _, _, found := fs.mappingFor(name); found {
+			return nil, false
+		}
+		prefix = name + filepathSeparator
+	}
+
+	var (
+		names []string
+		seen  = make(map[string]bool)
+	)
+
+	for _, root := range fs.virtualRoots {
+		if !strings.HasPrefix(root, prefix) {
+			continue
+		}
+		child := strings.TrimPrefix(root, prefix)
+		if i := strings.Index(child, filepathSeparator); i != -1 {
+			child = child[:i]
+		}
+		if !seen[child] {
+			seen[child] = true
+			names = append(names, child)
+		}
+	}
+
+	if prefix != "" && len(names) == 0 {
+		return nil, false
+	}
+
+	return names, true
+}
+
+// virtualDirEntry returns the FileInfo of the entry with the given name in
+// the virtual directory dir. Virtual roots are described by what they map to,
+// which may be a file, anything else is a virtual directory.
+func (fs *RootMappingFs) virtualDirEntry(dir, name string) os.FileInfo {
+	filename := name
+	if !fs.isRoot(dir) {
+		filename = filepath.Join(dir, name)
+	}
+	if fs.isVirtualRoot(filename) {
+		if fi, err := fs.Stat(filename); err == nil {
+			return fi
+		}
+	}
+	return newRootMappingDirFileInfo(name)
+}
+
+// isVirtualRoot reports whether name is one of the virtual roots, e.g. "content".
+func (fs *RootMappingFs) isVirtualRoot(name string) bool {
+	_, found := fs.rootMapToReal.Get([]byte(filepath.Clean(name)))
+	return found
+}
+
+// virtualRootInfo returns fi, the FileInfo of the real directory, under the
+// virtual name, as listed in the root of this filesystem.
+func (fs *RootMappingFs) virtualRootInfo(name string, fi os.FileInfo, realName string) os.FileInfo {
+	if rfi, ok := fi.(RealFilenameInfo); ok {
+		realName = rfi.RealFilename()
+	}
+	return &realFilenameInfo{FileInfo: fi, realFilename: realName, name: filepath.Base(filepath.Clean(name))}
+}
+
 // Open opens the named file for reading.
 func (fs *RootMappingFs) Open(name string) (afero.File, error) {
-	if fs.isRoot(name) {
+	return fs.OpenContext(context.Background(), name)
+}
+
+// OpenContext is Open, giving up when ctx is done.
+func (fs *RootMappingFs) OpenContext(ctx context.Context, name string) (afero.File, error) {
+	if _, ok := fs.virtualDir(name); ok {
 		return &rootMappingFile{name: name, fs: fs}, nil
 	}
-	realName := fs.realName(name)
-	f, err := fs.Fs.Open(realName)
+	rm, realName := fs.realName(name)
+
+	start := time.Now()
+	f, err := OpenContext(ctx, rm.Fs, realName)
+	fs.logOp("open", name, realName, start, err)
 	if err != nil {
 		return nil, err
 	}
-	return &rootMappingFile{File: f, name: name, fs: fs}, nil
+	return &rootMappingFile{File: f, name: name, fs: fs, rm: rm}, nil
 }
 
 // LstatIfPossible returns the os.FileInfo structure describing a given file.
@@ -134,42 +302,100 @@ func (fs *RootMappingFs) Open(name string) (afero.File, error) {
 // the FileInfo, a boolean is returned telling whether Lstat was called.
 func (fs *RootMappingFs) LstatIfPossible(name string) (os.FileInfo, bool, error) {
 
-	if fs.isRoot(name) {
+	if _, ok := fs.virtualDir(name); ok {
 		return newRootMappingDirFileInfo(name), false, nil
 	}
-	name = fs.realName(name)
-
-	if ls, ok := fs.Fs.(afero.Lstater); ok {
-		fi, b, err := ls.LstatIfPossible(name)
-		return &realFilenameInfo{FileInfo: fi, realFilename: name}, b, err
+	rm, realName := fs.realName(name)
+
+	if ls, ok := rm.Fs.(afero.Lstater); ok {
+		start := time.Now()
+		fi, b, err := ls.LstatIfPossible(realName)
+		fs.logOp("lstat", name, realName, start, err)
+		if err != nil {
+			return nil, b, err
+		}
+		if fs.isVirtualRoot(name) {
+			return rm.applyModTime(fs.virtualRootInfo(name, fi, realName)), b, nil
+		}
+		return rm.applyModTime(&realFilenameInfo{FileInfo: fi, realFilename: realName}), b, nil
 	}
 	fi, err := fs.Stat(name)
 	return fi, false, err
 }
 
-func (fs *RootMappingFs) realName(name string) string {
-	key, val, found := fs.rootMapToReal.LongestPrefix([]byte(filepath.Clean(name)))
+func (fs *RootMappingFs) logOp(op, name, realName string, start time.Time, err error) {
+	if fs.logger == NopLogger {
+		return
+	}
+	if err != nil && !os.IsNotExist(err) {
+		fs.logger.Log(LogLevelWarn, "root mapping failed", LogFieldOp, op, LogFieldPath, name, LogFieldMount, realName, LogFieldError, err)
+		return
+	}
+	fs.logger.Log(LogLevelDebug, "root mapping", LogFieldOp, op, LogFieldPath, name, LogFieldMount, realName, LogFieldDuration, time.Since(start))
+}
+
+// realName returns the root mapping holding name and the real filename of
+// name in the mapping's filesystem.
+func (fs *RootMappingFs) realName(name string) (RootMapping, string) {
+	name = filepath.Clean(name)
+	rm, from, found := fs.mappingFor(name)
 	if !found {
-		return name
+		return RootMapping{Fs: fs.Fs}, name
 	}
-	keystr := string(key)
 
-	return filepath.Join(val.(string), strings.TrimPrefix(name, keystr))
+	return rm, filepath.Join(rm.To, strings.TrimPrefix(name, from))
+}
+
+// mappingFor returns the root mapping with the longest virtual root holding
+// the clean name, i.e. name is the virtual root or below it.
+func (fs *RootMappingFs) mappingFor(name string) (rm RootMapping, from string, found bool) {
+	fs.rootMapToReal.WalkPath([]byte(name), func(k []byte, v interface{}) bool {
+		key := string(k)
+		if key == name || strings.HasPrefix(name, key+filepathSeparator) {
+			rm, from, found = v.(RootMapping), key, true
+		}
+		return false
+	})
+	return
 }
 
 func (f *rootMappingFile) Readdir(count int) ([]os.FileInfo, error) {
+	return f.ReaddirContext(context.Background(), count)
+}
+
+// ReaddirContext is Readdir, giving up when ctx is done.
+func (f *rootMappingFile) ReaddirContext(ctx context.Context, count int) ([]os.FileInfo, error) {
 	if f.File == nil {
+		names, _ := f.fs.virtualDir(f.name)
 		dirsn := make([]os.FileInfo, 0)
-		for i := 0; i < len(f.fs.virtualRoots); i++ {
+		for i := 0; i < len(names); i++ {
 			if count != -1 && i >= count {
 				break
 			}
-			dirsn = append(dirsn, newRootMappingDirFileInfo(f.fs.virtualRoots[i]))
+			dirsn = append(dirsn, f.fs.virtualDirEntry(f.name, names[i]))
 		}
 		return dirsn, nil
 	}
-	return f.File.Readdir(count)
+	fis, err := ReaddirContext(ctx, f.File, count)
+	if err != nil || f.rm.ModTime.IsZero() {
+		return fis, err
+	}
+	for i, fi := range fis {
+		fis[i] = f.rm.applyModTime(fi)
+	}
+	return fis, nil
+
+}
 
+func (f *rootMappingFile) Stat() (os.FileInfo, error) {
+	if f.File == nil {
+		return newRootMappingDirFileInfo(f.name), nil
+	}
+	fi, err := f.File.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return f.rm.applyModTime(fi), nil
 }
 
 func (f *rootMappingFile) Readdirnames(count int) ([]string, error) {
@@ -184,6 +410,69 @@ func (f *rootMappingFile) Readdirnames(count int) ([]string, error) {
 	return dirss, nil
 }
 
+// The file operations below are delegated to the real file. The virtual
+// directories have none, so they are not supported there.
+
+func (f *rootMappingFile) unsupported(op string) error {
+	return &ErrUnsupported{Op: op, Type: "RootMappingFs virtual directory", Path: f.name}
+}
+
+func (f *rootMappingFile) Read(p []byte) (int, error) {
+	if f.File == nil {
+		return 0, f.unsupported("read")
+	}
+	return f.File.Read(p)
+}
+
+func (f *rootMappingFile) ReadAt(p []byte, off int64) (int, error) {
+	if f.File == nil {
+		return 0, f.unsupported("read")
+	}
+	return f.File.ReadAt(p, off)
+}
+
+func (f *rootMappingFile) Seek(offset int64, whence int) (int64, error) {
+	if f.File == nil {
+		return 0, f.unsupported("seek")
+	}
+	return f.File.Seek(offset, whence)
+}
+
+func (f *rootMappingFile) Write(p []byte) (int, error) {
+	if f.File == nil {
+		return 0, f.unsupported("write")
+	}
+	return f.File.Write(p)
+}
+
+func (f *rootMappingFile) WriteAt(p []byte, off int64) (int, error) {
+	if f.File == nil {
+		return 0, f.unsupported("write")
+	}
+	return f.File.WriteAt(p, off)
+}
+
+func (f *rootMappingFile) WriteString(s string) (int, error) {
+	if f.File == nil {
+		return 0, f.unsupported("write")
+	}
+	return f.File.WriteString(s)
+}
+
+func (f *rootMappingFile) Sync() error {
+	if f.File == nil {
+		return f.unsupported("sync")
+	}
+	return f.File.Sync()
+}
+
+func (f *rootMappingFile) Truncate(size int64) error {
+	if f.File == nil {
+		return f.unsupported("truncate")
+	}
+	return f.File.Truncate(size)
+}
+
 func (f *rootMappingFile) Name() string {
 	return f.name
 }
@@ -18,6 +18,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/spf13/afero"
 	"github.com/stretchr/testify/require"
@@ -30,10 +31,61 @@ func TestRootMappingFsRealName(t *testing.T) {
 	rfs, err := NewRootMappingFs(fs, "f1", "f1t", "f2", "f2t")
 	assert.NoError(err)
 
-	assert.Equal(filepath.FromSlash("f1t/foo/file.txt"), rfs.realName(filepath.Join("f1", "foo", "file.txt")))
+	rm, realName := rfs.realName(filepath.Join("f1", "foo", "file.txt"))
+	assert.Equal(fs, rm.Fs)
+	assert.Equal(filepath.FromSlash("f1t/foo/file.txt"), realName)
 
 }
 
+func TestRootMappingFsPerMappingFs(t *testing.T) {
+	assert := require.New(t)
+	fs := afero.NewMemMapFs()
+	fs1 := afero.NewMemMapFs()
+	fs2 := afero.NewMemMapFs()
+
+	assert.NoError(afero.WriteFile(fs, filepath.Join("d", "file.txt"), []byte("default"), 0755))
+	assert.NoError(afero.WriteFile(fs1, filepath.Join("d", "file.txt"), []byte("fs1"), 0755))
+	assert.NoError(afero.WriteFile(fs2, filepath.Join("other", "file.txt"), []byte("fs2"), 0755))
+
+	rfs, err := NewRootMappingFsFromMappings(fs,
+		RootMapping{From: "a", To: "d"},
+		RootMapping{From: "b", To: "d", Fs: fs1},
+		RootMapping{From: "c", To: "other", Fs: fs2},
+	)
+	assert.NoError(err)
+
+	for _, test := range []struct {
+		dir    string
+		expect string
+	}{
+		{"a", "default"},
+		{"b", "fs1"},
+		{"c", "fs2"},
+	} {
+		b, err := afero.ReadFile(rfs, filepath.Join(test.dir, "file.txt"))
+		assert.NoError(err)
+		assert.Equal(test.expect, string(b))
+
+		fi, err := rfs.Stat(filepath.Join(test.dir, "file.txt"))
+		assert.NoError(err)
+		assert.Equal(int64(len(test.expect)), fi.Size())
+
+		fi, _, err = rfs.LstatIfPossible(test.dir)
+		assert.NoError(err)
+		assert.True(fi.IsDir())
+		assert.Equal(test.dir, fi.Name())
+	}
+
+	_, err = rfs.Stat(filepath.Join("c", "missing.txt"))
+	assert.True(os.IsNotExist(err))
+
+	root, err := rfs.Open(filepathSeparator)
+	assert.NoError(err)
+	dirnames, err := root.Readdirnames(-1)
+	assert.NoError(err)
+	assert.Equal([]string{"a", "b", "c"}, dirnames)
+}
+
 func TestRootMappingFsDirnames(t *testing.T) {
 	assert := require.New(t)
 	fs := afero.NewMemMapFs()
@@ -92,3 +144,127 @@ func TestRootMappingFsOs(t *testing.T) {
 	assert.Equal([]string{"bf1", "cf2", "af3"}, dirnames)
 
 }
+
+func TestRootMappingFsModTime(t *testing.T) {
+	assert := require.New(t)
+	fs := afero.NewMemMapFs()
+
+	assert.NoError(afero.WriteFile(fs, filepath.Join("d1", "sub", "file.txt"), []byte("content"), 0755))
+	assert.NoError(afero.WriteFile(fs, filepath.Join("d2", "file.txt"), []byte("content"), 0755))
+
+	mounted := time.Date(2019, time.May, 1, 0, 0, 0, 0, time.UTC)
+
+	rfs, err := NewRootMappingFsFromMappings(fs,
+		RootMapping{From: "a", To: "d1", ModTime: mounted},
+		RootMapping{From: "b", To: "d2"},
+	)
+	assert.NoError(err)
+
+	for _, name := range []string{"a", filepath.Join("a", "sub"), filepath.Join("a", "sub", "file.txt")} {
+		fi, err := rfs.Stat(name)
+		assert.NoError(err)
+		assert.Equal(mounted, fi.ModTime(), name)
+
+		fi, _, err = rfs.LstatIfPossible(name)
+		assert.NoError(err)
+		assert.Equal(mounted, fi.ModTime(), name)
+	}
+
+	fi, err := rfs.Stat(filepath.Join("a", "sub", "file.txt"))
+	assert.NoError(err)
+	assert.Equal("file.txt", fi.Name())
+	assert.Equal(int64(7), fi.Size())
+	assert.Equal(filepath.FromSlash("d1/sub/file.txt"), fi.(RealFilenameInfo).RealFilename())
+
+	fi, err = rfs.Stat("a")
+	assert.NoError(err)
+	assert.Equal("a", fi.Name())
+
+	f, err := rfs.Open(filepath.Join("a", "sub"))
+	assert.NoError(err)
+	fis, err := f.Readdir(-1)
+	assert.NoError(err)
+	assert.Len(fis, 1)
+	assert.Equal(mounted, fis[0].ModTime())
+	fi, err = f.Stat()
+	assert.NoError(err)
+	assert.Equal(mounted, fi.ModTime())
+	f.Close()
+
+	fi, err = rfs.Stat(filepath.Join("b", "file.txt"))
+	assert.NoError(err)
+	assert.NotEqual(mounted, fi.ModTime())
+}
+
+func TestRootMappingFsNested(t *testing.T) {
+	assert := require.New(t)
+	fs := afero.NewMemMapFs()
+
+	assert.NoError(afero.WriteFile(fs, filepath.FromSlash("libfoo/dist/js/foo.js"), []byte("foo"), 0755))
+	assert.NoError(afero.WriteFile(fs, filepath.FromSlash("libbar/bar.js"), []byte("bar"), 0755))
+	assert.NoError(afero.WriteFile(fs, filepath.FromSlash("css/main.css"), []byte("main"), 0755))
+
+	rfs, err := NewRootMappingFs(fs,
+		filepath.FromSlash("vendor/libfoo"), filepath.FromSlash("libfoo/dist/js"),
+		filepath.FromSlash("vendor/libbar"), "libbar",
+		"css", "css",
+	)
+	assert.NoError(err)
+
+	names, err := afero.ReadDir(rfs, "")
+	assert.NoError(err)
+	assert.Len(names, 2)
+	assert.Equal("css", names[0].Name())
+	assert.Equal("vendor", names[1].Name())
+
+	fi, err := rfs.Stat("vendor")
+	assert.NoError(err)
+	assert.True(fi.IsDir())
+
+	names, err = afero.ReadDir(rfs, "vendor")
+	assert.NoError(err)
+	assert.Len(names, 2)
+	assert.Equal("libbar", names[0].Name())
+	assert.Equal("libfoo", names[1].Name())
+
+	b, err := afero.ReadFile(rfs, filepath.FromSlash("vendor/libfoo/foo.js"))
+	assert.NoError(err)
+	assert.Equal("foo", string(b))
+
+	fi, err = rfs.Stat(filepath.FromSlash("vendor/libfoo"))
+	assert.NoError(err)
+	assert.True(fi.IsDir())
+	assert.Equal("libfoo", fi.Name())
+
+	// A virtual root must match whole path elements.
+	_, err = rfs.Stat(filepath.FromSlash("vendor/libfooo/foo.js"))
+	assert.True(os.IsNotExist(err))
+
+	_, err = rfs.Stat(filepath.FromSlash("vendor/libbaz"))
+	assert.True(os.IsNotExist(err))
+}
+
+func TestRootMappingFsVirtualDirUnsupported(t *testing.T) {
+	assert := require.New(t)
+	fs := afero.NewMemMapFs()
+
+	rfs, err := NewRootMappingFs(fs, filepath.FromSlash("v/w"), "dir")
+	assert.NoError(err)
+
+	fi, err := rfs.Stat("v")
+	assert.NoError(err)
+	assert.True(fi.ModTime().IsZero())
+	assert.Equal(int64(0), fi.Size())
+
+	f, err := rfs.Open("v")
+	assert.NoError(err)
+	defer f.Close()
+
+	_, err = f.Read(make([]byte, 1))
+	assert.True(IsUnsupported(err))
+	assert.Equal(`read v: operation not supported by RootMappingFs virtual directory`, err.Error())
+	_, err = f.WriteString("a")
+	assert.True(IsUnsupported(err))
+
+	assert.True(IsUnsupported(NoOpFs.Remove("foo")))
+}
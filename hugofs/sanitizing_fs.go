@@ -0,0 +1,199 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugofs
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/afero"
+)
+
+var (
+	_ afero.Fs      = (*sanitizingFs)(nil)
+	_ afero.Lstater = (*sanitizingFs)(nil)
+)
+
+// The errors returned by the filesystem created with NewSanitizingFs, wrapped
+// in an *os.PathError. See IsUnsafePath.
+var (
+	ErrPathTraversal = errors.New("path escapes the filesystem root")
+	ErrPathNUL       = errors.New("path contains a NUL byte")
+	ErrPathTooLong   = errors.New("path or path element too long")
+)
+
+const (
+	// The limits most filesystems have.
+	maxPathLen        = 4096
+	maxPathElementLen = 255
+)
+
+type sanitizingFs struct {
+	afero.Fs
+}
+
+// NewSanitizingFs creates a filesystem that rejects any path that could be
+// used to address files outside of fs, e.g. in a theme crafted to read
+// other files on disk: paths with ".." elements climbing above the root,
+// paths with a volume name (e.g. "C:") and paths with NUL bytes. Overlong
+// paths are also rejected. A leading separator is allowed, the path is then
+// relative to the root of fs, as in afero.BasePathFs.
+func NewSanitizingFs(fs afero.Fs) afero.Fs {
+	return &sanitizingFs{Fs: fs}
+}
+
+// IsUnsafePath returns whether err is from a path rejected by a filesystem
+// created with NewSanitizingFs.
+func IsUnsafePath(err error) bool {
+	if pe, ok := err.(*os.PathError); ok {
+		err = pe.Err
+	}
+	return err == ErrPathTraversal || err == ErrPathNUL || err == ErrPathTooLong
+}
+
+func checkPath(op, name string) error {
+	var err error
+
+	switch {
+	case strings.IndexByte(name, 0) != -1:
+		err = ErrPathNUL
+	case len(name) > maxPathLen:
+		err = ErrPathTooLong
+	case filepath.VolumeName(name) != "":
+		err = ErrPathTraversal
+	default:
+		depth := 0
+		for _, elem := range strings.FieldsFunc(filepath.ToSlash(name), func(r rune) bool { return r == '/' }) {
+			if len(elem) > maxPathElementLen {
+				err = ErrPathTooLong
+				break
+			}
+			switch elem {
+			case ".":
+			case "..":
+				depth--
+			default:
+				depth++
+			}
+			if depth < 0 {
+				err = ErrPathTraversal
+				break
+			}
+		}
+	}
+
+	if err != nil {
+		return &os.PathError{Op: op, Path: name, Err: err}
+	}
+
+	return nil
+}
+
+func (fs *sanitizingFs) Chmod(name string, mode os.FileMode) error {
+	if err := checkPath("chmod", name); err != nil {
+		return err
+	}
+	return fs.Fs.Chmod(name, mode)
+}
+
+func (fs *sanitizingFs) Chtimes(name string, atime, mtime time.Time) error {
+	if err := checkPath("chtimes", name); err != nil {
+		return err
+	}
+	return fs.Fs.Chtimes(name, atime, mtime)
+}
+
+func (fs *sanitizingFs) Create(name string) (afero.File, error) {
+	if err := checkPath("create", name); err != nil {
+		return nil, err
+	}
+	return fs.Fs.Create(name)
+}
+
+func (fs *sanitizingFs) LstatIfPossible(name string) (os.FileInfo, bool, error) {
+	if err := checkPath("lstat", name); err != nil {
+		return nil, false, err
+	}
+	if lstater, ok := fs.Fs.(afero.Lstater); ok {
+		return lstater.LstatIfPossible(name)
+	}
+	fi, err := fs.Fs.Stat(name)
+	return fi, false, err
+}
+
+func (fs *sanitizingFs) Mkdir(name string, perm os.FileMode) error {
+	if err := checkPath("mkdir", name); err != nil {
+		return err
+	}
+	return fs.Fs.Mkdir(name, perm)
+}
+
+func (fs *sanitizingFs) MkdirAll(name string, perm os.FileMode) error {
+	if err := checkPath("mkdir", name); err != nil {
+		return err
+	}
+	return fs.Fs.MkdirAll(name, perm)
+}
+
+func (fs *sanitizingFs) Name() string {
+	return "sanitizingFs"
+}
+
+func (fs *sanitizingFs) Open(name string) (afero.File, error) {
+	if err := checkPath("open", name); err != nil {
+		return nil, err
+	}
+	return fs.Fs.Open(name)
+}
+
+func (fs *sanitizingFs) OpenFile(name string, flag int, perm os.FileMode) (afero.File, error) {
+	if err := checkPath("open", name); err != nil {
+		return nil, err
+	}
+	return fs.Fs.OpenFile(name, flag, perm)
+}
+
+func (fs *sanitizingFs) Remove(name string) error {
+	if err := checkPath("remove", name); err != nil {
+		return err
+	}
+	return fs.Fs.Remove(name)
+}
+
+func (fs *sanitizingFs) RemoveAll(name string) error {
+	if err := checkPath("remove", name); err != nil {
+		return err
+	}
+	return fs.Fs.RemoveAll(name)
+}
+
+func (fs *sanitizingFs) Rename(oldname, newname string) error {
+	if err := checkPath("rename", oldname); err != nil {
+		return err
+	}
+	if err := checkPath("rename", newname); err != nil {
+		return err
+	}
+	return fs.Fs.Rename(oldname, newname)
+}
+
+func (fs *sanitizingFs) Stat(name string) (os.FileInfo, error) {
+	if err := checkPath("stat", name); err != nil {
+		return nil, err
+	}
+	return fs.Fs.Stat(name)
+}
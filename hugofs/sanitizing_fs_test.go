@@ -0,0 +1,74 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugofs
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSanitizingFs(t *testing.T) {
+	assert := require.New(t)
+
+	m := afero.NewMemMapFs()
+	assert.NoError(afero.WriteFile(m, filepath.FromSlash("/secret.txt"), []byte("secret"), 0755))
+	assert.NoError(afero.WriteFile(m, filepath.FromSlash("/theme/layouts/a.html"), []byte("a"), 0755))
+	fs := NewSanitizingFs(afero.NewBasePathFs(m, filepath.FromSlash("/theme")))
+
+	for _, filename := range []string{
+		"layouts/a.html",
+		"/layouts/a.html",
+		"./layouts/../layouts/a.html",
+	} {
+		_, err := fs.Stat(filepath.FromSlash(filename))
+		assert.NoError(err, filename)
+	}
+
+	for _, test := range []struct {
+		filename string
+		expected error
+	}{
+		{"../secret.txt", ErrPathTraversal},
+		{"/../secret.txt", ErrPathTraversal},
+		{"layouts/../../secret.txt", ErrPathTraversal},
+		{"layouts/a.html\x00.md", ErrPathNUL},
+		{strings.Repeat("a", 256), ErrPathTooLong},
+		{strings.Repeat("a/", 2049), ErrPathTooLong},
+	} {
+		filename := filepath.FromSlash(test.filename)
+
+		_, err := fs.Open(filename)
+		assert.True(IsUnsafePath(err), test.filename)
+		assert.Equal(test.expected, err.(*os.PathError).Err)
+		assert.False(os.IsNotExist(err))
+
+		_, err = fs.Stat(filename)
+		assert.True(IsUnsafePath(err))
+		_, _, err = fs.(afero.Lstater).LstatIfPossible(filename)
+		assert.True(IsUnsafePath(err))
+		assert.True(IsUnsafePath(fs.Remove(filename)))
+		assert.True(IsUnsafePath(fs.Rename("layouts", filename)))
+		_, err = fs.Create(filename)
+		assert.True(IsUnsafePath(err))
+	}
+
+	assert.False(IsUnsafePath(nil))
+	_, err := fs.Stat("nope.txt")
+	assert.False(IsUnsafePath(err))
+}
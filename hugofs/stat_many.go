@@ -0,0 +1,115 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugofs
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/gohugoio/hugo/config"
+	"github.com/spf13/afero"
+)
+
+var _ StatManyFs = (*RootMappingFs)(nil)
+
+// StatResult is the result of a Stat in StatMany.
+type StatResult struct {
+	FileInfo os.FileInfo
+	Err      error
+}
+
+// StatManyFs is implemented by filesystems that can Stat many files in one
+// go, e.g. by grouping them by the filesystem they live in.
+type StatManyFs interface {
+	StatMany(names []string) []StatResult
+}
+
+// StatMany returns the result of a Stat of every name in names, in the same
+// order. If fs does not implement StatManyFs, the files are stat'ed in
+// parallel.
+func StatMany(fs afero.Fs, names []string) []StatResult {
+	if sfs, ok := fs.(StatManyFs); ok {
+		return sfs.StatMany(names)
+	}
+	return statParallel(fs, names)
+}
+
+func statParallel(fs afero.Fs, names []string) []StatResult {
+	results := make([]StatResult, len(names))
+	if len(names) == 0 {
+		return results
+	}
+	if len(names) == 1 {
+		results[0].FileInfo, results[0].Err = fs.Stat(names[0])
+		return results
+	}
+
+	numWorkers := config.GetNumWorkerMultiplier()
+	if numWorkers > len(names) {
+		numWorkers = len(names)
+	}
+
+	indices := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < numWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range indices {
+				results[idx].FileInfo, results[idx].Err = fs.Stat(names[idx])
+			}
+		}()
+	}
+
+	for i := range names {
+		indices <- i
+	}
+	close(indices)
+	wg.Wait()
+
+	return results
+}
+
+// StatMany stats names grouped by the root they are mapped to. The groups
+// are stat'ed in parallel, the names within a group in sequence, so no
+// single mount gets more than one request at a time.
+func (fs *RootMappingFs) StatMany(names []string) []StatResult {
+	results := make([]StatResult, len(names))
+
+	groups := make(map[string][]int)
+	var keys []string
+	for i, name := range names {
+		key, _, _ := fs.rootMapToReal.LongestPrefix([]byte(filepath.Clean(name)))
+		k := string(key)
+		if _, found := groups[k]; !found {
+			keys = append(keys, k)
+		}
+		groups[k] = append(groups[k], i)
+	}
+
+	var wg sync.WaitGroup
+	for _, k := range keys {
+		wg.Add(1)
+		go func(indices []int) {
+			defer wg.Done()
+			for _, idx := range indices {
+				results[idx].FileInfo, results[idx].Err = fs.Stat(names[idx])
+			}
+		}(groups[k])
+	}
+	wg.Wait()
+
+	return results
+}
@@ -0,0 +1,113 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugofs
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+// concurrentStatFs records the max number of concurrent stats per top level
+// dir.
+type concurrentStatFs struct {
+	afero.Fs
+
+	mu      sync.Mutex
+	current map[string]int
+	max     map[string]int
+}
+
+func (fs *concurrentStatFs) Stat(name string) (os.FileInfo, error) {
+	dir := filepath.Dir(name)
+	fs.mu.Lock()
+	fs.current[dir]++
+	if fs.current[dir] > fs.max[dir] {
+		fs.max[dir] = fs.current[dir]
+	}
+	fs.mu.Unlock()
+
+	defer func() {
+		fs.mu.Lock()
+		fs.current[dir]--
+		fs.mu.Unlock()
+	}()
+
+	return fs.Fs.Stat(name)
+}
+
+func TestStatMany(t *testing.T) {
+	assert := require.New(t)
+
+	mfs := afero.NewMemMapFs()
+	var names []string
+	for i := 0; i < 20; i++ {
+		name := filepath.FromSlash(fmt.Sprintf("/f/f%d.txt", i))
+		if i%3 == 0 {
+			assert.NoError(afero.WriteFile(mfs, name, []byte("f"), 0755))
+		}
+		names = append(names, name)
+	}
+
+	results := StatMany(mfs, names)
+	assert.Len(results, 20)
+	for i, r := range results {
+		if i%3 == 0 {
+			assert.NoError(r.Err)
+			assert.Equal(fmt.Sprintf("f%d.txt", i), r.FileInfo.Name())
+		} else {
+			assert.True(os.IsNotExist(r.Err))
+		}
+	}
+
+	assert.Len(StatMany(mfs, nil), 0)
+}
+
+func TestRootMappingFsStatMany(t *testing.T) {
+	assert := require.New(t)
+
+	mfs := afero.NewMemMapFs()
+	sfs := &concurrentStatFs{Fs: mfs, current: make(map[string]int), max: make(map[string]int)}
+
+	var names []string
+	for _, mount := range []string{"a", "b", "c"} {
+		for i := 0; i < 10; i++ {
+			assert.NoError(afero.WriteFile(mfs, filepath.FromSlash(fmt.Sprintf("/%s/p%d.md", mount, i)), []byte("p"), 0755))
+			names = append(names, filepath.FromSlash(fmt.Sprintf("%smount/p%d.md", mount, i)))
+		}
+	}
+	names = append(names, filepath.FromSlash("amount/missing.md"))
+
+	rfs, err := NewRootMappingFs(sfs, "amount", "/a", "bmount", "/b", "cmount", "/c")
+	assert.NoError(err)
+
+	results := StatMany(rfs, names)
+	assert.Len(results, 31)
+	for i, r := range results[:30] {
+		assert.NoError(r.Err)
+		assert.Equal(fmt.Sprintf("p%d.md", i%10), r.FileInfo.Name())
+		assert.Equal(filepath.FromSlash(fmt.Sprintf("/%s/p%d.md", []string{"a", "b", "c"}[i/10], i%10)), r.FileInfo.(RealFilenameInfo).RealFilename())
+	}
+	assert.True(os.IsNotExist(results[30].Err))
+
+	// One stat at a time per mount.
+	for _, dir := range []string{"/a", "/b", "/c"} {
+		assert.Equal(1, sfs.max[filepath.FromSlash(dir)])
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugofs_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gohugoio/hugo/common/hmetrics"
+	"github.com/gohugoio/hugo/hugofs"
+	"github.com/gohugoio/hugo/hugofs/fstest"
+	"github.com/gohugoio/hugo/hugofs/hugofstest"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+// TestStress runs the concurrency stress test on the filesystems in this
+// package. Add any new composite filesystem or decorator here.
+func TestStress(t *testing.T) {
+	t.Parallel()
+
+	newBuilder := func(t *testing.T) *hugofstest.Builder {
+		b := hugofstest.New(t).
+			WithLang("en").WithLang("nn").WithDisabledLang("sv").
+			WithMount("content/en", "en").WithMount("content/nn", "nn")
+		for _, dir := range []string{"content/en", "content/nn", "static"} {
+			for _, filename := range []string{"page.md", "page.nn.md", "page.sv.md", "sect/a.md", "sect/b.en.md", "sect/b.nn.md", "sect/sub/c.md", "_index.md"} {
+				b.Add(dir+"/"+filename, filename)
+			}
+		}
+		return b
+	}
+
+	newSource := func(t *testing.T) afero.Fs {
+		return newBuilder(t).Source()
+	}
+
+	for _, test := range []struct {
+		name string
+		fs   func(t *testing.T) afero.Fs
+	}{
+		{"RootMappingFs", func(t *testing.T) afero.Fs {
+			fs, err := hugofs.NewRootMappingFs(newSource(t), "content", filepath.FromSlash("/content/en"), "static", filepath.FromSlash("/static"))
+			require.NoError(t, err)
+			return fs
+		}},
+		{"LanguageFs", func(t *testing.T) afero.Fs {
+			return newBuilder(t).LanguageFs("content/en")
+		}},
+		{"LanguageCompositeFs", func(t *testing.T) afero.Fs {
+			return newBuilder(t).Build()
+		}},
+		{"LanguageMetaFs", func(t *testing.T) afero.Fs {
+			return hugofs.NewLanguageMetaFs("en", afero.NewBasePathFs(newSource(t), filepath.FromSlash("/static")))
+		}},
+		{"DirIndexFs", func(t *testing.T) afero.Fs {
+			root := filepath.FromSlash("/content/en")
+			return afero.NewBasePathFs(hugofs.NewDirIndex().Fs(newSource(t), root, "en"), root)
+		}},
+		{"OrderedCopyOnWriteFs", func(t *testing.T) afero.Fs {
+			source := newSource(t)
+			return hugofs.NewOrderedCopyOnWriteFs(afero.NewBasePathFs(source, filepath.FromSlash("/content/en")), afero.NewBasePathFs(source, filepath.FromSlash("/content/nn")))
+		}},
+		{"MetricsFs", func(t *testing.T) afero.Fs {
+			return hugofs.NewMetricsFs(afero.NewBasePathFs(newSource(t), filepath.FromSlash("/content/en")), hmetrics.NewRegistry(), "content")
+		}},
+		{"SanitizingFs", func(t *testing.T) afero.Fs {
+			return hugofs.NewSanitizingFs(afero.NewBasePathFs(newSource(t), filepath.FromSlash("/content/en")))
+		}},
+		{"TimeoutFs", func(t *testing.T) afero.Fs {
+			// Generous timeouts, but still through the timeout code path.
+			timeouts := hugofs.Timeouts{Stat: time.Minute, Open: time.Minute, Read: time.Minute}
+			return hugofs.NewTimeoutFs(afero.NewBasePathFs(newSource(t), filepath.FromSlash("/content/en")), timeouts)
+		}},
+		{"PrefetchFs", func(t *testing.T) afero.Fs {
+			return hugofs.NewPrefetchFs(afero.NewBasePathFs(newSource(t), filepath.FromSlash("/content/en")), 4)
+		}},
+		{"QuotaFs", func(t *testing.T) afero.Fs {
+			quota := hugofs.Quota{MaxBytes: 1 << 30, MaxFiles: 1 << 20}
+			return hugofs.NewQuotaFs(afero.NewBasePathFs(newSource(t), filepath.FromSlash("/content/en")), quota)
+		}},
+		{"BoundedWriteFs", func(t *testing.T) afero.Fs {
+			return hugofs.NewBoundedWriteFs(afero.NewBasePathFs(newSource(t), filepath.FromSlash("/content/en")), 2)
+		}},
+	} {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			fstest.Stress(t, test.fs(t), fstest.StressOptions{})
+		})
+	}
+}
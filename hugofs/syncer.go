@@ -0,0 +1,278 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugofs
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/afero"
+)
+
+// ErrFileOverDir is returned by Syncer.Sync when asked to replace a non-empty
+// directory with a file.
+var ErrFileOverDir = errors.New("trying to overwrite a non-empty directory with a file")
+
+// Syncer mirrors the files in a source filesystem, typically the composed
+// static filesystem, to a destination. A file is only written if its content
+// has changed. As it works on the composed view, a file shadowed by another
+// mount is never copied.
+type Syncer struct {
+	SrcFs  afero.Fs
+	DestFs afero.Fs
+
+	// Delete files and directories in the destination not in the source.
+	Delete bool
+
+	// If set, the files and directories in the destination it returns
+	// true for are never deleted.
+	DeleteFilter func(fi os.FileInfo) bool
+
+	// Do not sync the modification times.
+	NoTimes bool
+
+	// Do not sync the permissions.
+	NoChmod bool
+
+	// Only report what would be done, do not change the destination.
+	DryRun bool
+}
+
+// SyncReport lists what a Sync did, or would have done in a dry run. The
+// filenames are in the destination.
+type SyncReport struct {
+	// The files copied because they were new or changed.
+	Copied []string
+
+	// The files already up to date.
+	Unchanged []string
+
+	// The files and directories deleted.
+	Deleted []string
+}
+
+// NewSyncer creates a new Syncer from src to dest.
+func NewSyncer(src, dest afero.Fs) *Syncer {
+	return &Syncer{SrcFs: src, DestFs: dest}
+}
+
+// Sync makes dst in the destination a copy of src in the source, both
+// files or directories.
+func (s *Syncer) Sync(dst, src string) (*SyncReport, error) {
+	sfi, err := s.SrcFs.Stat(src)
+	if err != nil {
+		return nil, err
+	}
+
+	if !sfi.IsDir() {
+		dfi, err := s.DestFs.Stat(dst)
+		if err == nil && dfi.IsDir() {
+			fis, err := afero.ReadDir(s.DestFs, dst)
+			if err != nil {
+				return nil, err
+			}
+			if len(fis) > 0 {
+				return nil, ErrFileOverDir
+			}
+		}
+	}
+
+	report := &SyncReport{}
+	if err := s.sync(report, dst, src, sfi); err != nil {
+		return report, errors.Wrapf(err, "failed to sync %q to %q", src, dst)
+	}
+
+	return report, nil
+}
+
+func (s *Syncer) sync(report *SyncReport, dst, src string, sfi os.FileInfo) error {
+	dfi, err := s.DestFs.Stat(dst)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	if !sfi.IsDir() {
+		return s.syncFile(report, dst, src, sfi, dfi)
+	}
+
+	if dfi != nil && !dfi.IsDir() {
+		if err := s.remove(report, dst); err != nil {
+			return err
+		}
+		dfi = nil
+	}
+	if dfi == nil && !s.DryRun {
+		if err := s.DestFs.MkdirAll(dst, 0777); err != nil {
+			return err
+		}
+	}
+
+	fis, err := afero.ReadDir(s.SrcFs, src)
+	if err != nil {
+		if os.IsNotExist(err) {
+			// Removed while syncing.
+			return nil
+		}
+		return err
+	}
+
+	names := make(map[string]bool, len(fis))
+	for _, fi := range fis {
+		names[fi.Name()] = true
+		if err := s.sync(report, filepath.Join(dst, fi.Name()), filepath.Join(src, fi.Name()), fi); err != nil {
+			return err
+		}
+	}
+
+	if s.Delete && dfi != nil {
+		dfis, err := afero.ReadDir(s.DestFs, dst)
+		if err != nil {
+			return err
+		}
+		for _, fi := range dfis {
+			if names[fi.Name()] || (s.DeleteFilter != nil && s.DeleteFilter(fi)) {
+				continue
+			}
+			if err := s.remove(report, filepath.Join(dst, fi.Name())); err != nil {
+				return err
+			}
+		}
+	}
+
+	return s.syncStats(dst, sfi)
+}
+
+func (s *Syncer) syncFile(report *SyncReport, dst, src string, sfi, dfi os.FileInfo) error {
+	if dfi != nil && dfi.IsDir() {
+		if err := s.remove(report, dst); err != nil {
+			return err
+		}
+		dfi = nil
+	}
+
+	if dfi != nil && dfi.Size() == sfi.Size() {
+		equal, err := s.equal(dst, src)
+		if err != nil {
+			return err
+		}
+		if equal {
+			report.Unchanged = append(report.Unchanged, dst)
+			return s.syncStats(dst, sfi)
+		}
+	}
+
+	report.Copied = append(report.Copied, dst)
+	if s.DryRun {
+		return nil
+	}
+
+	sf, err := s.SrcFs.Open(src)
+	if err != nil {
+		if os.IsNotExist(err) {
+			// Removed while syncing.
+			return nil
+		}
+		return err
+	}
+	defer sf.Close()
+
+	df, err := s.DestFs.Create(dst)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(df, sf); err != nil {
+		df.Close()
+		return err
+	}
+	if err := df.Close(); err != nil {
+		return err
+	}
+
+	return s.syncStats(dst, sfi)
+}
+
+func (s *Syncer) remove(report *SyncReport, dst string) error {
+	report.Deleted = append(report.Deleted, dst)
+	if s.DryRun {
+		return nil
+	}
+	return s.DestFs.RemoveAll(dst)
+}
+
+// syncStats sets the permissions and modification time of dst to those of
+// the source.
+func (s *Syncer) syncStats(dst string, sfi os.FileInfo) error {
+	if s.DryRun || (s.NoChmod && s.NoTimes) {
+		return nil
+	}
+
+	dfi, err := s.DestFs.Stat(dst)
+	if err != nil {
+		return err
+	}
+
+	if !s.NoChmod && dfi.Mode().Perm() != sfi.Mode().Perm() {
+		if err := s.DestFs.Chmod(dst, sfi.Mode().Perm()); err != nil {
+			return err
+		}
+	}
+
+	if !s.NoTimes && !dfi.ModTime().Equal(sfi.ModTime()) {
+		if err := s.DestFs.Chtimes(dst, sfi.ModTime(), sfi.ModTime()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// equal returns whether the files have the same content.
+func (s *Syncer) equal(dst, src string) (bool, error) {
+	df, err := s.DestFs.Open(dst)
+	if err != nil {
+		return false, err
+	}
+	defer df.Close()
+
+	sf, err := s.SrcFs.Open(src)
+	if err != nil {
+		return false, err
+	}
+	defer sf.Close()
+
+	dbuf, sbuf := make([]byte, 32*1024), make([]byte, 32*1024)
+	for {
+		dn, derr := io.ReadFull(df, dbuf)
+		sn, serr := io.ReadFull(sf, sbuf)
+		if !bytes.Equal(dbuf[:dn], sbuf[:sn]) {
+			return false, nil
+		}
+		if derr == io.EOF || derr == io.ErrUnexpectedEOF {
+			return serr == io.EOF || serr == io.ErrUnexpectedEOF, nil
+		}
+		if derr != nil {
+			return false, derr
+		}
+		if serr != nil {
+			if serr == io.EOF || serr == io.ErrUnexpectedEOF {
+				return false, nil
+			}
+			return false, serr
+		}
+	}
+}
@@ -0,0 +1,126 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugofs
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSyncer(t *testing.T) {
+	assert := require.New(t)
+
+	src, dest := afero.NewMemMapFs(), afero.NewMemMapFs()
+
+	// A static dir and a theme's static dir composed into one.
+	for filename, content := range map[string]string{
+		"/theme/static/a.txt":     "theme a",
+		"/theme/static/b/b.txt":   "theme b",
+		"/project/static/a.txt":   "project a",
+		"/project/static/c/c.txt": "project c",
+	} {
+		assert.NoError(afero.WriteFile(src, filepath.FromSlash(filename), []byte(content), 0755))
+	}
+	static := NewOrderedCopyOnWriteFs(
+		afero.NewBasePathFs(src, filepath.FromSlash("/theme/static")),
+		afero.NewBasePathFs(src, filepath.FromSlash("/project/static")))
+
+	public := filepath.FromSlash("/public")
+	for filename, content := range map[string]string{
+		"/public/c/c.txt":        "project c",
+		"/public/orphan.txt":     "orphan",
+		"/public/.git/HEAD":      "keep",
+		"/public/b":              "file where a dir should be",
+		"/public/old/orphan.txt": "orphan",
+	} {
+		assert.NoError(afero.WriteFile(dest, filepath.FromSlash(filename), []byte(content), 0755))
+	}
+
+	p := func(filenames ...string) []string {
+		for i, filename := range filenames {
+			filenames[i] = filepath.Join(public, filepath.FromSlash(filename))
+		}
+		return filenames
+	}
+
+	syncer := NewSyncer(static, dest)
+	syncer.Delete = true
+	syncer.DeleteFilter = func(fi os.FileInfo) bool {
+		return fi.IsDir() && strings.HasPrefix(fi.Name(), ".")
+	}
+	syncer.DryRun = true
+
+	report, err := syncer.Sync(public, string(filepath.Separator))
+	assert.NoError(err)
+	assert.Equal(p("a.txt", "b/b.txt"), report.Copied)
+	assert.Equal(p("c/c.txt"), report.Unchanged)
+	assert.Equal(p("b", "old", "orphan.txt"), report.Deleted)
+
+	// Nothing changed in a dry run.
+	b, err := afero.ReadFile(dest, filepath.Join(public, "orphan.txt"))
+	assert.NoError(err)
+	assert.Equal("orphan", string(b))
+	_, err = dest.Stat(filepath.Join(public, "a.txt"))
+	assert.True(os.IsNotExist(err))
+
+	syncer.DryRun = false
+	report, err = syncer.Sync(public, string(filepath.Separator))
+	assert.NoError(err)
+	assert.Equal(p("a.txt", "b/b.txt"), report.Copied)
+	assert.Equal(p("b", "old", "orphan.txt"), report.Deleted)
+
+	for filename, content := range map[string]string{
+		"a.txt":     "project a",
+		"b/b.txt":   "theme b",
+		"c/c.txt":   "project c",
+		".git/HEAD": "keep",
+	} {
+		b, err := afero.ReadFile(dest, filepath.Join(public, filepath.FromSlash(filename)))
+		assert.NoError(err)
+		assert.Equal(content, string(b))
+	}
+	for _, filename := range []string{"orphan.txt", "old"} {
+		_, err = dest.Stat(filepath.Join(public, filename))
+		assert.True(os.IsNotExist(err))
+	}
+
+	modTime := time.Now().Add(-time.Hour)
+	assert.NoError(src.Chtimes(filepath.FromSlash("/project/static/a.txt"), modTime, modTime))
+
+	// Only the changed files are written.
+	assert.NoError(afero.WriteFile(src, filepath.FromSlash("/theme/static/b/b.txt"), []byte("theme b2"), 0755))
+	report, err = syncer.Sync(public, string(filepath.Separator))
+	assert.NoError(err)
+	assert.Equal(p("b/b.txt"), report.Copied)
+	assert.Equal(p("a.txt", "c/c.txt"), report.Unchanged)
+	assert.Len(report.Deleted, 0)
+
+	fi, err := dest.Stat(filepath.Join(public, "a.txt"))
+	assert.NoError(err)
+	assert.True(fi.ModTime().Equal(modTime))
+
+	// Single file.
+	report, err = syncer.Sync(filepath.Join(public, "a.txt"), "a.txt")
+	assert.NoError(err)
+	assert.Equal(p("a.txt"), report.Unchanged)
+
+	_, err = syncer.Sync(filepath.Join(public, "c"), "a.txt")
+	assert.Equal(ErrFileOverDir, err)
+}
@@ -0,0 +1,231 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugofs
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/afero"
+)
+
+var (
+	_ afero.Fs            = (*TimeoutFs)(nil)
+	_ afero.Lstater       = (*TimeoutFs)(nil)
+	_ FilesystemUnwrapper = (*TimeoutFs)(nil)
+	_ ContextFs           = (*TimeoutFs)(nil)
+	_ ContextFile         = (*timeoutFile)(nil)
+	_ afero.File          = (*timeoutFile)(nil)
+)
+
+// ErrTimeout is the cause of the errors returned by a TimeoutFs when the
+// delegate does not answer in time.
+var ErrTimeout = errors.New("filesystem operation timed out")
+
+// Timeouts holds the per operation timeouts of a TimeoutFs. A zero value
+// means no timeout.
+type Timeouts struct {
+	// Stat and Lstat, on the filesystem and on open files.
+	Stat time.Duration
+
+	// Open and OpenFile.
+	Open time.Duration
+
+	// Read, ReadAt, Readdir and Readdirnames.
+	Read time.Duration
+}
+
+// TimeoutFs turns operations on the delegate filesystem that hang, e.g. on
+// a dead NFS mount, into errors with ErrTimeout as the cause. An operation
+// that times out is left running in the background; a file opened after its
+// timeout is closed when the open returns.
+type TimeoutFs struct {
+	afero.Fs
+
+	timeouts Timeouts
+}
+
+// NewTimeoutFs creates a new TimeoutFs enforcing timeouts on delegate.
+func NewTimeoutFs(delegate afero.Fs, timeouts Timeouts) *TimeoutFs {
+	return &TimeoutFs{Fs: delegate, timeouts: timeouts}
+}
+
+// IsTimeout returns whether err was caused by a TimeoutFs timeout.
+func IsTimeout(err error) bool {
+	if pe, ok := err.(*os.PathError); ok {
+		err = pe.Err
+	}
+	return errors.Cause(err) == ErrTimeout
+}
+
+// UnwrapFilesystem returns the decorated filesystem.
+func (fs *TimeoutFs) UnwrapFilesystem() afero.Fs {
+	return fs.Fs
+}
+
+func (fs *TimeoutFs) Name() string {
+	return "TimeoutFs"
+}
+
+func (fs *TimeoutFs) Open(name string) (afero.File, error) {
+	return fs.OpenContext(context.Background(), name)
+}
+
+// OpenContext opens name, giving up when ctx is done or the open timeout is
+// reached.
+func (fs *TimeoutFs) OpenContext(ctx context.Context, name string) (afero.File, error) {
+	return fs.open(ctx, name, func() (afero.File, error) {
+		return OpenContext(ctx, fs.Fs, name)
+	})
+}
+
+func (fs *TimeoutFs) OpenFile(name string, flag int, perm os.FileMode) (afero.File, error) {
+	return fs.open(context.Background(), name, func() (afero.File, error) {
+		return fs.Fs.OpenFile(name, flag, perm)
+	})
+}
+
+func (fs *TimeoutFs) Stat(name string) (os.FileInfo, error) {
+	return fs.StatContext(context.Background(), name)
+}
+
+// StatContext returns the os.FileInfo describing name, giving up when ctx is
+// done or the stat timeout is reached.
+func (fs *TimeoutFs) StatContext(ctx context.Context, name string) (os.FileInfo, error) {
+	v, err := withTimeout(ctx, "stat", name, fs.timeouts.Stat, func() (interface{}, error) {
+		return StatContext(ctx, fs.Fs, name)
+	}, nil)
+	fi, _ := v.(os.FileInfo)
+	return fi, err
+}
+
+// LstatIfPossible returns the os.FileInfo structure describing a given file.
+// It uses Lstat if supported by the wrapped filesystem.
+func (fs *TimeoutFs) LstatIfPossible(name string) (os.FileInfo, bool, error) {
+	lstater, ok := fs.Fs.(afero.Lstater)
+	if !ok {
+		fi, err := fs.Stat(name)
+		return fi, false, err
+	}
+
+	var lstatCalled bool
+	v, err := withTimeout(context.Background(), "lstat", name, fs.timeouts.Stat, func() (interface{}, error) {
+		fi, b, err := lstater.LstatIfPossible(name)
+		lstatCalled = b
+		return fi, err
+	}, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	fi, _ := v.(os.FileInfo)
+	return fi, lstatCalled, nil
+}
+
+func (fs *TimeoutFs) open(ctx context.Context, name string, open func() (afero.File, error)) (afero.File, error) {
+	v, err := withTimeout(ctx, "open", name, fs.timeouts.Open, func() (interface{}, error) {
+		return open()
+	}, closeFile)
+	if err != nil {
+		return nil, err
+	}
+	return &timeoutFile{File: v.(afero.File), timeouts: fs.timeouts}, nil
+}
+
+// withTimeout runs op, giving up when ctx is done or after d. If op returns
+// after that, its result is passed to late, if set.
+func withTimeout(ctx context.Context, opName, name string, d time.Duration, op func() (interface{}, error), late func(v interface{})) (interface{}, error) {
+	if d <= 0 {
+		return runContext(ctx, op, late)
+	}
+
+	tctx, cancel := context.WithTimeout(ctx, d)
+	defer cancel()
+
+	v, err := runContext(tctx, op, late)
+	if err != nil && err == tctx.Err() && ctx.Err() == nil {
+		// Our deadline, not the caller's.
+		return nil, &os.PathError{Op: opName, Path: name, Err: errors.Wrapf(ErrTimeout, "no response in %s", d)}
+	}
+	return v, err
+}
+
+type timeoutFile struct {
+	afero.File
+	timeouts Timeouts
+}
+
+func (f *timeoutFile) Read(p []byte) (int, error) {
+	return f.read("read", p, func(buf []byte) (int, error) {
+		return f.File.Read(buf)
+	})
+}
+
+func (f *timeoutFile) ReadAt(p []byte, off int64) (int, error) {
+	return f.read("readat", p, func(buf []byte) (int, error) {
+		return f.File.ReadAt(buf, off)
+	})
+}
+
+func (f *timeoutFile) read(opName string, p []byte, read func(buf []byte) (int, error)) (int, error) {
+	if f.timeouts.Read <= 0 {
+		return read(p)
+	}
+
+	// Read into a private buffer, as the read may complete after we have
+	// given up on it.
+	buf := make([]byte, len(p))
+	var n int
+	_, err := withTimeout(context.Background(), opName, f.Name(), f.timeouts.Read, func() (interface{}, error) {
+		var err error
+		n, err = read(buf)
+		return nil, err
+	}, nil)
+	if IsTimeout(err) {
+		return 0, err
+	}
+	copy(p, buf[:n])
+	return n, err
+}
+
+func (f *timeoutFile) Readdir(count int) ([]os.FileInfo, error) {
+	return f.ReaddirContext(context.Background(), count)
+}
+
+// ReaddirContext reads the directory, giving up when ctx is done or the read
+// timeout is reached.
+func (f *timeoutFile) ReaddirContext(ctx context.Context, count int) ([]os.FileInfo, error) {
+	v, err := withTimeout(ctx, "readdir", f.Name(), f.timeouts.Read, func() (interface{}, error) {
+		return ReaddirContext(ctx, f.File, count)
+	}, nil)
+	fis, _ := v.([]os.FileInfo)
+	return fis, err
+}
+
+func (f *timeoutFile) Readdirnames(count int) ([]string, error) {
+	v, err := withTimeout(context.Background(), "readdir", f.Name(), f.timeouts.Read, func() (interface{}, error) {
+		return f.File.Readdirnames(count)
+	}, nil)
+	names, _ := v.([]string)
+	return names, err
+}
+
+func (f *timeoutFile) Stat() (os.FileInfo, error) {
+	v, err := withTimeout(context.Background(), "stat", f.Name(), f.timeouts.Stat, func() (interface{}, error) {
+		return f.File.Stat()
+	}, nil)
+	fi, _ := v.(os.FileInfo)
+	return fi, err
+}
@@ -0,0 +1,89 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugofs
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+// hangingFs blocks all opens and stats of the hang filename until release
+// is closed.
+type hangingFs struct {
+	afero.Fs
+	hang    string
+	release chan struct{}
+}
+
+func (fs *hangingFs) Open(name string) (afero.File, error) {
+	if name == fs.hang {
+		<-fs.release
+	}
+	return fs.Fs.Open(name)
+}
+
+func (fs *hangingFs) Stat(name string) (os.FileInfo, error) {
+	if name == fs.hang {
+		<-fs.release
+	}
+	return fs.Fs.Stat(name)
+}
+
+func TestTimeoutFs(t *testing.T) {
+	assert := require.New(t)
+
+	mfs := afero.NewMemMapFs()
+	assert.NoError(afero.WriteFile(mfs, "/content/a.txt", []byte("abc"), 0755))
+	assert.NoError(afero.WriteFile(mfs, "/content/dead.txt", []byte("def"), 0755))
+
+	hfs := &hangingFs{Fs: mfs, hang: "/content/dead.txt", release: make(chan struct{})}
+	defer close(hfs.release)
+
+	timeout := 20 * time.Millisecond
+	fs := NewTimeoutFs(hfs, Timeouts{Stat: timeout, Open: timeout, Read: timeout})
+
+	b, err := afero.ReadFile(fs, "/content/a.txt")
+	assert.NoError(err)
+	assert.Equal("abc", string(b))
+
+	fi, err := fs.Stat("/content/a.txt")
+	assert.NoError(err)
+	assert.Equal("a.txt", fi.Name())
+
+	dirs, err := afero.ReadDir(fs, "/content")
+	assert.NoError(err)
+	assert.Len(dirs, 2)
+
+	_, err = fs.Stat("/content/missing.txt")
+	assert.True(os.IsNotExist(err))
+	assert.False(IsTimeout(err))
+
+	_, err = fs.Open("/content/dead.txt")
+	assert.Error(err)
+	assert.True(IsTimeout(err))
+	assert.Contains(err.Error(), "/content/dead.txt")
+
+	_, err = fs.Stat("/content/dead.txt")
+	assert.True(IsTimeout(err))
+
+	// No timeouts.
+	fs = NewTimeoutFs(mfs, Timeouts{})
+	b, err = afero.ReadFile(fs, "/content/dead.txt")
+	assert.NoError(err)
+	assert.Equal("def", string(b))
+}
@@ -0,0 +1,47 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugofs
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// ErrUnsupported is the error returned by the operations a filesystem or a
+// file in this package does not support, e.g. reading the virtual root
+// directory of a RootMappingFs.
+type ErrUnsupported struct {
+	// The operation, e.g. "read".
+	Op string
+
+	// The type of the filesystem or file, e.g. "RootMappingFs".
+	Type string
+
+	// The path the operation was invoked with, if any.
+	Path string
+}
+
+func (e *ErrUnsupported) Error() string {
+	if e.Path == "" {
+		return fmt.Sprintf("%s: operation not supported by %s", e.Op, e.Type)
+	}
+	return fmt.Sprintf("%s %s: operation not supported by %s", e.Op, e.Path, e.Type)
+}
+
+// IsUnsupported reports whether err, or its cause, is an *ErrUnsupported.
+func IsUnsupported(err error) bool {
+	_, ok := errors.Cause(err).(*ErrUnsupported)
+	return ok
+}
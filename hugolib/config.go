@@ -15,10 +15,12 @@ package hugolib
 
 import (
 	"fmt"
+	"net/http"
 
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/gohugoio/hugo/parser/metadecoders"
 
@@ -35,6 +37,8 @@ import (
 	"github.com/gohugoio/hugo/config/services"
 	"github.com/gohugoio/hugo/helpers"
 	"github.com/spf13/afero"
+	"github.com/spf13/cast"
+	jww "github.com/spf13/jwalterweatherman"
 	"github.com/spf13/viper"
 )
 
@@ -84,6 +88,9 @@ type ConfigSourceDescriptor struct {
 
 	// production, development
 	Environment string
+
+	// If set, the origin of every config value is recorded here.
+	Provenance *config.Provenance
 }
 
 func (d ConfigSourceDescriptor) configFilenames() []string {
@@ -118,7 +125,7 @@ func LoadConfig(d ConfigSourceDescriptor, doWithConfig ...func(cfg config.Provid
 	var configFiles []string
 
 	v := viper.New()
-	l := configLoader{ConfigSourceDescriptor: d}
+	l := configLoader{ConfigSourceDescriptor: d, deprecations: &config.DeprecationLog{}}
 
 	v.AutomaticEnv()
 	v.SetEnvPrefix("hugo")
@@ -126,11 +133,11 @@ func LoadConfig(d ConfigSourceDescriptor, doWithConfig ...func(cfg config.Provid
 	var cerr error
 
 	for _, name := range d.configFilenames() {
-		var filename string
-		if filename, cerr = l.loadConfig(name, v); cerr != nil && cerr != ErrNoConfigFile {
+		var filenames []string
+		if filenames, cerr = l.loadConfig(name, v); cerr != nil && cerr != ErrNoConfigFile {
 			return nil, nil, cerr
 		}
-		configFiles = append(configFiles, filename)
+		configFiles = append(configFiles, filenames...)
 	}
 
 	if d.AbsConfigDir != "" {
@@ -141,10 +148,19 @@ func LoadConfig(d ConfigSourceDescriptor, doWithConfig ...func(cfg config.Provid
 		cerr = err
 	}
 
+	if cerr == nil {
+		if err := l.loadRemoteConfig(v); err != nil {
+			return v, configFiles, err
+		}
+	}
+
 	if err := loadDefaultSettingsFor(v); err != nil {
 		return v, configFiles, err
 	}
 
+	// The environment overlay in the config dir is selected by this.
+	v.SetDefault("environment", d.Environment)
+
 	if cerr == nil {
 		themeConfigFiles, err := l.loadThemeConfig(v)
 		if err != nil {
@@ -156,6 +172,24 @@ func LoadConfig(d ConfigSourceDescriptor, doWithConfig ...func(cfg config.Provid
 		}
 	}
 
+	if w := l.deprecations.Warning(); w != "" {
+		helpers.DistinctWarnLog.Println(w)
+	}
+
+	for _, err := range validateConfig(v) {
+		jww.WARN.Println(err)
+	}
+
+	if d.Provenance != nil {
+		// Environment variables, e.g. HUGO_TITLE, win over the config files.
+		for key := range v.AllSettings() {
+			envKey := "HUGO_" + strings.ToUpper(key)
+			if _, found := os.LookupEnv(envKey); found {
+				d.Provenance.RecordKey("env "+envKey, key)
+			}
+		}
+	}
+
 	// We create languages based on the settings, so we need to make sure that
 	// all configuration is loaded/set before doing that.
 	for _, d := range doWithConfig {
@@ -174,9 +208,31 @@ func LoadConfig(d ConfigSourceDescriptor, doWithConfig ...func(cfg config.Provid
 
 type configLoader struct {
 	ConfigSourceDescriptor
+
+	// Collects the deprecated config keys used.
+	deprecations *config.DeprecationLog
+}
+
+// deprecatedConfigKeys translates renamed config keys to their new names.
+// When renaming a config key, add the old key pattern and the new key here,
+// e.g. "{oldKey,languages/*/oldKey}", "newKey", to keep existing sites
+// working while the users are warned about the rename.
+var deprecatedConfigKeys = mustNewDeprecatedKeys(
+	// Before 0.53 we used singular for "menu".
+	"{menu,languages/*/menu}", "menus",
+)
+
+func mustNewDeprecatedKeys(patternKeys ...string) *config.DeprecatedKeys {
+	d, err := config.NewDeprecatedKeys(patternKeys...)
+	if err != nil {
+		panic(err)
+	}
+	return d
 }
 
-func (l configLoader) loadConfig(configName string, v *viper.Viper) (string, error) {
+// loadConfig loads the named config file and the files it imports into v.
+// It returns the filenames loaded.
+func (l configLoader) loadConfig(configName string, v *viper.Viper) ([]string, error) {
 	baseDir := l.configFileDir()
 	var baseFilename string
 	if filepath.IsAbs(configName) {
@@ -205,22 +261,70 @@ func (l configLoader) loadConfig(configName string, v *viper.Viper) (string, err
 	}
 
 	if filename == "" {
-		return "", ErrNoConfigFile
+		return nil, ErrNoConfigFile
+	}
+
+	return l.loadConfigFile(filename, v, nil)
+
+}
+
+// loadConfigFile loads the given config file into v. Any config files listed
+// in its "imports" setting, relative to the file, are loaded first, so the
+// importing file wins on conflicts:
+//
+//	imports = ["config/menus.toml", "config/params.toml"]
+//
+// The importing files are in the stack, used to detect import cycles.
+func (l configLoader) loadConfigFile(filename string, v *viper.Viper, stack []string) ([]string, error) {
+	for i, f := range stack {
+		if f == filename {
+			return nil, errors.Errorf("config import cycle: %s", strings.Join(append(stack[i:], filename), " -> "))
+		}
 	}
 
 	m, err := config.FromFileToMap(l.Fs, filename)
 	if err != nil {
-		return "", l.wrapFileError(err, filename)
+		return nil, l.wrapFileError(err, filename)
+	}
+
+	deprecatedConfigKeys.Translate(filename, m, l.deprecations)
+
+	filenames := []string{filename}
+
+	if imports, found := m[importsKey]; found {
+		delete(m, importsKey)
+
+		importFilenames, err := cast.ToStringSliceE(imports)
+		if err != nil {
+			return nil, errors.Wrapf(err, "%s: invalid imports", filename)
+		}
+
+		for _, importFilename := range importFilenames {
+			if !filepath.IsAbs(importFilename) {
+				importFilename = filepath.Join(filepath.Dir(filename), filepath.FromSlash(importFilename))
+			}
+			imported, err := l.loadConfigFile(importFilename, v, append(stack, filename))
+			if err != nil {
+				return nil, err
+			}
+			filenames = append(filenames, imported...)
+		}
 	}
 
 	if err = v.MergeConfigMap(m); err != nil {
-		return "", l.wrapFileError(err, filename)
+		return nil, l.wrapFileError(err, filename)
 	}
 
-	return filename, nil
+	if l.Provenance != nil {
+		l.Provenance.Record(filename, m)
+	}
 
+	return filenames, nil
 }
 
+// importsKey is the config file setting listing the config files to import.
+const importsKey = "imports"
+
 func (l configLoader) wrapFileError(err error, filename string) error {
 	err, _ = herrors.WithFileContextForFile(
 		err,
@@ -231,6 +335,80 @@ func (l configLoader) wrapFileError(err error, filename string) error {
 	return err
 }
 
+// loadRemoteConfig fetches the config document in the remoteConfig section,
+// if any, and merges it below the project config:
+//
+//	[remoteConfig]
+//	url = "https://example.org/defaults.toml"
+//	sha256 = "..."
+//	maxAge = "1h"
+//	timeout = "30s"
+func (l configLoader) loadRemoteConfig(v *viper.Viper) error {
+	const (
+		remoteConfigKey = "remoteConfig"
+
+		// Keep a stalled server from blocking the build forever; we fall
+		// back to the cached document on timeout.
+		defaultRemoteConfigTimeout = 30 * time.Second
+	)
+
+	if !v.IsSet(remoteConfigKey) {
+		return nil
+	}
+
+	var rc struct {
+		URL     string
+		SHA256  string
+		MaxAge  time.Duration
+		Timeout time.Duration
+	}
+
+	if err := config.Decode(v, remoteConfigKey, &rc); err != nil {
+		return err
+	}
+
+	if rc.URL == "" {
+		return errors.New("remoteConfig: url not set")
+	}
+
+	if rc.Timeout <= 0 {
+		rc.Timeout = defaultRemoteConfigTimeout
+	}
+
+	cacheDir, err := helpers.GetCacheDir(l.Fs, v)
+	if err != nil {
+		return err
+	}
+
+	m, err := config.RemoteSource{
+		URL:      rc.URL,
+		SHA256:   rc.SHA256,
+		CacheDir: cacheDir,
+		MaxAge:   rc.MaxAge,
+		Fs:       l.Fs,
+		Client:   &http.Client{Timeout: rc.Timeout},
+	}.Load()
+	if err != nil {
+		return err
+	}
+
+	origin := fmt.Sprintf("remote %q", rc.URL)
+
+	deprecatedConfigKeys.Translate(origin, m, l.deprecations)
+
+	if l.Provenance != nil {
+		l.Provenance.RecordMissing(origin, m)
+	}
+
+	// Merge the project config back on top so it wins on conflicts.
+	local := v.AllSettings()
+	if err := v.MergeConfigMap(m); err != nil {
+		return err
+	}
+
+	return v.MergeConfigMap(local)
+}
+
 func (l configLoader) loadConfigFromConfigDir(v *viper.Viper) ([]string, error) {
 	sourceFs := l.Fs
 	configDir := l.AbsConfigDir
@@ -314,13 +492,16 @@ func (l configLoader) loadConfigFromConfigDir(v *viper.Viper) ([]string, error)
 				}
 			}
 
-			// Migrate menu => menus etc.
-			config.RenameKeys(root)
+			deprecatedConfigKeys.Translate(path, root, l.deprecations)
 
 			if err := v.MergeConfigMap(root); err != nil {
 				return l.wrapFileError(err, path)
 			}
 
+			if l.Provenance != nil {
+				l.Provenance.Record(path, root)
+			}
+
 			return nil
 
 		})
@@ -370,12 +551,53 @@ func loadLanguageSettings(cfg config.Provider, oldLangs langs.Languages) error {
 	if len(languages) == 0 {
 		languages2 = append(languages2, langs.NewDefaultLanguage(cfg))
 	} else {
+		var codes []string
+		for k, v := range languages {
+			if cast.ToBool(cast.ToStringMap(v)["customcode"]) {
+				// Not a BCP 47 language code.
+				continue
+			}
+			codes = append(codes, k)
+		}
+		if err := langs.ValidateLanguageCodes(codes...); err != nil {
+			return err
+		}
+
 		languages2, err = toSortedLanguages(cfg, languages)
 		if err != nil {
 			return _errors.Wrap(err, "Failed to parse multilingual config")
 		}
 	}
 
+	// Language aliases (e.g. legacy codes) resolve to the language they represent.
+	if canonical := languages2.CanonicalLang(defaultLang); canonical != defaultLang {
+		defaultLang = canonical
+		cfg.Set("defaultContentLanguage", defaultLang)
+	}
+
+	for _, l := range languages2 {
+		if l.MergeContentFrom != "" {
+			l.MergeContentFrom = languages2.CanonicalLang(l.MergeContentFrom)
+		}
+		for i, fallback := range l.Fallbacks {
+			l.Fallbacks[i] = languages2.CanonicalLang(fallback)
+		}
+		for _, alias := range l.Aliases {
+			for _, disabled := range disableLanguages {
+				if strings.EqualFold(alias, disabled) {
+					if l.Lang == defaultLang {
+						return fmt.Errorf("cannot disable default language %q", defaultLang)
+					}
+					l.Disabled = true
+				}
+			}
+		}
+	}
+
+	if err := languages2.Validate(); err != nil {
+		return err
+	}
+
 	if oldLangs != nil {
 		// When in multihost mode, the languages are mapped to a server, so
 		// some structural language changes will need a restart of the dev server.
@@ -447,7 +669,21 @@ func (l configLoader) loadThemeConfig(v1 *viper.Viper) ([]string, error) {
 	themesDir := paths.AbsPathify(l.WorkingDir, v1.GetString("themesDir"))
 	themes := config.GetStringSlicePreserveString(v1, "theme")
 
-	themeConfigs, err := paths.CollectThemes(l.Fs, themesDir, themes)
+	var (
+		configFilenames []string
+		options         []paths.CollectorOption
+	)
+
+	if workspaceFilename := v1.GetString("workspace"); workspaceFilename != "" {
+		w, err := paths.LoadWorkspace(l.Fs, paths.AbsPathify(l.WorkingDir, workspaceFilename))
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, paths.WithWorkspace(w))
+		configFilenames = append(configFilenames, w.Filename)
+	}
+
+	themeConfigs, err := paths.CollectThemes(l.Fs, themesDir, l.Environment, themes, options...)
 	if err != nil {
 		return nil, err
 	}
@@ -458,20 +694,65 @@ func (l configLoader) loadThemeConfig(v1 *viper.Viper) ([]string, error) {
 
 	v1.Set("allThemes", themeConfigs)
 
-	var configFilenames []string
 	for _, tc := range themeConfigs {
 		if tc.ConfigFilename != "" {
 			configFilenames = append(configFilenames, tc.ConfigFilename)
-			if err := l.applyThemeConfig(v1, tc); err != nil {
-				return nil, err
+		}
+		if tc.EnvironmentConfigFilename != "" {
+			configFilenames = append(configFilenames, tc.EnvironmentConfigFilename)
+		}
+		if tc.InWorkspace {
+			// Watch for config files added to an editable theme.
+			configDir := filepath.Join(tc.Dir, "config", l.Environment)
+			if _, err := l.Fs.Stat(configDir); err == nil {
+				configFilenames = append(configFilenames, configDir)
 			}
 		}
 	}
 
+	if err := MergeThemeConfigs(v1, themeConfigs, l.Provenance); err != nil {
+		return nil, err
+	}
+
 	return configFilenames, nil
 
 }
 
+// MergeThemeConfigs merges the config of the given themes into the project
+// config cfg in one pass. The themes must be ordered as returned by
+// paths.CollectThemes, where a theme wins over the themes after it, and the
+// project wins over all of them. Only the keys allowed by the theme config
+// policy are merged, and the theme params are also added in their own
+// namespace, e.g. params.mytheme. The origin of every value merged is
+// recorded in provenance, if set.
+func MergeThemeConfigs(cfg *viper.Viper, themes []paths.ThemeConfig, provenance *config.Provenance) error {
+	l := configLoader{ConfigSourceDescriptor: ConfigSourceDescriptor{Provenance: provenance}}
+	for _, tc := range themes {
+		if tc.Cfg == nil {
+			continue
+		}
+		if err := l.applyThemeConfig(cfg, tc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// themeConfigMerger merges the theme config into the project config, where
+// the theme's params, output formats and media types fill in any gaps.
+var themeConfigMerger = config.NewMerger(config.MergeNone).
+	WithStrategy("params", config.MergeDeep).
+	WithStrategy("outputformats", config.MergeDeep).
+	WithStrategy("mediatypes", config.MergeDeep)
+
+// themeConfigPolicy lists the config keys a theme may set. Any other key in
+// a theme's config is ignored with a warning, so a theme can never redefine
+// critical site settings such as baseURL or publishDir.
+var themeConfigPolicy = config.NewPolicy(
+	"params", "outputformats", "mediatypes", "languages", "menu", "menus",
+	"theme", "hugoversion").
+	Deny("baseurl", "publishdir", "outputs")
+
 func (l configLoader) applyThemeConfig(v1 *viper.Viper, theme paths.ThemeConfig) error {
 
 	const (
@@ -482,10 +763,24 @@ func (l configLoader) applyThemeConfig(v1 *viper.Viper, theme paths.ThemeConfig)
 
 	v2 := theme.Cfg
 
-	for _, key := range []string{paramsKey, "outputformats", "mediatypes"} {
-		l.mergeStringMapKeepLeft("", key, v1, v2)
+	if err := themeConfigPolicy.Check(fmt.Sprintf("theme %q", theme.Name), v2); err != nil {
+		jww.WARN.Printf("%s; they will be ignored", err)
+	}
+
+	if l.Provenance != nil {
+		origin := fmt.Sprintf("theme %q", theme.Name)
+		for _, key := range []string{paramsKey, "outputformats", "mediatypes", menuKey} {
+			if v2.IsSet(key) {
+				l.Provenance.RecordMissing(origin, map[string]interface{}{key: v2.Get(key)})
+			}
+		}
+		if v2.IsSet(paramsKey) {
+			l.Provenance.RecordMissing(origin, map[string]interface{}{paramsKey: map[string]interface{}{strings.ToLower(theme.Name): v2.Get(paramsKey)}})
+		}
 	}
 
+	themeConfigMerger.Merge(v1, v2, paramsKey, "outputformats", "mediatypes")
+
 	themeLower := strings.ToLower(theme.Name)
 	themeParamsNamespace := paramsKey + "." + themeLower
 
@@ -568,6 +863,144 @@ func (configLoader) mergeStringMapKeepLeft(rootKey, key string, v1, v2 config.Pr
 	}
 }
 
+// configSchema holds the known top-level config keys and their kinds.
+var configSchema = config.Schema{
+	"allthemes":                            config.KindAny,
+	"archetypedir":                         config.KindString,
+	"assetdir":                             config.KindString,
+	"author":                               config.KindAny,
+	"baseurl":                              config.KindString,
+	"blackfriday":                          config.KindAny,
+	"builddrafts":                          config.KindBool,
+	"buildexpired":                         config.KindBool,
+	"buildfuture":                          config.KindBool,
+	"buildmanifest":                        config.KindString,
+	"buildquota":                           config.KindMap,
+	"cachedirlistings":                     config.KindBool,
+	"caches":                               config.KindMap,
+	"canonifyurls":                         config.KindBool,
+	"cleandestinationdir":                  config.KindBool,
+	"contentdir":                           config.KindString,
+	"contentguard":                         config.KindMap,
+	"copyright":                            config.KindString,
+	"datadir":                              config.KindString,
+	"debug":                                config.KindBool,
+	"defaultcontentlanguage":               config.KindString,
+	"defaultcontentlanguageinsubdir":       config.KindBool,
+	"dirindex":                             config.KindString,
+	"disablealiases":                       config.KindBool,
+	"disablefastrender":                    config.KindBool,
+	"disablehugogeneratorinject":           config.KindBool,
+	"disablekinds":                         config.KindStringSlice,
+	"disablelanguages":                     config.KindStringSlice,
+	"disablelivereload":                    config.KindBool,
+	"disablepathtolower":                   config.KindBool,
+	"disqusshortname":                      config.KindString,
+	"enableemoji":                          config.KindBool,
+	"enablegitinfo":                        config.KindBool,
+	"enableinlineshortcodes":               config.KindBool,
+	"enablemissingtranslationplaceholders": config.KindBool,
+	"enablerobotstxt":                      config.KindBool,
+	"environment":                          config.KindString,
+	"fingerprint":                          config.KindMap,
+	"footnoteanchorprefix":                 config.KindString,
+	"footnotereturnlinkcontents":           config.KindString,
+	"forcesyncstatic":                      config.KindBool,
+	"frontmatter":                          config.KindMap,
+	"fstimeouts":                           config.KindMap,
+	"googleanalytics":                      config.KindString,
+	"hascjklanguage":                       config.KindBool,
+	"hashcache":                            config.KindString,
+	"i18ndir":                              config.KindString,
+	"ignorecache":                          config.KindBool,
+	"ignorefiles":                          config.KindStringSlice,
+	"imaging":                              config.KindMap,
+	"indexes":                              config.KindMap,
+	"languagecode":                         config.KindString,
+	"languagedirection":                    config.KindString,
+	"languagefromfrontmatter":              config.KindBool,
+	"languagename":                         config.KindString,
+	"languages":                            config.KindMap,
+	"layoutdir":                            config.KindString,
+	"log":                                  config.KindBool,
+	"logfile":                              config.KindString,
+	"mediatypes":                           config.KindMap,
+	"menus":                                config.KindMap,
+	"metadataformat":                       config.KindString,
+	"newcontenteditor":                     config.KindString,
+	"nochmod":                              config.KindBool,
+	"notimes":                              config.KindBool,
+	"outputformats":                        config.KindMap,
+	"outputs":                              config.KindMap,
+	"paginate":                             config.KindInt,
+	"paginatepath":                         config.KindString,
+	"params":                               config.KindMap,
+	"pathfolding":                          config.KindMap,
+	"permalinks":                           config.KindMap,
+	"pins":                                 config.KindAny,
+	"pluralizelisttitles":                  config.KindBool,
+	"prefetchfiles":                        config.KindInt,
+	"privacy":                              config.KindMap,
+	"publishconcurrency":                   config.KindInt,
+	"publishdir":                           config.KindString,
+	"pygmentscodefences":                   config.KindBool,
+	"pygmentscodefencesguesssyntax":        config.KindBool,
+	"pygmentsoptions":                      config.KindString,
+	"pygmentsstyle":                        config.KindString,
+	"pygmentsuseclasses":                   config.KindBool,
+	"pygmentsuseclassic":                   config.KindBool,
+	"related":                              config.KindMap,
+	"relativeurls":                         config.KindBool,
+	"remoteconfig":                         config.KindMap,
+	"removepathaccents":                    config.KindBool,
+	"resourcedir":                          config.KindString,
+	"rsslimit":                             config.KindInt,
+	"sectionpagesmenu":                     config.KindString,
+	"servermetrics":                        config.KindBool,
+	"servesourcefs":                        config.KindBool,
+	"services":                             config.KindMap,
+	"sitemap":                              config.KindAny,
+	"social":                               config.KindAny,
+	"staticdir":                            config.KindStringSlice,
+	"staticmounts":                         config.KindAny,
+	"summarylength":                        config.KindInt,
+	"taxonomies":                           config.KindMap,
+	"theme":                                config.KindStringSlice,
+	"themesdir":                            config.KindString,
+	"timeout":                              config.KindInt,
+	"title":                                config.KindString,
+	"titlecasestyle":                       config.KindString,
+	"uglyurls":                             config.KindAny,
+	"verbose":                              config.KindBool,
+	"verboselog":                           config.KindBool,
+	"watch":                                config.KindBool,
+	"watchdebounce":                        config.KindString,
+	"weight":                               config.KindInt,
+	"workspace":                            config.KindString,
+}
+
+// ConfigSchema returns a copy of the schema of the known top-level config
+// keys, e.g. to generate a JSON Schema from.
+func ConfigSchema() config.Schema {
+	s := make(config.Schema, len(configSchema))
+	for k, v := range configSchema {
+		s[k] = v
+	}
+	return s
+}
+
+// validateConfig validates the loaded config against configSchema and
+// returns the problems found: values of the wrong kind and unknown keys.
+// These are reported as warnings, not errors, as unknown keys may be used by
+// templates and many sites use forms of the keys that Hugo tolerates.
+func validateConfig(v *viper.Viper) []error {
+	err := configSchema.Validate(v.AllSettings())
+	if err == nil {
+		return nil
+	}
+	return err.(*config.ValidationError).Errors
+}
+
 func loadDefaultSettingsFor(v *viper.Viper) error {
 
 	c, err := helpers.NewContentSpec(v)
@@ -579,6 +1012,7 @@ func loadDefaultSettingsFor(v *viper.Viper) error {
 
 	v.SetDefault("cleanDestinationDir", false)
 	v.SetDefault("watch", false)
+	v.SetDefault("watchDebounce", "500ms")
 	v.SetDefault("metaDataFormat", "toml")
 	v.SetDefault("contentDir", "content")
 	v.SetDefault("layoutDir", "layouts")
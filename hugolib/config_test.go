@@ -14,8 +14,17 @@
 package hugolib
 
 import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/gohugoio/hugo/config"
+	"github.com/gohugoio/hugo/hugolib/paths"
+	"github.com/gohugoio/hugo/langs"
 	"github.com/spf13/afero"
 	"github.com/spf13/viper"
 	"github.com/stretchr/testify/require"
@@ -73,6 +82,500 @@ func TestLoadMultiConfig(t *testing.T) {
 	assert.Equal("same", cfg.GetString("DontChange"))
 }
 
+func TestLoadConfigThemeEnvironment(t *testing.T) {
+	t.Parallel()
+
+	assert := require.New(t)
+
+	mm := afero.NewMemMapFs()
+
+	writeToFs(t, mm, "hugo.toml", `theme = "mytheme"`)
+	writeToFs(t, mm, "themes/mytheme/config.toml", `
+[params]
+p1 = "p1_theme"
+`)
+	writeToFs(t, mm, "themes/mytheme/config/development/config.toml", `
+theme = "seo-debug"
+[params]
+p2 = "p2_theme_development"
+`)
+	writeToFs(t, mm, "themes/seo-debug/layouts/partials/seo.html", "SEO")
+
+	themeNames := func(cfg config.Provider) []string {
+		var names []string
+		for _, tc := range cfg.Get("allThemes").([]paths.ThemeConfig) {
+			names = append(names, tc.Name)
+		}
+		return names
+	}
+
+	cfg, configFiles, err := LoadConfig(ConfigSourceDescriptor{Fs: mm, Filename: "hugo.toml", Environment: "development"})
+	assert.NoError(err)
+	assert.Equal([]string{"mytheme", "seo-debug"}, themeNames(cfg))
+	assert.Equal("development", config.GetEnvironment(cfg))
+	assert.Equal("p1_theme", cfg.GetString("params.p1"))
+	assert.Equal("p2_theme_development", cfg.GetString("params.p2"))
+	assert.Contains(configFiles, filepath.FromSlash("themes/mytheme/config/development/config.toml"))
+
+	cfg, _, err = LoadConfig(ConfigSourceDescriptor{Fs: mm, Filename: "hugo.toml", Environment: "production"})
+	assert.NoError(err)
+	assert.Equal([]string{"mytheme"}, themeNames(cfg))
+	assert.False(cfg.IsSet("params.p2"))
+}
+
+func TestLoadConfigThemePolicy(t *testing.T) {
+	t.Parallel()
+
+	assert := require.New(t)
+
+	mm := afero.NewMemMapFs()
+
+	writeToFs(t, mm, "hugo.toml", `
+baseURL = "https://example.org"
+theme = "mytheme"
+`)
+	writeToFs(t, mm, "themes/mytheme/config.toml", `
+baseURL = "https://example.com"
+publishDir = "docs"
+[params]
+p1 = "p1_theme"
+`)
+
+	cfg, _, err := LoadConfig(ConfigSourceDescriptor{Fs: mm, Filename: "hugo.toml"})
+	assert.NoError(err)
+	assert.Equal("https://example.org", cfg.GetString("baseURL"))
+	assert.Equal("public", cfg.GetString("publishDir"))
+	assert.Equal("p1_theme", cfg.GetString("params.p1"))
+
+	err = themeConfigPolicy.Check(`theme "mytheme"`, cfg.Get("allThemes").([]paths.ThemeConfig)[0].Cfg)
+	assert.Error(err)
+	assert.Contains(err.Error(), "baseurl, publishdir")
+}
+
+func TestLoadConfigLanguageAliases(t *testing.T) {
+	t.Parallel()
+
+	assert := require.New(t)
+
+	mm := afero.NewMemMapFs()
+
+	writeToFs(t, mm, "hugo.toml", `
+defaultContentLanguage = "iw"
+disableLanguages = ["no"]
+[languages]
+[languages.he]
+weight = 1
+aliases = ["iw"]
+[languages.nb]
+weight = 2
+aliases = ["no"]
+`)
+
+	cfg, _, err := LoadConfig(ConfigSourceDescriptor{Fs: mm, Filename: "hugo.toml"})
+	assert.NoError(err)
+	assert.Equal("he", cfg.GetString("defaultContentLanguage"))
+	languages := cfg.Get("languagesSorted").(langs.Languages)
+	assert.Equal([]string{"he"}, languages.Active().Langs())
+	assert.Equal("he", languages.CanonicalLang("iw"))
+
+	writeToFs(t, mm, "hugo.toml", `
+[languages]
+[languages.he]
+aliases = ["iw"]
+[languages.iw]
+`)
+
+	_, _, err = LoadConfig(ConfigSourceDescriptor{Fs: mm, Filename: "hugo.toml"})
+	assert.Error(err)
+}
+
+func TestLoadConfigMergeContentFrom(t *testing.T) {
+	t.Parallel()
+
+	assert := require.New(t)
+
+	mm := afero.NewMemMapFs()
+
+	writeToFs(t, mm, "hugo.toml", `
+[languages]
+[languages.en]
+weight = 1
+[languages.en-gb]
+weight = 2
+mergeContentFrom = "en"
+`)
+
+	cfg, _, err := LoadConfig(ConfigSourceDescriptor{Fs: mm, Filename: "hugo.toml"})
+	assert.NoError(err)
+	languages := cfg.Get("languagesSorted").(langs.Languages)
+	assert.Equal("en", languages[1].MergeContentFrom)
+
+	writeToFs(t, mm, "hugo.toml", `
+[languages]
+[languages.en]
+[languages.en-gb]
+mergeContentFrom = "fr"
+`)
+
+	_, _, err = LoadConfig(ConfigSourceDescriptor{Fs: mm, Filename: "hugo.toml"})
+	assert.Error(err)
+	assert.Contains(err.Error(), `mergeContentFrom "fr" does not match any language definition`)
+}
+
+func TestLoadConfigCustomLanguageCode(t *testing.T) {
+	t.Parallel()
+
+	assert := require.New(t)
+
+	mm := afero.NewMemMapFs()
+
+	writeToFs(t, mm, "hugo.toml", `
+[languages]
+[languages.en]
+weight = 1
+[languages.docs_v2]
+weight = 2
+`)
+
+	_, _, err := LoadConfig(ConfigSourceDescriptor{Fs: mm, Filename: "hugo.toml"})
+	assert.Error(err)
+
+	writeToFs(t, mm, "hugo.toml", `
+[languages]
+[languages.en]
+weight = 1
+[languages.docs_v2]
+weight = 2
+customCode = true
+`)
+
+	cfg, _, err := LoadConfig(ConfigSourceDescriptor{Fs: mm, Filename: "hugo.toml"})
+	assert.NoError(err)
+	languages := cfg.Get("languagesSorted").(langs.Languages)
+	assert.Equal([]string{"en", "docs_v2"}, languages.Langs())
+	assert.True(languages[1].CustomCode)
+}
+
+func TestLoadConfigValidation(t *testing.T) {
+	t.Parallel()
+
+	assert := require.New(t)
+
+	mm := afero.NewMemMapFs()
+
+	writeToFs(t, mm, "hugo.toml", `
+title = ["a", "b"]
+paginate = "many"
+titel = "Typo"
+author = "Jane Doe"
+`)
+
+	// The problems are warnings, not errors.
+	cfg, _, err := LoadConfig(ConfigSourceDescriptor{Fs: mm, Filename: "hugo.toml"})
+	assert.NoError(err)
+	assert.Equal("Jane Doe", cfg.GetString("author"))
+
+	msg := (&config.ValidationError{Errors: validateConfig(cfg)}).Error()
+	assert.Contains(msg, `config key "paginate": expected int, got string`)
+	assert.Contains(msg, `config key "title": expected string, got []interface {}`)
+	assert.Contains(msg, `unknown config key "titel"`)
+	assert.NotContains(msg, `"author"`)
+}
+
+func TestLoadConfigProvenance(t *testing.T) {
+	t.Parallel()
+
+	assert := require.New(t)
+
+	mm := afero.NewMemMapFs()
+
+	writeToFs(t, mm, "hugo.toml", `
+theme = "mytheme"
+title = "Project"
+[params]
+p1 = "p1_project"
+`)
+	writeToFs(t, mm, "config/production/params.toml", `p2 = "p2_production"`)
+	writeToFs(t, mm, "themes/mytheme/config.toml", `
+[params]
+p1 = "p1_theme"
+p3 = "p3_theme"
+`)
+
+	provenance := config.NewProvenance()
+	_, _, err := LoadConfig(ConfigSourceDescriptor{Fs: mm, Filename: "hugo.toml", AbsConfigDir: "config", Provenance: provenance})
+	assert.NoError(err)
+
+	assert.Equal("hugo.toml", provenance.Origin("title"))
+	assert.Equal("hugo.toml", provenance.Origin("params.p1"))
+	assert.Equal(filepath.FromSlash("config/production/params.toml"), provenance.Origin("params.p2"))
+	assert.Equal(`theme "mytheme"`, provenance.Origin("params.p3"))
+	assert.Equal(`theme "mytheme"`, provenance.Origin("params.mytheme.p1"))
+	assert.Equal(config.OriginDefault, provenance.Origin("paginate"))
+}
+
+func TestLoadConfigImports(t *testing.T) {
+	t.Parallel()
+
+	assert := require.New(t)
+
+	mm := afero.NewMemMapFs()
+
+	writeToFs(t, mm, "hugo.toml", `
+imports = ["conf/params.toml", "conf/menus.yaml"]
+title = "Project"
+[params]
+p1 = "p1_project"
+`)
+	writeToFs(t, mm, "conf/params.toml", `
+imports = ["more/params.toml"]
+title = "Params"
+[params]
+p1 = "p1_params"
+p2 = "p2_params"
+`)
+	writeToFs(t, mm, "conf/more/params.toml", `
+[params]
+p2 = "p2_more"
+p3 = "p3_more"
+`)
+	writeToFs(t, mm, "conf/menus.yaml", `
+menu:
+  main:
+  - name: Home
+    url: /
+`)
+
+	provenance := config.NewProvenance()
+	cfg, configFiles, err := LoadConfig(ConfigSourceDescriptor{Fs: mm, Filename: "hugo.toml", Provenance: provenance})
+	assert.NoError(err)
+
+	assert.Equal("Project", cfg.GetString("title"))
+	assert.Equal("p1_project", cfg.GetString("params.p1"))
+	assert.Equal("p2_params", cfg.GetString("params.p2"))
+	assert.Equal("p3_more", cfg.GetString("params.p3"))
+	assert.True(cfg.IsSet("menus.main"))
+	assert.False(cfg.IsSet("imports"))
+
+	assert.Equal([]string{
+		"hugo.toml",
+		filepath.FromSlash("conf/params.toml"),
+		filepath.FromSlash("conf/more/params.toml"),
+		filepath.FromSlash("conf/menus.yaml"),
+	}, configFiles)
+
+	assert.Equal("hugo.toml", provenance.Origin("title"))
+	assert.Equal(filepath.FromSlash("conf/params.toml"), provenance.Origin("params.p2"))
+	assert.Equal(filepath.FromSlash("conf/more/params.toml"), provenance.Origin("params.p3"))
+
+	writeToFs(t, mm, "conf/more/params.toml", `imports = ["../../hugo.toml"]`)
+
+	_, _, err = LoadConfig(ConfigSourceDescriptor{Fs: mm, Filename: "hugo.toml"})
+	assert.Error(err)
+	assert.Contains(err.Error(), "config import cycle: hugo.toml -> "+filepath.FromSlash("conf/params.toml"))
+}
+
+func TestLoadConfigDeprecatedKeys(t *testing.T) {
+	// Not parallel, replaces the package level deprecatedConfigKeys.
+	assert := require.New(t)
+
+	old := deprecatedConfigKeys
+	defer func() { deprecatedConfigKeys = old }()
+	deprecatedConfigKeys = mustNewDeprecatedKeys("{oldTitle,languages/*/oldTitle}", "title")
+
+	mm := afero.NewMemMapFs()
+
+	writeToFs(t, mm, "hugo.toml", `
+oldTitle = "Project"
+defaultContentLanguage = "nn"
+[languages]
+[languages.nn]
+oldTitle = "Prosjekt"
+weight = 1
+`)
+
+	cfg, _, err := LoadConfig(ConfigSourceDescriptor{Fs: mm, Filename: "hugo.toml"})
+	assert.NoError(err)
+	assert.Equal("Project", cfg.GetString("title"))
+	assert.Equal("Prosjekt", cfg.GetString("languages.nn.title"))
+	assert.False(cfg.IsSet("oldTitle"))
+}
+
+func TestLoadConfigDeprecatedMenu(t *testing.T) {
+	t.Parallel()
+
+	assert := require.New(t)
+
+	mm := afero.NewMemMapFs()
+
+	writeToFs(t, mm, "hugo.toml", `
+[[menu.main]]
+name = "Home"
+[languages]
+[languages.nn]
+[[languages.nn.menu.main]]
+name = "Heim"
+`)
+
+	l := configLoader{ConfigSourceDescriptor: ConfigSourceDescriptor{Fs: mm}, deprecations: &config.DeprecationLog{}}
+	v := viper.New()
+	_, err := l.loadConfig("hugo.toml", v)
+	assert.NoError(err)
+	assert.True(v.IsSet("menus.main"))
+	assert.True(v.IsSet("languages.nn.menus.main"))
+	assert.Equal([]config.DeprecatedKeyUse{
+		{Key: "languages.nn.menu", Replacement: "languages.nn.menus", Origin: "hugo.toml"},
+		{Key: "menu", Replacement: "menus", Origin: "hugo.toml"},
+	}, l.deprecations.Uses())
+}
+
+func TestLoadConfigRemote(t *testing.T) {
+	t.Parallel()
+
+	assert := require.New(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `
+title = "Remote"
+paginate = 20
+[params]
+p1 = "p1_remote"
+p2 = "p2_remote"
+`)
+	}))
+	defer srv.Close()
+
+	mm := afero.NewMemMapFs()
+
+	writeToFs(t, mm, "hugo.toml", fmt.Sprintf(`
+title = "Project"
+cacheDir = "/cache"
+[params]
+p1 = "p1_project"
+[remoteConfig]
+url = "%s/defaults.toml"
+`, srv.URL))
+
+	provenance := config.NewProvenance()
+	cfg, _, err := LoadConfig(ConfigSourceDescriptor{Fs: mm, Filename: "hugo.toml", Provenance: provenance})
+	assert.NoError(err)
+
+	assert.Equal("Project", cfg.GetString("title"))
+	assert.Equal(20, cfg.GetInt("paginate"))
+	assert.Equal("p1_project", cfg.GetString("params.p1"))
+	assert.Equal("p2_remote", cfg.GetString("params.p2"))
+
+	origin := fmt.Sprintf("remote %q", srv.URL+"/defaults.toml")
+	assert.Equal("hugo.toml", provenance.Origin("title"))
+	assert.Equal(origin, provenance.Origin("paginate"))
+	assert.Equal(origin, provenance.Origin("params.p2"))
+
+	writeToFs(t, mm, "hugo.toml", fmt.Sprintf(`
+[remoteConfig]
+url = "%s/defaults.toml"
+sha256 = "abcd"
+`, srv.URL))
+
+	_, _, err = LoadConfig(ConfigSourceDescriptor{Fs: mm, Filename: "hugo.toml"})
+	assert.Error(err)
+}
+
+func TestLoadConfigRemoteTimeout(t *testing.T) {
+	t.Parallel()
+
+	assert := require.New(t)
+
+	var (
+		stalled int32
+		release = make(chan struct{})
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&stalled) == 1 {
+			select {
+			case <-release:
+			case <-r.Context().Done():
+			}
+			return
+		}
+		fmt.Fprint(w, `
+[params]
+p1 = "p1_remote"
+`)
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	mm := afero.NewMemMapFs()
+
+	writeToFs(t, mm, "hugo.toml", fmt.Sprintf(`
+cacheDir = "/cache"
+[remoteConfig]
+url = "%s/defaults.toml"
+timeout = "100ms"
+`, srv.URL))
+
+	cfg, _, err := LoadConfig(ConfigSourceDescriptor{Fs: mm, Filename: "hugo.toml"})
+	assert.NoError(err)
+	assert.Equal("p1_remote", cfg.GetString("params.p1"))
+
+	// The server stops responding; we should give up and use the cached copy.
+	atomic.StoreInt32(&stalled, 1)
+
+	start := time.Now()
+	cfg, _, err = LoadConfig(ConfigSourceDescriptor{Fs: mm, Filename: "hugo.toml"})
+	assert.NoError(err)
+	assert.Equal("p1_remote", cfg.GetString("params.p1"))
+	assert.True(time.Since(start) < 10*time.Second)
+}
+
+func TestMergeThemeConfigs(t *testing.T) {
+	t.Parallel()
+
+	assert := require.New(t)
+
+	newCfg := func(s string) config.Provider {
+		cfg, err := config.FromConfigString(s, "toml")
+		assert.NoError(err)
+		return cfg
+	}
+
+	v := viper.New()
+	v.Set("params", map[string]interface{}{"p1": "p1_project"})
+
+	themes := []paths.ThemeConfig{
+		{Name: "a", Cfg: newCfg(`
+baseURL = "https://a.org"
+[params]
+p1 = "p1_a"
+p2 = "p2_a"
+`)},
+		{Name: "b"},
+		{Name: "c", Cfg: newCfg(`
+[params]
+p2 = "p2_c"
+p3 = "p3_c"
+[menus]
+[[menus.main]]
+name = "Home"
+`)},
+	}
+
+	provenance := config.NewProvenance()
+	assert.NoError(MergeThemeConfigs(v, themes, provenance))
+
+	assert.False(v.IsSet("baseURL"))
+	assert.Equal("p1_project", v.GetString("params.p1"))
+	assert.Equal("p2_a", v.GetString("params.p2"))
+	assert.Equal("p3_c", v.GetString("params.p3"))
+	assert.Equal("p2_c", v.GetString("params.c.p2"))
+	assert.True(v.IsSet("menus.main"))
+
+	assert.Equal(`theme "a"`, provenance.Origin("params.p2"))
+	assert.Equal(`theme "c"`, provenance.Origin("params.p3"))
+	assert.Equal(`theme "c"`, provenance.Origin("params.c.p2"))
+}
+
 func TestLoadConfigFromTheme(t *testing.T) {
 	t.Parallel()
 
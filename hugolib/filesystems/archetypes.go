@@ -0,0 +1,82 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filesystems
+
+import (
+	"github.com/spf13/afero"
+)
+
+// ArchetypeFs is the composite filesystem of the project's and the themes'
+// archetype dirs, where a file in the project shadows the same file in the
+// themes.
+type ArchetypeFs struct {
+	afero.Fs
+}
+
+// ArchetypeFs returns the archetypes filesystem, nil if not set.
+func (s *SourceFilesystems) ArchetypeFs() *ArchetypeFs {
+	if s == nil || s.Archetypes == nil {
+		return nil
+	}
+	return &ArchetypeFs{Fs: s.Archetypes.Fs}
+}
+
+// Lookup returns the archetype to use for new content of the given kind
+// (e.g. "posts") and file extension (e.g. ".md") in lang, and whether the
+// archetype is a directory, i.e. a bundle. It returns an empty string if none
+// is found.
+//
+// The archetypes are tried in this order, the first found wins:
+//
+//	posts.nn.md
+//	posts.md
+//	default.nn.md
+//	default.md
+//	default
+//
+// The language specific archetypes are only tried if both lang and ext are
+// set. Since the project's files shadow the themes', a file in the project
+// wins over the same file in a theme, but a language specific archetype in a
+// theme wins over a generic one in the project.
+func (fs *ArchetypeFs) Lookup(kind, ext, lang string) (filename string, isDir bool) {
+	if fs == nil {
+		return "", false
+	}
+
+	var candidates []string
+
+	add := func(base string) {
+		if lang != "" && ext != "" {
+			candidates = append(candidates, base+"."+lang+ext)
+		}
+		candidates = append(candidates, base+ext)
+	}
+
+	if kind != "" {
+		add(kind)
+	}
+	add("default")
+	if ext != "" {
+		candidates = append(candidates, "default")
+	}
+
+	for _, candidate := range candidates {
+		fi, err := fs.Stat(candidate)
+		if err == nil {
+			return candidate, fi.IsDir()
+		}
+	}
+
+	return "", false
+}
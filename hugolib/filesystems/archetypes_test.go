@@ -0,0 +1,76 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filesystems
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/gohugoio/hugo/hugofs"
+	"github.com/gohugoio/hugo/hugolib/paths"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArchetypeFsLookup(t *testing.T) {
+	assert := require.New(t)
+	v := createConfig()
+	workDir := filepath.FromSlash("/mywork")
+	v.Set("workingDir", workDir)
+	v.Set("themesDir", "themes")
+	v.Set("theme", "mytheme")
+
+	fs := hugofs.NewMem(v)
+
+	for _, filename := range []string{
+		"myarchetypes/posts.md",
+		"myarchetypes/default.md",
+		"myarchetypes/bundle/index.md",
+		"themes/mytheme/archetypes/posts.nn.md",
+		"themes/mytheme/archetypes/default.sv.md",
+		"themes/mytheme/archetypes/events.md",
+	} {
+		assert.NoError(afero.WriteFile(fs.Source, filepath.Join(workDir, filepath.FromSlash(filename)), []byte("archetype"), 0755))
+	}
+
+	p, err := paths.New(fs, v)
+	assert.NoError(err)
+	bfs, err := NewBase(p)
+	assert.NoError(err)
+
+	afs := bfs.ArchetypeFs()
+
+	for _, test := range []struct {
+		kind, ext, lang string
+		expect          string
+		expectDir       bool
+	}{
+		{"posts", ".md", "en", "posts.md", false},
+		{"posts", ".md", "nn", "posts.nn.md", false},
+		{"posts", ".md", "", "posts.md", false},
+		{"events", ".md", "en", "events.md", false},
+		{"pages", ".md", "en", "default.md", false},
+		{"pages", ".md", "sv", "default.sv.md", false},
+		{"", ".md", "en", "default.md", false},
+		{"bundle", "", "en", "bundle", true},
+		{"pages", ".org", "en", "", false},
+	} {
+		filename, isDir := afs.Lookup(test.kind, test.ext, test.lang)
+		assert.Equal(test.expect, filename, test.kind+test.ext+" "+test.lang)
+		assert.Equal(test.expectDir, isDir)
+	}
+
+	filename, _ := (*ArchetypeFs)(nil).Lookup("posts", ".md", "en")
+	assert.Equal("", filename)
+}
@@ -0,0 +1,88 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filesystems
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/gohugoio/hugo/hugofs"
+)
+
+// AssetOrigin describes where a file in the assets filesystem comes from.
+type AssetOrigin struct {
+	// The path relative to the assets root, e.g. "scss/main.scss".
+	Path string `json:"path"`
+
+	// The absolute filename of the file used in the build.
+	Filename string `json:"filename"`
+
+	// The name of the theme the file belongs to, empty for the project.
+	Theme string `json:"theme,omitempty"`
+}
+
+func (o AssetOrigin) String() string {
+	if o.Theme == "" {
+		return fmt.Sprintf("%q", o.Filename)
+	}
+	return fmt.Sprintf("%q in theme %q", o.Filename, o.Theme)
+}
+
+// AssetOrigin returns the origin of the file at the given path relative to
+// the assets root, and whether it was found.
+func (b *BaseFs) AssetOrigin(path string) (AssetOrigin, bool) {
+	if b == nil || b.SourceFilesystems == nil || b.Assets == nil {
+		return AssetOrigin{}, false
+	}
+
+	path = filepath.Clean(path)
+	fi, err := b.Assets.Fs.Stat(path)
+	if err != nil || fi.IsDir() {
+		return AssetOrigin{}, false
+	}
+	rfi, ok := fi.(hugofs.RealFilenameInfo)
+	if !ok {
+		return AssetOrigin{}, false
+	}
+
+	filename := rfi.RealFilename()
+
+	return AssetOrigin{Path: path, Filename: filename, Theme: b.themeName(filename)}, true
+}
+
+// AssetOrigins returns the origin of every file in the assets filesystem,
+// keyed by its path relative to the assets root.
+func (b *BaseFs) AssetOrigins() (map[string]AssetOrigin, error) {
+	if b == nil || b.SourceFilesystems == nil || b.Assets == nil {
+		return nil, nil
+	}
+
+	origins := make(map[string]AssetOrigin)
+
+	// The first dir wins.
+	for _, dir := range b.byPrecedence(b.Assets.Dirnames) {
+		theme := b.themeName(dir)
+		err := walkFiles(b.Assets.SourceFs, dir, func(rel string) error {
+			if _, found := origins[rel]; !found {
+				origins[rel] = AssetOrigin{Path: rel, Filename: filepath.Join(dir, rel), Theme: theme}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return origins, nil
+}
@@ -0,0 +1,80 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filesystems
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/gohugoio/hugo/hugofs"
+	"github.com/gohugoio/hugo/hugolib/paths"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAssetOrigins(t *testing.T) {
+	assert := require.New(t)
+	v := createConfig()
+	workDir := filepath.FromSlash("/mywork")
+	v.Set("workingDir", workDir)
+	v.Set("themesDir", "themes")
+	v.Set("theme", []string{"btheme", "atheme"})
+
+	fs := hugofs.NewMem(v)
+
+	join := func(elem ...string) string {
+		return filepath.Join(append([]string{workDir}, elem...)...)
+	}
+
+	for _, filename := range []string{
+		join("myassets", "scss", "main.scss"),
+		join("themes", "atheme", "assets", "scss", "main.scss"),
+		join("themes", "atheme", "assets", "scss", "_vars.scss"),
+		join("themes", "btheme", "assets", "scss", "_vars.scss"),
+		join("themes", "btheme", "assets", "js", "app.js"),
+	} {
+		assert.NoError(afero.WriteFile(fs.Source, filename, []byte("// "+filename), 0755))
+	}
+
+	p, err := paths.New(fs, v)
+	assert.NoError(err)
+	bfs, err := NewBase(p)
+	assert.NoError(err)
+
+	main := filepath.Join("scss", "main.scss")
+	vars := filepath.Join("scss", "_vars.scss")
+	app := filepath.Join("js", "app.js")
+
+	origin, found := bfs.AssetOrigin(main)
+	assert.True(found)
+	assert.Equal(AssetOrigin{Path: main, Filename: join("myassets", "scss", "main.scss")}, origin)
+
+	origin, found = bfs.AssetOrigin(vars)
+	assert.True(found)
+	assert.Equal(AssetOrigin{Path: vars, Filename: join("themes", "btheme", "assets", "scss", "_vars.scss"), Theme: "btheme"}, origin)
+	assert.Contains(origin.String(), `in theme "btheme"`)
+
+	_, found = bfs.AssetOrigin("scss")
+	assert.False(found)
+	_, found = bfs.AssetOrigin("missing.css")
+	assert.False(found)
+
+	origins, err := bfs.AssetOrigins()
+	assert.NoError(err)
+	assert.Len(origins, 3)
+	for _, path := range []string{main, vars, app} {
+		origin, _ := bfs.AssetOrigin(path)
+		assert.Equal(origin, origins[path], path)
+	}
+}
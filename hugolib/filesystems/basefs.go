@@ -16,9 +16,9 @@
 package filesystems
 
 import (
-	"errors"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/gohugoio/hugo/config"
@@ -53,6 +53,19 @@ type BaseFs struct {
 
 	themeFs afero.Fs
 
+	// The content dirs with their language filesystems, in the order they
+	// are composed.
+	contentDirs []contentDir
+
+	// The layouts filesystem with its cached lookups, see LayoutsFs.
+	layoutsFs *LayoutsFs
+
+	// The files pinned to a mount, see Pin.
+	pinnedFiles []pinnedFile
+
+	// Optional guard against unexpected binary files in the content dirs.
+	contentGuard *hugofs.ContentGuard
+
 	// TODO(bep) improve the "theme interaction"
 	AbsThemeDirs []string
 }
@@ -70,6 +83,99 @@ func (b *BaseFs) RelContentDir(filename string) string {
 	return filename
 }
 
+// MountChanges lists the source directories added and removed when going
+// from one BaseFs to another, e.g. after a config change in server mode.
+type MountChanges struct {
+	Added   []string
+	Removed []string
+
+	// Set if the static directories or where they are published to changed.
+	StaticChanged bool
+}
+
+// IsZero returns whether there are no changes.
+func (m MountChanges) IsZero() bool {
+	return len(m.Added) == 0 && len(m.Removed) == 0 && !m.StaticChanged
+}
+
+// DiffMounts returns the source directories in b not in old, and the other
+// way around.
+func (b *BaseFs) DiffMounts(old *BaseFs) MountChanges {
+	var changes MountChanges
+
+	oldDirs, newDirs := old.mountDirs(), b.mountDirs()
+
+	for dir := range newDirs {
+		if !oldDirs[dir] {
+			changes.Added = append(changes.Added, dir)
+		}
+	}
+	for dir := range oldDirs {
+		if !newDirs[dir] {
+			changes.Removed = append(changes.Removed, dir)
+		}
+	}
+
+	sort.Strings(changes.Added)
+	sort.Strings(changes.Removed)
+
+	oldStatic, newStatic := old.staticMounts(), b.staticMounts()
+	if len(oldStatic) != len(newStatic) {
+		changes.StaticChanged = true
+	} else {
+		for mount := range newStatic {
+			if !oldStatic[mount] {
+				changes.StaticChanged = true
+				break
+			}
+		}
+	}
+
+	return changes
+}
+
+func (b *BaseFs) mountDirs() map[string]bool {
+	dirs := make(map[string]bool)
+	if b == nil || b.SourceFilesystems == nil {
+		return dirs
+	}
+
+	s := b.SourceFilesystems
+	for _, sfs := range []*SourceFilesystem{s.Content, s.Data, s.I18n, s.Layouts, s.Archetypes, s.Assets} {
+		if sfs == nil {
+			continue
+		}
+		for _, dir := range sfs.Dirnames {
+			dirs[filepath.Clean(dir)] = true
+		}
+	}
+
+	for _, sfs := range s.Static {
+		for _, dir := range sfs.Dirnames {
+			dirs[filepath.Clean(dir)] = true
+		}
+	}
+
+	return dirs
+}
+
+// staticMounts returns the static directories with the folder they are
+// published to.
+func (b *BaseFs) staticMounts() map[string]bool {
+	mounts := make(map[string]bool)
+	if b == nil || b.SourceFilesystems == nil {
+		return mounts
+	}
+
+	for _, sfs := range b.Static {
+		for _, dir := range sfs.Dirnames {
+			mounts[sfs.PublishFolder+"|"+filepath.Clean(dir)] = true
+		}
+	}
+
+	return mounts
+}
+
 // SourceFilesystems contains the different source file systems. These can be
 // composite file systems (theme and project etc.), and they have all root
 // set to the source type the provides: data, i18n, static, layouts.
@@ -106,6 +212,10 @@ type SourceFilesystem struct {
 	// Dirnames is absolute filenames to the directories in this filesystem.
 	Dirnames []string
 
+	// Targets maps the dirs in Dirnames mounted into a subdirectory of this
+	// filesystem to that subdirectory, see StaticMount.
+	Targets map[string]string
+
 	// When syncing a source folder to the target (e.g. /public), this may
 	// be set to publish into a subfolder. This is used for static syncing
 	// in multihost mode.
@@ -119,8 +229,8 @@ type SourceFilesystem struct {
 func (s SourceFilesystems) ContentStaticAssetFs(lang string) afero.Fs {
 	staticFs := s.StaticFs(lang)
 
-	base := afero.NewCopyOnWriteFs(s.Assets.Fs, staticFs)
-	return afero.NewCopyOnWriteFs(base, s.Content.Fs)
+	base := hugofs.NewOrderedCopyOnWriteFs(s.Assets.Fs, staticFs)
+	return hugofs.NewOrderedCopyOnWriteFs(base, s.Content.Fs)
 
 }
 
@@ -209,7 +319,11 @@ func (s SourceFilesystems) MakeStaticPathRelative(filename string) string {
 func (d *SourceFilesystem) MakePathRelative(filename string) string {
 	for _, currentPath := range d.Dirnames {
 		if strings.HasPrefix(filename, currentPath) {
-			return strings.TrimPrefix(filename, currentPath)
+			rel := strings.TrimPrefix(filename, currentPath)
+			if target, found := d.Targets[currentPath]; found {
+				rel = filePathSeparator + target + rel
+			}
+			return rel
 		}
 	}
 	return ""
@@ -271,10 +385,30 @@ func NewBase(p *paths.Paths, options ...func(*BaseFs) error) (*BaseFs, error) {
 
 	publishFs := afero.NewBasePathFs(fs.Destination, p.AbsPublishDir)
 
-	contentFs, absContentDirs, err := createContentFs(fs.Source, p.WorkingDir, p.DefaultContentLanguage, p.Languages)
+	var classifier hugofs.LanguageClassifier
+	if p.Cfg.GetBool("languageFromFrontMatter") {
+		classifier = hugofs.FrontMatterLanguageClassifier
+	}
+
+	contentFs, contentDirs, err := createContentFs(fs, p.WorkingDir, p.DefaultContentLanguage, p.Languages, classifier)
+	if err != nil {
+		return nil, err
+	}
+
+	guard, err := decodeContentGuard(p.Cfg)
 	if err != nil {
 		return nil, err
 	}
+	if guard != nil {
+		for _, cd := range contentDirs {
+			cd.fs.WithContentGuard(guard)
+		}
+	}
+
+	absContentDirs := make([]string, len(contentDirs))
+	for i, d := range contentDirs {
+		absContentDirs[i] = d.dir
+	}
 
 	// Make sure we don't have any overlapping content dirs. That will never work.
 	for i, d1 := range absContentDirs {
@@ -289,7 +423,9 @@ func NewBase(p *paths.Paths, options ...func(*BaseFs) error) (*BaseFs, error) {
 	}
 
 	b := &BaseFs{
-		PublishFs: publishFs,
+		PublishFs:    publishFs,
+		contentDirs:  contentDirs,
+		contentGuard: guard,
 	}
 
 	for _, opt := range options {
@@ -314,6 +450,16 @@ func NewBase(p *paths.Paths, options ...func(*BaseFs) error) (*BaseFs, error) {
 	b.themeFs = builder.themeFs
 	b.AbsThemeDirs = builder.absThemeDirs
 
+	pins, err := decodePins(p.Cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := b.applyPins(pins, builder.defaultLang()); err != nil {
+		return nil, err
+	}
+
+	b.layoutsFs = newLayoutsFs(sourceFilesystems.Layouts)
+
 	return b, nil
 }
 
@@ -323,6 +469,7 @@ type sourceFilesystemsBuilder struct {
 	themeFs      afero.Fs
 	hasTheme     bool
 	absThemeDirs []string
+	staticMounts []StaticMount
 }
 
 func newSourceFilesystemsBuilder(p *paths.Paths, b *BaseFs) *sourceFilesystemsBuilder {
@@ -345,6 +492,12 @@ func (b *sourceFilesystemsBuilder) Build() (*SourceFilesystems, error) {
 
 	b.hasTheme = len(b.absThemeDirs) > 0
 
+	staticMounts, err := decodeStaticMounts(b.p.Cfg)
+	if err != nil {
+		return nil, err
+	}
+	b.staticMounts = staticMounts
+
 	sfs, err := b.createRootMappingFs("dataDir", "data")
 	if err != nil {
 		return nil, err
@@ -439,7 +592,7 @@ func (b *sourceFilesystemsBuilder) createFs(
 		if fs == nil {
 			fs = themeFolderFs
 		} else {
-			fs = afero.NewCopyOnWriteFs(themeFolderFs, fs)
+			fs = hugofs.NewOrderedCopyOnWriteFs(themeFolderFs, fs)
 		}
 
 		for _, absThemeDir := range b.absThemeDirs {
@@ -453,9 +606,9 @@ func (b *sourceFilesystemsBuilder) createFs(
 	if fs == nil {
 		s.Fs = hugofs.NoOpFs
 	} else if readOnly {
-		s.Fs = afero.NewReadOnlyFs(fs)
+		s.Fs = afero.NewReadOnlyFs(hugofs.NewLanguageMetaFs(b.defaultLang(), fs))
 	} else {
-		s.Fs = fs
+		s.Fs = hugofs.NewLanguageMetaFs(b.defaultLang(), fs)
 	}
 
 	return s, nil
@@ -482,7 +635,7 @@ func (b *sourceFilesystemsBuilder) createRootMappingFs(dirKey, themeFolder strin
 	}
 
 	for _, theme := range b.p.AllThemes {
-		to := b.p.AbsPathify(filepath.Join(b.p.ThemesDir, theme.Name, themeFolder))
+		to := filepath.Join(theme.Dir, themeFolder)
 		if b.existsInSource(to) {
 			s.Dirnames = append(s.Dirnames, to)
 			from := theme
@@ -499,12 +652,22 @@ func (b *sourceFilesystemsBuilder) createRootMappingFs(dirKey, themeFolder strin
 	if err != nil {
 		return nil, err
 	}
+	fs.WithLogger(b.p.Fs.Logger)
 
-	s.Fs = afero.NewReadOnlyFs(fs)
+	s.Fs = afero.NewReadOnlyFs(hugofs.NewLanguageMetaFs(b.defaultLang(), fs))
 
 	return s, nil
 }
 
+// defaultLang is the language of the files in filesystems with no language
+// information of their own.
+func (b *sourceFilesystemsBuilder) defaultLang() string {
+	if b.p.DefaultContentLanguage == "" {
+		return "en"
+	}
+	return b.p.DefaultContentLanguage
+}
+
 func (b *sourceFilesystemsBuilder) existsInSource(abspath string) bool {
 	exists, _ := afero.Exists(b.p.Fs.Source, abspath)
 	return exists
@@ -539,15 +702,20 @@ func (b *sourceFilesystemsBuilder) createStaticFs() error {
 				return err
 			}
 
+			fs, err = b.addStaticMounts(s, fs)
+			if err != nil {
+				return err
+			}
+
 			if b.hasTheme {
 				themeFolder := "static"
-				fs = afero.NewCopyOnWriteFs(newRealBase(afero.NewBasePathFs(b.themeFs, themeFolder)), fs)
+				fs = hugofs.NewOrderedCopyOnWriteFs(newRealBase(afero.NewBasePathFs(b.themeFs, themeFolder)), fs)
 				for _, absThemeDir := range b.absThemeDirs {
 					s.Dirnames = append(s.Dirnames, filepath.Join(absThemeDir, themeFolder))
 				}
 			}
 
-			s.Fs = fs
+			s.Fs = hugofs.NewLanguageMetaFs(l.Lang, fs)
 			ms[l.Lang] = s
 
 		}
@@ -583,15 +751,20 @@ func (b *sourceFilesystemsBuilder) createStaticFs() error {
 		return err
 	}
 
+	fs, err = b.addStaticMounts(s, fs)
+	if err != nil {
+		return err
+	}
+
 	if b.hasTheme {
 		themeFolder := "static"
-		fs = afero.NewCopyOnWriteFs(newRealBase(afero.NewBasePathFs(b.themeFs, themeFolder)), fs)
+		fs = hugofs.NewOrderedCopyOnWriteFs(newRealBase(afero.NewBasePathFs(b.themeFs, themeFolder)), fs)
 		for _, absThemeDir := range b.absThemeDirs {
 			s.Dirnames = append(s.Dirnames, filepath.Join(absThemeDir, themeFolder))
 		}
 	}
 
-	s.Fs = fs
+	s.Fs = hugofs.NewLanguageMetaFs(b.defaultLang(), fs)
 	ms[""] = s
 
 	return nil
@@ -615,14 +788,23 @@ func getStringOrStringSlice(cfg config.Provider, key string, id int) []string {
 
 }
 
-func createContentFs(fs afero.Fs,
+// contentDir is a content dir with the language filesystem created for it.
+type contentDir struct {
+	dir string
+	fs  *hugofs.LanguageFs
+}
+
+func createContentFs(fs *hugofs.Fs,
 	workingDir,
 	defaultContentLanguage string,
-	languages langs.Languages) (afero.Fs, []string, error) {
+	languages langs.Languages,
+	classifier hugofs.LanguageClassifier) (afero.Fs, []contentDir, error) {
 
 	var contentLanguages langs.Languages
 	var contentDirSeen = make(map[string]bool)
-	languageSet := make(map[string]bool)
+
+	// Make the composition order explicit.
+	languages = languages.Sorted()
 
 	// The default content language needs to be first.
 	for _, language := range languages {
@@ -630,7 +812,6 @@ func createContentFs(fs afero.Fs,
 			contentLanguages = append(contentLanguages, language)
 			contentDirSeen[language.ContentDir] = true
 		}
-		languageSet[language.Lang] = true
 	}
 
 	for _, language := range languages {
@@ -645,26 +826,36 @@ func createContentFs(fs afero.Fs,
 
 	}
 
-	var absContentDirs []string
+	var contentDirs []contentDir
 
-	fs, err := createContentOverlayFs(fs, workingDir, contentLanguages, languageSet, &absContentDirs)
-	return fs, absContentDirs, err
+	cfs, err := createContentOverlayFs(fs, workingDir, contentLanguages, languages.AsSet(), languages.LangSubdirs(), languages.FallbackChains(), classifier, &contentDirs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if mergeFrom := languages.MergeContentFrom(); len(mergeFrom) > 0 {
+		cfs = hugofs.NewMergeTranslationsFs(cfs, mergeFrom)
+	}
+
+	return cfs, contentDirs, nil
 
 }
 
-func createContentOverlayFs(source afero.Fs,
+func createContentOverlayFs(fs *hugofs.Fs,
 	workingDir string,
 	languages langs.Languages,
-	languageSet map[string]bool,
-	absContentDirs *[]string) (afero.Fs, error) {
+	languageSet langs.LanguageSet,
+	languageSubdirs map[string]string,
+	languageFallbacks map[string][]string,
+	classifier hugofs.LanguageClassifier,
+	contentDirs *[]contentDir) (afero.Fs, error) {
 	if len(languages) == 0 {
-		return source, nil
+		return fs.Source, nil
 	}
 
 	language := languages[0]
 
-	contentDir := language.ContentDir
-	if contentDir == "" {
+	if language.ContentDir == "" {
 		panic("missing contentDir")
 	}
 
@@ -682,14 +873,29 @@ func createContentOverlayFs(source afero.Fs,
 		return nil, fmt.Errorf("invalid content dir %q: Path is too short", absContentDir)
 	}
 
-	*absContentDirs = append(*absContentDirs, absContentDir)
+	contentSource := fs.Source
+	if fs.Metrics != nil {
+		contentSource = hugofs.NewMetricsFs(contentSource, fs.Metrics, "content")
+	}
+	if fs.DirIndex != nil {
+		contentSource = fs.DirIndex.Fs(contentSource, absContentDir, language.Lang)
+	}
+
+	overlay := hugofs.NewLanguageFs(language.Lang, languageSet, afero.NewBasePathFs(contentSource, absContentDir)).
+		WithLanguageSubdirs(languageSubdirs).
+		WithLanguageFallbacks(languageFallbacks).
+		WithLogger(fs.Logger)
+	if classifier != nil {
+		overlay = overlay.WithLanguageClassifier(classifier)
+	}
+
+	*contentDirs = append(*contentDirs, contentDir{dir: absContentDir, fs: overlay})
 
-	overlay := hugofs.NewLanguageFs(language.Lang, languageSet, afero.NewBasePathFs(source, absContentDir))
 	if len(languages) == 1 {
 		return overlay, nil
 	}
 
-	base, err := createContentOverlayFs(source, workingDir, languages[1:], languageSet, absContentDirs)
+	base, err := createContentOverlayFs(fs, workingDir, languages[1:], languageSet, languageSubdirs, languageFallbacks, classifier, contentDirs)
 	if err != nil {
 		return nil, err
 	}
@@ -706,21 +912,17 @@ func createThemesOverlayFs(p *paths.Paths) (afero.Fs, []string, error) {
 		panic("AllThemes not set")
 	}
 
-	themesDir := p.AbsPathify(p.ThemesDir)
-	if themesDir == "" {
-		return nil, nil, errors.New("no themes dir set")
-	}
-
 	absPaths := make([]string, len(themes))
 
 	// The themes are ordered from left to right. We need to revert it to get the
 	// overlay logic below working as expected.
 	for i := 0; i < len(themes); i++ {
-		absPaths[i] = filepath.Join(themesDir, themes[len(themes)-1-i].Name)
+		absPaths[i] = themes[len(themes)-1-i].Dir
 	}
 
 	fs, err := createOverlayFs(p.Fs.Source, absPaths)
-	fs = hugofs.NewNoLstatFs(fs)
+	// The themes are third party code, make sure they stay in their dirs.
+	fs = hugofs.NewSanitizingFs(hugofs.NewNoLstatFs(fs))
 
 	return fs, absPaths, err
 
@@ -741,7 +943,7 @@ func createOverlayFs(source afero.Fs, absPaths []string) (afero.Fs, error) {
 		return nil, err
 	}
 
-	return afero.NewCopyOnWriteFs(base, overlay), nil
+	return hugofs.NewOrderedCopyOnWriteFs(base, overlay), nil
 }
 
 func removeDuplicatesKeepRight(in []string) []string {
@@ -176,6 +176,100 @@ func TestNewBaseFsEmpty(t *testing.T) {
 	assert.NotNil(bfs.Static)
 }
 
+func TestNewBaseFsDirIndex(t *testing.T) {
+	assert := require.New(t)
+	v := createConfig()
+	v.Set("workingDir", "mywork")
+	fs := hugofs.NewMem(v)
+	fs.DirIndex = hugofs.NewDirIndex()
+
+	contentDir := filepath.Join("mywork", "mycontent")
+	assert.NoError(afero.WriteFile(fs.Source, filepath.Join(contentDir, "f1.md"), []byte("Hugo Rocks!"), 0755))
+	assert.NoError(afero.WriteFile(fs.Source, filepath.Join(contentDir, "f2.md"), []byte("Hugo Rocks!"), 0755))
+
+	p, err := paths.New(fs, v)
+	assert.NoError(err)
+	bfs, err := NewBase(p)
+	assert.NoError(err)
+	checkFileCount(bfs.Content.Fs, "", assert, 2)
+
+	// The listing is served from the index until invalidated.
+	assert.NoError(fs.Source.Remove(filepath.Join(contentDir, "f2.md")))
+	checkFileCount(bfs.Content.Fs, "", assert, 2)
+	fs.DirIndex.Forget(filepath.Join(contentDir, "f2.md"))
+	checkFileCount(bfs.Content.Fs, "", assert, 1)
+}
+
+func TestNewBaseFsLanguageFromFrontMatter(t *testing.T) {
+	assert := require.New(t)
+	v := createConfig()
+	v.Set("workingDir", "mywork")
+	v.Set("defaultContentLanguage", "en")
+	v.Set("languageFromFrontMatter", true)
+
+	en := langs.NewLanguage("en", v)
+	nn := langs.NewLanguage("nn", v)
+	v.Set("languagesSorted", langs.Languages{en, nn})
+
+	fs := hugofs.NewMem(v)
+
+	contentDir := filepath.Join("mywork", "mycontent")
+	assert.NoError(afero.WriteFile(fs.Source, filepath.Join(contentDir, "p1.md"), []byte("---\nlang: nn\n---\n"), 0755))
+	assert.NoError(afero.WriteFile(fs.Source, filepath.Join(contentDir, "p2.md"), []byte("---\ntitle: P2\n---\n"), 0755))
+
+	p, err := paths.New(fs, v)
+	assert.NoError(err)
+	bfs, err := NewBase(p)
+	assert.NoError(err)
+
+	fis, err := afero.ReadDir(bfs.Content.Fs, "")
+	assert.NoError(err)
+
+	fileLangs := make(map[string]string)
+	for _, fi := range fis {
+		lfi := fi.(*hugofs.LanguageFileInfo)
+		fileLangs[lfi.RealName()] = lfi.Lang()
+	}
+	assert.Equal(map[string]string{"p1.md": "nn", "p2.md": "en"}, fileLangs)
+}
+
+func TestDiffMounts(t *testing.T) {
+	assert := require.New(t)
+
+	newBase := func(contentDir, staticDir string) *BaseFs {
+		v := createConfig()
+		v.Set("workingDir", "mywork")
+		v.Set("contentDir", contentDir)
+		v.Set("staticDir", staticDir)
+		fs := hugofs.NewMem(v)
+		for _, dir := range []string{"mycontent", "othercontent", "mystatic", "otherstatic"} {
+			assert.NoError(fs.Source.MkdirAll(filepath.Join("mywork", dir), 0755))
+		}
+		p, err := paths.New(fs, v)
+		assert.NoError(err)
+		bfs, err := NewBase(p)
+		assert.NoError(err)
+		return bfs
+	}
+
+	b1 := newBase("mycontent", "mystatic")
+
+	assert.True(b1.DiffMounts(newBase("mycontent", "mystatic")).IsZero())
+
+	changes := newBase("othercontent", "mystatic").DiffMounts(b1)
+	assert.Equal([]string{filepath.Join("mywork", "othercontent")}, changes.Added)
+	assert.Equal([]string{filepath.Join("mywork", "mycontent")}, changes.Removed)
+	assert.False(changes.StaticChanged)
+
+	changes = newBase("mycontent", "otherstatic").DiffMounts(b1)
+	assert.Equal([]string{filepath.Join("mywork", "otherstatic")}, changes.Added)
+	assert.True(changes.StaticChanged)
+
+	changes = b1.DiffMounts(nil)
+	assert.Len(changes.Removed, 0)
+	assert.Contains(changes.Added, filepath.Join("mywork", "mycontent"))
+}
+
 func TestRealDirs(t *testing.T) {
 	assert := require.New(t)
 	v := createConfig()
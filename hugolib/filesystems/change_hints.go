@@ -0,0 +1,272 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filesystems
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/gohugoio/hugo/hugofs"
+	"github.com/spf13/afero"
+)
+
+// The components of Hugo's source filesystems, as used in ChangeHint.
+const (
+	ComponentContent    = "content"
+	ComponentData       = "data"
+	ComponentI18n       = "i18n"
+	ComponentLayouts    = "layouts"
+	ComponentArchetypes = "archetypes"
+	ComponentAssets     = "assets"
+	ComponentStatic     = "static"
+)
+
+// ChangeHint describes where a changed file is in Hugo's virtual view of the
+// source filesystems, so a rebuild can be limited to what is affected by it.
+// See BaseFs.ResolveChange.
+type ChangeHint struct {
+	// The component the file belongs to, e.g. "content".
+	Component string
+
+	// The path relative to the component root, e.g. "blog/post.md".
+	Path string
+
+	// The source directory the file is in.
+	Dir string
+
+	// The language of the file. For content this is the language in the
+	// file name, e.g. "mypost.fr.md", or else the language of its content
+	// dir. For static files in multihost mode this is the language of the
+	// site the file is published to. Empty otherwise.
+	Lang string
+
+	// The files at the same path in other directories of the same
+	// component, in order of precedence. The files in ShadowedBy hide this
+	// file, the files in Shadows are hidden by it.
+	ShadowedBy []string
+	Shadows    []string
+}
+
+// ResolveChange returns where the file with the given absolute filename is
+// in the source filesystems. A file may be in more than one, e.g. a static
+// dir shared between the sites in multihost mode. It returns nil if the file
+// is not in any of the source directories. The file does not need to exist,
+// e.g. when it was removed.
+func (b *BaseFs) ResolveChange(filename string) []ChangeHint {
+	if b == nil || b.SourceFilesystems == nil {
+		return nil
+	}
+	filename = filepath.Clean(filename)
+
+	var hints []ChangeHint
+
+	if hint, ok := b.resolveContentChange(filename); ok {
+		hints = append(hints, hint)
+	}
+
+	s := b.SourceFilesystems
+	for _, c := range []struct {
+		component string
+		sfs       *SourceFilesystem
+	}{
+		{ComponentData, s.Data},
+		{ComponentI18n, s.I18n},
+		{ComponentLayouts, s.Layouts},
+		{ComponentArchetypes, s.Archetypes},
+		{ComponentAssets, s.Assets},
+	} {
+		if hint, ok := b.resolveChange(c.component, "", c.sfs, filename); ok {
+			hints = append(hints, hint)
+		}
+	}
+
+	for _, lang := range s.staticLangs() {
+		if hint, ok := b.resolveChange(ComponentStatic, lang, s.Static[lang], filename); ok {
+			hints = append(hints, hint)
+		}
+	}
+
+	return hints
+}
+
+func (b *BaseFs) resolveChange(component, lang string, sfs *SourceFilesystem, filename string) (ChangeHint, bool) {
+	if sfs == nil {
+		return ChangeHint{}, false
+	}
+
+	dirs := b.byPrecedence(sfs.Dirnames)
+
+	for i, dir := range dirs {
+		rel, ok := relTo(dir, filename)
+		if !ok {
+			continue
+		}
+
+		hint := ChangeHint{Component: component, Path: rel, Dir: dir, Lang: lang}
+
+		// Look for the same path in the other dirs in one go, as they
+		// may live on slow mounts.
+		others := make([]string, len(dirs))
+		for j, other := range dirs {
+			others[j] = filepath.Join(other, rel)
+		}
+		results := hugofs.StatMany(sfs.SourceFs, others)
+
+		for j, otherFilename := range others {
+			if j == i || results[j].Err != nil {
+				continue
+			}
+			if j < i {
+				hint.ShadowedBy = append(hint.ShadowedBy, otherFilename)
+			} else {
+				hint.Shadows = append(hint.Shadows, otherFilename)
+			}
+		}
+
+		return hint, true
+	}
+
+	return ChangeHint{}, false
+}
+
+// resolveContentChange resolves a change in the content dirs. The content
+// files shadow each other by path and language, see hugofs.LanguageDirsMerger.
+func (b *BaseFs) resolveContentChange(filename string) (ChangeHint, bool) {
+	for _, cd := range b.contentDirs {
+		rel, ok := relTo(cd.dir, filename)
+		if !ok {
+			continue
+		}
+
+		lang, baseName := cd.fs.FileLang(rel)
+		hint := ChangeHint{Component: ComponentContent, Path: rel, Dir: filepath.Clean(cd.dir), Lang: lang}
+
+		// Find the same translation in the other content dirs and order them
+		// as they are merged: by weight, then by composition order.
+		type candidate struct {
+			filename string
+			weight   int
+			index    int
+		}
+
+		weight := func(d contentDir, lang string) int {
+			if d.fs.Lang() == lang {
+				return 2
+			}
+			return 1
+		}
+
+		self := candidate{filename: filename, weight: weight(cd, lang)}
+		var candidates []candidate
+		ext := filepath.Ext(rel)
+
+		for i, other := range b.contentDirs {
+			if other.dir == cd.dir {
+				self.index = i
+				continue
+			}
+			dir := filepath.Join(other.dir, filepath.Dir(rel))
+			fis, err := afero.ReadDir(b.Content.SourceFs, dir)
+			if err != nil {
+				continue
+			}
+			for _, fi := range fis {
+				if fi.IsDir() || filepath.Ext(fi.Name()) != ext {
+					continue
+				}
+				if l, n := other.fs.FileLang(fi.Name()); l == lang && n == baseName {
+					candidates = append(candidates, candidate{filename: filepath.Join(dir, fi.Name()), weight: weight(other, l), index: i})
+				}
+			}
+		}
+
+		before := func(c1, c2 candidate) bool {
+			if c1.weight != c2.weight {
+				return c1.weight > c2.weight
+			}
+			return c1.index < c2.index
+		}
+
+		sort.SliceStable(candidates, func(i, j int) bool {
+			return before(candidates[i], candidates[j])
+		})
+
+		for _, c := range candidates {
+			if before(c, self) {
+				hint.ShadowedBy = append(hint.ShadowedBy, c.filename)
+			} else {
+				hint.Shadows = append(hint.Shadows, c.filename)
+			}
+		}
+
+		return hint, true
+	}
+
+	return ChangeHint{}, false
+}
+
+// staticLangs returns the keys of Static, sorted.
+func (s *SourceFilesystems) staticLangs() []string {
+	langs := make([]string, 0, len(s.Static))
+	for lang := range s.Static {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// byPrecedence returns the given source dirs ordered by precedence, the
+// project's dirs before the themes'. In the project, as with the static
+// dirs, the last dir wins. The themes are ordered as configured.
+func (b *BaseFs) byPrecedence(dirnames []string) []string {
+	var project, themes []string
+
+	for i := len(dirnames) - 1; i >= 0; i-- {
+		dir := filepath.Clean(dirnames[i])
+		if b.themeIndex(dir) == -1 {
+			project = append(project, dir)
+		} else {
+			themes = append(themes, dir)
+		}
+	}
+
+	// AbsThemeDirs is ordered from the lowest precedence to the highest.
+	sort.SliceStable(themes, func(i, j int) bool {
+		return b.themeIndex(themes[i]) > b.themeIndex(themes[j])
+	})
+
+	return append(project, themes...)
+}
+
+// themeIndex returns the index in AbsThemeDirs of the theme dir is in, -1 if
+// none.
+func (b *BaseFs) themeIndex(dir string) int {
+	for i, themeDir := range b.AbsThemeDirs {
+		if _, ok := relTo(themeDir, dir); ok || filepath.Clean(themeDir) == dir {
+			return i
+		}
+	}
+	return -1
+}
+
+// relTo returns filename relative to dir, if it is below it.
+func relTo(dir, filename string) (string, bool) {
+	prefix := filepath.Clean(dir) + string(os.PathSeparator)
+	if !strings.HasPrefix(filename, prefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(filename, prefix), true
+}
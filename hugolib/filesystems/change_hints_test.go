@@ -0,0 +1,132 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filesystems
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/gohugoio/hugo/hugofs"
+	"github.com/gohugoio/hugo/hugolib/paths"
+	"github.com/gohugoio/hugo/langs"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveChange(t *testing.T) {
+	assert := require.New(t)
+	v := createConfig()
+	workDir := filepath.FromSlash("/mywork")
+	v.Set("workingDir", workDir)
+	v.Set("themesDir", "themes")
+	v.Set("theme", []string{"btheme", "atheme"})
+	v.Set("defaultContentLanguage", "en")
+
+	en := langs.NewLanguage("en", v)
+	en.ContentDir = "content_en"
+	nn := langs.NewLanguage("nn", v)
+	nn.ContentDir = "content_nn"
+	v.Set("languagesSorted", langs.Languages{en, nn})
+
+	fs := hugofs.NewMem(v)
+
+	join := func(elem ...string) string {
+		return filepath.Join(append([]string{workDir}, elem...)...)
+	}
+
+	for _, filename := range []string{
+		join("content_en", "blog", "post.md"),
+		join("content_en", "blog", "post.nn.md"),
+		join("content_nn", "blog", "post.md"),
+		join("content_nn", "blog", "post.en.md"),
+		join("mylayouts", "_default", "single.html"),
+		join("themes", "atheme", "layouts", "_default", "single.html"),
+		join("themes", "btheme", "layouts", "_default", "single.html"),
+		join("themes", "atheme", "data", "d.toml"),
+		join("mystatic", "s.txt"),
+		join("themes", "btheme", "static", "s.txt"),
+	} {
+		assert.NoError(afero.WriteFile(fs.Source, filename, []byte("Hugo Rocks!"), 0755))
+	}
+
+	p, err := paths.New(fs, v)
+	assert.NoError(err)
+	bfs, err := NewBase(p)
+	assert.NoError(err)
+
+	assert.Nil(bfs.ResolveChange(join("README.md")))
+	assert.Nil((*BaseFs)(nil).ResolveChange(join("README.md")))
+
+	// The same translation in two content dirs.
+	assert.Equal([]ChangeHint{{
+		Component: ComponentContent,
+		Path:      filepath.FromSlash("blog/post.md"),
+		Dir:       join("content_en"),
+		Lang:      "en",
+		Shadows:   []string{join("content_nn", "blog", "post.en.md")},
+	}}, bfs.ResolveChange(join("content_en", "blog", "post.md")))
+
+	assert.Equal([]ChangeHint{{
+		Component:  ComponentContent,
+		Path:       filepath.FromSlash("blog/post.en.md"),
+		Dir:        join("content_nn"),
+		Lang:       "en",
+		ShadowedBy: []string{join("content_en", "blog", "post.md")},
+	}}, bfs.ResolveChange(join("content_nn", "blog", "post.en.md")))
+
+	assert.Equal([]ChangeHint{{
+		Component:  ComponentContent,
+		Path:       filepath.FromSlash("blog/post.nn.md"),
+		Dir:        join("content_en"),
+		Lang:       "nn",
+		ShadowedBy: []string{join("content_nn", "blog", "post.md")},
+	}}, bfs.ResolveChange(join("content_en", "blog", "post.nn.md")))
+
+	// Removed files are resolved, too.
+	assert.Equal([]ChangeHint{{
+		Component: ComponentContent,
+		Path:      filepath.FromSlash("blog/removed.md"),
+		Dir:       join("content_nn"),
+		Lang:      "nn",
+	}}, bfs.ResolveChange(join("content_nn", "blog", "removed.md")))
+
+	single := filepath.FromSlash("_default/single.html")
+
+	assert.Equal([]ChangeHint{{
+		Component: ComponentLayouts,
+		Path:      single,
+		Dir:       join("mylayouts"),
+		Shadows:   []string{join("themes", "btheme", "layouts", single), join("themes", "atheme", "layouts", single)},
+	}}, bfs.ResolveChange(join("mylayouts", single)))
+
+	assert.Equal([]ChangeHint{{
+		Component:  ComponentLayouts,
+		Path:       single,
+		Dir:        join("themes", "atheme", "layouts"),
+		ShadowedBy: []string{join("mylayouts", single), join("themes", "btheme", "layouts", single)},
+	}}, bfs.ResolveChange(join("themes", "atheme", "layouts", single)))
+
+	assert.Equal([]ChangeHint{{
+		Component: ComponentData,
+		Path:      "d.toml",
+		Dir:       join("themes", "atheme", "data"),
+	}}, bfs.ResolveChange(join("themes", "atheme", "data", "d.toml")))
+
+	assert.Equal([]ChangeHint{{
+		Component:  ComponentStatic,
+		Path:       "s.txt",
+		Dir:        join("themes", "btheme", "static"),
+		ShadowedBy: []string{join("mystatic", "s.txt")},
+	}}, bfs.ResolveChange(join("themes", "btheme", "static", "s.txt")))
+}
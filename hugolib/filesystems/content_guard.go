@@ -0,0 +1,77 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filesystems
+
+import (
+	"fmt"
+
+	"github.com/gohugoio/hugo/config"
+	"github.com/gohugoio/hugo/hugofs"
+)
+
+// The contentGuard actions.
+const (
+	contentGuardWarn    = "warn"
+	contentGuardExclude = "exclude"
+)
+
+// contentGuardConfig configures the guard against unexpected binary files
+// in the content dirs, see hugofs.ContentGuard:
+//
+//	[contentGuard]
+//	action = "exclude"
+//	allow = ["docx"]
+//	deny = ["exe", "zip"]
+type contentGuardConfig struct {
+	// Either "warn" or "exclude".
+	Action string
+
+	// File extensions never flagged.
+	Allow []string
+
+	// File extensions always flagged, hugofs.DefaultContentGuardDeny if not
+	// set.
+	Deny []string
+}
+
+// decodeContentGuard returns the configured content guard, nil if not
+// configured.
+func decodeContentGuard(cfg config.Provider) (*hugofs.ContentGuard, error) {
+	if !cfg.IsSet("contentGuard") {
+		return nil, nil
+	}
+
+	var c contentGuardConfig
+	if err := config.Decode(cfg, "contentGuard", &c); err != nil {
+		return nil, err
+	}
+
+	switch c.Action {
+	case "", contentGuardWarn:
+		return hugofs.NewContentGuard(false, c.Allow, c.Deny), nil
+	case contentGuardExclude:
+		return hugofs.NewContentGuard(true, c.Allow, c.Deny), nil
+	default:
+		return nil, fmt.Errorf("contentGuard: invalid action %q, must be %q or %q", c.Action, contentGuardWarn, contentGuardExclude)
+	}
+}
+
+// ContentGuardOffenders returns the unexpected binary files found in the
+// content dirs so far, nil if the content guard is not configured.
+func (b *BaseFs) ContentGuardOffenders() []hugofs.ContentGuardOffender {
+	if b == nil || b.contentGuard == nil {
+		return nil
+	}
+	return b.contentGuard.Offenders()
+}
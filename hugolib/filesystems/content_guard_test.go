@@ -0,0 +1,61 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filesystems
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/gohugoio/hugo/hugofs"
+	"github.com/gohugoio/hugo/hugolib/paths"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContentGuardConfig(t *testing.T) {
+	assert := require.New(t)
+
+	v := createConfig()
+	guard, err := decodeContentGuard(v)
+	assert.NoError(err)
+	assert.Nil(guard)
+
+	v.Set("contentGuard", map[string]interface{}{"action": "delete"})
+	_, err = decodeContentGuard(v)
+	assert.Error(err)
+
+	workDir := filepath.FromSlash("/mywork")
+	v = createConfig()
+	v.Set("workingDir", workDir)
+	v.Set("contentGuard", map[string]interface{}{"action": "exclude", "deny": []string{"pdf"}})
+
+	fs := hugofs.NewMem(v)
+	for _, filename := range []string{"post.md", "paper.pdf", "setup.exe"} {
+		assert.NoError(afero.WriteFile(fs.Source, filepath.Join(workDir, "mycontent", filename), []byte("abc"), 0755))
+	}
+
+	p, err := paths.New(fs, v)
+	assert.NoError(err)
+	bfs, err := NewBase(p)
+	assert.NoError(err)
+
+	fis, err := afero.ReadDir(bfs.Content.Fs, "")
+	assert.NoError(err)
+	assert.Len(fis, 2)
+
+	offenders := bfs.ContentGuardOffenders()
+	assert.Len(offenders, 1)
+	assert.Equal(filepath.Join(workDir, "mycontent", "paper.pdf"), offenders[0].Filename)
+	assert.True(offenders[0].Excluded)
+}
@@ -0,0 +1,143 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filesystems
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/gohugoio/hugo/parser/metadecoders"
+	"github.com/spf13/afero"
+)
+
+// DataFs gives access to the data files in the project's and the themes' data
+// dirs, which are merged into one data tree.
+type DataFs struct {
+	afero.Fs
+
+	b *BaseFs
+}
+
+// DataFile is a file in one of the data dirs.
+type DataFile struct {
+	// The slash separated key of the file in the data tree, e.g.
+	// "people/authors" for "data/people/authors.yaml".
+	Key string `json:"key"`
+
+	// The absolute filename.
+	Filename string `json:"filename"`
+
+	// The name of the theme the file belongs to, empty for the project.
+	Theme string `json:"theme,omitempty"`
+}
+
+func (f DataFile) String() string {
+	if f.Theme == "" {
+		return fmt.Sprintf("%q", f.Filename)
+	}
+	return fmt.Sprintf("%q (theme %q)", f.Filename, f.Theme)
+}
+
+// DataFiles is a list of data files.
+type DataFiles []DataFile
+
+func (files DataFiles) String() string {
+	s := make([]string, len(files))
+	for i, f := range files {
+		s[i] = f.String()
+	}
+	return strings.Join(s, ", ")
+}
+
+// DataConflict describes a key in the data tree provided by more than one
+// data file, e.g. two themes shipping data/authors.yaml, or an authors.yaml
+// and an authors.toml in the same data dir. Maps are merged, with the values
+// from the winner taking precedence; anything else is taken from the winner
+// only.
+type DataConflict struct {
+	Key    string    `json:"key"`
+	Winner DataFile  `json:"winner"`
+	Losers DataFiles `json:"losers"`
+}
+
+// DataFs returns the data filesystem, nil if not set.
+func (b *BaseFs) DataFs() *DataFs {
+	if b == nil || b.SourceFilesystems == nil || b.Data == nil {
+		return nil
+	}
+	return &DataFs{Fs: b.Data.Fs, b: b}
+}
+
+// Files returns all the data files, in order of precedence: the project's
+// files first, then the themes' in the configured order. Within a data dir
+// the files are in lexical order.
+func (fs *DataFs) Files() ([]DataFile, error) {
+	if fs == nil {
+		return nil, nil
+	}
+
+	var files []DataFile
+
+	for _, dir := range fs.b.Data.Dirnames {
+		dir = filepath.Clean(dir)
+		theme := fs.b.themeName(dir)
+		err := walkFiles(fs.b.Data.SourceFs, dir, func(rel string) error {
+			ext := filepath.Ext(rel)
+			if metadecoders.FormatFromString(ext) == "" {
+				return nil
+			}
+			files = append(files, DataFile{
+				Key:      filepath.ToSlash(strings.TrimSuffix(rel, ext)),
+				Filename: filepath.Join(dir, rel),
+				Theme:    theme,
+			})
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return files, nil
+}
+
+// Conflicts returns the keys in the data tree provided by more than one data
+// file, in the order the keys are first seen in Files.
+func (fs *DataFs) Conflicts() ([]DataConflict, error) {
+	files, err := fs.Files()
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []string
+	byKey := make(map[string][]DataFile)
+	for _, f := range files {
+		if _, found := byKey[f.Key]; !found {
+			keys = append(keys, f.Key)
+		}
+		byKey[f.Key] = append(byKey[f.Key], f)
+	}
+
+	var conflicts []DataConflict
+	for _, key := range keys {
+		files := byKey[key]
+		if len(files) < 2 {
+			continue
+		}
+		conflicts = append(conflicts, DataConflict{Key: key, Winner: files[0], Losers: files[1:]})
+	}
+
+	return conflicts, nil
+}
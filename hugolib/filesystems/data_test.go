@@ -0,0 +1,88 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filesystems
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/gohugoio/hugo/hugofs"
+	"github.com/gohugoio/hugo/hugolib/paths"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDataFsConflicts(t *testing.T) {
+	assert := require.New(t)
+	v := createConfig()
+	workDir := filepath.FromSlash("/mywork")
+	v.Set("workingDir", workDir)
+	v.Set("themesDir", "themes")
+	v.Set("theme", []string{"btheme", "atheme"})
+
+	fs := hugofs.NewMem(v)
+
+	join := func(elem ...string) string {
+		return filepath.Join(append([]string{workDir}, elem...)...)
+	}
+
+	for _, filename := range []string{
+		join("mydata", "authors.yaml"),
+		join("mydata", "people", "team.toml"),
+		join("mydata", "people", "team.json"),
+		join("mydata", "README.txt"),
+		join("themes", "atheme", "data", "authors.yaml"),
+		join("themes", "atheme", "data", "menus.yaml"),
+		join("themes", "btheme", "data", "authors.json"),
+	} {
+		assert.NoError(afero.WriteFile(fs.Source, filename, []byte("{}"), 0755))
+	}
+
+	p, err := paths.New(fs, v)
+	assert.NoError(err)
+	bfs, err := NewBase(p)
+	assert.NoError(err)
+
+	dfs := bfs.DataFs()
+
+	files, err := dfs.Files()
+	assert.NoError(err)
+	assert.Len(files, 6)
+	assert.Equal(DataFile{Key: "authors", Filename: join("mydata", "authors.yaml")}, files[0])
+	assert.Equal(DataFile{Key: "people/team", Filename: join("mydata", "people", "team.json")}, files[1])
+
+	conflicts, err := dfs.Conflicts()
+	assert.NoError(err)
+	assert.Equal([]DataConflict{
+		{
+			Key:    "authors",
+			Winner: DataFile{Key: "authors", Filename: join("mydata", "authors.yaml")},
+			Losers: DataFiles{
+				{Key: "authors", Filename: join("themes", "btheme", "data", "authors.json"), Theme: "btheme"},
+				{Key: "authors", Filename: join("themes", "atheme", "data", "authors.yaml"), Theme: "atheme"},
+			},
+		},
+		{
+			Key:    "people/team",
+			Winner: DataFile{Key: "people/team", Filename: join("mydata", "people", "team.json")},
+			Losers: DataFiles{{Key: "people/team", Filename: join("mydata", "people", "team.toml")}},
+		},
+	}, conflicts)
+
+	assert.Contains(conflicts[0].Losers.String(), `(theme "btheme")`)
+
+	conflicts, err = (*BaseFs)(nil).DataFs().Conflicts()
+	assert.NoError(err)
+	assert.Nil(conflicts)
+}
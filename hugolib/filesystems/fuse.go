@@ -0,0 +1,158 @@
+// +build fuse
+
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filesystems
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+
+	// The FUSE driver is not in go.mod, as default builds do not need it.
+	// Run "go get bazil.org/fuse" before building with -tags fuse.
+	"bazil.org/fuse"
+	fusefs "bazil.org/fuse/fs"
+)
+
+// MountFUSE mounts the source filesystems read-only at mountpoint, in the
+// same layout as HTTPHandler, so they can be explored with ordinary tools.
+// If lang is set, only the content files in that language are included.
+// It blocks until ctx is done or the filesystem is unmounted.
+func (b *BaseFs) MountFUSE(ctx context.Context, mountpoint, lang string) error {
+	c, err := fuse.Mount(
+		mountpoint,
+		fuse.ReadOnly(),
+		fuse.FSName("hugo"),
+		fuse.Subtype("hugofs"),
+	)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- fusefs.Serve(c, &fuseFs{fs: b.HTTPFileSystem(lang)})
+	}()
+
+	<-c.Ready
+	if err := c.MountError; err != nil {
+		return err
+	}
+
+	select {
+	case <-ctx.Done():
+		if err := fuse.Unmount(mountpoint); err != nil {
+			return err
+		}
+		return <-errc
+	case err := <-errc:
+		return err
+	}
+}
+
+type fuseFs struct {
+	fs http.FileSystem
+}
+
+func (f *fuseFs) Root() (fusefs.Node, error) {
+	return f.node("/")
+}
+
+func (f *fuseFs) node(name string) (*fuseNode, error) {
+	file, err := f.fs.Open(name)
+	if err != nil {
+		return nil, fuseErr(err)
+	}
+	defer file.Close()
+
+	fi, err := file.Stat()
+	if err != nil {
+		return nil, fuseErr(err)
+	}
+
+	return &fuseNode{fs: f, name: name, fi: fi}, nil
+}
+
+var (
+	_ fusefs.Node               = (*fuseNode)(nil)
+	_ fusefs.NodeStringLookuper = (*fuseNode)(nil)
+	_ fusefs.HandleReadDirAller = (*fuseNode)(nil)
+	_ fusefs.HandleReadAller    = (*fuseNode)(nil)
+)
+
+type fuseNode struct {
+	fs   *fuseFs
+	name string
+	fi   os.FileInfo
+}
+
+func (n *fuseNode) Attr(ctx context.Context, a *fuse.Attr) error {
+	if n.fi.IsDir() {
+		a.Mode = os.ModeDir | 0555
+	} else {
+		a.Mode = 0444
+		a.Size = uint64(n.fi.Size())
+	}
+	a.Mtime = n.fi.ModTime()
+	return nil
+}
+
+func (n *fuseNode) Lookup(ctx context.Context, name string) (fusefs.Node, error) {
+	return n.fs.node(path.Join(n.name, name))
+}
+
+func (n *fuseNode) ReadDirAll(ctx context.Context) ([]fuse.Dirent, error) {
+	f, err := n.fs.fs.Open(n.name)
+	if err != nil {
+		return nil, fuseErr(err)
+	}
+	defer f.Close()
+
+	fis, err := f.Readdir(-1)
+	if err != nil {
+		return nil, fuseErr(err)
+	}
+
+	dirents := make([]fuse.Dirent, len(fis))
+	for i, fi := range fis {
+		dirents[i] = fuse.Dirent{Name: fi.Name(), Type: fuse.DT_File}
+		if fi.IsDir() {
+			dirents[i].Type = fuse.DT_Dir
+		}
+	}
+
+	return dirents, nil
+}
+
+func (n *fuseNode) ReadAll(ctx context.Context) ([]byte, error) {
+	f, err := n.fs.fs.Open(n.name)
+	if err != nil {
+		return nil, fuseErr(err)
+	}
+	defer f.Close()
+
+	b, err := ioutil.ReadAll(f)
+	return b, fuseErr(err)
+}
+
+func fuseErr(err error) error {
+	if os.IsNotExist(err) {
+		return fuse.ENOENT
+	}
+	return err
+}
@@ -0,0 +1,175 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filesystems
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gohugoio/hugo/hugofs"
+	"github.com/spf13/afero"
+)
+
+// HTTPLangHeader is the request header to select the language in the
+// handler returned by HTTPHandler. The "lang" query parameter may also be
+// used.
+const HTTPLangHeader = "X-Hugo-Lang"
+
+// HTTPHandler returns a handler serving the source filesystems as Hugo sees
+// them, with the themes, overrides and language merging applied, e.g. for
+// development tools. The top level dirs are the components, e.g. "content"
+// and "layouts".
+func (b *BaseFs) HTTPHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lang := r.URL.Query().Get("lang")
+		if lang == "" {
+			lang = r.Header.Get(HTTPLangHeader)
+		}
+		http.FileServer(b.HTTPFileSystem(lang)).ServeHTTP(w, r)
+	})
+}
+
+// HTTPFileSystem returns the source filesystems as a http.FileSystem, see
+// HTTPHandler. If lang is set, only the content files in that language and
+// the static files of that language are included.
+func (b *BaseFs) HTTPFileSystem(lang string) http.FileSystem {
+	return &httpFs{b: b, lang: lang}
+}
+
+type httpFs struct {
+	b    *BaseFs
+	lang string
+}
+
+func (fs *httpFs) components() map[string]afero.Fs {
+	m := make(map[string]afero.Fs)
+	add := func(name string, sfs *SourceFilesystem) {
+		if sfs != nil && sfs.Fs != nil {
+			m[name] = sfs.Fs
+		}
+	}
+
+	add(ComponentContent, fs.b.Content)
+	add(ComponentData, fs.b.Data)
+	add(ComponentI18n, fs.b.I18n)
+	add(ComponentLayouts, fs.b.Layouts)
+	add(ComponentArchetypes, fs.b.Archetypes)
+	add(ComponentAssets, fs.b.Assets)
+	if len(fs.b.Static) > 0 {
+		m[ComponentStatic] = fs.b.StaticFs(fs.lang)
+	}
+
+	return m
+}
+
+func (fs *httpFs) Open(name string) (http.File, error) {
+	name = path.Clean("/" + name)
+	components := fs.components()
+
+	if name == "/" {
+		var fis []os.FileInfo
+		for component := range components {
+			fis = append(fis, httpDirInfo(component))
+		}
+		hugofs.SortFileInfos(fis)
+		return &httpRootDir{fis: fis}, nil
+	}
+
+	parts := strings.SplitN(strings.TrimPrefix(name, "/"), "/", 2)
+	cfs, found := components[parts[0]]
+	if !found {
+		return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+	}
+
+	rel := filePathSeparator
+	if len(parts) == 2 {
+		rel = filepath.FromSlash(parts[1])
+	}
+
+	f, err := cfs.Open(rel)
+	if err != nil {
+		return nil, err
+	}
+
+	if parts[0] == ComponentContent && fs.lang != "" {
+		return &httpLangFile{File: f, lang: fs.lang}, nil
+	}
+
+	return f, nil
+}
+
+// httpLangFile hides the content files in other languages.
+type httpLangFile struct {
+	afero.File
+	lang string
+}
+
+func (f *httpLangFile) Readdir(count int) ([]os.FileInfo, error) {
+	fis, err := f.File.Readdir(count)
+	if err != nil {
+		return nil, err
+	}
+
+	n := 0
+	for _, fi := range fis {
+		if la, ok := fi.(hugofs.LanguageAnnouncer); ok && !fi.IsDir() && la.Lang() != f.lang {
+			continue
+		}
+		fis[n] = fi
+		n++
+	}
+
+	return fis[:n], nil
+}
+
+// httpRootDir lists the components.
+type httpRootDir struct {
+	fis []os.FileInfo
+	pos int
+}
+
+func (d *httpRootDir) Close() error                   { return nil }
+func (d *httpRootDir) Read(p []byte) (int, error)     { return 0, io.EOF }
+func (d *httpRootDir) Stat() (os.FileInfo, error)     { return httpDirInfo(""), nil }
+func (d *httpRootDir) Seek(int64, int) (int64, error) { return 0, nil }
+
+func (d *httpRootDir) Readdir(count int) ([]os.FileInfo, error) {
+	fis := d.fis[d.pos:]
+	if count <= 0 {
+		d.pos = len(d.fis)
+		return fis, nil
+	}
+	if len(fis) == 0 {
+		return nil, io.EOF
+	}
+	if count < len(fis) {
+		fis = fis[:count]
+	}
+	d.pos += len(fis)
+	return fis, nil
+}
+
+type httpDirInfo string
+
+func (fi httpDirInfo) Name() string       { return string(fi) }
+func (fi httpDirInfo) Size() int64        { return 0 }
+func (fi httpDirInfo) Mode() os.FileMode  { return os.ModeDir | 0555 }
+func (fi httpDirInfo) ModTime() time.Time { return time.Time{} }
+func (fi httpDirInfo) IsDir() bool        { return true }
+func (fi httpDirInfo) Sys() interface{}   { return nil }
@@ -0,0 +1,104 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filesystems
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/gohugoio/hugo/hugofs"
+	"github.com/gohugoio/hugo/hugolib/paths"
+	"github.com/gohugoio/hugo/langs"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPHandler(t *testing.T) {
+	assert := require.New(t)
+	v := createConfig()
+	workDir := filepath.FromSlash("/mywork")
+	v.Set("workingDir", workDir)
+	v.Set("themesDir", "themes")
+	v.Set("theme", "mytheme")
+	v.Set("defaultContentLanguage", "en")
+
+	en := langs.NewLanguage("en", v)
+	nn := langs.NewLanguage("nn", v)
+	v.Set("languagesSorted", langs.Languages{en, nn})
+
+	fs := hugofs.NewMem(v)
+
+	for filename, content := range map[string]string{
+		"mycontent/blog/post.md":                      "post en",
+		"mycontent/blog/post.nn.md":                   "post nn",
+		"mylayouts/_default/single.html":              "project single",
+		"themes/mytheme/layouts/_default/list.html":   "theme list",
+		"themes/mytheme/layouts/_default/single.html": "theme single",
+	} {
+		assert.NoError(afero.WriteFile(fs.Source, filepath.Join(workDir, filepath.FromSlash(filename)), []byte(content), 0755))
+	}
+
+	p, err := paths.New(fs, v)
+	assert.NoError(err)
+	bfs, err := NewBase(p)
+	assert.NoError(err)
+
+	get := func(path string, header ...string) (int, string) {
+		r := httptest.NewRequest("GET", path, nil)
+		if len(header) > 0 {
+			r.Header.Set(HTTPLangHeader, header[0])
+		}
+		w := httptest.NewRecorder()
+		bfs.HTTPHandler().ServeHTTP(w, r)
+		b, err := ioutil.ReadAll(w.Result().Body)
+		assert.NoError(err)
+		return w.Code, string(b)
+	}
+
+	code, body := get("/")
+	assert.Equal(http.StatusOK, code)
+	assert.Contains(body, `<a href="content/">content/</a>`)
+	assert.Contains(body, `<a href="layouts/">layouts/</a>`)
+
+	// The project overrides the theme.
+	_, body = get("/layouts/_default/single.html")
+	assert.Equal("project single", body)
+	_, body = get("/layouts/_default/list.html")
+	assert.Equal("theme list", body)
+
+	// The content files are listed as the language filesystems name them,
+	// marked with the language of their content dir.
+	_, body = get("/content/blog/")
+	assert.Contains(body, ">__hugofs_en_post.md<")
+	assert.Contains(body, ">__hugofs_en_post.nn.md<")
+
+	_, body = get("/content/blog/?lang=nn")
+	assert.NotContains(body, ">__hugofs_en_post.md<")
+	assert.Contains(body, ">__hugofs_en_post.nn.md<")
+
+	_, body = get("/content/blog/", "en")
+	assert.Contains(body, ">__hugofs_en_post.md<")
+	assert.NotContains(body, ">__hugofs_en_post.nn.md<")
+
+	_, body = get("/content/blog/__hugofs_en_post.nn.md")
+	assert.Equal("post nn", body)
+	_, body = get("/content/blog/post.md")
+	assert.Equal("post en", body)
+
+	code, _ = get("/nosuchcomponent/a.txt")
+	assert.Equal(http.StatusNotFound, code)
+}
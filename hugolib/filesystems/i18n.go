@@ -0,0 +1,136 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filesystems
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/gohugoio/hugo/parser/metadecoders"
+	"github.com/spf13/afero"
+)
+
+// I18nFs gives access to the translation files in the project's and the
+// themes' i18n dirs, which are merged into one translation bundle per
+// language.
+type I18nFs struct {
+	afero.Fs
+
+	b *BaseFs
+}
+
+// I18nFile is a translation file in one of the i18n dirs.
+type I18nFile struct {
+	// The language code, lower case, e.g. "en" for both "i18n/en.toml" and
+	// "i18n/buttons.en.toml".
+	Lang string `json:"lang"`
+
+	// The absolute filename.
+	Filename string `json:"filename"`
+
+	// The name of the theme the file belongs to, empty for the project.
+	Theme string `json:"theme,omitempty"`
+}
+
+func (f I18nFile) String() string {
+	if f.Theme == "" {
+		return fmt.Sprintf("%q", f.Filename)
+	}
+	return fmt.Sprintf("%q (theme %q)", f.Filename, f.Theme)
+}
+
+// I18nFs returns the i18n filesystem, nil if not set.
+func (b *BaseFs) I18nFs() *I18nFs {
+	if b == nil || b.SourceFilesystems == nil || b.I18n == nil {
+		return nil
+	}
+	return &I18nFs{Fs: b.I18n.Fs, b: b}
+}
+
+// Files returns all the translation files, in order of precedence: the
+// project's files first, then the themes' in the configured order. Within an
+// i18n dir the files are in lexical order.
+func (fs *I18nFs) Files() ([]I18nFile, error) {
+	if fs == nil {
+		return nil, nil
+	}
+
+	var files []I18nFile
+
+	for _, dir := range fs.b.I18n.Dirnames {
+		dir = filepath.Clean(dir)
+		theme := fs.b.themeName(dir)
+		err := walkFiles(fs.b.I18n.SourceFs, dir, func(rel string) error {
+			lang := i18nLang(rel)
+			if lang == "" {
+				return nil
+			}
+			files = append(files, I18nFile{
+				Lang:     lang,
+				Filename: filepath.Join(dir, rel),
+				Theme:    theme,
+			})
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return files, nil
+}
+
+// Languages returns the translation files grouped by language code. The
+// files for each language are in order of precedence, so a translation in a
+// file takes precedence over the same translation in any later file.
+func (fs *I18nFs) Languages() (map[string][]I18nFile, error) {
+	files, err := fs.Files()
+	if err != nil {
+		return nil, err
+	}
+
+	langs := make(map[string][]I18nFile)
+	for _, f := range files {
+		langs[f.Lang] = append(langs[f.Lang], f)
+	}
+
+	return langs, nil
+}
+
+// Language returns the translation files for the given language code, in
+// order of precedence.
+func (fs *I18nFs) Language(lang string) ([]I18nFile, error) {
+	langs, err := fs.Languages()
+	if err != nil {
+		return nil, err
+	}
+	return langs[strings.ToLower(lang)], nil
+}
+
+// i18nLang returns the language code of the translation file with the given
+// name, which is the last dot separated part of the base name before the
+// extension. It returns an empty string if this isn't a translation file.
+func i18nLang(filename string) string {
+	base := filepath.Base(filename)
+	ext := filepath.Ext(base)
+	if metadecoders.FormatFromString(ext) == "" {
+		return ""
+	}
+	base = strings.TrimSuffix(base, ext)
+	if i := strings.LastIndex(base, "."); i != -1 {
+		base = base[i+1:]
+	}
+	return strings.ToLower(base)
+}
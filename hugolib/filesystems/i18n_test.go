@@ -0,0 +1,97 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filesystems
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/gohugoio/hugo/hugofs"
+	"github.com/gohugoio/hugo/hugolib/paths"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestI18nFsLanguages(t *testing.T) {
+	assert := require.New(t)
+	v := createConfig()
+	workDir := filepath.FromSlash("/mywork")
+	v.Set("workingDir", workDir)
+	v.Set("themesDir", "themes")
+	v.Set("theme", []string{"btheme", "atheme"})
+
+	fs := hugofs.NewMem(v)
+
+	join := func(elem ...string) string {
+		return filepath.Join(append([]string{workDir}, elem...)...)
+	}
+
+	for _, filename := range []string{
+		join("myi18n", "en.toml"),
+		join("myi18n", "buttons.nn.yaml"),
+		join("myi18n", "README.txt"),
+		join("themes", "atheme", "i18n", "en.toml"),
+		join("themes", "atheme", "i18n", "en-US.toml"),
+		join("themes", "btheme", "i18n", "nn.json"),
+	} {
+		assert.NoError(afero.WriteFile(fs.Source, filename, []byte("{}"), 0755))
+	}
+
+	p, err := paths.New(fs, v)
+	assert.NoError(err)
+	bfs, err := NewBase(p)
+	assert.NoError(err)
+
+	ifs := bfs.I18nFs()
+
+	files, err := ifs.Files()
+	assert.NoError(err)
+	assert.Len(files, 5)
+
+	langs, err := ifs.Languages()
+	assert.NoError(err)
+	assert.Len(langs, 3)
+	assert.Equal([]I18nFile{
+		{Lang: "en", Filename: join("myi18n", "en.toml")},
+		{Lang: "en", Filename: join("themes", "atheme", "i18n", "en.toml"), Theme: "atheme"},
+	}, langs["en"])
+
+	nn, err := ifs.Language("NN")
+	assert.NoError(err)
+	assert.Equal([]I18nFile{
+		{Lang: "nn", Filename: join("myi18n", "buttons.nn.yaml")},
+		{Lang: "nn", Filename: join("themes", "btheme", "i18n", "nn.json"), Theme: "btheme"},
+	}, nn)
+
+	enUS, err := ifs.Language("en-US")
+	assert.NoError(err)
+	assert.Len(enUS, 1)
+}
+
+func TestI18nLang(t *testing.T) {
+	assert := require.New(t)
+
+	for _, test := range []struct {
+		filename string
+		expect   string
+	}{
+		{"en.toml", "en"},
+		{"en-US.yaml", "en-us"},
+		{filepath.FromSlash("sub/buttons.nn.json"), "nn"},
+		{"README.md", ""},
+		{"nn", ""},
+	} {
+		assert.Equal(test.expect, i18nLang(test.filename), test.filename)
+	}
+}
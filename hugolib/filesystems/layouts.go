@@ -0,0 +1,126 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filesystems
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/gohugoio/hugo/hugofs"
+	"github.com/spf13/afero"
+)
+
+// LayoutsFs is the composite filesystem of the project's and the themes'
+// layouts dirs, where a file in the project shadows the same file in the
+// themes. It caches the resolution of layout paths to the files that win,
+// which is the hot path when looking up templates for big sites. Call
+// Invalidate when files in the layouts dirs change.
+type LayoutsFs struct {
+	afero.Fs
+
+	dirs []string
+
+	mu    sync.RWMutex
+	cache map[string]layoutResolution
+}
+
+type layoutResolution struct {
+	filename string
+	found    bool
+}
+
+func newLayoutsFs(sfs *SourceFilesystem) *LayoutsFs {
+	return &LayoutsFs{Fs: sfs.Fs, dirs: sfs.Dirnames, cache: make(map[string]layoutResolution)}
+}
+
+// LayoutsFs returns the layouts filesystem, nil if not set.
+func (b *BaseFs) LayoutsFs() *LayoutsFs {
+	if b == nil {
+		return nil
+	}
+	return b.layoutsFs
+}
+
+// Resolve returns the real filename of the file at the given path relative
+// to the layouts root, e.g. "_default/single.html", and whether it exists.
+func (fs *LayoutsFs) Resolve(name string) (string, bool, error) {
+	name = strings.TrimPrefix(filepath.Clean(name), filePathSeparator)
+
+	fs.mu.RLock()
+	r, found := fs.cache[name]
+	fs.mu.RUnlock()
+	if found {
+		return r.filename, r.found, nil
+	}
+
+	fi, err := fs.Fs.Stat(name)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return "", false, err
+		}
+	} else if !fi.IsDir() {
+		r = layoutResolution{filename: name, found: true}
+		if rfi, ok := fi.(hugofs.RealFilenameInfo); ok {
+			r.filename = rfi.RealFilename()
+		}
+	}
+
+	fs.mu.Lock()
+	fs.cache[name] = r
+	fs.mu.Unlock()
+
+	return r.filename, r.found, nil
+}
+
+// Exists reports whether there is a file at the given path relative to the
+// layouts root.
+func (fs *LayoutsFs) Exists(name string) (bool, error) {
+	_, found, err := fs.Resolve(name)
+	return found, err
+}
+
+// Invalidate removes the cached resolutions affected by a change to the
+// files or directories with the given absolute filenames. Filenames outside
+// the layouts dirs are ignored.
+func (fs *LayoutsFs) Invalidate(filenames ...string) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	for _, filename := range filenames {
+		filename = filepath.Clean(filename)
+		for _, dir := range fs.dirs {
+			rel, ok := relTo(dir, filename)
+			if !ok {
+				if filepath.Clean(dir) != filename {
+					continue
+				}
+				rel = ""
+			}
+			for name := range fs.cache {
+				if rel == "" || name == rel || strings.HasPrefix(name, rel+filePathSeparator) {
+					delete(fs.cache, name)
+				}
+			}
+		}
+	}
+}
+
+// Reset removes all the cached resolutions.
+func (fs *LayoutsFs) Reset() {
+	fs.mu.Lock()
+	fs.cache = make(map[string]layoutResolution)
+	fs.mu.Unlock()
+}
@@ -0,0 +1,96 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filesystems
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/gohugoio/hugo/hugofs"
+	"github.com/gohugoio/hugo/hugolib/paths"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLayoutsFs(t *testing.T) {
+	assert := require.New(t)
+	v := createConfig()
+	workDir := filepath.FromSlash("/mywork")
+	v.Set("workingDir", workDir)
+	v.Set("themesDir", "themes")
+	v.Set("theme", "mytheme")
+
+	fs := hugofs.NewMem(v)
+
+	join := func(elem ...string) string {
+		return filepath.Join(append([]string{workDir}, elem...)...)
+	}
+
+	projectSingle := join("mylayouts", "_default", "single.html")
+	themeSingle := join("themes", "mytheme", "layouts", "_default", "single.html")
+	themeList := join("themes", "mytheme", "layouts", "_default", "list.html")
+
+	for _, filename := range []string{join("mylayouts", "index.html"), themeSingle, themeList} {
+		assert.NoError(afero.WriteFile(fs.Source, filename, []byte("theme"), 0755))
+	}
+
+	p, err := paths.New(fs, v)
+	assert.NoError(err)
+	bfs, err := NewBase(p)
+	assert.NoError(err)
+
+	lfs := bfs.LayoutsFs()
+	assert.NotNil(lfs)
+
+	single := filepath.Join("_default", "single.html")
+
+	filename, found, err := lfs.Resolve(single)
+	assert.NoError(err)
+	assert.True(found)
+	assert.Equal(themeSingle, filename)
+
+	found, err = lfs.Exists(filepath.Join("_default", "baseof.html"))
+	assert.NoError(err)
+	assert.False(found)
+
+	found, err = lfs.Exists("_default")
+	assert.NoError(err)
+	assert.False(found)
+
+	// The resolution is cached until invalidated.
+	assert.NoError(afero.WriteFile(fs.Source, projectSingle, []byte("project"), 0755))
+	filename, _, _ = lfs.Resolve(single)
+	assert.Equal(themeSingle, filename)
+
+	lfs.Invalidate(join("other", "single.html"))
+	filename, _, _ = lfs.Resolve(single)
+	assert.Equal(themeSingle, filename)
+
+	lfs.Invalidate(projectSingle)
+	filename, _, _ = lfs.Resolve(single)
+	assert.Equal(projectSingle, filename)
+
+	// Removing a directory invalidates everything below it.
+	found, _ = lfs.Exists(filepath.Join("_default", "list.html"))
+	assert.True(found)
+	assert.NoError(fs.Source.RemoveAll(join("themes", "mytheme", "layouts", "_default")))
+	found, _ = lfs.Exists(filepath.Join("_default", "list.html"))
+	assert.True(found)
+	lfs.Invalidate(join("themes", "mytheme", "layouts", "_default"))
+	found, _ = lfs.Exists(filepath.Join("_default", "list.html"))
+	assert.False(found)
+
+	lfs.Reset()
+	assert.Len(lfs.cache, 0)
+}
@@ -0,0 +1,127 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filesystems
+
+import (
+	"path/filepath"
+
+	"github.com/spf13/afero"
+)
+
+// Mount describes a source directory mounted into one of the components,
+// e.g. a theme's layouts dir.
+type Mount struct {
+	// The component mounted into, e.g. "layouts".
+	Component string `json:"component"`
+
+	// The absolute path to the mounted directory.
+	Source string `json:"source"`
+
+	// The name of the theme the directory belongs to, empty for the
+	// project.
+	Theme string `json:"theme,omitempty"`
+
+	// The language of the content or, in multihost mode, the static files
+	// in this directory, if any.
+	Lang string `json:"lang,omitempty"`
+
+	// The subdirectory of the component the directory is mounted into, if
+	// not the root, see StaticMount.
+	Target string `json:"target,omitempty"`
+}
+
+// Mounts returns every existing directory mounted into the source
+// filesystems, by component and then in order of precedence, the first wins.
+// The content dirs are listed in the order they are composed, see
+// hugofs.LanguageDirsMerger for how their files shadow each other.
+func (b *BaseFs) Mounts() []Mount {
+	if b == nil || b.SourceFilesystems == nil {
+		return nil
+	}
+
+	var mounts []Mount
+
+	for _, cd := range b.contentDirs {
+		dir := filepath.Clean(cd.dir)
+		mounts = append(mounts, Mount{Component: ComponentContent, Source: dir, Theme: b.themeName(dir), Lang: cd.fs.Lang()})
+	}
+
+	for _, c := range []struct {
+		component string
+		sfs       *SourceFilesystem
+	}{
+		{ComponentData, b.Data},
+		{ComponentI18n, b.I18n},
+		{ComponentLayouts, b.Layouts},
+		{ComponentArchetypes, b.Archetypes},
+		{ComponentAssets, b.Assets},
+	} {
+		if c.sfs == nil {
+			continue
+		}
+		for _, dir := range b.byPrecedence(c.sfs.Dirnames) {
+			if !isDir(c.sfs.SourceFs, dir) {
+				continue
+			}
+			mounts = append(mounts, Mount{Component: c.component, Source: dir, Theme: b.themeName(dir)})
+		}
+	}
+
+	for _, lang := range b.staticLangs() {
+		sfs := b.Static[lang]
+		for _, dir := range b.staticByPrecedence(sfs) {
+			if !isDir(sfs.SourceFs, dir) {
+				continue
+			}
+			mounts = append(mounts, Mount{Component: ComponentStatic, Source: dir, Theme: b.themeName(dir), Lang: lang, Target: sfs.Targets[dir]})
+		}
+	}
+
+	return mounts
+}
+
+// staticByPrecedence returns the dirs of the static filesystem sfs in order
+// of precedence: the project's static dirs, the static mounts and then the
+// themes' static dirs.
+func (b *BaseFs) staticByPrecedence(sfs *SourceFilesystem) []string {
+	var dirnames, mounted []string
+	for _, dir := range sfs.Dirnames {
+		if _, found := sfs.Targets[dir]; found {
+			mounted = append(mounted, dir)
+		} else {
+			dirnames = append(dirnames, dir)
+		}
+	}
+
+	dirnames = b.byPrecedence(dirnames)
+	i := 0
+	for i < len(dirnames) && b.themeIndex(dirnames[i]) == -1 {
+		i++
+	}
+
+	return append(dirnames[:i:i], append(mounted, dirnames[i:]...)...)
+}
+
+func isDir(fs afero.Fs, dir string) bool {
+	fi, err := fs.Stat(dir)
+	return err == nil && fi.IsDir()
+}
+
+// themeName returns the name of the theme dir belongs to, if any.
+func (b *BaseFs) themeName(dir string) string {
+	if i := b.themeIndex(dir); i != -1 {
+		return filepath.Base(b.AbsThemeDirs[i])
+	}
+	return ""
+}
@@ -0,0 +1,78 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filesystems
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/gohugoio/hugo/hugofs"
+	"github.com/gohugoio/hugo/hugolib/paths"
+	"github.com/gohugoio/hugo/langs"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMounts(t *testing.T) {
+	assert := require.New(t)
+	v := createConfig()
+	workDir := filepath.FromSlash("/mywork")
+	v.Set("workingDir", workDir)
+	v.Set("themesDir", "themes")
+	v.Set("theme", []string{"btheme", "atheme"})
+	v.Set("defaultContentLanguage", "en")
+
+	en := langs.NewLanguage("en", v)
+	en.ContentDir = "content_en"
+	nn := langs.NewLanguage("nn", v)
+	nn.ContentDir = "content_nn"
+	v.Set("languagesSorted", langs.Languages{en, nn})
+
+	fs := hugofs.NewMem(v)
+
+	join := func(elem ...string) string {
+		return filepath.Join(append([]string{workDir}, elem...)...)
+	}
+
+	for _, dir := range []string{
+		join("content_en"),
+		join("content_nn"),
+		join("mylayouts"),
+		join("themes", "atheme", "layouts"),
+		join("themes", "btheme", "layouts"),
+		join("themes", "atheme", "data"),
+		join("mystatic"),
+		join("themes", "btheme", "static"),
+	} {
+		assert.NoError(afero.WriteFile(fs.Source, filepath.Join(dir, "f.txt"), []byte("Hugo Rocks!"), 0755))
+	}
+
+	p, err := paths.New(fs, v)
+	assert.NoError(err)
+	bfs, err := NewBase(p)
+	assert.NoError(err)
+
+	assert.Equal([]Mount{
+		{Component: ComponentContent, Source: join("content_en"), Lang: "en"},
+		{Component: ComponentContent, Source: join("content_nn"), Lang: "nn"},
+		{Component: ComponentData, Source: join("themes", "atheme", "data"), Theme: "atheme"},
+		{Component: ComponentLayouts, Source: join("mylayouts")},
+		{Component: ComponentLayouts, Source: join("themes", "btheme", "layouts"), Theme: "btheme"},
+		{Component: ComponentLayouts, Source: join("themes", "atheme", "layouts"), Theme: "atheme"},
+		{Component: ComponentStatic, Source: join("mystatic")},
+		{Component: ComponentStatic, Source: join("themes", "btheme", "static"), Theme: "btheme"},
+	}, bfs.Mounts())
+
+	assert.Nil((*BaseFs)(nil).Mounts())
+}
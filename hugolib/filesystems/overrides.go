@@ -0,0 +1,193 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filesystems
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/gohugoio/hugo/hugofs"
+	"github.com/spf13/afero"
+)
+
+// Override describes a file provided by more than one mount, where the file
+// in one of them shadows the others.
+type Override struct {
+	// The component, e.g. "layouts".
+	Component string `json:"component"`
+
+	// The path relative to the component root, e.g. "_default/single.html".
+	// For content files this is the path without any language marker.
+	Path string `json:"path"`
+
+	// The language of the content or, in multihost mode, the static files,
+	// if any.
+	Lang string `json:"lang,omitempty"`
+
+	// The file used in the build.
+	Winner OverrideCandidate `json:"winner"`
+
+	// The shadowed files, in order of precedence.
+	Losers []OverrideCandidate `json:"losers"`
+}
+
+// OverrideCandidate is one of the files in an Override.
+type OverrideCandidate struct {
+	// The absolute filename.
+	Filename string `json:"filename"`
+
+	// The name of the theme the file belongs to, empty for the project.
+	Theme string `json:"theme,omitempty"`
+
+	// The language of the mount the file lives in, if any.
+	MountLang string `json:"mountLang,omitempty"`
+}
+
+// Overrides returns every file that is provided by more than one of the
+// mounts listed in Mounts, with the file that wins and the files it shadows,
+// sorted by component, language and path. This explains which of the
+// project's and the themes' files are used in the build.
+//
+// Content files are matched by language and translation base name, so a
+// "post.nn.md" in the English content dir and a "post.md" in the Norwegian
+// one are the same file. The one in the language's own content dir wins.
+// A file pinned to its mount, see Pin, wins over everything else.
+func (b *BaseFs) Overrides() ([]Override, error) {
+	if b == nil || b.SourceFilesystems == nil {
+		return nil, nil
+	}
+
+	type key struct {
+		component, lang, path string
+	}
+
+	var (
+		keys       []key
+		candidates = make(map[key][]OverrideCandidate)
+	)
+
+	add := func(k key, c OverrideCandidate) {
+		if _, found := candidates[k]; !found {
+			keys = append(keys, k)
+		}
+		candidates[k] = append(candidates[k], c)
+	}
+
+	fs := b.Content.SourceFs
+
+	for _, cd := range b.contentDirs {
+		dir := filepath.Clean(cd.dir)
+		theme := b.themeName(dir)
+		err := walkFiles(fs, dir, func(rel string) error {
+			fi, err := cd.fs.Stat(rel)
+			if err != nil {
+				return err
+			}
+			lang := cd.fs.Lang()
+			path := rel
+			if lfi, ok := fi.(*hugofs.LanguageFileInfo); ok {
+				lang = lfi.Lang()
+				path = filepath.Join(filepath.Dir(rel), lfi.TranslationBaseName()+filepath.Ext(rel))
+			}
+			c := OverrideCandidate{Filename: filepath.Join(dir, rel), Theme: theme, MountLang: cd.fs.Lang()}
+			k := key{component: ComponentContent, lang: lang, path: path}
+			if c.MountLang == lang {
+				// The file in the language's own content dir wins.
+				candidates[k] = append([]OverrideCandidate{c}, candidates[k]...)
+				if len(candidates[k]) == 1 {
+					keys = append(keys, k)
+				}
+				return nil
+			}
+			add(k, c)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, m := range b.Mounts() {
+		if m.Component == ComponentContent {
+			continue
+		}
+		m := m
+		err := walkFiles(fs, m.Source, func(rel string) error {
+			add(key{component: m.Component, lang: m.Lang, path: filepath.Join(m.Target, rel)}, OverrideCandidate{Filename: filepath.Join(m.Source, rel), Theme: m.Theme, MountLang: m.Lang})
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var overrides []Override
+	for _, k := range keys {
+		cs := candidates[k]
+		if len(cs) < 2 {
+			continue
+		}
+		if filename, found := b.pinned(k.component, k.path); found {
+			// The pinned file wins.
+			for i, c := range cs {
+				if c.Filename == filename {
+					cs = append([]OverrideCandidate{c}, append(cs[:i:i], cs[i+1:]...)...)
+					break
+				}
+			}
+		}
+		overrides = append(overrides, Override{
+			Component: k.component,
+			Path:      k.path,
+			Lang:      k.lang,
+			Winner:    cs[0],
+			Losers:    cs[1:],
+		})
+	}
+
+	sort.SliceStable(overrides, func(i, j int) bool {
+		oi, oj := overrides[i], overrides[j]
+		if oi.Component != oj.Component {
+			return oi.Component < oj.Component
+		}
+		if oi.Lang != oj.Lang {
+			return oi.Lang < oj.Lang
+		}
+		return oi.Path < oj.Path
+	})
+
+	return overrides, nil
+}
+
+// walkFiles calls fn with the path relative to dir of every regular file
+// below dir.
+func walkFiles(fs afero.Fs, dir string, fn func(rel string) error) error {
+	return afero.Walk(fs, dir, func(filename string, fi os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if fi.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, filename)
+		if err != nil {
+			return err
+		}
+		return fn(rel)
+	})
+}
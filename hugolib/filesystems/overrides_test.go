@@ -0,0 +1,96 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filesystems
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/gohugoio/hugo/hugofs"
+	"github.com/gohugoio/hugo/hugolib/paths"
+	"github.com/gohugoio/hugo/langs"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOverrides(t *testing.T) {
+	assert := require.New(t)
+	v := createConfig()
+	workDir := filepath.FromSlash("/mywork")
+	v.Set("workingDir", workDir)
+	v.Set("themesDir", "themes")
+	v.Set("theme", []string{"btheme", "atheme"})
+	v.Set("defaultContentLanguage", "en")
+
+	en := langs.NewLanguage("en", v)
+	en.ContentDir = "content_en"
+	nn := langs.NewLanguage("nn", v)
+	nn.ContentDir = "content_nn"
+	v.Set("languagesSorted", langs.Languages{en, nn})
+
+	fs := hugofs.NewMem(v)
+
+	join := func(elem ...string) string {
+		return filepath.Join(append([]string{workDir}, elem...)...)
+	}
+
+	for _, filename := range []string{
+		join("content_en", "post", "p1.md"),
+		join("content_en", "post", "p2.nn.md"),
+		join("content_nn", "post", "p1.md"),
+		join("content_nn", "post", "p2.md"),
+		join("mylayouts", "index.html"),
+		join("themes", "atheme", "layouts", "index.html"),
+		join("themes", "atheme", "layouts", "_default", "single.html"),
+		join("themes", "btheme", "layouts", "_default", "single.html"),
+		join("themes", "btheme", "layouts", "_default", "list.html"),
+		join("mystatic", "logo.png"),
+	} {
+		assert.NoError(afero.WriteFile(fs.Source, filename, []byte("Hugo Rocks!"), 0755))
+	}
+
+	p, err := paths.New(fs, v)
+	assert.NoError(err)
+	bfs, err := NewBase(p)
+	assert.NoError(err)
+
+	overrides, err := bfs.Overrides()
+	assert.NoError(err)
+
+	assert.Equal([]Override{
+		{
+			Component: ComponentContent,
+			Path:      filepath.FromSlash("post/p2.md"),
+			Lang:      "nn",
+			Winner:    OverrideCandidate{Filename: join("content_nn", "post", "p2.md"), MountLang: "nn"},
+			Losers:    []OverrideCandidate{{Filename: join("content_en", "post", "p2.nn.md"), MountLang: "en"}},
+		},
+		{
+			Component: ComponentLayouts,
+			Path:      filepath.FromSlash("_default/single.html"),
+			Winner:    OverrideCandidate{Filename: join("themes", "btheme", "layouts", "_default", "single.html"), Theme: "btheme"},
+			Losers:    []OverrideCandidate{{Filename: join("themes", "atheme", "layouts", "_default", "single.html"), Theme: "atheme"}},
+		},
+		{
+			Component: ComponentLayouts,
+			Path:      "index.html",
+			Winner:    OverrideCandidate{Filename: join("mylayouts", "index.html")},
+			Losers:    []OverrideCandidate{{Filename: join("themes", "atheme", "layouts", "index.html"), Theme: "atheme"}},
+		},
+	}, overrides)
+
+	overrides, err = (*BaseFs)(nil).Overrides()
+	assert.NoError(err)
+	assert.Nil(overrides)
+}
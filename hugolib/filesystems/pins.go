@@ -0,0 +1,190 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filesystems
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/gohugoio/hugo/config"
+	"github.com/gohugoio/hugo/hugofs"
+	"github.com/spf13/afero"
+)
+
+// PinProject is the Pin mount value for the project.
+const PinProject = "project"
+
+// Pin pins a path in one of the components to the file in a given mount,
+// overriding the normal precedence, e.g.:
+//
+//	[[pins]]
+//	path = "layouts/partials/head.html"
+//	mount = "mytheme"
+//
+// The file is used even if the project or a theme with a higher precedence
+// provides the same path. Pins are supported for the layouts, archetypes,
+// assets and static components.
+type Pin struct {
+	// The slash separated path, including the component.
+	Path string
+
+	// The name of the theme to take the file from, or "project".
+	Mount string
+}
+
+// pinnedFile is a pin resolved to a real file.
+type pinnedFile struct {
+	component string
+	path      string
+	filename  string
+}
+
+func decodePins(cfg config.Provider) ([]Pin, error) {
+	var pins []Pin
+	if err := config.Decode(cfg, "pins", &pins); err != nil {
+		return nil, err
+	}
+
+	for i, pin := range pins {
+		if pin.Path == "" || pin.Mount == "" {
+			return nil, fmt.Errorf("pin %d: both path and mount must be set", i)
+		}
+	}
+
+	return pins, nil
+}
+
+// applyPins layers the pinned files on top of the source filesystems they
+// belong to.
+func (b *BaseFs) applyPins(pins []Pin, defaultLang string) error {
+	if len(pins) == 0 {
+		return nil
+	}
+
+	type pinnedFs struct {
+		sfs      *SourceFilesystem
+		lang     string
+		mappings []hugofs.RootMapping
+	}
+
+	var pinned []*pinnedFs
+	byFs := make(map[*SourceFilesystem]*pinnedFs)
+
+	add := func(sfs *SourceFilesystem, lang string, pin Pin, component, path string) error {
+		filename, found := b.pinnedFilename(sfs, pin.Mount, path)
+		if !found {
+			return fmt.Errorf("pin %q: not found in mount %q", pin.Path, pin.Mount)
+		}
+		pfs, found := byFs[sfs]
+		if !found {
+			pfs = &pinnedFs{sfs: sfs, lang: lang}
+			byFs[sfs] = pfs
+			pinned = append(pinned, pfs)
+		}
+		pfs.mappings = append(pfs.mappings, hugofs.RootMapping{From: path, To: filename})
+		b.pinnedFiles = append(b.pinnedFiles, pinnedFile{component: component, path: path, filename: filename})
+		return nil
+	}
+
+	for _, pin := range pins {
+		p := filepath.Clean(filepath.FromSlash(pin.Path))
+		parts := strings.SplitN(p, filePathSeparator, 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("pin %q: path must start with the component, e.g. \"layouts/\"", pin.Path)
+		}
+		component, path := parts[0], parts[1]
+
+		var err error
+		switch component {
+		case ComponentLayouts:
+			err = add(b.Layouts, defaultLang, pin, component, path)
+		case ComponentArchetypes:
+			err = add(b.Archetypes, defaultLang, pin, component, path)
+		case ComponentAssets:
+			err = add(b.Assets, defaultLang, pin, component, path)
+		case ComponentStatic:
+			for _, lang := range b.staticLangs() {
+				l := lang
+				if l == "" {
+					l = defaultLang
+				}
+				if err = add(b.Static[lang], l, pin, component, path); err != nil {
+					break
+				}
+			}
+		default:
+			err = fmt.Errorf("pin %q: the %s component does not support pins", pin.Path, component)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, pfs := range pinned {
+		rfs, err := hugofs.NewRootMappingFsFromMappings(pfs.sfs.SourceFs, pfs.mappings...)
+		if err != nil {
+			return err
+		}
+		layer := afero.NewReadOnlyFs(hugofs.NewLanguageMetaFs(pfs.lang, rfs))
+		pfs.sfs.Fs = hugofs.NewOrderedCopyOnWriteFs(pfs.sfs.Fs, layer)
+	}
+
+	return nil
+}
+
+// pinnedFilename returns the real filename of path in the dir of sfs
+// belonging to mount, a theme name or PinProject.
+func (b *BaseFs) pinnedFilename(sfs *SourceFilesystem, mount, path string) (string, bool) {
+	if sfs == nil {
+		return "", false
+	}
+
+	var dirs []string
+	if sfs.Targets != nil {
+		dirs = b.staticByPrecedence(sfs)
+	} else {
+		dirs = b.byPrecedence(sfs.Dirnames)
+	}
+
+	for _, dir := range dirs {
+		theme := b.themeName(dir)
+		if (mount == PinProject && theme != "") || (mount != PinProject && theme != mount) {
+			continue
+		}
+		rel := path
+		if target, found := sfs.Targets[dir]; found {
+			var ok bool
+			if rel, ok = relTo(target, path); !ok {
+				continue
+			}
+		}
+		filename := filepath.Join(dir, rel)
+		if fi, err := sfs.SourceFs.Stat(filename); err == nil && !fi.IsDir() {
+			return filename, true
+		}
+	}
+
+	return "", false
+}
+
+// pinned returns the filename pinned for path in component, if any.
+func (b *BaseFs) pinned(component, path string) (string, bool) {
+	for _, pf := range b.pinnedFiles {
+		if pf.component == component && pf.path == path {
+			return pf.filename, true
+		}
+	}
+	return "", false
+}
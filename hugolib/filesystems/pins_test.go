@@ -0,0 +1,115 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filesystems
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/gohugoio/hugo/hugofs"
+	"github.com/gohugoio/hugo/hugolib/paths"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPins(t *testing.T) {
+	assert := require.New(t)
+	v := createConfig()
+	workDir := filepath.FromSlash("/mywork")
+	v.Set("workingDir", workDir)
+	v.Set("themesDir", "themes")
+	v.Set("theme", []string{"btheme", "atheme"})
+	v.Set("pins", []map[string]interface{}{
+		{"path": "layouts/partials/head.html", "mount": "atheme"},
+		{"path": "layouts/partials/Footer.html", "mount": "project"},
+		{"path": "static/css/main.css", "mount": "btheme"},
+	})
+
+	fs := hugofs.NewMem(v)
+
+	join := func(elem ...string) string {
+		return filepath.Join(append([]string{workDir}, elem...)...)
+	}
+
+	for filename, content := range map[string]string{
+		join("mylayouts", "partials", "head.html"):                     "project",
+		join("mylayouts", "partials", "Footer.html"):                   "project",
+		join("themes", "atheme", "layouts", "partials", "head.html"):   "atheme",
+		join("themes", "btheme", "layouts", "partials", "head.html"):   "btheme",
+		join("themes", "btheme", "layouts", "partials", "Footer.html"): "btheme",
+		join("themes", "btheme", "layouts", "partials", "nav.html"):    "btheme",
+		join("mystatic", "css", "main.css"):                            "project",
+		join("themes", "btheme", "static", "css", "main.css"):          "btheme",
+	} {
+		assert.NoError(afero.WriteFile(fs.Source, filename, []byte(content), 0755))
+	}
+
+	p, err := paths.New(fs, v)
+	assert.NoError(err)
+	bfs, err := NewBase(p)
+	assert.NoError(err)
+
+	layouts := bfs.Layouts.Fs
+	checkFileContent(layouts, filepath.Join("partials", "head.html"), assert, "atheme")
+	checkFileContent(layouts, filepath.Join("partials", "Footer.html"), assert, "project")
+	checkFileContent(layouts, filepath.Join("partials", "nav.html"), assert, "btheme")
+	checkFileContent(bfs.StaticFs("en"), filepath.Join("css", "main.css"), assert, "btheme")
+
+	filename, found, err := bfs.LayoutsFs().Resolve(filepath.Join("partials", "head.html"))
+	assert.NoError(err)
+	assert.True(found)
+	assert.Equal(join("themes", "atheme", "layouts", "partials", "head.html"), filename)
+
+	fis, err := afero.ReadDir(layouts, "partials")
+	assert.NoError(err)
+	assert.Len(fis, 3)
+	for _, fi := range fis {
+		assert.False(fi.IsDir(), fi.Name())
+	}
+
+	overrides, err := bfs.Overrides()
+	assert.NoError(err)
+	winners := make(map[string]string)
+	for _, o := range overrides {
+		winners[o.Component+"/"+filepath.ToSlash(o.Path)] = o.Winner.Filename
+	}
+	assert.Equal(join("themes", "atheme", "layouts", "partials", "head.html"), winners["layouts/partials/head.html"])
+	assert.Equal(join("mylayouts", "partials", "Footer.html"), winners["layouts/partials/Footer.html"])
+	assert.Equal(join("themes", "btheme", "static", "css", "main.css"), winners["static/css/main.css"])
+}
+
+func TestPinsErrors(t *testing.T) {
+	assert := require.New(t)
+
+	for _, pin := range []map[string]interface{}{
+		{"path": "layouts/partials/missing.html", "mount": "project"},
+		{"path": "layouts/partials/head.html", "mount": "othertheme"},
+		{"path": "content/post.md", "mount": "project"},
+		{"path": "head.html", "mount": "project"},
+		{"path": "layouts/partials/head.html"},
+	} {
+		v := createConfig()
+		workDir := filepath.FromSlash("/mywork")
+		v.Set("workingDir", workDir)
+		v.Set("pins", []map[string]interface{}{pin})
+
+		fs := hugofs.NewMem(v)
+		assert.NoError(afero.WriteFile(fs.Source, filepath.Join(workDir, "mylayouts", "partials", "head.html"), []byte("project"), 0755))
+
+		p, err := paths.New(fs, v)
+		assert.NoError(err)
+		_, err = NewBase(p)
+		assert.Error(err, pin)
+	}
+}
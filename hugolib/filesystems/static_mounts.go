@@ -0,0 +1,88 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filesystems
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/gohugoio/hugo/config"
+	"github.com/gohugoio/hugo/hugofs"
+	"github.com/spf13/afero"
+)
+
+// StaticMount mounts a directory into a subdirectory of the static
+// filesystems, e.g. a theme's "dist/js" into "vendor/libfoo".
+type StaticMount struct {
+	// The directory to mount, absolute or relative to the working dir.
+	Source string
+
+	// The slash separated subdirectory of static to mount the directory
+	// into.
+	Target string
+}
+
+// decodeStaticMounts decodes the staticMounts configuration, e.g.:
+//
+//	[[staticMounts]]
+//	source = "themes/libfoo/dist/js"
+//	target = "vendor/libfoo"
+func decodeStaticMounts(cfg config.Provider) ([]StaticMount, error) {
+	var mounts []StaticMount
+	if err := config.Decode(cfg, "staticMounts", &mounts); err != nil {
+		return nil, err
+	}
+
+	for i, m := range mounts {
+		if m.Source == "" {
+			return nil, fmt.Errorf("static mount %d: source not set", i)
+		}
+		target := filepath.Clean(filepath.FromSlash(m.Target))
+		if m.Target == "" || target == "." || filepath.IsAbs(target) || target == ".." || strings.HasPrefix(target, ".."+filePathSeparator) {
+			return nil, fmt.Errorf("static mount %q: target %q must be a subdirectory of static", m.Source, m.Target)
+		}
+		mounts[i].Target = target
+	}
+
+	return mounts, nil
+}
+
+// addStaticMounts layers the static mounts below fs, the project's static
+// filesystem, with the first mount taking precedence. The mounted dirs are
+// added to s.
+func (b *sourceFilesystemsBuilder) addStaticMounts(s *SourceFilesystem, fs afero.Fs) (afero.Fs, error) {
+	for _, m := range b.staticMounts {
+		absDir := b.p.AbsPathify(m.Source)
+		if !b.existsInSource(absDir) {
+			return nil, fmt.Errorf("static mount %q: source dir %q not found", m.Source, absDir)
+		}
+
+		mfs, err := hugofs.NewRootMappingFs(b.p.Fs.Source, m.Target, absDir)
+		if err != nil {
+			return nil, err
+		}
+		mfs.WithLogger(b.p.Fs.Logger)
+
+		fs = hugofs.NewOrderedCopyOnWriteFs(afero.NewReadOnlyFs(mfs), fs)
+
+		s.Dirnames = append(s.Dirnames, absDir)
+		if s.Targets == nil {
+			s.Targets = make(map[string]string)
+		}
+		s.Targets[absDir] = m.Target
+	}
+
+	return fs, nil
+}
@@ -0,0 +1,120 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filesystems
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/gohugoio/hugo/hugofs"
+	"github.com/gohugoio/hugo/hugolib/paths"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStaticMounts(t *testing.T) {
+	assert := require.New(t)
+	v := createConfig()
+	workDir := filepath.FromSlash("/mywork")
+	v.Set("workingDir", workDir)
+	v.Set("themesDir", "themes")
+	v.Set("theme", "t1")
+	v.Set("staticMounts", []map[string]interface{}{
+		{"source": "themes/libfoo/dist/js", "target": "vendor/libfoo"},
+		{"source": "node_modules/libbar", "target": "vendor/libfoo"},
+	})
+
+	fs := hugofs.NewMem(v)
+
+	join := func(elem ...string) string {
+		return filepath.Join(append([]string{workDir}, elem...)...)
+	}
+
+	for filename, content := range map[string]string{
+		join("mystatic", "vendor", "libfoo", "override.js"):          "project",
+		join("themes", "libfoo", "dist", "js", "foo.js"):             "libfoo",
+		join("themes", "libfoo", "dist", "js", "override.js"):        "libfoo",
+		join("themes", "libfoo", "dist", "js", "shared.js"):          "libfoo",
+		join("node_modules", "libbar", "shared.js"):                  "libbar",
+		join("node_modules", "libbar", "bar.js"):                     "libbar",
+		join("themes", "t1", "static", "vendor", "libfoo", "t"):      "t1",
+		join("themes", "t1", "static", "vendor", "libfoo", "foo.js"): "t1",
+	} {
+		assert.NoError(afero.WriteFile(fs.Source, filename, []byte(content), 0755))
+	}
+
+	p, err := paths.New(fs, v)
+	assert.NoError(err)
+	bfs, err := NewBase(p)
+	assert.NoError(err)
+
+	sfs := bfs.StaticFs("en")
+
+	for filename, expect := range map[string]string{
+		"override.js": "project",
+		"foo.js":      "libfoo",
+		"shared.js":   "libfoo",
+		"bar.js":      "libbar",
+		"t":           "t1",
+	} {
+		checkFileContent(sfs, filepath.Join("vendor", "libfoo", filename), assert, expect)
+	}
+
+	fis, err := afero.ReadDir(sfs, filepath.Join("vendor", "libfoo"))
+	assert.NoError(err)
+	assert.Len(fis, 5)
+
+	assert.Equal(filepath.FromSlash("/vendor/libfoo/foo.js"),
+		bfs.MakeStaticPathRelative(join("themes", "libfoo", "dist", "js", "foo.js")))
+
+	var static []Mount
+	for _, m := range bfs.Mounts() {
+		if m.Component == ComponentStatic {
+			static = append(static, m)
+		}
+	}
+	assert.Equal([]Mount{
+		{Component: ComponentStatic, Source: join("mystatic")},
+		{Component: ComponentStatic, Source: join("themes", "libfoo", "dist", "js"), Target: filepath.FromSlash("vendor/libfoo")},
+		{Component: ComponentStatic, Source: join("node_modules", "libbar"), Target: filepath.FromSlash("vendor/libfoo")},
+		{Component: ComponentStatic, Source: join("themes", "t1", "static"), Theme: "t1"},
+	}, static)
+
+	overrides, err := bfs.Overrides()
+	assert.NoError(err)
+	var staticPaths []string
+	for _, o := range overrides {
+		if o.Component == ComponentStatic {
+			staticPaths = append(staticPaths, filepath.ToSlash(o.Path))
+		}
+	}
+	assert.Equal([]string{"vendor/libfoo/foo.js", "vendor/libfoo/override.js", "vendor/libfoo/shared.js"}, staticPaths)
+}
+
+func TestDecodeStaticMounts(t *testing.T) {
+	assert := require.New(t)
+
+	for _, target := range []string{"", ".", "..", "../foo", "/foo"} {
+		v := createConfig()
+		v.Set("staticMounts", []map[string]interface{}{{"source": "foo", "target": target}})
+		_, err := decodeStaticMounts(v)
+		assert.Error(err, target)
+	}
+
+	v := createConfig()
+	v.Set("staticMounts", []map[string]interface{}{{"source": "foo", "target": "a/b/"}})
+	mounts, err := decodeStaticMounts(v)
+	assert.NoError(err)
+	assert.Equal([]StaticMount{{Source: "foo", Target: filepath.FromSlash("a/b")}}, mounts)
+}
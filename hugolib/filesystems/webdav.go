@@ -0,0 +1,91 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filesystems
+
+import (
+	"context"
+	"net/http"
+	"os"
+
+	"golang.org/x/net/webdav"
+)
+
+// NewWebDAVHandler returns a read-only WebDAV handler over the source
+// filesystems returned by baseFs, so they can be mounted as a network drive,
+// e.g. to see which theme files are in use. baseFs is called on every
+// request, as the filesystems may be recreated on config changes. See
+// HTTPHandler for the layout and how to select a language. The prefix is
+// stripped from the request paths.
+func NewWebDAVHandler(prefix string, baseFs func() *BaseFs) http.Handler {
+	// Shared by all requests, as clients may lock and unlock in different
+	// requests.
+	ls := webdav.NewMemLS()
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lang := r.URL.Query().Get("lang")
+		if lang == "" {
+			lang = r.Header.Get(HTTPLangHeader)
+		}
+		h := &webdav.Handler{
+			Prefix:     prefix,
+			FileSystem: &webdavFs{fs: baseFs().HTTPFileSystem(lang)},
+			LockSystem: ls,
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// webdavFs is a read-only webdav.FileSystem.
+type webdavFs struct {
+	fs http.FileSystem
+}
+
+func (fs *webdavFs) Mkdir(ctx context.Context, name string, perm os.FileMode) error {
+	return &os.PathError{Op: "mkdir", Path: name, Err: os.ErrPermission}
+}
+
+func (fs *webdavFs) OpenFile(ctx context.Context, name string, flag int, perm os.FileMode) (webdav.File, error) {
+	if flag&(os.O_WRONLY|os.O_RDWR|os.O_APPEND|os.O_CREATE|os.O_TRUNC) != 0 {
+		return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrPermission}
+	}
+	f, err := fs.fs.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return webdavFile{f}, nil
+}
+
+func (fs *webdavFs) RemoveAll(ctx context.Context, name string) error {
+	return &os.PathError{Op: "remove", Path: name, Err: os.ErrPermission}
+}
+
+func (fs *webdavFs) Rename(ctx context.Context, oldName, newName string) error {
+	return &os.PathError{Op: "rename", Path: oldName, Err: os.ErrPermission}
+}
+
+func (fs *webdavFs) Stat(ctx context.Context, name string) (os.FileInfo, error) {
+	f, err := fs.fs.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return f.Stat()
+}
+
+type webdavFile struct {
+	http.File
+}
+
+func (f webdavFile) Write(p []byte) (int, error) {
+	return 0, os.ErrPermission
+}
@@ -0,0 +1,86 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filesystems
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gohugoio/hugo/hugofs"
+	"github.com/gohugoio/hugo/hugolib/paths"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebDAVHandler(t *testing.T) {
+	assert := require.New(t)
+	v := createConfig()
+	workDir := filepath.FromSlash("/mywork")
+	v.Set("workingDir", workDir)
+	v.Set("themesDir", "themes")
+	v.Set("theme", "mytheme")
+
+	fs := hugofs.NewMem(v)
+
+	for filename, content := range map[string]string{
+		"mylayouts/_default/single.html":              "project single",
+		"themes/mytheme/layouts/_default/list.html":   "theme list",
+		"themes/mytheme/layouts/_default/single.html": "theme single",
+	} {
+		assert.NoError(afero.WriteFile(fs.Source, filepath.Join(workDir, filepath.FromSlash(filename)), []byte(content), 0755))
+	}
+
+	p, err := paths.New(fs, v)
+	assert.NoError(err)
+	bfs, err := NewBase(p)
+	assert.NoError(err)
+
+	h := NewWebDAVHandler("/dav", func() *BaseFs { return bfs })
+
+	do := func(method, path, body string, header ...string) (int, string) {
+		r := httptest.NewRequest(method, path, strings.NewReader(body))
+		for i := 0; i < len(header); i += 2 {
+			r.Header.Set(header[i], header[i+1])
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		b, err := ioutil.ReadAll(w.Result().Body)
+		assert.NoError(err)
+		return w.Code, string(b)
+	}
+
+	code, body := do("PROPFIND", "/dav/layouts/_default/", "", "Depth", "1")
+	assert.Equal(http.StatusMultiStatus, code)
+	assert.Contains(body, "/dav/layouts/_default/single.html")
+	assert.Contains(body, "/dav/layouts/_default/list.html")
+
+	code, body = do("GET", "/dav/layouts/_default/single.html", "")
+	assert.Equal(http.StatusOK, code)
+	assert.Equal("project single", body)
+
+	code, _ = do("PUT", "/dav/layouts/_default/single.html", "changed")
+	assert.NotEqual(http.StatusCreated, code)
+	code, _ = do("DELETE", "/dav/layouts/_default/single.html", "")
+	assert.NotEqual(http.StatusNoContent, code)
+	code, _ = do("MKCOL", "/dav/layouts/partials", "")
+	assert.NotEqual(http.StatusCreated, code)
+
+	b, err := afero.ReadFile(fs.Source, filepath.Join(workDir, "mylayouts", "_default", "single.html"))
+	assert.NoError(err)
+	assert.Equal("project single", string(b))
+}
@@ -14,6 +14,7 @@
 package hugolib
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"path"
@@ -199,12 +200,12 @@ func (h *HugoSites) IsMultihost() bool {
 	return h != nil && h.multihost
 }
 
-func (h *HugoSites) LanguageSet() map[string]bool {
-	set := make(map[string]bool)
-	for _, s := range h.Sites {
-		set[s.language.Lang] = true
+func (h *HugoSites) LanguageSet() langs.LanguageSet {
+	languages := make(langs.Languages, len(h.Sites))
+	for i, s := range h.Sites {
+		languages[i] = s.language
 	}
-	return set
+	return languages.AsSet()
 }
 
 func (h *HugoSites) NumLogErrors() int {
@@ -450,10 +451,7 @@ func createSitesFromConfig(cfg deps.DepsCfg) ([]*Site, error) {
 
 	languages := getLanguages(cfg.Cfg)
 
-	for _, lang := range languages {
-		if lang.Disabled {
-			continue
-		}
+	for _, lang := range languages.Active() {
 		var s *Site
 		var err error
 		cfg.Language = lang
@@ -562,6 +560,16 @@ type BuildCfg struct {
 
 	// Recently visited URLs. This is used for partial re-rendering.
 	RecentlyVisited map[string]bool
+
+	// Context used to abort the build, e.g. on server shutdown. Optional.
+	Context context.Context
+}
+
+func (cfg *BuildCfg) context() context.Context {
+	if cfg.Context == nil {
+		return context.Background()
+	}
+	return cfg.Context
 }
 
 // shouldRender is used in the Fast Render Mode to determine if we need to re-render
@@ -810,6 +818,14 @@ func (h *HugoSites) loadData(fs afero.Fs) (err error) {
 		}
 	}
 
+	conflicts, err := h.BaseFs.DataFs().Conflicts()
+	if err != nil {
+		return err
+	}
+	for _, c := range conflicts {
+		h.Log.WARN.Printf("Data key %q is provided by more than one data file; %s wins over %s", c.Key, c.Winner, c.Losers)
+	}
+
 	return
 }
 
@@ -26,6 +26,7 @@ import (
 
 	"github.com/fsnotify/fsnotify"
 	"github.com/gohugoio/hugo/helpers"
+	"github.com/gohugoio/hugo/hugofs"
 )
 
 // Build builds all sites. If filesystem events are provided,
@@ -37,7 +38,7 @@ func (h *HugoSites) Build(config BuildCfg, events ...fsnotify.Event) error {
 		defer h.runningMu.Unlock()
 	}
 
-	ctx, task := trace.NewTask(context.Background(), "Build")
+	ctx, task := trace.NewTask(config.context(), "Build")
 	defer task.End()
 
 	errCollector := h.StartErrorCollector()
@@ -63,6 +64,11 @@ func (h *HugoSites) Build(config BuildCfg, events ...fsnotify.Event) error {
 		h.Metrics.Reset()
 	}
 
+	if r, ok := h.Fs.Source.(hugofs.Reseter); ok {
+		// E.g. the read quota is per build.
+		r.Reset()
+	}
+
 	// Need a pointer as this may be modified.
 	conf := &config
 
@@ -93,7 +99,7 @@ func (h *HugoSites) Build(config BuildCfg, events ...fsnotify.Event) error {
 			var err error
 
 			f := func() {
-				err = h.process(conf, events...)
+				err = h.process(ctx, conf, events...)
 			}
 			trace.WithRegion(ctx, "process", f)
 			if err != nil {
@@ -205,7 +211,7 @@ func (h *HugoSites) initRebuild(config *BuildCfg) error {
 	return nil
 }
 
-func (h *HugoSites) process(config *BuildCfg, events ...fsnotify.Event) error {
+func (h *HugoSites) process(ctx context.Context, config *BuildCfg, events ...fsnotify.Event) error {
 	// We should probably refactor the Site and pull up most of the logic from there to here,
 	// but that seems like a daunting task.
 	// So for now, if there are more than one site (language),
@@ -215,13 +221,31 @@ func (h *HugoSites) process(config *BuildCfg, events ...fsnotify.Event) error {
 
 	if len(events) > 0 {
 		// This is a rebuild
-		changed, err := firstSite.processPartial(events)
+		changed, err := firstSite.processPartial(ctx, events)
 		config.whatChanged = &changed
 		return err
 	}
 
-	return firstSite.process(*config)
+	if err := firstSite.process(ctx, *config); err != nil {
+		return err
+	}
+
+	h.logContentGuardOffenders()
 
+	return nil
+
+}
+
+// logContentGuardOffenders logs the unexpected binary files found in the
+// content dirs, see the contentGuard setting.
+func (h *HugoSites) logContentGuardOffenders() {
+	for _, o := range h.BaseFs.ContentGuardOffenders() {
+		action := "found"
+		if o.Excluded {
+			action = "excluded"
+		}
+		h.Log.WARN.Printf("Unexpected binary file %s in content dir %q (%s): %q", action, o.Mount, o.Reason, o.Filename)
+	}
 }
 
 func (h *HugoSites) assemble(config *BuildCfg) error {
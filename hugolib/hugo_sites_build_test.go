@@ -1535,3 +1535,33 @@ PNG Data
 
 	return &multiSiteTestBuilder{sitesBuilder: b, configFormat: configFormat, config: config, configData: configData}
 }
+
+func TestRebuildReadQuotaPerBuild(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+
+	newBuilder := func(quota hugofs.Quota) (*sitesBuilder, *hugofs.QuotaFs) {
+		b := newTestSitesBuilder(t).WithSimpleConfigFile().Running()
+		qfs := hugofs.NewQuotaFs(b.Fs.Source, quota)
+		b.Fs.Source = qfs
+		b.WithContent("p1.md", "---\ntitle: P1\n---\nContent")
+		b.WithTemplatesAdded("_default/single.html", "Single: {{ .Title }}")
+		b.CreateSites()
+		return b, qfs
+	}
+
+	b, qfs := newBuilder(hugofs.Quota{})
+	b.Build(BuildCfg{})
+	files, _ := qfs.Usage()
+	assert.True(files > 0)
+
+	// Room for one full build only.
+	b, _ = newBuilder(hugofs.Quota{MaxFiles: files})
+	b.Build(BuildCfg{})
+
+	for i := 0; i < 2; i++ {
+		b.EditFiles("content/p1.md", fmt.Sprintf("---\ntitle: P1 %d\n---\nContent", i))
+		b.Build(BuildCfg{})
+		b.AssertFileContent("public/p1/index.html", fmt.Sprintf("Single: P1 %d", i))
+	}
+}
@@ -14,6 +14,7 @@
 package hugolib
 
 import (
+	"strings"
 	"sync"
 
 	"github.com/gohugoio/hugo/common/maps"
@@ -68,11 +69,7 @@ func newMultiLingualFromSites(cfg config.Provider, sites ...*Site) (*Multilingua
 		languages[i] = s.language
 	}
 
-	defaultLang := cfg.GetString("defaultContentLanguage")
-
-	if defaultLang == "" {
-		defaultLang = "en"
-	}
+	defaultLang := config.GetStringDefault(cfg, "defaultContentLanguage", "en")
 
 	return &Multilingual{Languages: languages, DefaultLang: langs.NewLanguage(defaultLang, cfg)}, nil
 
@@ -108,12 +105,28 @@ func toSortedLanguages(cfg config.Provider, l map[string]interface{}) (langs.Lan
 				language.Title = cast.ToString(v)
 			case "languagename":
 				language.LanguageName = cast.ToString(v)
+			case "languagedirection":
+				language.LanguageDirection = strings.ToLower(cast.ToString(v))
+				if language.LanguageDirection != "ltr" && language.LanguageDirection != "rtl" {
+					return nil, fmt.Errorf("invalid languageDirection %q for language %q: must be one of \"ltr\" or \"rtl\"", v, lang)
+				}
 			case "weight":
 				language.Weight = cast.ToInt(v)
 			case "contentdir":
 				language.ContentDir = cast.ToString(v)
 			case "disabled":
 				language.Disabled = cast.ToBool(v)
+			case "customcode":
+				language.CustomCode = cast.ToBool(v)
+			case "fallbacks":
+				language.Fallbacks = cast.ToStringSlice(v)
+			case "mergecontentfrom":
+				language.MergeContentFrom = cast.ToString(v)
+			case "aliases":
+				language.Aliases = cast.ToStringSlice(v)
+				for i, alias := range language.Aliases {
+					language.Aliases[i] = strings.ToLower(alias)
+				}
 			case "params":
 				m := cast.ToStringMap(v)
 				// Needed for case insensitive fetching of params values
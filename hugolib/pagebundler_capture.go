@@ -14,6 +14,7 @@
 package hugolib
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
@@ -53,6 +54,9 @@ type capturer struct {
 	fs         afero.Fs
 	logger     *loggers.Logger
 
+	// Used to abort the file system operations, e.g. when the build fails.
+	ctx context.Context
+
 	// Filenames limits the content to process to a list of filenames/directories.
 	// This is used for partial building in server mode.
 	filenames []string
@@ -103,6 +107,7 @@ func newCapturer(
 		sourceSpec:     sourceSpec,
 		fs:             sourceSpec.SourceFs,
 		logger:         logger,
+		ctx:            context.Background(),
 		contentChanges: contentChanges,
 		seen:           make(map[string]bool),
 		filenames:      filenames}
@@ -188,12 +193,49 @@ func (c *capturer) capturePartial(filenames ...string) error {
 			if active {
 				c.copyOrHandleSingle(f)
 			}
+
+			borrowed, err := c.borrowedCopies(dir, fi)
+			if err != nil {
+				return err
+			}
+			for _, bfi := range borrowed {
+				f, active := c.newFileInfo(bfi, tp)
+				if active {
+					c.copyOrHandleSingle(f)
+				}
+			}
 		}
 	}
 
 	return nil
 }
 
+// borrowedCopies returns the copies of fi in dirname in the languages that
+// fill their gaps with its content, see the mergeContentFrom language
+// setting.
+func (c *capturer) borrowedCopies(dirname string, fi pathLangFileFi) (pathLangFileFis, error) {
+	if len(c.sourceSpec.PathSpec.Languages.MergeContentFrom()) == 0 {
+		return nil, nil
+	}
+
+	fis, err := c.readDir(dirname)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var borrowed pathLangFileFis
+	for _, bfi := range fis {
+		if lfi, ok := bfi.(*hugofs.LanguageFileInfo); ok && lfi.BorrowedFrom() != "" && lfi.Filename() == fi.Filename() {
+			borrowed = append(borrowed, bfi)
+		}
+	}
+
+	return borrowed, nil
+}
+
 func (c *capturer) capture() error {
 	if len(c.filenames) > 0 {
 		return c.capturePartial(c.filenames...)
@@ -540,12 +582,12 @@ func (c *capturer) readDir(dirname string) (pathLangFileFis, error) {
 		return nil, nil
 	}
 
-	dir, err := c.fs.Open(dirname)
+	dir, err := hugofs.OpenContext(c.ctx, c.fs, dirname)
 	if err != nil {
 		return nil, err
 	}
 	defer dir.Close()
-	fis, err := dir.Readdir(-1)
+	fis, err := hugofs.ReaddirContext(c.ctx, dir, -1)
 	if err != nil {
 		return nil, err
 	}
@@ -564,6 +606,10 @@ func (c *capturer) readDir(dirname string) (pathLangFileFis, error) {
 				if err == errSkipCyclicDir || os.IsNotExist(err) {
 					continue
 				}
+				if hugofs.IsTimeout(err) {
+					c.logger.ERROR.Printf("Skipped %q: %s", fip.Filename(), err)
+					continue
+				}
 				return nil, err
 			}
 
@@ -186,3 +186,44 @@ func BenchmarkMergeByLanguage(b *testing.B) {
 		}
 	}
 }
+
+func TestMergeContentFrom(t *testing.T) {
+	t.Parallel()
+
+	b := newTestSitesBuilder(t).WithConfigFile("toml", `
+baseURL = "https://example.org"
+defaultContentLanguage = "en"
+
+[languages]
+[languages.en]
+weight = 1
+[languages.en-gb]
+weight = 2
+mergeContentFrom = "en"
+`)
+
+	b.WithContent(
+		"about.md", "---\ntitle: About\n---\nAbout EN",
+		"contact.md", "---\ntitle: Contact\n---\nContact EN",
+		"contact.en-gb.md", "---\ntitle: Contact\n---\nContact GB",
+	)
+	b.WithTemplates(
+		"_default/single.html", "Single: {{ .Title }}|{{ .Lang }}|{{ .Content }}",
+		"index.html", "Pages: {{ range .Site.RegularPages }}{{ .Title }}|{{ .Lang }}|{{ end }}",
+	)
+
+	b.Running().Build(BuildCfg{})
+
+	b.AssertFileContent("public/en-gb/about/index.html", "Single: About|en-gb|<p>About EN</p>")
+	b.AssertFileContent("public/en-gb/contact/index.html", "Single: Contact|en-gb|<p>Contact GB</p>")
+	b.AssertFileContent("public/contact/index.html", "Single: Contact|en|<p>Contact EN</p>")
+	b.AssertFileContent("public/en-gb/index.html", "Pages: About|en-gb|Contact|en-gb|")
+	b.AssertFileContent("public/index.html", "Pages: About|en|Contact|en|")
+
+	// The borrowed copy is rebuilt with the original.
+	b.EditFiles("content/about.md", "---\ntitle: About\n---\nAbout EN edited")
+	b.Build(BuildCfg{})
+
+	b.AssertFileContent("public/about/index.html", "<p>About EN edited</p>")
+	b.AssertFileContent("public/en-gb/about/index.html", "Single: About|en-gb|<p>About EN edited</p>")
+}
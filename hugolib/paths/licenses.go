@@ -0,0 +1,247 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package paths
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/gohugoio/hugo/parser/metadecoders"
+	"github.com/pkg/errors"
+	"github.com/spf13/afero"
+	"github.com/spf13/cast"
+)
+
+// Where the license of a theme was found.
+const (
+	LicenseSourceMetadata = "metadata"
+	LicenseSourceFile     = "file"
+)
+
+// ThemeLicense describes the license and origin of a theme used in the build.
+type ThemeLicense struct {
+	// The theme name.
+	Name string `json:"name"`
+
+	// The theme version, if set in the theme's metadata.
+	Version string `json:"version,omitempty"`
+
+	// The SPDX license identifier, e.g. "MIT". If the license could not be
+	// identified, this is "NOASSERTION".
+	License string `json:"license"`
+
+	// Either LicenseSourceMetadata or LicenseSourceFile, empty if no license
+	// was found.
+	LicenseSource string `json:"licenseSource,omitempty"`
+
+	// The license filename relative to the theme directory, if the license was
+	// detected from a file.
+	LicenseFile string `json:"licenseFile,omitempty"`
+
+	// Where the theme comes from: the homepage or original repository from its
+	// metadata, or "workspace" for themes loaded from a workspace directory.
+	Origin string `json:"origin,omitempty"`
+
+	// The absolute theme directory.
+	Dir string `json:"-"`
+}
+
+const licenseNoAssertion = "NOASSERTION"
+
+// The theme metadata file, as used in the Hugo themes site.
+const themeMetadataFilename = "theme.toml"
+
+var licenseFilenames = []string{
+	"LICENSE", "LICENSE.md", "LICENSE.txt",
+	"LICENCE", "LICENCE.md", "LICENCE.txt",
+	"COPYING", "COPYING.md", "COPYING.txt",
+}
+
+// licenseMatchers is ordered so the more specific licenses are tried first,
+// e.g. the LGPL before the GPL.
+var licenseMatchers = []struct {
+	id string
+	re *regexp.Regexp
+}{
+	{"AGPL-3.0", regexp.MustCompile(`(?i)GNU AFFERO GENERAL PUBLIC LICENSE`)},
+	{"LGPL-3.0", regexp.MustCompile(`(?is)GNU LESSER GENERAL PUBLIC LICENSE.*Version 3`)},
+	{"LGPL-2.1", regexp.MustCompile(`(?is)GNU LESSER GENERAL PUBLIC LICENSE.*Version 2\.1`)},
+	{"GPL-3.0", regexp.MustCompile(`(?is)GNU GENERAL PUBLIC LICENSE.*Version 3`)},
+	{"GPL-2.0", regexp.MustCompile(`(?is)GNU GENERAL PUBLIC LICENSE.*Version 2`)},
+	{"MPL-2.0", regexp.MustCompile(`(?i)Mozilla Public License,? Version 2\.0`)},
+	{"Apache-2.0", regexp.MustCompile(`(?i)Apache License,?\s+Version 2\.0`)},
+	{"Unlicense", regexp.MustCompile(`(?i)This is free and unencumbered software released into the public domain`)},
+	{"ISC", regexp.MustCompile(`(?i)Permission to use, copy, modify, and/or distribute this software for any`)},
+	{"BSD-3-Clause", regexp.MustCompile(`(?is)Redistribution and use in source and binary forms.*Neither the name`)},
+	{"BSD-2-Clause", regexp.MustCompile(`(?i)Redistribution and use in source and binary forms`)},
+	{"MIT", regexp.MustCompile(`(?i)Permission is hereby granted, free of charge, to any person obtaining a copy`)},
+}
+
+// DetectLicense tries to identify the license in the given license text. It
+// returns the SPDX identifier, or an empty string if not recognized.
+func DetectLicense(text string) string {
+	for _, m := range licenseMatchers {
+		if m.re.MatchString(text) {
+			return m.id
+		}
+	}
+	return ""
+}
+
+// ThemeLicenses returns a license report for the given themes, in the order
+// given. The license is taken from the "license" field in the theme's
+// theme.toml if set, else detected from a LICENSE file (or similar) in the
+// theme's root directory.
+func ThemeLicenses(fs afero.Fs, themes []ThemeConfig) ([]ThemeLicense, error) {
+	var licenses []ThemeLicense
+
+	for _, tc := range themes {
+		l, err := themeLicense(fs, tc)
+		if err != nil {
+			return nil, err
+		}
+		licenses = append(licenses, l)
+	}
+
+	return licenses, nil
+}
+
+func themeLicense(fs afero.Fs, tc ThemeConfig) (ThemeLicense, error) {
+	l := ThemeLicense{Name: tc.Name, Dir: tc.Dir, License: licenseNoAssertion}
+
+	m, err := readThemeMetadata(fs, tc)
+	if err != nil {
+		return l, err
+	}
+
+	l.Version = cast.ToString(m["version"])
+
+	if license := strings.TrimSpace(cast.ToString(m["license"])); license != "" {
+		l.License = license
+		l.LicenseSource = LicenseSourceMetadata
+	}
+
+	if homepage := cast.ToString(m["homepage"]); homepage != "" {
+		l.Origin = homepage
+	} else if original, ok := m["original"]; ok {
+		l.Origin = cast.ToString(cast.ToStringMap(original)["repo"])
+	}
+
+	if tc.InWorkspace {
+		l.Origin = "workspace"
+	}
+
+	if l.LicenseSource != "" {
+		return l, nil
+	}
+
+	for _, name := range licenseFilenames {
+		b, err := afero.ReadFile(fs, filepath.Join(tc.Dir, name))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return l, errors.Wrapf(err, "failed to read license file for theme %q", tc.Name)
+		}
+		l.LicenseSource = LicenseSourceFile
+		l.LicenseFile = name
+		if id := DetectLicense(string(b)); id != "" {
+			l.License = id
+		}
+		break
+	}
+
+	return l, nil
+}
+
+// readThemeMetadata reads the theme.toml in the theme's root directory, if
+// any.
+func readThemeMetadata(fs afero.Fs, tc ThemeConfig) (map[string]interface{}, error) {
+	m, err := metadecoders.Default.UnmarshalFileToMap(fs, filepath.Join(tc.Dir, themeMetadataFilename))
+	if err != nil && !os.IsNotExist(errors.Cause(err)) {
+		return nil, errors.Wrapf(err, "failed to read metadata for theme %q", tc.Name)
+	}
+	return m, nil
+}
+
+// WriteCycloneDX writes the given licenses as a CycloneDX JSON bill of
+// materials, with one component per theme.
+func WriteCycloneDX(w io.Writer, licenses []ThemeLicense) error {
+	type license struct {
+		ID   string `json:"id,omitempty"`
+		Name string `json:"name,omitempty"`
+	}
+	type licenseChoice struct {
+		License license `json:"license"`
+	}
+	type externalReference struct {
+		Type string `json:"type"`
+		URL  string `json:"url"`
+	}
+	type component struct {
+		Type               string              `json:"type"`
+		Name               string              `json:"name"`
+		Version            string              `json:"version,omitempty"`
+		Licenses           []licenseChoice     `json:"licenses,omitempty"`
+		ExternalReferences []externalReference `json:"externalReferences,omitempty"`
+	}
+
+	components := make([]component, 0, len(licenses))
+	for _, l := range licenses {
+		c := component{Type: "library", Name: l.Name, Version: l.Version}
+		switch {
+		case l.License == licenseNoAssertion:
+		case isSPDXIdentifier(l.License):
+			c.Licenses = []licenseChoice{{License: license{ID: l.License}}}
+		default:
+			c.Licenses = []licenseChoice{{License: license{Name: l.License}}}
+		}
+		if strings.Contains(l.Origin, "://") {
+			c.ExternalReferences = []externalReference{{Type: "vcs", URL: l.Origin}}
+		}
+		components = append(components, c)
+	}
+
+	bom := struct {
+		BOMFormat   string      `json:"bomFormat"`
+		SpecVersion string      `json:"specVersion"`
+		Version     int         `json:"version"`
+		Components  []component `json:"components"`
+	}{
+		BOMFormat:   "CycloneDX",
+		SpecVersion: "1.2",
+		Version:     1,
+		Components:  components,
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(bom)
+}
+
+var spdxIdentifiers = func() map[string]bool {
+	m := make(map[string]bool)
+	for _, lm := range licenseMatchers {
+		m[lm.id] = true
+	}
+	return m
+}()
+
+func isSPDXIdentifier(s string) bool {
+	return spdxIdentifiers[s]
+}
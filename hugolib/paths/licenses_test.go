@@ -0,0 +1,110 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package paths
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectLicense(t *testing.T) {
+	assert := require.New(t)
+
+	for _, test := range []struct {
+		text   string
+		expect string
+	}{
+		{"MIT License\n\nPermission is hereby granted, free of charge, to any person obtaining a copy", "MIT"},
+		{"Apache License\n                           Version 2.0, January 2004", "Apache-2.0"},
+		{"GNU GENERAL PUBLIC LICENSE\nVersion 3, 29 June 2007", "GPL-3.0"},
+		{"GNU LESSER GENERAL PUBLIC LICENSE\nVersion 3, 29 June 2007", "LGPL-3.0"},
+		{"Redistribution and use in source and binary forms ... 3. Neither the name", "BSD-3-Clause"},
+		{"Redistribution and use in source and binary forms ...", "BSD-2-Clause"},
+		{"All rights reserved.", ""},
+	} {
+		assert.Equal(test.expect, DetectLicense(test.text), test.text)
+	}
+}
+
+func TestThemeLicenses(t *testing.T) {
+	assert := require.New(t)
+
+	fs := afero.NewMemMapFs()
+
+	writeFile := func(filename, content string) {
+		assert.NoError(afero.WriteFile(fs, filepath.FromSlash(filename), []byte(content), 0755))
+	}
+
+	writeFile("themes/a/theme.toml", `
+license = "MIT"
+version = "1.2.0"
+homepage = "https://example.org/a"
+`)
+	writeFile("themes/a/LICENSE", "Apache License, Version 2.0")
+	writeFile("themes/b/theme.toml", `
+[original]
+repo = "https://example.org/b"
+`)
+	writeFile("themes/b/LICENSE.md", "Apache License\nVersion 2.0")
+	writeFile("themes/c/COPYING", "Some custom terms.")
+	writeFile("ws/d/layouts/index.html", "")
+
+	themes := []ThemeConfig{
+		{Name: "a", Dir: filepath.FromSlash("themes/a")},
+		{Name: "b", Dir: filepath.FromSlash("themes/b")},
+		{Name: "c", Dir: filepath.FromSlash("themes/c")},
+		{Name: "d", Dir: filepath.FromSlash("ws/d"), InWorkspace: true},
+	}
+
+	licenses, err := ThemeLicenses(fs, themes)
+	assert.NoError(err)
+	assert.Len(licenses, 4)
+
+	assert.Equal("MIT", licenses[0].License)
+	assert.Equal(LicenseSourceMetadata, licenses[0].LicenseSource)
+	assert.Equal("1.2.0", licenses[0].Version)
+	assert.Equal("https://example.org/a", licenses[0].Origin)
+
+	assert.Equal("Apache-2.0", licenses[1].License)
+	assert.Equal(LicenseSourceFile, licenses[1].LicenseSource)
+	assert.Equal("LICENSE.md", licenses[1].LicenseFile)
+	assert.Equal("https://example.org/b", licenses[1].Origin)
+
+	assert.Equal(licenseNoAssertion, licenses[2].License)
+	assert.Equal(LicenseSourceFile, licenses[2].LicenseSource)
+	assert.Equal("COPYING", licenses[2].LicenseFile)
+
+	assert.Equal(licenseNoAssertion, licenses[3].License)
+	assert.Equal("", licenses[3].LicenseSource)
+	assert.Equal("workspace", licenses[3].Origin)
+
+	var buf bytes.Buffer
+	assert.NoError(WriteCycloneDX(&buf, licenses))
+
+	var bom map[string]interface{}
+	assert.NoError(json.Unmarshal(buf.Bytes(), &bom))
+	assert.Equal("CycloneDX", bom["bomFormat"])
+	components := bom["components"].([]interface{})
+	assert.Len(components, 4)
+	a := components[0].(map[string]interface{})
+	assert.Equal("a", a["name"])
+	assert.Equal("1.2.0", a["version"])
+	assert.Contains(buf.String(), `"id": "MIT"`)
+	assert.NotContains(buf.String(), licenseNoAssertion)
+}
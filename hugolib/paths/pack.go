@@ -0,0 +1,155 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package paths
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/afero"
+	"github.com/spf13/cast"
+)
+
+// PackManifestFilename is the name of the manifest written to the root of a
+// theme archive created by PackTheme.
+const PackManifestFilename = "hugo-pack.json"
+
+// PackManifest describes the content of a theme archive.
+type PackManifest struct {
+	// The theme name.
+	Name string `json:"name"`
+
+	// The theme version, if set in the theme's metadata.
+	Version string `json:"version,omitempty"`
+
+	// The files in the archive, sorted by path.
+	Files []PackFile `json:"files"`
+
+	// The hex encoded SHA-256 checksum of the archive. Only set in the
+	// manifest written to a theme extracted by UnpackTheme.
+	ArchiveSHA256 string `json:"archiveSHA256,omitempty"`
+}
+
+// PackFile describes a file in a theme archive.
+type PackFile struct {
+	// The slash separated path relative to the theme root.
+	Path string `json:"path"`
+
+	// The file size in bytes.
+	Size int64 `json:"size"`
+
+	// The hex encoded SHA-256 checksum of the file content.
+	SHA256 string `json:"sha256"`
+}
+
+// PackTheme writes a self-contained zip archive of the theme in tc to w. The
+// archive holds all the files in the theme directory, except hidden files and
+// directories (e.g. .git), below a top level directory named after the theme,
+// and a manifest with the checksums of all these files. This is suitable for
+// transferring a theme into an environment without network access.
+func PackTheme(fs afero.Fs, tc ThemeConfig, w io.Writer) (PackManifest, error) {
+	manifest := PackManifest{Name: tc.Name}
+
+	m, err := readThemeMetadata(fs, tc)
+	if err != nil {
+		return manifest, err
+	}
+	manifest.Version = cast.ToString(m["version"])
+
+	var filenames []string
+	err = afero.Walk(fs, tc.Dir, func(filename string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if filename != tc.Dir && strings.HasPrefix(fi.Name(), ".") {
+			if fi.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !fi.IsDir() {
+			filenames = append(filenames, filename)
+		}
+		return nil
+	})
+	if err != nil {
+		return manifest, errors.Wrapf(err, "failed to walk theme %q", tc.Name)
+	}
+
+	zw := zip.NewWriter(w)
+
+	for _, filename := range filenames {
+		rel, err := filepath.Rel(tc.Dir, filename)
+		if err != nil {
+			return manifest, err
+		}
+		rel = filepath.ToSlash(rel)
+		if rel == PackManifestFilename {
+			continue
+		}
+
+		pf, err := packFile(fs, zw, filename, path.Join(tc.Name, rel))
+		if err != nil {
+			return manifest, errors.Wrapf(err, "failed to pack %q", filename)
+		}
+		pf.Path = rel
+		manifest.Files = append(manifest.Files, pf)
+	}
+
+	sort.Slice(manifest.Files, func(i, j int) bool {
+		return manifest.Files[i].Path < manifest.Files[j].Path
+	})
+
+	mw, err := zw.Create(path.Join(tc.Name, PackManifestFilename))
+	if err != nil {
+		return manifest, err
+	}
+	enc := json.NewEncoder(mw)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(manifest); err != nil {
+		return manifest, err
+	}
+
+	return manifest, zw.Close()
+}
+
+func packFile(fs afero.Fs, zw *zip.Writer, filename, name string) (PackFile, error) {
+	f, err := fs.Open(filename)
+	if err != nil {
+		return PackFile{}, err
+	}
+	defer f.Close()
+
+	fw, err := zw.Create(name)
+	if err != nil {
+		return PackFile{}, err
+	}
+
+	h := sha256.New()
+	n, err := io.Copy(io.MultiWriter(fw, h), f)
+	if err != nil {
+		return PackFile{}, err
+	}
+
+	return PackFile{Size: n, SHA256: hex.EncodeToString(h.Sum(nil))}, nil
+}
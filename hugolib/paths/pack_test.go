@@ -0,0 +1,76 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package paths
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPackTheme(t *testing.T) {
+	assert := require.New(t)
+
+	fs := afero.NewMemMapFs()
+
+	writeFile := func(filename, content string) {
+		assert.NoError(afero.WriteFile(fs, filepath.FromSlash(filename), []byte(content), 0755))
+	}
+
+	writeFile("themes/a/theme.toml", `version = "1.0.0"`)
+	writeFile("themes/a/layouts/index.html", "home")
+	writeFile("themes/a/static/css/main.css", "body {}")
+	writeFile("themes/a/.git/HEAD", "ref")
+	writeFile("themes/a/.DS_Store", "")
+
+	var buf bytes.Buffer
+	manifest, err := PackTheme(fs, ThemeConfig{Name: "a", Dir: filepath.FromSlash("themes/a")}, &buf)
+	assert.NoError(err)
+	assert.Equal("a", manifest.Name)
+	assert.Equal("1.0.0", manifest.Version)
+	assert.Len(manifest.Files, 3)
+	assert.Equal("layouts/index.html", manifest.Files[0].Path)
+	assert.Equal(int64(4), manifest.Files[0].Size)
+	// echo -n home | sha256sum
+	assert.Equal("4ea140588150773ce3aace786aeef7f4049ce100fa649c94fbbddb960f1da942", manifest.Files[0].SHA256)
+	assert.Equal("static/css/main.css", manifest.Files[1].Path)
+	assert.Equal("theme.toml", manifest.Files[2].Path)
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	assert.NoError(err)
+
+	files := make(map[string]string)
+	for _, f := range zr.File {
+		r, err := f.Open()
+		assert.NoError(err)
+		b, err := ioutil.ReadAll(r)
+		assert.NoError(err)
+		r.Close()
+		files[f.Name] = string(b)
+	}
+
+	assert.Len(files, 4)
+	assert.Equal("home", files["a/layouts/index.html"])
+	assert.Equal("body {}", files["a/static/css/main.css"])
+
+	var packed PackManifest
+	assert.NoError(json.Unmarshal([]byte(files["a/"+PackManifestFilename]), &packed))
+	assert.Equal(manifest, packed)
+}
@@ -65,6 +65,9 @@ type Paths struct {
 	Language  *langs.Language
 	Languages langs.Languages
 
+	// The sub directory each language is published to, see GetLangSubDir.
+	langSubdirs map[string]string
+
 	// The PathSpec looks up its config settings in both the current language
 	// and then in the global Viper config.
 	// Some settings, the settings listed below, does not make sense to be set
@@ -141,8 +144,9 @@ func New(fs *hugofs.Fs, cfg config.Provider) (*Paths, error) {
 
 	var multihostTargetBasePaths []string
 	if languages.IsMultihost() {
+		targetPaths := languages.TargetPaths()
 		for _, l := range languages {
-			multihostTargetBasePaths = append(multihostTargetBasePaths, l.Lang)
+			multihostTargetBasePaths = append(multihostTargetBasePaths, targetPaths[l.Lang])
 		}
 	}
 
@@ -172,6 +176,7 @@ func New(fs *hugofs.Fs, cfg config.Provider) (*Paths, error) {
 		Language:                 language,
 		Languages:                languages,
 		MultihostTargetBasePaths: multihostTargetBasePaths,
+		langSubdirs:              languages.LangSubdirs(),
 
 		PaginatePath: cfg.GetString("paginatePath"),
 	}
@@ -216,6 +221,18 @@ func (p *Paths) Themes() []string {
 	return p.themes
 }
 
+// WorkspaceThemes returns the themes loaded from local directories listed in
+// a workspace file.
+func (p *Paths) WorkspaceThemes() []ThemeConfig {
+	var themes []ThemeConfig
+	for _, tc := range p.AllThemes {
+		if tc.InWorkspace {
+			themes = append(themes, tc)
+		}
+	}
+	return themes
+}
+
 func (p *Paths) GetTargetLanguageBasePath() string {
 	if p.Languages.IsMultihost() {
 		// In a multihost configuration all assets will be published below the language code.
@@ -248,19 +265,7 @@ func (p *Paths) GetLanguagePrefix() string {
 
 // GetLangSubDir returns the given language's subdir if needed.
 func (p *Paths) GetLangSubDir(lang string) string {
-	if !p.multilingual {
-		return ""
-	}
-
-	if p.Languages.IsMultihost() {
-		return ""
-	}
-
-	if lang == "" || (lang == p.DefaultContentLanguage && !p.defaultContentLanguageInSubdir) {
-		return ""
-	}
-
-	return lang
+	return p.langSubdirs[lang]
 }
 
 // AbsPathify creates an absolute path if given a relative path. If already
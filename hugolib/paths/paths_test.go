@@ -17,6 +17,7 @@ import (
 	"testing"
 
 	"github.com/gohugoio/hugo/hugofs"
+	"github.com/gohugoio/hugo/langs"
 	"github.com/spf13/viper"
 	"github.com/stretchr/testify/require"
 )
@@ -42,3 +43,24 @@ func TestNewPaths(t *testing.T) {
 	assert.Equal("no", p.DefaultContentLanguage)
 	assert.Equal(true, p.multilingual)
 }
+
+func TestGetLangSubDir(t *testing.T) {
+	assert := require.New(t)
+
+	v := viper.New()
+	fs := hugofs.NewMem(v)
+
+	v.Set("defaultContentLanguage", "no")
+	v.Set("contentDir", "content")
+	v.Set("workingDir", "work")
+	v.Set("resourceDir", "resources")
+	v.Set("publishDir", "public")
+	v.Set("languagesSorted", langs.NewLanguages(langs.NewLanguage("no", v), langs.NewLanguage("en", v)))
+
+	p, err := New(fs, v)
+	assert.NoError(err)
+
+	assert.Equal("", p.GetLangSubDir("no"))
+	assert.Equal("en", p.GetLangSubDir("en"))
+	assert.Equal("", p.GetLangSubDir("sv"))
+}
@@ -14,10 +14,15 @@
 package paths
 
 import (
+	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
+	"github.com/gohugoio/hugo/common/hugo"
 	"github.com/gohugoio/hugo/config"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
 	"github.com/spf13/afero"
 	"github.com/spf13/cast"
 )
@@ -26,19 +31,257 @@ type ThemeConfig struct {
 	// The theme name as provided by the folder name below /themes.
 	Name string
 
+	// The absolute directory of the theme. This is usually below /themes, but
+	// it may be a local directory listed in a workspace file.
+	Dir string
+
+	// Whether this theme is loaded from a local directory listed in a
+	// workspace file. These are usually edited alongside the project, so
+	// they should be treated as project files in server mode.
+	InWorkspace bool
+
 	// Optional configuration filename (e.g. "/themes/mytheme/config.json").
 	ConfigFilename string
 
+	// Optional environment specific configuration filename
+	// (e.g. "/themes/mytheme/config/development/config.toml"). This is merged
+	// on top of the config in ConfigFilename, which means that a theme can
+	// import theme components only for a given environment.
+	EnvironmentConfigFilename string
+
 	// Optional config read from the ConfigFile above.
 	Cfg config.Provider
+
+	// Optional Hugo version requirements, set in the theme's config in the
+	// "hugoVersion" section.
+	HugoVersion HugoVersion
+}
+
+// HugoVersion holds the Hugo binary version requirements for a theme.
+type HugoVersion struct {
+	// The minimum Hugo version that this theme works with.
+	Min hugo.VersionString
+
+	// The maximum Hugo version that this theme works with.
+	Max hugo.VersionString
+
+	// Set if the extended version of Hugo is needed.
+	Extended bool
+}
+
+// problems returns a list of the requirements in v not met by the running
+// Hugo binary.
+func (v HugoVersion) problems() []string {
+	var problems []string
+
+	if v.Extended && !hugo.IsExtended() {
+		problems = append(problems, "requires the extended version of Hugo")
+	}
+
+	if v.Min != "" && hugo.CompareVersion(v.Min.String()) > 0 {
+		problems = append(problems, "requires Hugo version "+v.Min.String()+" or newer")
+	}
+
+	if v.Max != "" && hugo.CompareVersion(v.Max.String()) < 0 {
+		problems = append(problems, "requires Hugo version "+v.Max.String()+" or older")
+	}
+
+	return problems
+}
+
+// themeComponentFolders are the folders in a theme that contribute files to
+// the build.
+var themeComponentFolders = []string{"archetypes", "assets", "data", "i18n", "layouts", "static"}
+
+// UnusedThemes returns the names of the themes found in themesDir that are
+// not imported, directly or indirectly, by the project, and the names of the
+// imported themes that contribute neither config nor any files to the build.
+// Both lists are sorted.
+func UnusedThemes(fs afero.Fs, themesDir string, themes []ThemeConfig) (unreferenced, empty []string, err error) {
+	used := make(map[string]bool)
+
+	for _, tc := range themes {
+		used[strings.ToLower(tc.Name)] = true
+
+		if tc.Cfg != nil {
+			continue
+		}
+
+		var hasFiles bool
+		for _, folder := range themeComponentFolders {
+			if exists, _ := afero.DirExists(fs, filepath.Join(tc.Dir, folder)); exists {
+				hasFiles = true
+				break
+			}
+		}
+		if !hasFiles {
+			empty = append(empty, tc.Name)
+		}
+	}
+
+	fis, err := afero.ReadDir(fs, themesDir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, nil, err
+	}
+
+	for _, fi := range fis {
+		if !fi.IsDir() || strings.HasPrefix(fi.Name(), ".") {
+			continue
+		}
+		if !used[strings.ToLower(fi.Name())] {
+			unreferenced = append(unreferenced, fi.Name())
+		}
+	}
+
+	sort.Strings(unreferenced)
+	sort.Strings(empty)
+
+	return unreferenced, empty, nil
 }
 
 // Create file system, an ordered theme list from left to right, no duplicates.
 type themesCollector struct {
-	themesDir string
-	fs        afero.Fs
-	seen      map[string]bool
-	themes    []ThemeConfig
+	themesDir   string
+	environment string
+	workspace   *Workspace
+	hooks       []CollectorHooks
+	fs          afero.Fs
+	seen        map[string]bool
+	themes      []ThemeConfig
+}
+
+// CollectorOption configures the theme collector used in CollectThemes.
+type CollectorOption func(c *themesCollector) error
+
+// WithWorkspace tells the theme collector to load the themes listed in
+// the given workspace from their local directories.
+func WithWorkspace(w *Workspace) CollectorOption {
+	return func(c *themesCollector) error {
+		c.workspace = w
+		return nil
+	}
+}
+
+// CollectorHooks holds callbacks invoked at the different stages of the theme
+// collection. Any of them may be nil. A non-nil error returned from a
+// callback stops the collection, which can be used to enforce a policy.
+type CollectorHooks struct {
+	// OnResolved is called when the directory of a theme is resolved, before
+	// its config is loaded. Only Name, Dir and InWorkspace are set.
+	OnResolved func(tc ThemeConfig) error
+
+	// OnConfigLoaded is called when the config of a theme is loaded.
+	OnConfigLoaded func(tc ThemeConfig) error
+
+	// OnCollected is called with all themes, in order, when the collection
+	// is done and all requirements are verified.
+	OnCollected func(themes []ThemeConfig) error
+}
+
+// WithHooks registers callbacks invoked during the theme collection.
+// It can be used multiple times; the hooks are invoked in the order
+// they were registered.
+func WithHooks(hooks CollectorHooks) CollectorOption {
+	return func(c *themesCollector) error {
+		c.hooks = append(c.hooks, hooks)
+		return nil
+	}
+}
+
+func (c *themesCollector) onResolved(tc ThemeConfig) error {
+	for _, h := range c.hooks {
+		if h.OnResolved != nil {
+			if err := h.OnResolved(tc); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (c *themesCollector) onConfigLoaded(tc ThemeConfig) error {
+	for _, h := range c.hooks {
+		if h.OnConfigLoaded != nil {
+			if err := h.OnConfigLoaded(tc); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (c *themesCollector) onCollected() error {
+	for _, h := range c.hooks {
+		if h.OnCollected != nil {
+			if err := h.OnCollected(c.themes); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// themeDir returns the absolute directory of the given theme.
+func (c *themesCollector) themeDir(theme string) string {
+	if dir, found := c.workspace.Dir(theme); found {
+		return dir
+	}
+	return filepath.Join(c.themesDir, theme)
+}
+
+// unpackIfArchived extracts the theme archive, e.g. "/themes/mytheme.zip" or
+// "/themes/mytheme.tar.gz", into "/themes/mytheme" if the theme directory does
+// not exist, or if it was extracted from an archive with another checksum.
+// The archive is verified against its manifest, see UnpackTheme.
+func (c *themesCollector) unpackIfArchived(theme string) error {
+	if _, found := c.workspace.Dir(theme); found {
+		return nil
+	}
+
+	dir := c.themeDir(theme)
+
+	var archiveFilename string
+	for _, ext := range themeArchiveExtensions {
+		if exists, _ := afero.Exists(c.fs, dir+ext); exists {
+			archiveFilename = dir + ext
+			break
+		}
+	}
+	if archiveFilename == "" {
+		return nil
+	}
+
+	if exists, _ := afero.Exists(c.fs, dir); exists {
+		unpacked, found, err := readUnpackedManifest(c.fs, dir)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read manifest for theme %q", theme)
+		}
+		if !found {
+			// Not extracted from an archive, leave it alone.
+			return nil
+		}
+
+		sum, err := fileSHA256(c.fs, archiveFilename)
+		if err != nil {
+			return err
+		}
+		if sum == unpacked.ArchiveSHA256 {
+			return nil
+		}
+
+		// The archive has changed, replace the stale theme.
+		if err := c.fs.RemoveAll(dir); err != nil {
+			return err
+		}
+	}
+
+	if _, err := UnpackTheme(c.fs, archiveFilename, dir); err != nil {
+		// Do not leave a partially extracted theme behind.
+		c.fs.RemoveAll(dir)
+		return errors.Wrapf(err, "failed to unpack theme %q", theme)
+	}
+
+	return nil
 }
 
 func (c *themesCollector) isSeen(theme string) bool {
@@ -53,6 +296,9 @@ func (c *themesCollector) isSeen(theme string) bool {
 func (c *themesCollector) addAndRecurse(themes ...string) error {
 	for i := 0; i < len(themes); i++ {
 		theme := themes[i]
+		if err := c.unpackIfArchived(theme); err != nil {
+			return err
+		}
 		configFilename := c.getConfigFileIfProvided(theme)
 		if !c.isSeen(theme) {
 			tc, err := c.add(theme, configFilename)
@@ -71,34 +317,86 @@ func (c *themesCollector) add(name, configFilename string) (ThemeConfig, error)
 	var cfg config.Provider
 	var tc ThemeConfig
 
-	if configFilename != "" {
+	_, inWorkspace := c.workspace.Dir(name)
+
+	tc = ThemeConfig{Name: name, Dir: c.themeDir(name), InWorkspace: inWorkspace}
+	if err := c.onResolved(tc); err != nil {
+		return tc, err
+	}
+
+	envConfigFilename := c.getEnvironmentConfigFileIfProvided(name)
+
+	var configFilenames []string
+	for _, filename := range []string{configFilename, envConfigFilename} {
+		if filename != "" {
+			configFilenames = append(configFilenames, filename)
+		}
+	}
+
+	if len(configFilenames) > 0 {
 		var err error
-		cfg, err = config.FromFile(c.fs, configFilename)
+		cfg, err = config.FromFiles(c.fs, configFilenames...)
 		if err != nil {
 			return tc, err
 		}
 	}
 
-	tc = ThemeConfig{Name: name, ConfigFilename: configFilename, Cfg: cfg}
+	tc.ConfigFilename = configFilename
+	tc.EnvironmentConfigFilename = envConfigFilename
+	tc.Cfg = cfg
+
+	if cfg != nil && cfg.IsSet("hugoVersion") {
+		if err := mapstructure.WeakDecode(cfg.GetStringMap("hugoVersion"), &tc.HugoVersion); err != nil {
+			return tc, errors.Wrapf(err, "failed to decode hugoVersion for theme %q", name)
+		}
+	}
+
+	if err := c.onConfigLoaded(tc); err != nil {
+		return tc, err
+	}
+
 	c.themes = append(c.themes, tc)
 	return tc, nil
 
 }
 
 func collectThemeNames(p *Paths) ([]ThemeConfig, error) {
-	return CollectThemes(p.Fs.Source, p.AbsPathify(p.ThemesDir), p.Themes())
+	var options []CollectorOption
+
+	if workspaceFilename := p.Cfg.GetString("workspace"); workspaceFilename != "" {
+		w, err := LoadWorkspace(p.Fs.Source, p.AbsPathify(workspaceFilename))
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, WithWorkspace(w))
+	}
+
+	return CollectThemes(p.Fs.Source, p.AbsPathify(p.ThemesDir), config.GetEnvironment(p.Cfg), p.Themes(), options...)
 
 }
 
-func CollectThemes(fs afero.Fs, themesDir string, themes []string) ([]ThemeConfig, error) {
+// CollectThemes collects the given themes and the theme components they
+// import, ordered from left to right with no duplicates.
+// If environment is set, any theme config in
+// "/themes/mytheme/config/<environment>" will be applied on top of the theme's
+// main config, which allows theme components to be imported for a given
+// environment only.
+func CollectThemes(fs afero.Fs, themesDir, environment string, themes []string, options ...CollectorOption) ([]ThemeConfig, error) {
 	if len(themes) == 0 {
 		return nil, nil
 	}
 
 	c := &themesCollector{
-		fs:        fs,
-		themesDir: themesDir,
-		seen:      make(map[string]bool)}
+		fs:          fs,
+		themesDir:   themesDir,
+		environment: environment,
+		seen:        make(map[string]bool)}
+
+	for _, opt := range options {
+		if err := opt(c); err != nil {
+			return nil, err
+		}
+	}
 
 	for i := 0; i < len(themes); i++ {
 		theme := themes[i]
@@ -107,12 +405,38 @@ func CollectThemes(fs afero.Fs, themesDir string, themes []string) ([]ThemeConfi
 		}
 	}
 
+	if err := c.verifyRequirements(); err != nil {
+		return nil, err
+	}
+
+	if err := c.onCollected(); err != nil {
+		return nil, err
+	}
+
 	return c.themes, nil
 
 }
 
+// verifyRequirements checks the requirements of all the collected themes and
+// reports every problem found in one error.
+func (c *themesCollector) verifyRequirements() error {
+	var problems []string
+
+	for _, tc := range c.themes {
+		for _, problem := range tc.HugoVersion.problems() {
+			problems = append(problems, "theme \""+tc.Name+"\" "+problem)
+		}
+	}
+
+	if len(problems) > 0 {
+		return errors.Errorf("theme requirements not met:\n%s", strings.Join(problems, "\n"))
+	}
+
+	return nil
+}
+
 func (c *themesCollector) getConfigFileIfProvided(theme string) string {
-	configDir := filepath.Join(c.themesDir, theme)
+	configDir := c.themeDir(theme)
 
 	var (
 		configFilename string
@@ -137,6 +461,23 @@ func (c *themesCollector) getConfigFileIfProvided(theme string) string {
 
 }
 
+func (c *themesCollector) getEnvironmentConfigFileIfProvided(theme string) string {
+	if c.environment == "" {
+		return ""
+	}
+
+	configDir := filepath.Join(c.themeDir(theme), "config", c.environment)
+
+	for _, configFormats := range config.ValidConfigFileExtensions {
+		configFilename := filepath.Join(configDir, "config."+configFormats)
+		if exists, _ := afero.Exists(c.fs, configFilename); exists {
+			return configFilename
+		}
+	}
+
+	return ""
+}
+
 func (c *themesCollector) addThemeNamesFromTheme(theme ThemeConfig) error {
 	if theme.Cfg != nil && theme.Cfg.IsSet("theme") {
 		v := theme.Cfg.Get("theme")
@@ -0,0 +1,138 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package paths
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollectThemesRequirements(t *testing.T) {
+	assert := require.New(t)
+
+	fs := afero.NewMemMapFs()
+
+	writeFile := func(filename, content string) {
+		assert.NoError(afero.WriteFile(fs, filepath.FromSlash(filename), []byte(content), 0755))
+	}
+
+	writeFile("themes/a/config.toml", `
+theme = ["b", "c"]
+[hugoVersion]
+min = "0.20"
+`)
+	writeFile("themes/b/config.toml", `
+[hugoVersion]
+min = "1000.0"
+`)
+	writeFile("themes/c/config.toml", `
+[hugoVersion]
+max = "0.20"
+`)
+
+	_, err := CollectThemes(fs, "themes", "", []string{"a"})
+	assert.Error(err)
+	assert.Contains(err.Error(), `theme "b" requires Hugo version 1000.0 or newer`)
+	assert.Contains(err.Error(), `theme "c" requires Hugo version 0.20 or older`)
+	assert.NotContains(err.Error(), `theme "a"`)
+
+	themes, err := CollectThemes(fs, "themes", "", []string{"c"})
+	assert.Error(err)
+	assert.Nil(themes)
+
+	writeFile("themes/c/config.toml", `
+[hugoVersion]
+min = 0.20
+`)
+
+	themes, err = CollectThemes(fs, "themes", "", []string{"c"})
+	assert.NoError(err)
+	assert.Len(themes, 1)
+	assert.Equal("0.2", themes[0].HugoVersion.Min.String())
+}
+
+func TestUnusedThemes(t *testing.T) {
+	assert := require.New(t)
+
+	fs := afero.NewMemMapFs()
+
+	writeFile := func(filename, content string) {
+		assert.NoError(afero.WriteFile(fs, filepath.FromSlash(filename), []byte(content), 0755))
+	}
+
+	writeFile("themes/a/config.toml", `theme = ["b", "c"]`)
+	writeFile("themes/b/layouts/index.html", "b")
+	writeFile("themes/c/README.md", "c")
+	writeFile("themes/d/layouts/index.html", "d")
+	writeFile("themes/.git/HEAD", "git")
+
+	themes, err := CollectThemes(fs, "themes", "", []string{"a"})
+	assert.NoError(err)
+
+	unreferenced, empty, err := UnusedThemes(fs, "themes", themes)
+	assert.NoError(err)
+	assert.Equal([]string{"d"}, unreferenced)
+	assert.Equal([]string{"c"}, empty)
+
+	unreferenced, _, err = UnusedThemes(fs, "nothemes", nil)
+	assert.NoError(err)
+	assert.Empty(unreferenced)
+}
+
+func TestCollectThemesHooks(t *testing.T) {
+	assert := require.New(t)
+
+	fs := afero.NewMemMapFs()
+
+	assert.NoError(afero.WriteFile(fs, filepath.FromSlash("themes/a/config.toml"), []byte(`theme = "b"`), 0755))
+	assert.NoError(afero.WriteFile(fs, filepath.FromSlash("themes/b/layouts/index.html"), []byte("b"), 0755))
+
+	var events []string
+
+	hooks := CollectorHooks{
+		OnResolved: func(tc ThemeConfig) error {
+			events = append(events, "resolved:"+tc.Name)
+			return nil
+		},
+		OnConfigLoaded: func(tc ThemeConfig) error {
+			events = append(events, fmt.Sprintf("config:%s:%t", tc.Name, tc.Cfg != nil))
+			return nil
+		},
+		OnCollected: func(themes []ThemeConfig) error {
+			events = append(events, "collected:"+themes[0].Name+","+themes[1].Name)
+			return nil
+		},
+	}
+
+	_, err := CollectThemes(fs, "themes", "", []string{"a"}, WithHooks(hooks))
+	assert.NoError(err)
+	assert.Equal([]string{"resolved:a", "config:a:true", "resolved:b", "config:b:false", "collected:a,b"}, events)
+
+	deny := CollectorHooks{
+		OnResolved: func(tc ThemeConfig) error {
+			if tc.Name == "b" {
+				return errors.New("theme b is not allowed")
+			}
+			return nil
+		},
+	}
+
+	_, err = CollectThemes(fs, "themes", "", []string{"a"}, WithHooks(hooks), WithHooks(deny))
+	assert.EqualError(err, "theme b is not allowed")
+}
@@ -0,0 +1,331 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package paths
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/afero"
+)
+
+// themeArchiveExtensions are the supported theme archive formats.
+var themeArchiveExtensions = []string{".zip", ".tar.gz", ".tgz"}
+
+// isThemeArchive reports whether filename is a supported theme archive.
+func isThemeArchive(filename string) bool {
+	for _, ext := range themeArchiveExtensions {
+		if strings.HasSuffix(filename, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// UnpackTheme extracts the theme archive archiveFilename into dir. The
+// archive is a zip archive as created by PackTheme, or a tar.gz archive with
+// the same layout. Every extracted file is verified against the checksums in
+// the archive's manifest, and UnpackTheme fails if the manifest is missing,
+// or if any file is missing, modified or not listed.
+//
+// The manifest, with the checksum of the archive added, is written to dir, so
+// the theme collector can detect a changed archive and extract it again.
+func UnpackTheme(fs afero.Fs, archiveFilename, dir string) (PackManifest, error) {
+	var manifest PackManifest
+
+	if !isThemeArchive(archiveFilename) {
+		return manifest, errors.Errorf("unsupported theme archive %q", archiveFilename)
+	}
+
+	f, err := fs.Open(archiveFilename)
+	if err != nil {
+		return manifest, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	size, err := io.Copy(h, f)
+	if err != nil {
+		return manifest, err
+	}
+	archiveSum := hex.EncodeToString(h.Sum(nil))
+	if _, err := f.Seek(0, 0); err != nil {
+		return manifest, err
+	}
+
+	var entries []archiveFile
+	if strings.HasSuffix(archiveFilename, ".zip") {
+		entries, err = readZipArchive(f, size)
+	} else {
+		var tempDir string
+		tempDir, err = afero.TempDir(fs, "", "hugo_theme")
+		if err != nil {
+			return manifest, err
+		}
+		defer fs.RemoveAll(tempDir)
+		entries, err = readTarGzArchive(fs, f, tempDir)
+	}
+	if err != nil {
+		return manifest, errors.Wrapf(err, "failed to read theme archive %q", archiveFilename)
+	}
+
+	// The files are stored below a top level directory named after the theme.
+	files := make(map[string]archiveFile)
+	for _, af := range entries {
+		parts := strings.SplitN(af.name, "/", 2)
+		if len(parts) != 2 || !isSafeArchivePath(parts[1]) {
+			return manifest, errors.Errorf("theme archive %q: invalid path %q", archiveFilename, af.name)
+		}
+		files[parts[1]] = af
+	}
+
+	af, found := files[PackManifestFilename]
+	if !found {
+		return manifest, errors.Errorf("theme archive %q: no %s manifest found", archiveFilename, PackManifestFilename)
+	}
+	if err := readPackManifest(af, &manifest); err != nil {
+		return manifest, errors.Wrapf(err, "theme archive %q: failed to read manifest", archiveFilename)
+	}
+	delete(files, PackManifestFilename)
+
+	expected := make(map[string]PackFile)
+	for _, pf := range manifest.Files {
+		expected[pf.Path] = pf
+	}
+	for name := range expected {
+		if _, found := files[name]; !found {
+			return manifest, errors.Errorf("theme archive %q: %q listed in manifest but not found", archiveFilename, name)
+		}
+	}
+
+	for name, af := range files {
+		pf, found := expected[name]
+		if !found {
+			return manifest, errors.Errorf("theme archive %q: %q not listed in manifest", archiveFilename, name)
+		}
+		if err := unpackFile(fs, af, filepath.Join(dir, filepath.FromSlash(name)), pf); err != nil {
+			return manifest, errors.Wrapf(err, "theme archive %q", archiveFilename)
+		}
+	}
+
+	manifest.ArchiveSHA256 = archiveSum
+
+	b, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return manifest, err
+	}
+	if err := fs.MkdirAll(dir, 0777); err != nil {
+		return manifest, err
+	}
+	if err := afero.WriteFile(fs, filepath.Join(dir, PackManifestFilename), b, 0666); err != nil {
+		return manifest, err
+	}
+
+	return manifest, nil
+}
+
+// readUnpackedManifest reads the manifest written by UnpackTheme to the
+// theme in dir. It returns false if dir is not an extracted theme.
+func readUnpackedManifest(fs afero.Fs, dir string) (PackManifest, bool, error) {
+	var manifest PackManifest
+
+	b, err := afero.ReadFile(fs, filepath.Join(dir, PackManifestFilename))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return manifest, false, nil
+		}
+		return manifest, false, err
+	}
+
+	if err := json.Unmarshal(b, &manifest); err != nil {
+		return manifest, false, err
+	}
+
+	return manifest, manifest.ArchiveSHA256 != "", nil
+}
+
+// fileSHA256 returns the hex encoded SHA-256 checksum of the given file.
+func fileSHA256(fs afero.Fs, filename string) (string, error) {
+	f, err := fs.Open(filename)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// archiveFile is a regular file in a theme archive.
+type archiveFile struct {
+	// The slash separated path in the archive.
+	name string
+	open func() (io.ReadCloser, error)
+}
+
+func readZipArchive(r io.ReaderAt, size int64) ([]archiveFile, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []archiveFile
+	for _, zf := range zr.File {
+		if strings.HasSuffix(zf.Name, "/") {
+			continue
+		}
+		if !zf.Mode().IsRegular() {
+			return nil, errors.Errorf("%q is not a regular file", zf.Name)
+		}
+		files = append(files, archiveFile{name: zf.Name, open: zf.Open})
+	}
+
+	return files, nil
+}
+
+// maxThemeArchiveSize is the max total size of the files in a tar.gz theme
+// archive, to stop a decompression bomb from filling up the disk.
+var maxThemeArchiveSize int64 = 1 << 30
+
+// readTarGzArchive extracts the files in the gzipped tar archive in r to
+// tempDir, as a tar archive can only be read once.
+func readTarGzArchive(fs afero.Fs, r io.Reader, tempDir string) ([]archiveFile, error) {
+	gr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer gr.Close()
+
+	var (
+		files []archiveFile
+		total int64
+	)
+	tr := tar.NewReader(gr)
+	for i := 0; ; i++ {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			continue
+		case tar.TypeReg:
+		default:
+			return nil, errors.Errorf("%q is not a regular file", hdr.Name)
+		}
+
+		total += hdr.Size
+		if hdr.Size < 0 || total > maxThemeArchiveSize {
+			return nil, errors.Errorf("archive too large: more than %d bytes", maxThemeArchiveSize)
+		}
+
+		// The archive names are checked later, so do not use them on disk.
+		filename := filepath.Join(tempDir, strconv.Itoa(i))
+		if err := extractTarFile(fs, tr, filename, hdr.Size); err != nil {
+			return nil, err
+		}
+
+		files = append(files, archiveFile{
+			name: strings.TrimPrefix(hdr.Name, "./"),
+			open: func() (io.ReadCloser, error) {
+				return fs.Open(filename)
+			},
+		})
+	}
+
+	return files, nil
+}
+
+func extractTarFile(fs afero.Fs, r io.Reader, filename string, size int64) error {
+	f, err := fs.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := io.CopyN(f, r, size); err != nil {
+		return err
+	}
+
+	return f.Close()
+}
+
+func isSafeArchivePath(name string) bool {
+	if name == "" || path.IsAbs(name) || strings.Contains(name, "\\") {
+		return false
+	}
+	for _, part := range strings.Split(name, "/") {
+		if part == ".." {
+			return false
+		}
+	}
+	return true
+}
+
+func readPackManifest(af archiveFile, manifest *PackManifest) error {
+	r, err := af.open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	return json.NewDecoder(r).Decode(manifest)
+}
+
+func unpackFile(fs afero.Fs, af archiveFile, filename string, pf PackFile) error {
+	r, err := af.open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	if err := fs.MkdirAll(filepath.Dir(filename), 0777); err != nil {
+		return err
+	}
+
+	f, err := fs.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	n, err := io.Copy(io.MultiWriter(f, h), r)
+	if err != nil {
+		return err
+	}
+
+	if n != pf.Size || hex.EncodeToString(h.Sum(nil)) != pf.SHA256 {
+		return errors.Errorf("checksum mismatch for %q", pf.Path)
+	}
+
+	return nil
+}
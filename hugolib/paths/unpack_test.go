@@ -0,0 +1,251 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package paths
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnpackTheme(t *testing.T) {
+	assert := require.New(t)
+
+	fs := afero.NewMemMapFs()
+
+	writeFile := func(filename, content string) {
+		assert.NoError(afero.WriteFile(fs, filepath.FromSlash(filename), []byte(content), 0755))
+	}
+
+	writeFile("src/a/theme.toml", `version = "1.0.0"`)
+	writeFile("src/a/layouts/index.html", "home")
+
+	var buf bytes.Buffer
+	packed, err := PackTheme(fs, ThemeConfig{Name: "a", Dir: filepath.FromSlash("src/a")}, &buf)
+	assert.NoError(err)
+	writeFile("a.zip", buf.String())
+
+	manifest, err := UnpackTheme(fs, "a.zip", "out")
+	assert.NoError(err)
+	sum := sha256.Sum256(buf.Bytes())
+	assert.Equal(hex.EncodeToString(sum[:]), manifest.ArchiveSHA256)
+	manifest.ArchiveSHA256 = ""
+	assert.Equal(packed, manifest)
+
+	b, err := afero.ReadFile(fs, filepath.FromSlash("out/layouts/index.html"))
+	assert.NoError(err)
+	assert.Equal("home", string(b))
+
+	// The manifest is kept with the archive checksum.
+	unpacked, found, err := readUnpackedManifest(fs, "out")
+	assert.NoError(err)
+	assert.True(found)
+	assert.Equal(hex.EncodeToString(sum[:]), unpacked.ArchiveSHA256)
+	assert.Equal(packed.Files, unpacked.Files)
+
+	manifestJSON, err := json.Marshal(packed)
+	assert.NoError(err)
+
+	for _, test := range []struct {
+		name   string
+		files  map[string]string
+		expect string
+	}{
+		{"modified", map[string]string{
+			"a/" + PackManifestFilename: string(manifestJSON),
+			"a/theme.toml":              `version = "1.0.0"`,
+			"a/layouts/index.html":      "evil",
+		}, "checksum mismatch"},
+		{"added", map[string]string{
+			"a/" + PackManifestFilename: string(manifestJSON),
+			"a/theme.toml":              `version = "1.0.0"`,
+			"a/layouts/index.html":      "home",
+			"a/layouts/extra.html":      "extra",
+		}, "not listed in manifest"},
+		{"missing", map[string]string{
+			"a/" + PackManifestFilename: string(manifestJSON),
+			"a/theme.toml":              `version = "1.0.0"`,
+		}, "not found"},
+		{"unsafe", map[string]string{
+			"a/../../etc/passwd": "root",
+		}, "invalid path"},
+		{"plain", map[string]string{
+			"a/layouts/index.html": "plain",
+		}, "no hugo-pack.json manifest found"},
+	} {
+		writeFile(test.name+".zip", zipArchive(t, test.files))
+		_, err = UnpackTheme(fs, test.name+".zip", test.name)
+		assert.Error(err, test.name)
+		assert.Contains(err.Error(), test.expect, test.name)
+	}
+
+	writeFile("b.tar.gz", themeArchive(t, ".tar.gz", "b", map[string]string{
+		"layouts/index.html": "tar",
+	}))
+	_, err = UnpackTheme(fs, "b.tar.gz", "b")
+	assert.NoError(err)
+	b, err = afero.ReadFile(fs, filepath.FromSlash("b/layouts/index.html"))
+	assert.NoError(err)
+	assert.Equal("tar", string(b))
+
+	writeFile("modified.tar.gz", tarGzArchive(t, map[string]string{
+		"a/" + PackManifestFilename: string(manifestJSON),
+		"a/theme.toml":              `version = "1.0.0"`,
+		"a/layouts/index.html":      "evil",
+	}))
+	_, err = UnpackTheme(fs, "modified.tar.gz", "modified-tar")
+	assert.Error(err)
+	assert.Contains(err.Error(), "checksum mismatch")
+
+	// The tar entries are extracted to a temporary dir, up to a max size.
+	defer func(size int64) { maxThemeArchiveSize = size }(maxThemeArchiveSize)
+	maxThemeArchiveSize = 10
+	writeFile("large.tar.gz", themeArchive(t, ".tar.gz", "large", map[string]string{
+		"layouts/index.html": "more than ten bytes",
+	}))
+	_, err = UnpackTheme(fs, "large.tar.gz", "large")
+	assert.Error(err)
+	assert.Contains(err.Error(), "archive too large")
+	_, err = fs.Stat(filepath.FromSlash("large/layouts/index.html"))
+	assert.Error(err)
+}
+
+func TestCollectThemesFromArchive(t *testing.T) {
+	assert := require.New(t)
+
+	fs := afero.NewMemMapFs()
+
+	writeFile := func(filename, content string) {
+		assert.NoError(afero.WriteFile(fs, filepath.FromSlash(filename), []byte(content), 0755))
+	}
+
+	readFile := func(filename string) string {
+		b, err := afero.ReadFile(fs, filepath.FromSlash(filename))
+		assert.NoError(err)
+		return string(b)
+	}
+
+	writeFile("themes/a.zip", themeArchive(t, ".zip", "a", map[string]string{
+		"config.toml":        `theme = "b"`,
+		"layouts/index.html": "home",
+		"layouts/old.html":   "old",
+	}))
+	writeFile("themes/b/config.toml", `theme = "d"`)
+	writeFile("themes/d.tar.gz", themeArchive(t, ".tar.gz", "d", map[string]string{
+		"layouts/index.html": "tar",
+	}))
+
+	themes, err := CollectThemes(fs, "themes", "", []string{"a"})
+	assert.NoError(err)
+	assert.Len(themes, 3)
+	assert.Equal("a", themes[0].Name)
+	assert.Equal(filepath.FromSlash("themes/a/config.toml"), themes[0].ConfigFilename)
+	assert.Equal("b", themes[1].Name)
+	assert.Equal("d", themes[2].Name)
+
+	assert.Equal("home", readFile("themes/a/layouts/index.html"))
+	assert.Equal("tar", readFile("themes/d/layouts/index.html"))
+
+	// Not extracted again while the archive is unchanged.
+	writeFile("themes/a/layouts/index.html", "local")
+	_, err = CollectThemes(fs, "themes", "", []string{"a"})
+	assert.NoError(err)
+	assert.Equal("local", readFile("themes/a/layouts/index.html"))
+
+	// An updated archive replaces the stale theme.
+	writeFile("themes/a.zip", themeArchive(t, ".zip", "a", map[string]string{
+		"config.toml":        `theme = "b"`,
+		"layouts/index.html": "home v2",
+	}))
+	_, err = CollectThemes(fs, "themes", "", []string{"a"})
+	assert.NoError(err)
+	assert.Equal("home v2", readFile("themes/a/layouts/index.html"))
+	exists, _ := afero.Exists(fs, filepath.FromSlash("themes/a/layouts/old.html"))
+	assert.False(exists)
+
+	// A theme dir not extracted from the archive is left alone.
+	writeFile("themes/e/layouts/index.html", "e")
+	writeFile("themes/e.zip", themeArchive(t, ".zip", "e", map[string]string{
+		"layouts/index.html": "archived",
+	}))
+	_, err = CollectThemes(fs, "themes", "", []string{"e"})
+	assert.NoError(err)
+	assert.Equal("e", readFile("themes/e/layouts/index.html"))
+
+	writeFile("themes/c.zip", zipArchive(t, map[string]string{
+		"c/../x": "",
+	}))
+	_, err = CollectThemes(fs, "themes", "", []string{"c"})
+	assert.Error(err)
+	assert.Contains(err.Error(), `failed to unpack theme "c"`)
+	exists, _ = afero.Exists(fs, filepath.FromSlash("themes/c"))
+	assert.False(exists)
+}
+
+func zipArchive(t *testing.T, files map[string]string) string {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return buf.String()
+}
+
+func tarGzArchive(t *testing.T, files map[string]string) string {
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	for name, content := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}))
+		_, err := tw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gw.Close())
+	return buf.String()
+}
+
+// themeArchive creates a theme archive with the given extension holding the
+// files below the directory name, and a manifest listing them.
+func themeArchive(t *testing.T, ext, name string, files map[string]string) string {
+	manifest := PackManifest{Name: name}
+	archived := make(map[string]string)
+	for filename, content := range files {
+		sum := sha256.Sum256([]byte(content))
+		manifest.Files = append(manifest.Files, PackFile{Path: filename, Size: int64(len(content)), SHA256: hex.EncodeToString(sum[:])})
+		archived[name+"/"+filename] = content
+	}
+
+	b, err := json.Marshal(manifest)
+	require.NoError(t, err)
+	archived[name+"/"+PackManifestFilename] = string(b)
+
+	if ext == ".zip" {
+		return zipArchive(t, archived)
+	}
+	return tarGzArchive(t, archived)
+}
@@ -0,0 +1,159 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package paths
+
+import (
+	"bufio"
+	"bytes"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/afero"
+)
+
+// Workspace represents a workspace file (e.g. "hugo.work") listing local theme
+// directories. Any theme found in a workspace will be loaded from its local
+// directory instead of from the themes dir, for all the projects using the
+// same workspace file.
+//
+// The format is similar to Go's go.work files:
+//
+//	// Comments are allowed.
+//	use ../mytheme
+//	use (
+//	    ../components/seo
+//	    ../components/gallery
+//	)
+//
+// The theme name is the base name of the directory. Relative directories are
+// resolved relative to the directory holding the workspace file.
+type Workspace struct {
+	// The absolute filename of the workspace file.
+	Filename string
+
+	// Maps the lower case theme name to its absolute directory.
+	dirs map[string]string
+}
+
+// LoadWorkspace loads the workspace file with the given absolute filename.
+func LoadWorkspace(fs afero.Fs, filename string) (*Workspace, error) {
+	b, err := afero.ReadFile(fs, filename)
+	if err != nil {
+		return nil, err
+	}
+
+	dirs, err := parseWorkspace(b)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse workspace file %q", filename)
+	}
+
+	w := &Workspace{Filename: filename, dirs: make(map[string]string)}
+	baseDir := filepath.Dir(filename)
+
+	for _, dir := range dirs {
+		dir = AbsPathify(baseDir, filepath.FromSlash(dir))
+		name := strings.ToLower(filepath.Base(dir))
+		if existing, found := w.dirs[name]; found {
+			return nil, errors.Errorf("workspace file %q: theme %q used in both %q and %q", filename, name, existing, dir)
+		}
+		w.dirs[name] = dir
+	}
+
+	return w, nil
+}
+
+// Dir returns the local directory of the given theme and whether it was
+// found in this workspace.
+func (w *Workspace) Dir(theme string) (string, bool) {
+	if w == nil {
+		return "", false
+	}
+	dir, found := w.dirs[strings.ToLower(theme)]
+	return dir, found
+}
+
+// Dirs returns all the local directories in this workspace, sorted.
+func (w *Workspace) Dirs() []string {
+	if w == nil {
+		return nil
+	}
+	var dirs []string
+	for _, dir := range w.dirs {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+	return dirs
+}
+
+func parseWorkspace(b []byte) ([]string, error) {
+	var (
+		dirs    []string
+		inBlock bool
+		lineNum int
+	)
+
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+
+	for scanner.Scan() {
+		lineNum++
+		line := scanner.Text()
+		if i := strings.Index(line, "//"); i != -1 {
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+
+		if line == "" {
+			continue
+		}
+
+		if inBlock {
+			if line == ")" {
+				inBlock = false
+				continue
+			}
+			dirs = append(dirs, unquoteWorkspaceDir(line))
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if fields[0] != "use" {
+			return nil, errors.Errorf("line %d: unknown directive %q", lineNum, fields[0])
+		}
+
+		switch {
+		case len(fields) == 2 && fields[1] == "(":
+			inBlock = true
+		case len(fields) == 2:
+			dirs = append(dirs, unquoteWorkspaceDir(fields[1]))
+		default:
+			return nil, errors.Errorf("line %d: usage: use path/to/theme", lineNum)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if inBlock {
+		return nil, errors.New("unterminated use block")
+	}
+
+	return dirs, nil
+}
+
+func unquoteWorkspaceDir(s string) string {
+	return strings.Trim(s, `"`)
+}
@@ -0,0 +1,76 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package paths
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseWorkspace(t *testing.T) {
+	assert := require.New(t)
+
+	dirs, err := parseWorkspace([]byte(`
+// My workspace.
+use ../mytheme
+use (
+	../components/seo // SEO
+	"../components/gallery"
+)
+`))
+	assert.NoError(err)
+	assert.Equal([]string{"../mytheme", "../components/seo", "../components/gallery"}, dirs)
+
+	_, err = parseWorkspace([]byte(`replace ../mytheme`))
+	assert.Error(err)
+
+	_, err = parseWorkspace([]byte(`use (
+	../mytheme
+`))
+	assert.Error(err)
+}
+
+func TestCollectThemesWorkspace(t *testing.T) {
+	assert := require.New(t)
+
+	fs := afero.NewMemMapFs()
+
+	writeFile := func(filename, content string) {
+		assert.NoError(afero.WriteFile(fs, filepath.FromSlash(filename), []byte(content), 0755))
+	}
+
+	writeFile("/work/hugo.work", `
+use ./mytheme
+use ./components/seo
+`)
+	writeFile("/work/mytheme/config.toml", `theme = ["seo", "gallery"]`)
+	writeFile("/work/site/themes/mytheme/config.toml", `theme = "gallery"`)
+
+	w, err := LoadWorkspace(fs, filepath.FromSlash("/work/hugo.work"))
+	assert.NoError(err)
+	assert.Equal([]string{filepath.FromSlash("/work/components/seo"), filepath.FromSlash("/work/mytheme")}, w.Dirs())
+
+	themes, err := CollectThemes(fs, filepath.FromSlash("/work/site/themes"), "", []string{"mytheme"}, WithWorkspace(w))
+	assert.NoError(err)
+	assert.Len(themes, 3)
+
+	assert.Equal("mytheme", themes[0].Name)
+	assert.Equal(filepath.FromSlash("/work/mytheme"), themes[0].Dir)
+	assert.Equal(filepath.FromSlash("/work/mytheme/config.toml"), themes[0].ConfigFilename)
+	assert.Equal(filepath.FromSlash("/work/components/seo"), themes[1].Dir)
+	assert.Equal(filepath.FromSlash("/work/site/themes/gallery"), themes[2].Dir)
+}
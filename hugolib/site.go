@@ -920,7 +920,7 @@ func (s *Site) translateFileEvents(events []fsnotify.Event) []fsnotify.Event {
 // reBuild partially rebuilds a site given the filesystem events.
 // It returns whetever the content source was changed.
 // TODO(bep) clean up/rewrite this method.
-func (s *Site) processPartial(events []fsnotify.Event) (whatChanged, error) {
+func (s *Site) processPartial(ctx context.Context, events []fsnotify.Event) (whatChanged, error) {
 
 	events = s.filterFileEvents(events)
 	events = s.translateFileEvents(events)
@@ -979,6 +979,15 @@ func (s *Site) processPartial(events []fsnotify.Event) (whatChanged, error) {
 		s.ResourceSpec.ResourceCache.DeletePartitions(cachePartitions...)
 	}
 
+	if len(tmplChanged) > 0 {
+		// A template added or removed may change which file wins for a path.
+		if lfs := s.BaseFs.LayoutsFs(); lfs != nil {
+			for _, ev := range tmplChanged {
+				lfs.Invalidate(ev.Name)
+			}
+		}
+	}
+
 	if len(tmplChanged) > 0 || len(i18nChanged) > 0 {
 		sites := s.h.Sites
 		first := sites[0]
@@ -1060,7 +1069,7 @@ func (s *Site) processPartial(events []fsnotify.Event) (whatChanged, error) {
 
 		filenamesChanged = helpers.UniqueStrings(filenamesChanged)
 
-		if err := s.readAndProcessContent(filenamesChanged...); err != nil {
+		if err := s.readAndProcessContent(ctx, filenamesChanged...); err != nil {
 			return whatChanged{}, err
 		}
 
@@ -1076,11 +1085,11 @@ func (s *Site) processPartial(events []fsnotify.Event) (whatChanged, error) {
 
 }
 
-func (s *Site) process(config BuildCfg) (err error) {
+func (s *Site) process(ctx context.Context, config BuildCfg) (err error) {
 	if err = s.initialize(); err != nil {
 		return
 	}
-	if err := s.readAndProcessContent(); err != nil {
+	if err := s.readAndProcessContent(ctx); err != nil {
 		return err
 	}
 	return err
@@ -1335,9 +1344,7 @@ func (c *contentCaptureResultHandler) handleCopyFile(f pathLangFile) {
 	proc.processAsset(f)
 }
 
-func (s *Site) readAndProcessContent(filenames ...string) error {
-
-	ctx := context.Background()
+func (s *Site) readAndProcessContent(ctx context.Context, filenames ...string) error {
 	g, ctx := errgroup.WithContext(ctx)
 
 	defaultContentLanguage := s.SourceSpec.DefaultContentLanguage
@@ -1378,6 +1385,7 @@ func (s *Site) readAndProcessContent(filenames ...string) error {
 	}
 
 	c := newCapturer(s.Log, sourceSpec, handler, bundleMap, filenames...)
+	c.ctx = ctx
 
 	err1 := c.capture()
 
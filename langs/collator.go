@@ -0,0 +1,68 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package langs
+
+import (
+	"sort"
+	"sync"
+
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
+)
+
+// Collator compares strings using the collation rules of a language, e.g.
+// sorting "ø" after "z" in Norwegian. It is safe for concurrent use.
+type Collator struct {
+	mu sync.Mutex
+	c  *collate.Collator
+}
+
+// Collator returns the collator for this language. It is created on first
+// use and cached for the lifetime of l.
+func (l *Language) Collator() *Collator {
+	l.collatorInit.Do(func() {
+		l.collator = newCollator(l.Lang)
+	})
+	return l.collator
+}
+
+func newCollator(lang string) *Collator {
+	tag, err := language.Parse(lang)
+	if err != nil {
+		// Not a well-formed code, e.g. a custom one.
+		// Use the root collation order.
+		tag = language.Und
+	}
+	return &Collator{c: collate.New(tag)}
+}
+
+// CompareStrings returns an integer comparing the two strings.
+// The result will be 0 if a == b, -1 if a < b, and +1 if a > b.
+func (c *Collator) CompareStrings(a, b string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.c.CompareString(a, b)
+}
+
+// Less reports whether a sorts before b.
+func (c *Collator) Less(a, b string) bool {
+	return c.CompareStrings(a, b) < 0
+}
+
+// SortStrings sorts s in place.
+func (c *Collator) SortStrings(s []string) {
+	sort.SliceStable(s, func(i, j int) bool {
+		return c.Less(s[i], s[j])
+	})
+}
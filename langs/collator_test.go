@@ -0,0 +1,42 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package langs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollator(t *testing.T) {
+	assert := require.New(t)
+
+	nn := &Language{Lang: "nn"}
+	assert.True(nn.Collator() == nn.Collator())
+
+	words := []string{"ørret", "abbor", "ål", "zebra", "ærfugl"}
+	nn.Collator().SortStrings(words)
+	assert.Equal([]string{"abbor", "zebra", "ærfugl", "ørret", "ål"}, words)
+
+	en := &Language{Lang: "en"}
+	words = []string{"zebra", "Zulu", "apple", "Äpfel"}
+	en.Collator().SortStrings(words)
+	assert.Equal([]string{"Äpfel", "apple", "zebra", "Zulu"}, words)
+	assert.Equal(0, en.Collator().CompareStrings("a", "a"))
+	assert.True(en.Collator().Less("a", "b"))
+
+	// Custom codes fall back to the root collation.
+	custom := &Language{Lang: "docs-v2"}
+	assert.True(custom.Collator().Less("a", "b"))
+}
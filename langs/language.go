@@ -14,12 +14,16 @@
 package langs
 
 import (
+	"fmt"
 	"sort"
 	"strings"
+	"sync"
 
 	"github.com/gohugoio/hugo/common/maps"
 	"github.com/gohugoio/hugo/config"
+	"github.com/pkg/errors"
 	"github.com/spf13/cast"
+	"golang.org/x/text/language"
 )
 
 // These are the settings that should only be looked up in the global Viper
@@ -37,6 +41,24 @@ var globalOnlySettings = map[string]bool{
 	strings.ToLower("resourceDir"):                    true,
 }
 
+// rtlLanguages are the primary language subtags of the languages usually
+// written right-to-left.
+var rtlLanguages = map[string]bool{
+	"ar":  true,
+	"arc": true,
+	"ckb": true,
+	"dv":  true,
+	"fa":  true,
+	"he":  true,
+	"khw": true,
+	"ks":  true,
+	"ps":  true,
+	"sd":  true,
+	"ug":  true,
+	"ur":  true,
+	"yi":  true,
+}
+
 // Language manages specific-language configuration.
 type Language struct {
 	Lang         string
@@ -44,8 +66,31 @@ type Language struct {
 	Title        string
 	Weight       int
 
+	// LanguageDirection is the text direction, "ltr" or "rtl". If not set
+	// in config, it is derived from the language code.
+	LanguageDirection string
+
 	Disabled bool
 
+	// CustomCode is set for languages with a code that is not a valid BCP 47
+	// language code, e.g. the "docs-v2" pseudo-language. The code is then
+	// not validated, but the language is otherwise like any other.
+	CustomCode bool
+
+	// Aliases are alternative codes for this language, e.g. legacy codes
+	// such as "iw" for "he". A file named "mypost.iw.md" will be in this
+	// language.
+	Aliases []string
+
+	// Fallbacks is an ordered list of language codes to use when content is
+	// missing in this language, e.g. ["nb", "en"] for "nn".
+	Fallbacks []string
+
+	// MergeContentFrom is the code of a language whose content fills the gaps
+	// in this language's content, e.g. "en" for "en-gb". Content picked from
+	// that language is marked as borrowed.
+	MergeContentFrom string
+
 	// If set per language, this tells Hugo that all content files without any
 	// language indicator (e.g. my-page.en.md) is in this language.
 	// This is usually a path relative to the working dir, but it can be an
@@ -62,6 +107,9 @@ type Language struct {
 	// This is the map Hugo looks in when looking for configuration values (baseURL etc.).
 	// Values in this map can also be fetched from the params map above.
 	settings map[string]interface{}
+
+	collatorInit sync.Once
+	collator     *Collator
 }
 
 func (l *Language) String() string {
@@ -83,34 +131,93 @@ func NewLanguage(lang string, cfg config.Provider) *Language {
 		panic("contentDir not set")
 	}
 
-	l := &Language{Lang: lang, ContentDir: defaultContentDir, Cfg: cfg, params: params, settings: make(map[string]interface{})}
+	l := &Language{Lang: lang, LanguageDirection: defaultLanguageDirection(lang), ContentDir: defaultContentDir, Cfg: cfg, params: params, settings: make(map[string]interface{})}
 	return l
 }
 
+// defaultLanguageDirection returns the usual text direction for the given
+// language code, e.g. "rtl" for "ar" and "he-IL".
+func defaultLanguageDirection(lang string) string {
+	primary := strings.ToLower(lang)
+	if i := strings.IndexAny(primary, "-_"); i != -1 {
+		primary = primary[:i]
+	}
+	if rtlLanguages[primary] {
+		return "rtl"
+	}
+	return "ltr"
+}
+
+// IsRTL returns whether this language is written right-to-left.
+func (l *Language) IsRTL() bool {
+	return l.LanguageDirection == "rtl"
+}
+
 // NewDefaultLanguage creates the default language for a config.Provider.
 // If not otherwise specified the default is "en".
 func NewDefaultLanguage(cfg config.Provider) *Language {
-	defaultLang := cfg.GetString("defaultContentLanguage")
+	defaultLang := config.GetStringDefault(cfg, "defaultContentLanguage", "en")
+
+	return NewLanguage(defaultLang, cfg)
+}
+
+// ValidateLanguageCodes checks that the given language codes are well-formed
+// BCP 47 language tags (e.g. "en", "en-us", "zh-hant"), and that no two codes
+// represent the same language after canonicalization (e.g. "iw" and "he").
+// All problems found are returned in one error.
+func ValidateLanguageCodes(codes ...string) error {
+	var problems []string
+	seen := make(map[string]string)
+
+	sorted := make([]string, len(codes))
+	copy(sorted, codes)
+	sort.Strings(sorted)
+
+	for _, code := range sorted {
+		canonical, err := canonicalLanguageCode(code)
+		if err != nil {
+			problems = append(problems, err.Error())
+			continue
+		}
+		if existing, found := seen[canonical]; found {
+			problems = append(problems, fmt.Sprintf("language codes %q and %q are the same language (%s)", existing, code, canonical))
+			continue
+		}
+		seen[canonical] = code
+	}
 
-	if defaultLang == "" {
-		defaultLang = "en"
+	if len(problems) > 0 {
+		return errors.Errorf("invalid language configuration:\n%s", strings.Join(problems, "\n"))
 	}
 
-	return NewLanguage(defaultLang, cfg)
+	return nil
+}
+
+// canonicalLanguageCode returns the canonical form of the given BCP 47 language
+// code, or an error if it is not well-formed.
+func canonicalLanguageCode(code string) (string, error) {
+	if strings.Contains(code, "_") {
+		return "", errors.Errorf("%q is not a valid BCP 47 language code: did you mean %q?", code, strings.Replace(code, "_", "-", -1))
+	}
+
+	tag, err := language.Parse(code)
+	if err != nil {
+		if _, ok := err.(language.ValueError); ok {
+			// Well-formed, but not known to us.
+			return strings.ToLower(code), nil
+		}
+		return "", errors.Errorf("%q is not a valid BCP 47 language code", code)
+	}
+
+	return strings.ToLower(tag.String()), nil
 }
 
 // Languages is a sortable list of languages.
 type Languages []*Language
 
 // NewLanguages creates a sorted list of languages.
-// NOTE: function is currently unused.
 func NewLanguages(l ...*Language) Languages {
-	languages := make(Languages, len(l))
-	for i := 0; i < len(l); i++ {
-		languages[i] = l[i]
-	}
-	sort.Sort(languages)
-	return languages
+	return Languages(l).Sorted()
 }
 
 func (l Languages) Len() int { return len(l) }
@@ -121,17 +228,367 @@ func (l Languages) Less(i, j int) bool {
 		return l[i].Lang < l[j].Lang
 	}
 
-	return wj == 0 || wi < wj
+	// Languages without a weight are sorted last.
+	if wi == 0 || wj == 0 {
+		return wj == 0
+	}
+
+	return wi < wj
 
 }
 
 func (l Languages) Swap(i, j int) { l[i], l[j] = l[j], l[i] }
 
+// Sorted returns a sorted copy of l. The languages are sorted by Weight, then
+// by language code. Languages without a Weight are sorted last.
+// This is the order used when composing the language filesystems, which
+// means that it also decides which file wins on duplicates.
+func (l Languages) Sorted() Languages {
+	sorted := make(Languages, len(l))
+	copy(sorted, l)
+	sort.Stable(sorted)
+	return sorted
+}
+
+// Active returns the languages in l that are not disabled, in order.
+// The disabled languages keep their configuration, but no site is built
+// for them and their content files are hidden.
+func (l Languages) Active() Languages {
+	var active Languages
+	for _, language := range l {
+		if !language.Disabled {
+			active = append(active, language)
+		}
+	}
+	return active
+}
+
+// LanguageSet maps language codes, including any aliases, to their Language.
+type LanguageSet map[string]*Language
+
+// AsSet returns a set of all the languages in l, keyed by their codes and
+// aliases.
+func (l Languages) AsSet() LanguageSet {
+	s := make(LanguageSet)
+	for _, language := range l {
+		s[language.Lang] = language
+		for _, alias := range language.Aliases {
+			s[alias] = language
+		}
+	}
+	return s
+}
+
+// Has returns whether code is a language code or alias in s.
+func (s LanguageSet) Has(code string) bool {
+	_, found := s[code]
+	return found
+}
+
+// Get returns the language with the given code or alias, nil if not found.
+func (s LanguageSet) Get(code string) *Language {
+	return s[code]
+}
+
+// Enabled returns whether code is a language code or alias in s and the
+// language is not disabled.
+func (s LanguageSet) Enabled(code string) bool {
+	language, found := s[code]
+	return found && !language.Disabled
+}
+
+// Default returns the default content language, nil if not in s.
+func (s LanguageSet) Default() *Language {
+	for _, language := range s {
+		if language.Cfg == nil {
+			continue
+		}
+		return s.Get(language.Cfg.GetString("defaultContentLanguage"))
+	}
+	return nil
+}
+
+// AliasMap returns all the language aliases in l mapped to the canonical
+// language code.
+func (l Languages) AliasMap() map[string]string {
+	m := make(map[string]string)
+	for _, language := range l {
+		for _, alias := range language.Aliases {
+			m[alias] = language.Lang
+		}
+	}
+	return m
+}
+
+// CanonicalLang resolves the given language code or alias to the code of
+// the language in l it represents. If not found, code is returned as is.
+func (l Languages) CanonicalLang(code string) string {
+	for _, language := range l {
+		if strings.EqualFold(language.Lang, code) {
+			return language.Lang
+		}
+		for _, alias := range language.Aliases {
+			if strings.EqualFold(alias, code) {
+				return language.Lang
+			}
+		}
+	}
+	return code
+}
+
+// Langs returns the language codes of l, in order.
+func (l Languages) Langs() []string {
+	langs := make([]string, len(l))
+	for i, language := range l {
+		langs[i] = language.Lang
+	}
+	return langs
+}
+
+// Validate checks l for conflicts: duplicate language codes or aliases, and
+// fallbacks or mergeContentFrom settings referencing missing languages or
+// forming a cycle. Languages may share the same weight, see Less.
+// All problems found are reported in one error.
+func (l Languages) Validate() error {
+	var problems []string
+
+	owners := make(map[string]string)
+	addCode := func(code, owner string) {
+		key := strings.ToLower(code)
+		if existing, found := owners[key]; found {
+			if existing == owner {
+				problems = append(problems, fmt.Sprintf("language %q: alias %q is the same as the language code", owner, code))
+			} else {
+				problems = append(problems, fmt.Sprintf("language code %q is used by both %q and %q", code, existing, owner))
+			}
+			return
+		}
+		owners[key] = owner
+	}
+
+	byLang := make(map[string]*Language)
+	for _, language := range l {
+		addCode(language.Lang, language.Lang)
+		byLang[language.Lang] = language
+	}
+	for _, language := range l {
+		for _, alias := range language.Aliases {
+			addCode(alias, language.Lang)
+		}
+	}
+
+	for _, language := range l {
+		for _, fallback := range language.Fallbacks {
+			if _, found := byLang[fallback]; !found {
+				problems = append(problems, fmt.Sprintf("language %q: fallback %q does not match any language definition", language.Lang, fallback))
+			}
+		}
+		if from := language.MergeContentFrom; from != "" {
+			if from == language.Lang {
+				problems = append(problems, fmt.Sprintf("language %q: cannot merge content from itself", language.Lang))
+			} else if _, found := byLang[from]; !found {
+				problems = append(problems, fmt.Sprintf("language %q: mergeContentFrom %q does not match any language definition", language.Lang, from))
+			}
+		}
+	}
+
+	problems = append(problems, l.fallbackCycles()...)
+
+	if len(problems) > 0 {
+		return errors.Errorf("invalid language configuration:\n%s", strings.Join(problems, "\n"))
+	}
+
+	return nil
+}
+
+// fallbackCycles returns a description of every cycle in the fallbacks of l.
+func (l Languages) fallbackCycles() []string {
+	byLang := make(map[string]*Language)
+	for _, language := range l {
+		byLang[language.Lang] = language
+	}
+
+	var (
+		cycles   []string
+		reported = make(map[string]bool)
+		done     = make(map[string]bool)
+	)
+
+	var visit func(path []string)
+	visit = func(path []string) {
+		lang := path[len(path)-1]
+		for _, fallback := range byLang[lang].Fallbacks {
+			if _, found := byLang[fallback]; !found || done[fallback] {
+				continue
+			}
+			for i, p := range path {
+				if p != fallback {
+					continue
+				}
+				cycle := append(append([]string{}, path[i:]...), fallback)
+				members := append([]string{}, path[i:]...)
+				sort.Strings(members)
+				key := strings.Join(members, ",")
+				if !reported[key] {
+					reported[key] = true
+					cycles = append(cycles, fmt.Sprintf("fallback cycle: %s", strings.Join(cycle, " -> ")))
+				}
+				break
+			}
+			if !contains(path, fallback) {
+				visit(append(path, fallback))
+			}
+		}
+	}
+
+	for _, language := range l {
+		visit([]string{language.Lang})
+		done[language.Lang] = true
+	}
+
+	return cycles
+}
+
+func contains(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// FallbackChain returns the ordered list of languages to try when content is
+// missing in the given language. The fallbacks are followed transitively, so
+// if "nn" falls back to "nb" and "nb" falls back to "en", the chain for "nn"
+// is ["nb", "en"]. Languages not in l and cycles are ignored.
+func (l Languages) FallbackChain(lang string) []string {
+	byLang := make(map[string]*Language)
+	for _, language := range l {
+		byLang[language.Lang] = language
+	}
+
+	var chain []string
+	seen := map[string]bool{lang: true}
+
+	var collect func(lang string)
+	collect = func(lang string) {
+		language, found := byLang[lang]
+		if !found {
+			return
+		}
+		for _, fallback := range language.Fallbacks {
+			if seen[fallback] {
+				continue
+			}
+			if _, found := byLang[fallback]; !found {
+				continue
+			}
+			seen[fallback] = true
+			chain = append(chain, fallback)
+			collect(fallback)
+		}
+	}
+
+	collect(lang)
+
+	return chain
+}
+
+// MergeContentFrom returns the language whose content fills the gaps in
+// each language in l with MergeContentFrom set, keyed by language code.
+func (l Languages) MergeContentFrom() map[string]string {
+	m := make(map[string]string)
+	for _, language := range l {
+		if language.MergeContentFrom != "" {
+			m[language.Lang] = language.MergeContentFrom
+		}
+	}
+	return m
+}
+
+// FallbackChains returns the FallbackChain of every language in l with
+// fallbacks, keyed by language code.
+func (l Languages) FallbackChains() map[string][]string {
+	m := make(map[string][]string)
+	for _, language := range l {
+		if chain := l.FallbackChain(language.Lang); len(chain) > 0 {
+			m[language.Lang] = chain
+		}
+	}
+	return m
+}
+
 // Params retunrs language-specific params merged with the global params.
 func (l *Language) Params() map[string]interface{} {
 	return l.params
 }
 
+// Param returns the param with the given key from the language-specific params
+// merged with the global params. The key is case-insensitive and may be a
+// dotted path into nested maps, e.g. "social.twitter".
+// It returns nil if the param is not set.
+func (l *Language) Param(key string) interface{} {
+	var v interface{} = l.params
+	for _, k := range strings.Split(strings.ToLower(key), ".") {
+		m, err := cast.ToStringMapE(v)
+		if err != nil {
+			return nil
+		}
+		var found bool
+		if v, found = m[k]; !found {
+			return nil
+		}
+	}
+	return v
+}
+
+// ParamString returns the param with the given key as a string, or
+// defaultValue if not set. See Param.
+func (l *Language) ParamString(key, defaultValue string) string {
+	v := l.Param(key)
+	if v == nil {
+		return defaultValue
+	}
+	s, err := cast.ToStringE(v)
+	if err != nil {
+		return defaultValue
+	}
+	return s
+}
+
+// ParamBool returns the param with the given key as a bool, or defaultValue
+// if not set. See Param.
+func (l *Language) ParamBool(key string, defaultValue bool) bool {
+	v := l.Param(key)
+	if v == nil {
+		return defaultValue
+	}
+	b, err := cast.ToBoolE(v)
+	if err != nil {
+		return defaultValue
+	}
+	return b
+}
+
+// ParamStringSlice returns the param with the given key as a string slice, or
+// defaultValue if not set. A single string value is returned as a slice with
+// one element. See Param.
+func (l *Language) ParamStringSlice(key string, defaultValue []string) []string {
+	v := l.Param(key)
+	if v == nil {
+		return defaultValue
+	}
+	if s, ok := v.(string); ok {
+		return []string{s}
+	}
+	ss, err := cast.ToStringSliceE(v)
+	if err != nil {
+		return defaultValue
+	}
+	return ss
+}
+
 // IsMultihost returns whether there are more than one language and at least one of
 // the languages has baseURL specificed on the language level.
 func (l Languages) IsMultihost() bool {
@@ -147,6 +604,67 @@ func (l Languages) IsMultihost() bool {
 	return false
 }
 
+// TargetPaths returns the path below publishDir each language in l is
+// published to, keyed by language code. In multihost mode every language
+// is published to a sub directory named after its code, else they all
+// share the root and the path is empty.
+func (l Languages) TargetPaths() map[string]string {
+	multihost := l.IsMultihost()
+	m := make(map[string]string)
+	for _, language := range l {
+		if multihost {
+			m[language.Lang] = language.Lang
+		} else {
+			m[language.Lang] = ""
+		}
+	}
+	return m
+}
+
+// DefaultContentLanguageInSubdir reports whether the default content language
+// is published below a sub directory named after its code. This is always
+// the case in multihost mode.
+func (l Languages) DefaultContentLanguageInSubdir() bool {
+	if len(l) == 0 || l[0].Cfg == nil {
+		return false
+	}
+	return l.IsMultihost() || l[0].Cfg.GetBool("defaultContentLanguageInSubdir")
+}
+
+// LangSubdirs returns the sub directory below the site root each language in
+// l is published to, keyed by language code. The value is empty for a
+// language published to the root, which is the case for all languages if
+// there is only one or in multihost mode, where every language has its own
+// root.
+func (l Languages) LangSubdirs() map[string]string {
+	m := make(map[string]string)
+	if len(l) == 0 {
+		return m
+	}
+
+	var defaultLang string
+	if l[0].Cfg != nil {
+		defaultLang = l[0].Cfg.GetString("defaultContentLanguage")
+	}
+	subdirs := len(l) > 1 && !l.IsMultihost()
+	defaultInSubdir := l.DefaultContentLanguageInSubdir()
+
+	for _, language := range l {
+		if !subdirs || (language.Lang == defaultLang && !defaultInSubdir) {
+			m[language.Lang] = ""
+		} else {
+			m[language.Lang] = language.Lang
+		}
+	}
+	return m
+}
+
+// BaseURL returns the baseURL set on the language level, or an empty
+// string if this language uses the site's baseURL.
+func (l *Language) BaseURL() string {
+	return cast.ToString(l.GetLocal("baseURL"))
+}
+
 // SetParam sets a param with the given key and value.
 // SetParam is case-insensitive.
 func (l *Language) SetParam(k string, v interface{}) {
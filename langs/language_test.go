@@ -46,3 +46,246 @@ func TestLanguageParams(t *testing.T) {
 	assert.Equal("p1p", lang.Params()["p1"])
 	assert.Equal("p1cfg", lang.Get("p1"))
 }
+
+func TestLanguageTypedParams(t *testing.T) {
+	assert := require.New(t)
+
+	v := viper.New()
+	v.Set("contentDir", "content")
+	v.Set("params", map[string]interface{}{
+		"mainSections": []interface{}{"blog", "docs"},
+		"showToc":      true,
+		"author":       "global",
+		"social": map[string]interface{}{
+			"twitter": "@global",
+		},
+	})
+
+	lang := NewLanguage("nn", v)
+	lang.SetParam("Author", "nynorsk")
+	lang.SetParam("tags", "one")
+
+	assert.Equal("nynorsk", lang.ParamString("author", "none"))
+	assert.Equal("nynorsk", lang.ParamString("AUTHOR", "none"))
+	assert.Equal("@global", lang.ParamString("social.Twitter", "none"))
+	assert.Equal("none", lang.ParamString("social.github", "none"))
+	assert.Equal("none", lang.ParamString("author.name", "none"))
+	assert.True(lang.ParamBool("showtoc", false))
+	assert.True(lang.ParamBool("missing", true))
+	assert.Equal([]string{"blog", "docs"}, lang.ParamStringSlice("mainsections", nil))
+	assert.Equal([]string{"one"}, lang.ParamStringSlice("tags", nil))
+	assert.Equal([]string{"def"}, lang.ParamStringSlice("missing", []string{"def"}))
+	assert.Nil(lang.Param("missing"))
+}
+
+func TestLanguagesFallbackChain(t *testing.T) {
+	assert := require.New(t)
+
+	languages := Languages{
+		{Lang: "nn", Fallbacks: []string{"nb", "sv"}},
+		{Lang: "nb", Fallbacks: []string{"en", "nn"}},
+		{Lang: "sv", Fallbacks: []string{"en"}},
+		{Lang: "en", Fallbacks: []string{"xx"}},
+	}
+
+	assert.Equal([]string{"nb", "en", "sv"}, languages.FallbackChain("nn"))
+	assert.Equal([]string{"en", "nn", "sv"}, languages.FallbackChain("nb"))
+	assert.Equal([]string{"en"}, languages.FallbackChain("sv"))
+	assert.Empty(languages.FallbackChain("en"))
+	assert.Empty(languages.FallbackChain("de"))
+
+	assert.Equal(map[string][]string{
+		"nn": {"nb", "en", "sv"},
+		"nb": {"en", "nn", "sv"},
+		"sv": {"en"},
+	}, languages.FallbackChains())
+}
+
+func TestLanguageDirection(t *testing.T) {
+	assert := require.New(t)
+
+	v := viper.New()
+	v.Set("contentDir", "content")
+
+	for _, test := range []struct {
+		lang     string
+		expected string
+	}{
+		{"en", "ltr"},
+		{"ar", "rtl"},
+		{"he-IL", "rtl"},
+		{"fa_IR", "rtl"},
+		{"ur", "rtl"},
+		{"nn", "ltr"},
+		// Hausa is written in Latin script today.
+		{"ha", "ltr"},
+	} {
+		l := NewLanguage(test.lang, v)
+		assert.Equal(test.expected, l.LanguageDirection, test.lang)
+		assert.Equal(test.expected == "rtl", l.IsRTL(), test.lang)
+	}
+}
+
+func TestLanguagesSorted(t *testing.T) {
+	assert := require.New(t)
+
+	languages := Languages{
+		{Lang: "sv"},
+		{Lang: "nn", Weight: 20},
+		{Lang: "de"},
+		{Lang: "en", Weight: 10},
+		{Lang: "nb", Weight: 20},
+	}
+
+	sorted := languages.Sorted()
+	assert.Equal([]string{"en", "nb", "nn", "de", "sv"}, sorted.Langs())
+	// The original is left untouched.
+	assert.Equal([]string{"sv", "nn", "de", "en", "nb"}, languages.Langs())
+	assert.Equal(sorted.Langs(), NewLanguages(languages...).Langs())
+}
+
+func TestLanguagesActive(t *testing.T) {
+	assert := require.New(t)
+
+	languages := Languages{
+		{Lang: "en"},
+		{Lang: "fr", Disabled: true},
+		{Lang: "nn"},
+	}
+
+	assert.Equal([]string{"en", "nn"}, languages.Active().Langs())
+}
+
+func TestValidateLanguageCodes(t *testing.T) {
+	assert := require.New(t)
+
+	assert.NoError(ValidateLanguageCodes("en", "nn", "nb", "zh-hant", "pt-br", "sr-latn", "art-x-klingon", "xx"))
+	assert.NoError(ValidateLanguageCodes())
+
+	err := ValidateLanguageCodes("en", "en_US", "docs-v2", "he", "iw", "pt-BR", "pt-br")
+	assert.Error(err)
+	msg := err.Error()
+	assert.Contains(msg, `"en_US" is not a valid BCP 47 language code: did you mean "en-US"?`)
+	assert.Contains(msg, `"docs-v2" is not a valid BCP 47 language code`)
+	assert.Contains(msg, `language codes "he" and "iw" are the same language (he)`)
+	assert.Contains(msg, `language codes "pt-BR" and "pt-br" are the same language (pt-br)`)
+	assert.NotContains(msg, `"en"`)
+}
+
+func TestLanguagesAliases(t *testing.T) {
+	assert := require.New(t)
+
+	languages := Languages{
+		{Lang: "he", Aliases: []string{"iw"}},
+		{Lang: "nb", Aliases: []string{"no"}, Disabled: true},
+		{Lang: "en"},
+	}
+
+	set := languages.AsSet()
+	assert.Len(set, 5)
+	assert.True(set.Has("iw"))
+	assert.False(set.Has("sv"))
+	assert.Equal(languages[0], set.Get("iw"))
+	assert.Equal(languages[0], set.Get("he"))
+	assert.Nil(set.Get("sv"))
+	assert.True(set.Enabled("he"))
+	assert.False(set.Enabled("no"))
+	assert.False(set.Enabled("sv"))
+	assert.Equal(map[string]string{"iw": "he", "no": "nb"}, languages.AliasMap())
+	assert.Equal("he", languages.CanonicalLang("iw"))
+	assert.Equal("he", languages.CanonicalLang("IW"))
+	assert.Equal("en", languages.CanonicalLang("en"))
+	assert.Equal("sv", languages.CanonicalLang("sv"))
+}
+
+func TestLanguagesTargetPaths(t *testing.T) {
+	assert := require.New(t)
+
+	v := viper.New()
+	v.Set("contentDir", "content")
+	v.Set("baseURL", "https://example.com")
+
+	en, nn := NewLanguage("en", v), NewLanguage("nn", v)
+	languages := Languages{en, nn}
+
+	assert.Equal("", en.BaseURL())
+	assert.Equal(map[string]string{"en": "", "nn": ""}, languages.TargetPaths())
+
+	en.Set("baseURL", "https://example.com")
+	nn.Set("baseURL", "https://example.no")
+
+	assert.Equal("https://example.no", nn.BaseURL())
+	assert.Equal(map[string]string{"en": "en", "nn": "nn"}, languages.TargetPaths())
+}
+
+func TestLanguagesLangSubdirs(t *testing.T) {
+	assert := require.New(t)
+
+	v := viper.New()
+	v.Set("contentDir", "content")
+	v.Set("defaultContentLanguage", "en")
+
+	en, nn := NewLanguage("en", v), NewLanguage("nn", v)
+
+	assert.Equal(map[string]string{"en": ""}, Languages{en}.LangSubdirs())
+
+	languages := Languages{en, nn}
+	assert.False(languages.DefaultContentLanguageInSubdir())
+	assert.Equal(map[string]string{"en": "", "nn": "nn"}, languages.LangSubdirs())
+
+	v.Set("defaultContentLanguageInSubdir", true)
+	assert.True(languages.DefaultContentLanguageInSubdir())
+	assert.Equal(map[string]string{"en": "en", "nn": "nn"}, languages.LangSubdirs())
+
+	v.Set("defaultContentLanguageInSubdir", false)
+	en.Set("baseURL", "https://example.com")
+	nn.Set("baseURL", "https://example.no")
+	assert.True(languages.DefaultContentLanguageInSubdir())
+	assert.Equal(map[string]string{"en": "", "nn": ""}, languages.LangSubdirs())
+}
+
+func TestLanguagesValidate(t *testing.T) {
+	assert := require.New(t)
+
+	languages := Languages{
+		{Lang: "en", Weight: 1},
+		{Lang: "nb", Weight: 1, Aliases: []string{"no"}, Fallbacks: []string{"en"}},
+		{Lang: "nn", Fallbacks: []string{"nb", "en"}, MergeContentFrom: "nb"},
+		{Lang: "sv"},
+	}
+	assert.NoError(languages.Validate())
+	assert.Equal(map[string]string{"nn": "nb"}, languages.MergeContentFrom())
+
+	err := Languages{
+		{Lang: "en", Weight: 1, Aliases: []string{"EN"}},
+		{Lang: "nb", Weight: 1, Aliases: []string{"no"}, Fallbacks: []string{"nn"}},
+		{Lang: "nn", Aliases: []string{"no"}, Fallbacks: []string{"nb", "da"}, MergeContentFrom: "nn"},
+		{Lang: "sv", Fallbacks: []string{"sv"}, MergeContentFrom: "fi"},
+	}.Validate()
+
+	assert.Error(err)
+	msg := err.Error()
+	assert.Contains(msg, `language "en": alias "EN" is the same as the language code`)
+	assert.Contains(msg, `language code "no" is used by both "nb" and "nn"`)
+	assert.NotContains(msg, "weight")
+	assert.Contains(msg, `language "nn": fallback "da" does not match any language definition`)
+	assert.Contains(msg, `language "nn": cannot merge content from itself`)
+	assert.Contains(msg, `language "sv": mergeContentFrom "fi" does not match any language definition`)
+	assert.Contains(msg, `fallback cycle: nb -> nn -> nb`)
+	assert.Contains(msg, `fallback cycle: sv -> sv`)
+	assert.NotContains(msg, `fallback cycle: nn -> nb -> nn`)
+}
+
+func TestLanguageSetDefault(t *testing.T) {
+	assert := require.New(t)
+
+	v := viper.New()
+	v.Set("contentDir", "content")
+	v.Set("defaultContentLanguage", "nn")
+
+	en, nn := NewLanguage("en", v), NewLanguage("nn", v)
+
+	assert.Equal(nn, Languages{en, nn}.AsSet().Default())
+	assert.Nil(Languages{en}.AsSet().Default())
+	assert.Nil(Languages{{Lang: "en"}}.AsSet().Default())
+}
@@ -109,9 +109,12 @@ func (p DestinationPublisher) Publish(d Descriptor) error {
 	if err != nil {
 		return err
 	}
-	defer f.Close()
 
 	_, err = io.Copy(f, src)
+	if cerr := f.Close(); err == nil {
+		// Some filesystems only write the content on close.
+		err = cerr
+	}
 	if err == nil && d.StatCounter != nil {
 		atomic.AddUint64(d.StatCounter, uint64(1))
 	}
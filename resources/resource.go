@@ -455,15 +455,15 @@ func (l *genericResource) initHash() error {
 	var err error
 	l.hashInit.Do(func() {
 		var hash string
-		var f hugio.ReadSeekCloser
-		f, err = l.ReadSeekCloser()
-		if err != nil {
-			err = errors.Wrap(err, "failed to open source file")
-			return
-		}
-		defer f.Close()
+		hash, err = l.spec.Fs.HashCache.Hash("md5fast", l.osFileInfo, func() (string, error) {
+			f, err := l.ReadSeekCloser()
+			if err != nil {
+				return "", errors.Wrap(err, "failed to open source file")
+			}
+			defer f.Close()
 
-		hash, err = helpers.MD5FromFileFast(f)
+			return helpers.MD5FromFileFast(f)
+		})
 		if err != nil {
 			return
 		}
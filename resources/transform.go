@@ -25,6 +25,7 @@ import (
 	"github.com/gohugoio/hugo/helpers"
 	"github.com/gohugoio/hugo/resources/resource"
 	"github.com/mitchellh/hashstructure"
+	"github.com/pkg/errors"
 
 	"fmt"
 	"io"
@@ -289,6 +290,20 @@ func (r *transformedResource) openPublishFileForWriting(relTargetPath string) (i
 	return helpers.OpenFilesForWriting(r.cache.rs.PublishFs, r.linker.relTargetPathsFor(relTargetPath)...)
 }
 
+// withAssetOrigin adds the theme and real filename of the asset at
+// sourcePath to err if it comes from a theme, so the theme providing a
+// broken file can be found.
+func (r *transformedResource) withAssetOrigin(err error, sourcePath string) error {
+	if sourcePath == "" || r.cache == nil || r.cache.rs == nil {
+		return err
+	}
+	origin, found := r.cache.rs.BaseFs.AssetOrigin(sourcePath)
+	if !found || origin.Theme == "" {
+		return err
+	}
+	return errors.Wrapf(err, "failed to transform %s", origin)
+}
+
 func (r *transformedResource) transform(setContent, publish bool) (err error) {
 
 	// This can be the last resource in a chain.
@@ -426,7 +441,7 @@ func (r *transformedResource) transform(setContent, publish bool) (err error) {
 			}
 
 			// Abort.
-			return err
+			return r.withAssetOrigin(err, tctx.SourcePath)
 		}
 
 		if tctx.OutPath != "" {
@@ -20,6 +20,7 @@ import (
 	"github.com/gohugoio/hugo/helpers"
 
 	"github.com/gohugoio/hugo/hugofs"
+	"github.com/gohugoio/hugo/langs"
 	"github.com/spf13/afero"
 	"github.com/stretchr/testify/require"
 )
@@ -63,13 +64,13 @@ func TestFileInfo(t *testing.T) {
 
 func TestFileInfoLanguage(t *testing.T) {
 	assert := require.New(t)
-	langs := map[string]bool{
-		"sv": true,
-		"en": true,
+	languages := langs.Languages{
+		{Lang: "sv"},
+		{Lang: "en"},
 	}
 
 	m := afero.NewMemMapFs()
-	lfs := hugofs.NewLanguageFs("sv", langs, m)
+	lfs := hugofs.NewLanguageFs("sv", languages.AsSet(), m)
 	v := newTestConfig()
 
 	fs := hugofs.NewFrom(m, v)
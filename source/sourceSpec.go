@@ -18,6 +18,7 @@ import (
 	"path/filepath"
 	"regexp"
 
+	"github.com/gohugoio/hugo/hugofs"
 	"github.com/gohugoio/hugo/langs"
 	"github.com/spf13/afero"
 
@@ -79,10 +80,7 @@ func NewSourceSpec(ps *helpers.PathSpec, fs afero.Fs) *SourceSpec {
 // IgnoreFile returns whether a given file should be ignored.
 func (s *SourceSpec) IgnoreFile(filename string) bool {
 	if filename == "" {
-		if _, ok := s.SourceFs.(*afero.OsFs); ok {
-			return true
-		}
-		return false
+		return hugofs.IsOsFs(s.SourceFs)
 	}
 
 	base := filepath.Base(filename)
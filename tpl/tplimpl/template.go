@@ -728,6 +728,9 @@ func (t *templateHandler) loadTemplates(prefix string) error {
 			Prefix:        prefix,
 			OutputFormats: t.OutputFormatsConfig,
 			FileExists: func(filename string) (bool, error) {
+				if lfs := t.BaseFs.LayoutsFs(); lfs != nil {
+					return lfs.Exists(filename)
+				}
 				return helpers.Exists(filename, t.Layouts.Fs)
 			},
 			ContainsAny: func(filename string, subslices [][]byte) (bool, error) {
@@ -927,7 +930,11 @@ func (t *templateHandler) addTemplateFile(name, baseTemplatePath, path string) e
 		s := removeLeadingBOM(string(b))
 
 		realFilename := filename
-		if fi, err := fs.Stat(filename); err == nil {
+		if lfs := t.BaseFs.LayoutsFs(); lfs != nil {
+			if f, found, err := lfs.Resolve(filename); err == nil && found {
+				realFilename = f
+			}
+		} else if fi, err := fs.Stat(filename); err == nil {
 			if fir, ok := fi.(hugofs.RealFilenameInfo); ok {
 				realFilename = fir.RealFilename()
 			}
@@ -19,7 +19,11 @@ import (
 	"github.com/fsnotify/fsnotify"
 )
 
-// Batcher batches file watch events in a given interval.
+// Batcher batches file watch events. A batch is sent when there have been no
+// new events for the given interval, so a burst of events, e.g. from an
+// editor saving a file, ends up in one batch. To not wait forever on a busy
+// filesystem, a batch is never held back for more than maxWaitIntervals
+// intervals. The events in a batch are consolidated, see Coalesce.
 type Batcher struct {
 	*fsnotify.Watcher
 	interval time.Duration
@@ -28,37 +32,62 @@ type Batcher struct {
 	Events chan []fsnotify.Event // Events are returned on this channel
 }
 
+const maxWaitIntervals = 5
+
 // New creates and starts a Batcher with the given time interval.
 func New(interval time.Duration) (*Batcher, error) {
 	watcher, err := fsnotify.NewWatcher()
 
-	batcher := &Batcher{}
+	batcher := newBatcher(interval)
 	batcher.Watcher = watcher
-	batcher.interval = interval
-	batcher.done = make(chan struct{}, 1)
-	batcher.Events = make(chan []fsnotify.Event, 1)
 
 	if err == nil {
-		go batcher.run()
+		go batcher.run(watcher.Events)
 	}
 
 	return batcher, err
 }
 
-func (b *Batcher) run() {
-	tick := time.Tick(b.interval)
-	evs := make([]fsnotify.Event, 0)
+func newBatcher(interval time.Duration) *Batcher {
+	return &Batcher{
+		interval: interval,
+		done:     make(chan struct{}, 1),
+		Events:   make(chan []fsnotify.Event, 1),
+	}
+}
+
+func (b *Batcher) run(events <-chan fsnotify.Event) {
+	var (
+		evs []fsnotify.Event
+
+		// Fires when there have been no new events for an interval.
+		quiet <-chan time.Time
+
+		// Fires when the oldest event in evs has waited long enough.
+		deadline <-chan time.Time
+	)
+
+	flush := func() {
+		if evs := Coalesce(evs); len(evs) > 0 {
+			b.Events <- evs
+		}
+		evs = nil
+		quiet, deadline = nil, nil
+	}
+
 OuterLoop:
 	for {
 		select {
-		case ev := <-b.Watcher.Events:
+		case ev := <-events:
 			evs = append(evs, ev)
-		case <-tick:
-			if len(evs) == 0 {
-				continue
+			quiet = time.After(b.interval)
+			if deadline == nil {
+				deadline = time.After(maxWaitIntervals * b.interval)
 			}
-			b.Events <- evs
-			evs = make([]fsnotify.Event, 0)
+		case <-quiet:
+			flush()
+		case <-deadline:
+			flush()
 		case <-b.done:
 			break OuterLoop
 		}
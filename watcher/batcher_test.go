@@ -0,0 +1,120 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package watcher
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCoalesce(t *testing.T) {
+	t.Parallel()
+
+	ev := func(name string, op fsnotify.Op) fsnotify.Event {
+		return fsnotify.Event{Name: name, Op: op}
+	}
+
+	for i, test := range []struct {
+		in       []fsnotify.Event
+		expected []fsnotify.Event
+	}{
+		{nil, []fsnotify.Event{}},
+		// Save storm.
+		{
+			[]fsnotify.Event{ev("a.md", fsnotify.Write), ev("a.md", fsnotify.Write), ev("b.md", fsnotify.Write), ev("a.md", fsnotify.Write|fsnotify.Chmod)},
+			[]fsnotify.Event{ev("a.md", fsnotify.Write), ev("b.md", fsnotify.Write)},
+		},
+		// Temporary file renamed to the target.
+		{
+			[]fsnotify.Event{ev("a.md.tmp", fsnotify.Create), ev("a.md.tmp", fsnotify.Write), ev("a.md.tmp", fsnotify.Rename), ev("a.md", fsnotify.Create)},
+			[]fsnotify.Event{ev("a.md", fsnotify.Create)},
+		},
+		// Target renamed to a backup and replaced.
+		{
+			[]fsnotify.Event{ev("a.md", fsnotify.Rename), ev("a.md~", fsnotify.Create), ev("a.md", fsnotify.Create), ev("a.md", fsnotify.Write), ev("a.md~", fsnotify.Remove)},
+			[]fsnotify.Event{ev("a.md", fsnotify.Write)},
+		},
+		// Moved.
+		{
+			[]fsnotify.Event{ev("a.md", fsnotify.Rename), ev("b.md", fsnotify.Create)},
+			[]fsnotify.Event{ev("a.md", fsnotify.Rename), ev("b.md", fsnotify.Create)},
+		},
+		// Written, then removed.
+		{
+			[]fsnotify.Event{ev("a.md", fsnotify.Write), ev("a.md", fsnotify.Remove)},
+			[]fsnotify.Event{ev("a.md", fsnotify.Remove)},
+		},
+		// Created, removed and created again.
+		{
+			[]fsnotify.Event{ev("a.md", fsnotify.Create), ev("a.md", fsnotify.Remove), ev("a.md", fsnotify.Create), ev("a.md", fsnotify.Write)},
+			[]fsnotify.Event{ev("a.md", fsnotify.Create|fsnotify.Write)},
+		},
+		{
+			[]fsnotify.Event{ev("a.md", fsnotify.Chmod)},
+			[]fsnotify.Event{ev("a.md", fsnotify.Chmod)},
+		},
+	} {
+		require.Equal(t, test.expected, Coalesce(test.in), "test %d", i)
+	}
+}
+
+func TestBatcher(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+
+	interval := 50 * time.Millisecond
+	b := newBatcher(interval)
+	events := make(chan fsnotify.Event)
+	go b.run(events)
+	defer func() { b.done <- struct{}{} }()
+
+	// A burst of events ends up in one batch.
+	for i := 0; i < 10; i++ {
+		events <- fsnotify.Event{Name: "a.md", Op: fsnotify.Write}
+	}
+	events <- fsnotify.Event{Name: "b.md", Op: fsnotify.Write}
+
+	select {
+	case evs := <-b.Events:
+		assert.Equal([]fsnotify.Event{{Name: "a.md", Op: fsnotify.Write}, {Name: "b.md", Op: fsnotify.Write}}, evs)
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out")
+	}
+
+	// A steady stream of events is not held back forever.
+	start := time.Now()
+	stop := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case events <- fsnotify.Event{Name: "c.md", Op: fsnotify.Write}:
+				time.Sleep(interval / 5)
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	select {
+	case evs := <-b.Events:
+		assert.Equal([]fsnotify.Event{{Name: "c.md", Op: fsnotify.Write}}, evs)
+		assert.True(time.Since(start) < 10*maxWaitIntervals*interval)
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out")
+	}
+	close(stop)
+}
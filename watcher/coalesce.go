@@ -0,0 +1,84 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package watcher
+
+import (
+	"github.com/fsnotify/fsnotify"
+)
+
+// Coalesce consolidates the events in evs into at most one event per file,
+// in the order the files were first seen:
+//
+//   - Repeated events for the same file are merged into one with all the
+//     operations, e.g. the many writes of an editor saving a file.
+//   - A file created and then removed or renamed away, e.g. an editor's
+//     temporary file, is dropped.
+//   - A file removed or renamed away and then created again, e.g. an editor
+//     replacing the file on save, is reported as written.
+//   - A chmod is only reported for files with no other changes.
+func Coalesce(evs []fsnotify.Event) []fsnotify.Event {
+	type fileState struct {
+		op fsnotify.Op
+
+		// Whether the file did not exist before the first event.
+		created bool
+		dropped bool
+	}
+
+	var names []string
+	states := make(map[string]*fileState)
+
+	for _, ev := range evs {
+		state, found := states[ev.Name]
+		if !found {
+			state = &fileState{created: ev.Op&fsnotify.Create != 0}
+			states[ev.Name] = state
+			names = append(names, ev.Name)
+		}
+
+		gone := ev.Op&(fsnotify.Remove|fsnotify.Rename) != 0
+
+		switch {
+		case gone && state.created:
+			// Never existed as far as the rest of Hugo is concerned.
+			state.op = 0
+			state.dropped = true
+		case ev.Op&fsnotify.Create != 0 && state.op&(fsnotify.Remove|fsnotify.Rename) != 0:
+			// Replaced.
+			state.op = fsnotify.Write
+		case ev.Op&fsnotify.Create != 0 && state.dropped:
+			state.op = fsnotify.Create
+			state.dropped = false
+		case gone:
+			state.op = ev.Op & (fsnotify.Remove | fsnotify.Rename)
+		default:
+			state.op |= ev.Op
+		}
+	}
+
+	coalesced := make([]fsnotify.Event, 0, len(names))
+	for _, name := range names {
+		state := states[name]
+		if state.dropped || state.op == 0 {
+			continue
+		}
+		op := state.op
+		if op != fsnotify.Chmod {
+			op &^= fsnotify.Chmod
+		}
+		coalesced = append(coalesced, fsnotify.Event{Name: name, Op: op})
+	}
+
+	return coalesced
+}